@@ -0,0 +1,42 @@
+// brev-fake-server serves an in-memory Brev API for local development and
+// integration tests, e.g.
+//
+//	go run ./cmd/brev-fake-server --addr localhost:8080
+//	BREV_API_URL=http://localhost:8080 brev login --token <printed token>
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/brevdev/brev-cli/pkg/fakeserver"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	delay := flag.Duration("transition-delay", fakeserver.DefaultTransitionDelay, "how long workspaces stay in states like DEPLOYING or STOPPING")
+	flag.Parse()
+
+	gin.SetMode(gin.ReleaseMode)
+	server := fakeserver.NewServer().WithTransitionDelay(*delay)
+	token, err := server.Token()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("fake brev api listening on http://%s\n\n", *addr)
+	fmt.Printf("  export BREV_API_URL=http://%s\n", *addr)
+	fmt.Printf("  brev login --token %s\n\n", token)
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Fatal(httpServer.ListenAndServe())
+}
@@ -5,10 +5,12 @@ import (
 
 	"github.com/brevdev/brev-cli/pkg/cmd"
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/errors"
 )
 
 func main() {
+	errors.SentryURL = config.GlobalConfig.GetSentryURL()
 	done := errors.GetDefaultErrorReporter().Setup()
 	defer done()
 	command := cmd.NewDefaultBrevCommand()
@@ -16,6 +18,6 @@ func main() {
 	if err := command.Execute(); err != nil {
 		cmderrors.DisplayAndHandleError(err)
 		done()
-		os.Exit(1)
+		os.Exit(errors.GetExitCode(err))
 	}
 }
@@ -2,6 +2,7 @@ package autostartconf
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
 )
@@ -237,6 +238,63 @@ User=` + store.GetOSUser() + `
 	return nil
 }
 
+// NewAutoStopConfigurer runs the idle detector, only on linux since that is
+// the only place dev environments run
+func NewAutoStopConfigurer(store AutoStartStore) DaemonConfigurer {
+	if runtime.GOOS != osLinux {
+		return nil
+	}
+	return LinuxSystemdConfigurer{
+		Store: store,
+		ValueConfigFile: `
+[Install]
+WantedBy=multi-user.target
+
+[Unit]
+Description=Brev autostop idle detector
+After=network.target
+
+[Service]
+Type=simple
+ExecStart=brev tasks run autostopd
+Restart=always
+User=` + store.GetOSUser() + `
+`,
+		ServiceName: "brevautostopd.service",
+		ServiceType: "system",
+		TargetBin:   targetBin,
+	}
+}
+
+// NewSSHConfigSyncConfigurer runs brev refresh --watch as a systemd user
+// service, so it follows the user's login session and doesn't need root
+func NewSSHConfigSyncConfigurer(store AutoStartStore) DaemonConfigurer {
+	if runtime.GOOS != osLinux {
+		return nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "brev"
+	}
+	return LinuxSystemdConfigurer{
+		Store: store,
+		ValueConfigFile: `
+[Install]
+WantedBy=default.target
+
+[Unit]
+Description=Brev ssh config sync
+
+[Service]
+Type=simple
+ExecStart=` + exe + ` refresh --watch
+Restart=always
+`,
+		ServiceName: "brevsshsyncd.service",
+		ServiceType: userUnitServiceType,
+	}
+}
+
 func NewBrevMonConfigure(
 	store AutoStartStore,
 	disableAutostop bool,
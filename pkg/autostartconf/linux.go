@@ -16,27 +16,53 @@ type LinuxSystemdConfigurer struct {
 }
 
 const (
-	systemDConfigDir = "/etc/systemd/system/"
+	systemDConfigDir     = "/etc/systemd/system/"
+	systemDUserConfigDir = ".config/systemd/user"
+	// userUnitServiceType installs into the user's own systemd instance
+	// (systemctl --user), which does not need root
+	userUnitServiceType = "user-unit"
 )
 
 func (lsc LinuxSystemdConfigurer) getDestConfigFile() string {
 	return path.Join(systemDConfigDir, lsc.ServiceName)
 }
 
+func (lsc LinuxSystemdConfigurer) getUnitPath() (string, error) {
+	if lsc.ServiceType != userUnitServiceType {
+		return lsc.getDestConfigFile(), nil
+	}
+	home, err := lsc.Store.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path.Join(home, systemDUserConfigDir, lsc.ServiceName), nil
+}
+
+func (lsc LinuxSystemdConfigurer) systemctl(args ...string) []string {
+	if lsc.ServiceType == userUnitServiceType {
+		return append([]string{"systemctl", "--user"}, args...)
+	}
+	return append([]string{"systemctl"}, args...)
+}
+
 func (lsc LinuxSystemdConfigurer) UnInstall() error {
-	exists, err := lsc.Store.FileExists(lsc.getDestConfigFile())
+	dest, err := lsc.getUnitPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	exists, err := lsc.Store.FileExists(dest)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 	if exists {
-		errother := lsc.Store.Remove(lsc.getDestConfigFile())
+		errother := lsc.Store.Remove(dest)
 		if errother != nil {
 			return breverrors.WrapAndTrace(errother)
 		}
 	}
 	err = ExecCommands([][]string{
-		{"systemctl", "disable", lsc.ServiceName},
-		{"systemctl", "stop", lsc.ServiceName},
+		lsc.systemctl("disable", lsc.ServiceName),
+		lsc.systemctl("stop", lsc.ServiceName),
 	})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -46,25 +72,32 @@ func (lsc LinuxSystemdConfigurer) UnInstall() error {
 
 func (lsc LinuxSystemdConfigurer) Install() error {
 	_ = lsc.UnInstall() // best effort
-	err := lsc.Store.CopyBin(lsc.TargetBin)
+	// user units run the binary in place since they can't write to /usr/local/bin
+	if lsc.ServiceType != userUnitServiceType {
+		err := lsc.Store.CopyBin(lsc.TargetBin)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	dest, err := lsc.getUnitPath()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	err = lsc.Store.WriteString(lsc.getDestConfigFile(), lsc.ValueConfigFile)
+	err = lsc.Store.WriteString(dest, lsc.ValueConfigFile)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	if ShouldSymlink() {
+	if ShouldSymlink() && lsc.ServiceType != userUnitServiceType {
 		errother := lsc.CreateForcedSymlink()
 		if errother != nil {
 			return breverrors.WrapAndTrace(errother)
 		}
 	} else {
 		errother := ExecCommands([][]string{
-			{"systemctl", "enable", lsc.ServiceName},
-			{"systemctl", "start", lsc.ServiceName},
-			{"systemctl", "daemon-reload"},
+			lsc.systemctl("enable", lsc.ServiceName),
+			lsc.systemctl("start", lsc.ServiceName),
+			lsc.systemctl("daemon-reload"),
 		})
 		if errother != nil {
 			return breverrors.WrapAndTrace(errother)
@@ -0,0 +1,233 @@
+// Package apply converges dev environments to a manifest file
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/waiter"
+)
+
+var (
+	applyLong = `Create, modify or delete dev environments so that they match a manifest file.
+
+A plan of the changes is printed and must be confirmed before anything is applied.
+Only dev environments you created are managed, and ones missing from the
+manifest are only deleted when --prune is passed.`
+	applyExample = `
+  brev apply -f brev.yaml
+  brev apply -f brev.yaml --dry-run
+  brev apply -f brev.yaml --prune --yes
+
+example brev.yaml:
+
+  version: v1
+  org: my-org
+  workspaces:
+    - name: api
+      gitRepo: https://github.com/brevdev/brev-cli
+      class: 4x16
+      isStoppable: true
+    - name: trainer
+      instanceType: g5.xlarge
+      setupRepo: github.com:my-org/env-setup.git
+      setupPath: .brev/trainer.sh
+	`
+)
+
+type ApplyStore interface {
+	GetCurrentUser() (*entity.User, error)
+	GetActiveOrganizationOrDefault() (*entity.Organization, error)
+	GetOrganizations(options *store.GetOrganizationsOptions) ([]entity.Organization, error)
+	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+	CreateWorkspace(organizationID string, options *store.CreateWorkspacesOptions) (*entity.Workspace, error)
+	ModifyWorkspace(workspaceID string, options *store.ModifyWorkspaceRequest) (*entity.Workspace, error)
+	DeleteWorkspace(workspaceID string) (*entity.Workspace, error)
+	GetFileAsString(path string) (string, error)
+}
+
+type ApplyOptions struct {
+	File   string
+	DryRun bool
+	Yes    bool
+	Prune  bool
+	Wait   bool
+}
+
+func NewCmdApply(t *terminal.Terminal, applyStore ApplyStore) *cobra.Command {
+	var opts ApplyOptions
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "apply",
+		DisableFlagsInUseLine: true,
+		Short:                 "Converge dev environments to a manifest file",
+		Long:                  applyLong,
+		Example:               applyExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunApply(t, applyStore, opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&opts.File, "file", "f", "brev.yaml", "manifest file describing the dev environments")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "print the plan without applying it")
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "apply the plan without asking for confirmation")
+	cmd.Flags().BoolVar(&opts.Prune, "prune", false, "delete your dev environments that are not in the manifest")
+	cmd.Flags().BoolVarP(&opts.Wait, "wait", "w", false, "wait for created dev environments to be running")
+
+	return cmd
+}
+
+func RunApply(t *terminal.Terminal, applyStore ApplyStore, opts ApplyOptions) error {
+	contents, err := applyStore.GetFileAsString(opts.File)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	manifest, err := ParseManifest([]byte(contents))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	user, err := applyStore.GetCurrentUser()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	org, err := getOrg(applyStore, manifest.Org)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	existing, err := applyStore.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{UserID: user.ID})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	plan := MakePlan(*manifest, existing, user, config.GlobalConfig.GetDefaultClusterID(), opts.Prune)
+	displayPlan(t, org, plan)
+	if !plan.HasChanges() || opts.DryRun {
+		return nil
+	}
+
+	if !opts.Yes {
+		res := terminal.PromptSelectInput(terminal.PromptSelectContent{
+			Label:    "Apply these changes?",
+			ErrorMsg: "error",
+			Items:    []string{"no", "yes"},
+		})
+		if res != "yes" {
+			t.Vprint("Apply cancelled")
+			return nil
+		}
+	}
+
+	err = applyPlan(t, applyStore, org, plan, opts.Wait)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func getOrg(applyStore ApplyStore, orgName string) (*entity.Organization, error) {
+	if orgName == "" {
+		org, err := applyStore.GetActiveOrganizationOrDefault()
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		if org == nil {
+			return nil, breverrors.NewValidationError("no orgs exist")
+		}
+		return org, nil
+	}
+	orgs, err := applyStore.GetOrganizations(&store.GetOrganizationsOptions{Name: orgName})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if len(orgs) == 0 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("no org with name %s", orgName))
+	} else if len(orgs) > 1 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("more than one org with name %s", orgName))
+	}
+	return &orgs[0], nil
+}
+
+func displayPlan(t *terminal.Terminal, org *entity.Organization, plan Plan) {
+	t.Vprintf("Plan for org %s: %d to create, %d to modify, %d to delete\n\n",
+		t.Yellow(org.Name), plan.Count(Create), plan.Count(Modify), plan.Count(Delete))
+	for _, a := range plan.Actions {
+		switch a.Type {
+		case Create:
+			t.Vprint(t.Green("  + %s", a.Name))
+		case Modify:
+			t.Vprint(t.Yellow("  ~ %s", a.Name))
+		case Delete:
+			t.Vprint(t.Red("  - %s", a.Name))
+		case NoOp:
+			t.Vprintf("    %s (up to date)\n", a.Name)
+		}
+		for _, c := range a.Changes {
+			t.Vprintf("        %s\n", c)
+		}
+		for _, w := range a.Warnings {
+			t.Vprint(t.Yellow("        warning: %s", w))
+		}
+	}
+	t.Vprint("")
+	if !plan.HasChanges() {
+		t.Vprint(t.Green("Dev environments match the manifest, nothing to do"))
+	}
+}
+
+func applyPlan(t *terminal.Terminal, applyStore ApplyStore, org *entity.Organization, plan Plan, wait bool) error {
+	var allErr error
+	created := []*entity.Workspace{}
+	for _, a := range plan.Actions {
+		var err error
+		switch a.Type {
+		case Create:
+			var w *entity.Workspace
+			w, err = applyStore.CreateWorkspace(org.ID, a.Create)
+			if err == nil {
+				created = append(created, w)
+			}
+		case Modify:
+			_, err = applyStore.ModifyWorkspace(a.Workspace.ID, a.Modify)
+		case Delete:
+			_, err = applyStore.DeleteWorkspace(a.Workspace.ID)
+		case NoOp:
+			continue
+		}
+		if err != nil {
+			t.Vprint(t.Red("  %s %s failed: %v", a.Type, a.Name, err))
+			allErr = multierror.Append(allErr, fmt.Errorf("%s %s: %w", a.Type, a.Name, err))
+			continue
+		}
+		t.Vprint(t.Green("  %s %s done", a.Type, a.Name))
+	}
+
+	if wait {
+		for _, w := range created {
+			_, err := waiter.NewWaiter(applyStore, t).WithWaitMessage(fmt.Sprintf(" waiting for %s", w.Name)).WaitFor(context.Background(), w.ID, waiter.Running)
+			if err != nil {
+				allErr = multierror.Append(allErr, err)
+			}
+		}
+	}
+
+	if allErr != nil {
+		return breverrors.WrapAndTrace(allErr)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package apply
+
+import (
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifest = `
+version: v1
+workspaces:
+  - name: api
+    gitRepo: https://github.com/brevdev/brev-cli
+    class: 4x16
+    isStoppable: true
+  - name: web
+    class: 2x8
+  - name: trainer
+    instanceType: g5.xlarge
+`
+
+func TestParseManifest(t *testing.T) {
+	m, err := ParseManifest([]byte(testManifest))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, m.Workspaces, 3)
+	assert.Equal(t, "4x16", m.Workspaces[0].WorkspaceClassID)
+	assert.True(t, *m.Workspaces[0].IsStoppable)
+
+	_, err = ParseManifest([]byte("workspaces:\n  - name: a\n  - name: a\n"))
+	assert.Error(t, err)
+
+	_, err = ParseManifest([]byte("workspaces:\n  - name: a\n    gpu: g5.xlarge\n"))
+	assert.Error(t, err)
+
+	_, err = ParseManifest([]byte("version: v2\n"))
+	assert.Error(t, err)
+}
+
+func TestMakePlan(t *testing.T) {
+	m, err := ParseManifest([]byte(testManifest))
+	if !assert.Nil(t, err) {
+		return
+	}
+	user := &entity.User{ID: "me"}
+	existing := []entity.Workspace{
+		{ID: "1", Name: "api", CreatedByUserID: "me", GitRepo: "github.com:brevdev/brev-cli.git", WorkspaceClassID: "2x8", IsStoppable: false},
+		{ID: "2", Name: "web", CreatedByUserID: "me", WorkspaceClassID: "2x8"},
+		{ID: "3", Name: "old", CreatedByUserID: "me"},
+		{ID: "4", Name: "teammate", CreatedByUserID: "other"},
+	}
+
+	plan := MakePlan(*m, existing, user, "cluster", false)
+	assert.Equal(t, 1, plan.Count(Create))
+	assert.Equal(t, 1, plan.Count(Modify))
+	assert.Equal(t, 1, plan.Count(NoOp))
+	assert.Equal(t, 0, plan.Count(Delete))
+
+	api := plan.Actions[0]
+	assert.Equal(t, Modify, api.Type)
+	assert.Equal(t, "4x16", api.Modify.WorkspaceClassID)
+	assert.True(t, *api.Modify.IsStoppable)
+	assert.Empty(t, api.Warnings)
+
+	trainer := plan.Actions[2]
+	assert.Equal(t, Create, trainer.Type)
+	assert.Equal(t, "g5.xlarge", trainer.Create.InstanceType)
+	assert.Equal(t, "cluster", trainer.Create.WorkspaceGroupID)
+
+	plan = MakePlan(*m, existing, user, "cluster", true)
+	assert.Equal(t, 1, plan.Count(Delete))
+	assert.Equal(t, "old", plan.Actions[3].Name)
+}
+
+func TestMakeModifyActionSetupPath(t *testing.T) {
+	w := entity.Workspace{ID: "1", Name: "api", StartupScriptPath: ".brev/setup.sh"}
+
+	action := makeModifyAction(WorkspaceManifest{Name: "api", SetupPath: ".brev/setup.sh"}, w)
+	assert.Equal(t, NoOp, action.Type)
+
+	action = makeModifyAction(WorkspaceManifest{Name: "api", SetupPath: "scripts/setup.sh"}, w)
+	assert.Equal(t, Modify, action.Type)
+	assert.Equal(t, "scripts/setup.sh", action.Modify.StartupScriptPath)
+
+	action = makeModifyAction(WorkspaceManifest{Name: "api", SetupRepo: "github.com/me/setup", SetupPath: "setup.sh"}, w)
+	assert.Equal(t, Modify, action.Type)
+	assert.Equal(t, "setup.sh", action.Modify.Repos["configRepo"].SetupExecPath)
+
+	withRepos := WorkspaceManifest{
+		Name:      "api",
+		Repos:     entity.ReposV0{"web": entity.RepoV0{Repository: "github.com/me/web"}},
+		SetupRepo: "github.com/me/setup",
+		SetupPath: "setup.sh",
+	}
+	action = makeModifyAction(withRepos, w)
+	assert.Equal(t, Modify, action.Type)
+	assert.Equal(t, "github.com/me/web", action.Modify.Repos["web"].Repository)
+	assert.Equal(t, "setup.sh", action.Modify.Repos["configRepo"].SetupExecPath)
+	assert.Len(t, withRepos.Repos, 1, "the manifest is left as is")
+
+	w.ReposV0 = action.Modify.Repos
+	assert.Equal(t, NoOp, makeModifyAction(withRepos, w).Type)
+	create := makeCreateAction(withRepos, &entity.User{}, "cluster")
+	assert.Equal(t, "setup.sh", create.Create.Repos["configRepo"].SetupExecPath)
+}
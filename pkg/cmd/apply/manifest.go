@@ -0,0 +1,295 @@
+package apply
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/start"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/featureflag"
+	"github.com/brevdev/brev-cli/pkg/store"
+	allutil "github.com/brevdev/brev-cli/pkg/util"
+)
+
+const ManifestVersion = "v1"
+
+// Manifest is the desired set of dev environments, usually kept in brev.yaml
+type Manifest struct {
+	Version    string              `json:"version"`
+	Org        string              `json:"org,omitempty"`
+	Workspaces []WorkspaceManifest `json:"workspaces"`
+}
+
+type WorkspaceManifest struct {
+	Name             string            `json:"name"`
+	GitRepo          string            `json:"gitRepo,omitempty"`
+	WorkspaceClassID string            `json:"class,omitempty"`
+	InstanceType     string            `json:"instanceType,omitempty"`
+	WorkspaceGroupID string            `json:"workspaceGroupId,omitempty"`
+	IsStoppable      *bool             `json:"isStoppable,omitempty"`
+	SetupRepo        string            `json:"setupRepo,omitempty"`
+	SetupPath        string            `json:"setupPath,omitempty"`
+	IDEConfig        *entity.IDEConfig `json:"ideConfig,omitempty"`
+	Repos            entity.ReposV0    `json:"repos,omitempty"`
+	Execs            entity.ExecsV0    `json:"execs,omitempty"`
+}
+
+func ParseManifest(contents []byte) (*Manifest, error) {
+	var m Manifest
+	err := yaml.UnmarshalStrict(contents, &m)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid manifest: %v", err))
+	}
+	err = m.Validate()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &m, nil
+}
+
+func (m Manifest) Validate() error {
+	if m.Version != "" && m.Version != ManifestVersion {
+		return breverrors.NewValidationError(fmt.Sprintf("unsupported manifest version %q, expected %s", m.Version, ManifestVersion))
+	}
+	seen := map[string]bool{}
+	for i, w := range m.Workspaces {
+		if w.Name == "" {
+			return breverrors.NewValidationError(fmt.Sprintf("workspaces[%d]: name is required", i))
+		}
+		if seen[w.Name] {
+			return breverrors.NewValidationError(fmt.Sprintf("workspaces[%d]: duplicate name %s", i, w.Name))
+		}
+		seen[w.Name] = true
+		if w.SetupRepo != "" && w.SetupPath == "" {
+			return breverrors.NewValidationError(fmt.Sprintf("workspaces[%d]: setupPath is required when setupRepo is set", i))
+		}
+	}
+	return nil
+}
+
+type ActionType string
+
+const (
+	Create ActionType = "create"
+	Modify ActionType = "modify"
+	Delete ActionType = "delete"
+	NoOp   ActionType = "no-op"
+)
+
+type Action struct {
+	Type      ActionType
+	Name      string
+	Workspace *entity.Workspace // existing workspace, nil on create
+	Create    *store.CreateWorkspacesOptions
+	Modify    *store.ModifyWorkspaceRequest
+	Changes   []string
+	Warnings  []string
+}
+
+type Plan struct {
+	Actions []Action
+}
+
+func (p Plan) Count(actionType ActionType) int {
+	count := 0
+	for _, a := range p.Actions {
+		if a.Type == actionType {
+			count++
+		}
+	}
+	return count
+}
+
+func (p Plan) HasChanges() bool {
+	return p.Count(Create)+p.Count(Modify)+p.Count(Delete) > 0
+}
+
+// MakePlan diffs the manifest against the user's existing workspaces. Only
+// workspaces created by user are considered, and ones missing from the
+// manifest are deleted only when prune is set
+func MakePlan(m Manifest, existing []entity.Workspace, user *entity.User, clusterID string, prune bool) Plan {
+	byName := map[string]entity.Workspace{}
+	for _, w := range store.FilterForUserWorkspaces(existing, user.ID) {
+		byName[w.Name] = w
+	}
+
+	plan := Plan{}
+	for _, desired := range m.Workspaces {
+		w, ok := byName[desired.Name]
+		if !ok {
+			plan.Actions = append(plan.Actions, makeCreateAction(desired, user, clusterID))
+			continue
+		}
+		delete(byName, desired.Name)
+		plan.Actions = append(plan.Actions, makeModifyAction(desired, w))
+	}
+
+	if prune {
+		names := []string{}
+		for name := range byName {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			w := byName[name]
+			plan.Actions = append(plan.Actions, Action{
+				Type:      Delete,
+				Name:      name,
+				Workspace: &w,
+			})
+		}
+	}
+	return plan
+}
+
+func normalizeGitRepo(repo string) string {
+	if repo != "" && allutil.IsGitURL(repo) {
+		return start.MakeNewWorkspaceFromURL(repo).GitRepo
+	}
+	return repo
+}
+
+func makeCreateAction(desired WorkspaceManifest, user *entity.User, clusterID string) Action {
+	if desired.WorkspaceGroupID != "" {
+		clusterID = desired.WorkspaceGroupID
+	}
+	options := store.NewCreateWorkspacesOptions(clusterID, desired.Name).
+		WithGitRepo(normalizeGitRepo(desired.GitRepo))
+	if desired.WorkspaceClassID != "" {
+		options = options.WithWorkspaceClassID(desired.WorkspaceClassID)
+	}
+	options = resolveWorkspaceUserOptions(options, user)
+	if desired.InstanceType != "" {
+		options = options.WithInstanceType(desired.InstanceType)
+	}
+	if desired.SetupRepo != "" {
+		options = options.WithCustomSetupRepo(desired.SetupRepo, desired.SetupPath)
+	} else if desired.SetupPath != "" {
+		options.StartupScriptPath = desired.SetupPath
+	}
+	options.IsStoppable = desired.IsStoppable
+	options.IDEConfig = desired.IDEConfig
+	if desired.Repos != nil {
+		options.Repos = manifestRepos(desired)
+	}
+	if desired.Execs != nil {
+		options.Execs = desired.Execs
+	}
+
+	changes := []string{}
+	if options.GitRepo != "" {
+		changes = append(changes, fmt.Sprintf("gitRepo %s", options.GitRepo))
+	}
+	if options.InstanceType != "" {
+		changes = append(changes, fmt.Sprintf("instanceType %s", options.InstanceType))
+	} else {
+		changes = append(changes, fmt.Sprintf("class %s", options.WorkspaceClassID))
+	}
+	return Action{
+		Type:    Create,
+		Name:    desired.Name,
+		Create:  options,
+		Changes: changes,
+	}
+}
+
+// manifestRepos are the repos of desired with its setupRepo as the configRepo
+// entry, the way WithCustomSetupRepo sets it when there are no other repos
+func manifestRepos(desired WorkspaceManifest) entity.ReposV0 {
+	if desired.Repos == nil || desired.SetupRepo == "" {
+		return desired.Repos
+	}
+	repos := entity.ReposV0{}
+	for name, repo := range desired.Repos {
+		repos[name] = repo
+	}
+	repos["configRepo"] = entity.RepoV0{Repository: desired.SetupRepo, SetupExecPath: desired.SetupPath}
+	return repos
+}
+
+func makeModifyAction(desired WorkspaceManifest, w entity.Workspace) Action {
+	req := &store.ModifyWorkspaceRequest{}
+	changes := []string{}
+	warnings := []string{}
+
+	if desired.WorkspaceClassID != "" && desired.WorkspaceClassID != w.WorkspaceClassID {
+		req.WorkspaceClassID = desired.WorkspaceClassID
+		changes = append(changes, fmt.Sprintf("class %s -> %s", w.WorkspaceClassID, desired.WorkspaceClassID))
+	}
+	if desired.InstanceType != "" && desired.InstanceType != w.InstanceType {
+		req.InstanceType = desired.InstanceType
+		changes = append(changes, fmt.Sprintf("instanceType %s -> %s", w.InstanceType, desired.InstanceType))
+	}
+	if desired.IsStoppable != nil && *desired.IsStoppable != w.IsStoppable {
+		req.IsStoppable = desired.IsStoppable
+		changes = append(changes, fmt.Sprintf("isStoppable %t -> %t", w.IsStoppable, *desired.IsStoppable))
+	}
+	if desired.IDEConfig != nil && !reflect.DeepEqual(*desired.IDEConfig, w.IDEConfig) {
+		req.IDEConfig = desired.IDEConfig
+		changes = append(changes, "ideConfig")
+	}
+	if repos := manifestRepos(desired); repos != nil && !reflect.DeepEqual(repos, w.ReposV0) {
+		req.Repos = repos
+		changes = append(changes, "repos")
+	}
+	if desired.Execs != nil && !reflect.DeepEqual(desired.Execs, w.ExecsV0) {
+		req.Execs = desired.Execs
+		changes = append(changes, "execs")
+	}
+	// mirrors makeCreateAction: with a setupRepo the path lives in the
+	// configRepo entry, otherwise it is the startup script path. With repos
+	// the entry was merged into them above
+	if desired.SetupRepo != "" && desired.Repos == nil {
+		configRepo := entity.RepoV0{Repository: desired.SetupRepo, SetupExecPath: desired.SetupPath}
+		if current, ok := w.ReposV0["configRepo"]; !ok || current.Repository != configRepo.Repository || current.SetupExecPath != configRepo.SetupExecPath {
+			req.Repos = entity.ReposV0{"configRepo": configRepo}
+			changes = append(changes, fmt.Sprintf("setupRepo %s:%s -> %s:%s", current.Repository, current.SetupExecPath, desired.SetupRepo, desired.SetupPath))
+		}
+	} else if desired.SetupRepo == "" && desired.SetupPath != "" && desired.SetupPath != w.StartupScriptPath {
+		req.StartupScriptPath = desired.SetupPath
+		changes = append(changes, fmt.Sprintf("setupPath %s -> %s", w.StartupScriptPath, desired.SetupPath))
+	}
+
+	if repo := normalizeGitRepo(desired.GitRepo); repo != "" && repo != w.GitRepo {
+		warnings = append(warnings, fmt.Sprintf("gitRepo can not be changed in place (%s -> %s), delete %s to recreate it", w.GitRepo, repo, w.Name))
+	}
+	if desired.WorkspaceGroupID != "" && desired.WorkspaceGroupID != w.WorkspaceGroupID {
+		warnings = append(warnings, fmt.Sprintf("workspaceGroupId can not be changed in place (%s -> %s)", w.WorkspaceGroupID, desired.WorkspaceGroupID))
+	}
+
+	actionType := Modify
+	if len(changes) == 0 {
+		actionType = NoOp
+		req = nil
+	}
+	return Action{
+		Type:      actionType,
+		Name:      desired.Name,
+		Workspace: &w,
+		Modify:    req,
+		Changes:   changes,
+		Warnings:  warnings,
+	}
+}
+
+func resolveWorkspaceUserOptions(options *store.CreateWorkspacesOptions, user *entity.User) *store.CreateWorkspacesOptions {
+	if options.WorkspaceTemplateID == "" {
+		if featureflag.IsAdmin(user.GlobalUserType) {
+			options.WorkspaceTemplateID = store.DevWorkspaceTemplateID
+		} else {
+			options.WorkspaceTemplateID = store.UserWorkspaceTemplateID
+		}
+	}
+	if options.WorkspaceClassID == "" {
+		if featureflag.IsAdmin(user.GlobalUserType) {
+			options.WorkspaceClassID = store.DevWorkspaceClassID
+		} else {
+			options.WorkspaceClassID = store.UserWorkspaceClassID
+		}
+	}
+	return options
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/samber/mo"
 	"github.com/spf13/cobra"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/terminal"
@@ -18,6 +19,7 @@ var (
 )
 
 func NewCmdautostop(t *terminal.Terminal, store autostopStore) *cobra.Command {
+	var selector bulk.Selector
 	cmd := &cobra.Command{
 		Use:                   "autostop",
 		DisableFlagsInUseLine: true,
@@ -25,6 +27,13 @@ func NewCmdautostop(t *terminal.Terminal, store autostopStore) *cobra.Command {
 		Long:                  long,
 		Example:               example,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			selector.Names = args
+			if selector.IsBulk() {
+				return runBulkAutostop(t, selector, store)
+			}
+			if len(args) == 0 {
+				return breverrors.NewValidationError("please provide a dev environment to autostop")
+			}
 			err := Runautostop(
 				runAutostopArgs{
 					t:     t,
@@ -38,12 +47,16 @@ func NewCmdautostop(t *terminal.Terminal, store autostopStore) *cobra.Command {
 			return nil
 		},
 	}
+	bulk.AddFlags(cmd, &selector)
+	cmd.AddCommand(newCmdStatus(t, store))
 	return cmd
 }
 
 type autostopStore interface {
+	GetBrevHomePath() (string, error)
+	UserHomeDir() (string, error)
 	AutoStopWorkspace(workspaceID string) (*entity.Workspace, error)
-	GetActiveOrganizationOrDefault() (*entity.Organization, error)
+	bulk.SelectorStore
 	GetWorkspaceByNameOrID(orgID string, nameOrID string) ([]entity.Workspace, error)
 }
 
@@ -84,3 +97,20 @@ func Runautostop(args runAutostopArgs) error {
 	}
 	return nil
 }
+
+func runBulkAutostop(t *terminal.Terminal, selector bulk.Selector, store autostopStore) error {
+	err := bulk.Apply(t, store, selector, bulk.Operation{
+		Verb: "autostop",
+		Run: func(w entity.Workspace) error {
+			_, err := store.AutoStopWorkspace(w.ID)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package autostop
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/background"
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/idle"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var statusLong = `Show the autostop policy and what each activity signal reports right now.
+
+The policy is read from $HOME/.brev/autostop.yaml and every stop is recorded in
+$HOME/.brev/autostop_audit.log. Restart the autostop daemon after changing the policy.`
+
+func newCmdStatus(t *terminal.Terminal, store autostopStore) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the autostop policy and current activity signals",
+		Long:  statusLong,
+		Args:  cmderrors.TransformToValidationError(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunStatus(t, store)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+}
+
+func RunStatus(t *terminal.Terminal, store autostopStore) error {
+	brevHome, err := store.GetBrevHomePath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	home, err := store.UserHomeDir()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	fs := afero.NewOsFs()
+	policy, err := idle.LoadPolicy(fs, brevHome)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	t.Vprintf("Policy: %s\n", filepath.Join(brevHome, idle.PolicyFileName))
+	t.Vprintf("Stop after %s with no activity", t.Yellow(policy.GetIdleTimeout().String()))
+	if policy.DryRun {
+		t.Vprintf(" (dry run, stops are only logged)")
+	}
+	t.Vprint("\n")
+
+	signals := idle.NewSignals(*policy, fs, background.NewJobStore(fs, home))
+	detector := idle.NewDetector(*policy, signals)
+	// the process signal compares two samples, so prime it first
+	detector.Check()
+	time.Sleep(time.Second)
+	decision := detector.Check()
+	for _, a := range decision.Activity {
+		state := t.Green("idle")
+		if a.Active {
+			state = t.Yellow("active")
+		}
+		t.Vprintf("  %-24s %-8s %s\n", a.Signal, state, a.Detail)
+	}
+	if len(decision.ActiveSignals()) == 0 {
+		t.Vprint(t.Green("\nNo activity right now"))
+	} else {
+		t.Vprintf("\nActive: %s\n", strings.Join(decision.ActiveSignals(), ", "))
+	}
+	return nil
+}
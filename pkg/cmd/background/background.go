@@ -1,22 +1,43 @@
 package background
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/output"
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
+var (
+	backgroundLong = `Run a command in the background, with optional 'brev stop self' once it finishes.
+
+Each job gets its own log file in $HOME/brev-background-logs and autostop is
+disabled while jobs are running. It is re-enabled once the last job finishes.
+
+Quote commands that share a name with a subcommand, ex: brev bg "ls -la"`
+	backgroundExample = `  brev background ./train.sh --epochs 10
+  brev bg --stop python train.py
+  brev bg ls
+  brev bg logs -f 1
+  brev bg wait 1
+  brev bg kill 1`
+)
+
 type BackgroundStore interface {
 	util.GetWorkspaceByNameOrIDErrStore
 	GetActiveOrganizationOrDefault() (*entity.Organization, error)
@@ -25,13 +46,14 @@ type BackgroundStore interface {
 	GetWorkspace(workspaceID string) (*entity.Workspace, error)
 	GetCurrentWorkspaceID() (string, error)
 	CreateWorkspace(organizationID string, options *store.CreateWorkspacesOptions) (*entity.Workspace, error)
+	StopWorkspace(workspaceID string) (*entity.Workspace, error)
+	UserHomeDir() (string, error)
 }
 
 func DisableAutoStop(s BackgroundStore, workspaceID string) error {
 	isStoppable := false
 	_, err := s.ModifyWorkspace(workspaceID, &store.ModifyWorkspaceRequest{
-		WorkspaceClassID: workspaceID,
-		IsStoppable:      &isStoppable,
+		IsStoppable: &isStoppable,
 	})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -42,8 +64,7 @@ func DisableAutoStop(s BackgroundStore, workspaceID string) error {
 func EnableAutoStop(s BackgroundStore, workspaceID string) error {
 	isStoppable := true
 	_, err := s.ModifyWorkspace(workspaceID, &store.ModifyWorkspaceRequest{
-		WorkspaceClassID: workspaceID,
-		IsStoppable:      &isStoppable,
+		IsStoppable: &isStoppable,
 	})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -52,150 +73,489 @@ func EnableAutoStop(s BackgroundStore, workspaceID string) error {
 }
 
 func NewCmdBackground(t *terminal.Terminal, s BackgroundStore) *cobra.Command {
+	var stop bool
+	var progress bool
+
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
 		Use:                   "background [flags] [command]",
 		Aliases:               []string{"bg"},
 		DisableFlagsInUseLine: true,
 		Short:                 "Run a command in the background with optional 'brev stop self' at the end",
-		Long:                  "This command will run a specified command in the background using nohup and write logs to $HOME/brev-background-logs.",
-		Example:               "background ./myscript.sh --stop",
+		Long:                  backgroundLong,
+		Example:               backgroundExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Parse the flags
-			stopFlag, err := cmd.Flags().GetBool("stop")
-			if err != nil {
-				log.Fatal(err)
+			if progress {
+				return RunLs(t, s, output.Options{})
 			}
-			progressFlag, err := cmd.Flags().GetBool("progress")
-			if err != nil {
-				log.Fatal(err)
+			if len(args) == 0 {
+				return breverrors.NewValidationError("please provide a command to run in the background")
 			}
-			if progressFlag {
-				checkProgress()
-				return nil
+			err := RunBackground(t, s, strings.Join(args, " "), stop)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
 			}
+			return nil
+		},
+	}
+	// everything after the command belongs to it, ex: brev bg python train.py --epochs 10
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().BoolVar(&stop, "stop", false, "Stop the workspace after the command is finished")
+	cmd.Flags().BoolVar(&progress, "progress", false, "Show progress of the background commands")
+	_ = cmd.Flags().MarkDeprecated("progress", "use brev bg ls instead")
 
-			// Join the args into a command string
-			command := ""
-			if len(args) > 0 {
-				command = args[0]
-			}
-			for i := 1; i < len(args); i++ {
-				command += " " + args[i]
-			}
+	cmd.AddCommand(newCmdLs(t, s))
+	cmd.AddCommand(newCmdLogs(t, s))
+	cmd.AddCommand(newCmdKill(t, s))
+	cmd.AddCommand(newCmdWait(t, s))
+	cmd.AddCommand(newCmdSupervise(s))
 
-			// Create logs directory if it doesn't exist
-			logsDir := os.Getenv("HOME") + "/brev-background-logs"
-			err = os.MkdirAll(logsDir, os.ModePerm)
-			if err != nil {
-				log.Fatal(err)
-			}
+	return cmd
+}
 
-			wsID, err := s.GetCurrentWorkspaceID()
-			if err == nil {
-				// Disable auto stop
-				err = DisableAutoStop(s, wsID)
-				if err != nil {
-					log.Fatal(err)
-				}
-			}
+func getJobStore(s BackgroundStore) (*JobStore, error) {
+	home, err := s.UserHomeDir()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return NewJobStore(afero.NewOsFs(), home), nil
+}
 
-			// Run the command in the background using nohup
-			c := exec.Command("nohup", "bash", "-c", command+">"+logsDir+"/log.txt 2>&1 &") // #nosec G204
-			err = c.Start()
-			if err != nil {
-				log.Fatal(err)
-			}
+// RunBackground records the job and hands it to a detached supervisor process
+// that outlives this one, runs the command and records how it exited
+func RunBackground(t *terminal.Terminal, s BackgroundStore, command string, stop bool) error {
+	jobStore, err := getJobStore(s)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	job, err := jobStore.Create(command, stop)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	wsID, err := s.GetCurrentWorkspaceID()
+	if err == nil && wsID != "" {
+		err = DisableAutoStop(s, wsID)
+		if err != nil {
+			failJob(s, jobStore, job, wsID)
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+
+	err = startSupervisor(job)
+	if err != nil {
+		failJob(s, jobStore, job, wsID)
+		return breverrors.WrapAndTrace(err)
+	}
+
+	t.Vprintf("Started background job %s: %s\n", t.Green(job.ID), command)
+	t.Vprintf("Logs: %s\n", job.LogPath)
+	t.Vprintf("Follow with %s\n", t.Yellow("brev bg logs -f %s", job.ID))
+	return nil
+}
+
+func startSupervisor(job *Job) error {
+	brevPath, err := os.Executable()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	logFile, err := os.OpenFile(job.LogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644) //nolint:gosec // path is from our job store
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer logFile.Close() //nolint:errcheck,gosec // the supervisor has its own handle
+
+	c := exec.Command(brevPath, "background", "supervise", job.ID) // #nosec G204
+	c.Stdout = logFile
+	c.Stderr = logFile
+	c.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	err = c.Start()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	err = c.Process.Release()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
 
-			// Write logs
-			logFile, err := os.OpenFile(logsDir+"/log.txt", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o666) //nolint: gosec // TODO
+// failJob is called when the supervisor could not be started, so that the job
+// does not stay pending and keep autostop disabled forever. It is best effort
+// since the original error is what gets reported
+func failJob(s BackgroundStore, jobStore *JobStore, job *Job, wsID string) {
+	finishedAt := time.Now()
+	job.Status = Failed
+	job.FinishedAt = &finishedAt
+	_ = jobStore.Save(job)
+	if wsID == "" {
+		return
+	}
+	hasJobs, err := jobStore.HasUnfinishedJobs(job.ID)
+	if err == nil && !hasJobs {
+		_ = EnableAutoStop(s, wsID)
+	}
+}
+
+func newCmdSupervise(s BackgroundStore) *cobra.Command {
+	return &cobra.Command{
+		Use:    "supervise",
+		Hidden: true,
+		Args:   cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunSupervise(s, args[0])
 			if err != nil {
-				log.Fatal(err)
+				return breverrors.WrapAndTrace(err)
 			}
+			return nil
+		},
+	}
+}
+
+// RunSupervise runs a job to completion. If no other jobs are left it then
+// either stops the workspace or re-enables autostop
+func RunSupervise(s BackgroundStore, jobID string) error {
+	jobStore, err := getJobStore(s)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	job, err := jobStore.Get(jobID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	job.SupervisorPID = os.Getpid()
+
+	err = runJob(jobStore, job, os.Stdout)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	fmt.Printf("%s: job %s %s\n", time.Now().Format("2006-01-02 15:04:05"), job.ID, job.Status)
+
+	wsID, err := s.GetCurrentWorkspaceID()
+	if err != nil || wsID == "" {
+		return nil //nolint:nilerr // not on a workspace, nothing to stop
+	}
+	hasJobs, err := jobStore.HasUnfinishedJobs(job.ID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if hasJobs {
+		if job.StopWhenDone {
+			fmt.Println("other background jobs are still running, the last one to finish will stop this dev environment")
+		}
+		return nil
+	}
+	// a --stop job that finished while this one ran left the stopping to us
+	shouldStop, err := jobStore.StopRequestedSince(job.CreatedAt)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if shouldStop {
+		fmt.Println("stopping this dev environment")
+		_, err = s.StopWorkspace(wsID)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	err = EnableAutoStop(s, wsID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
 
-			defer logFile.Close() //nolint: gosec,errcheck // TODO
+// runJob runs the command in its own process group, so kill can signal it and
+// its children, and records the outcome on job
+func runJob(jobStore *JobStore, job *Job, logs io.Writer) error {
+	c := exec.Command("bash", "-c", job.Command) // #nosec G204
+	c.Stdout = logs
+	c.Stderr = logs
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	startedAt := time.Now()
+	err := c.Start()
+	if err != nil {
+		job.Status = Failed
+		_ = jobStore.Save(job)
+		return breverrors.WrapAndTrace(err)
+	}
+	job.PID = c.Process.Pid
+	job.Status = Running
+	job.StartedAt = &startedAt
+	err = jobStore.Save(job)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	waitErr := c.Wait()
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	exitCode := 0
+	var exitErr *exec.ExitError
+	switch {
+	case waitErr == nil:
+		job.Status = Succeeded
+	case errors.As(waitErr, &exitErr):
+		job.Status = Failed
+		exitCode = exitErr.ExitCode()
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			job.Status = Killed
+			exitCode = 128 + int(ws.Signal())
+		}
+	default:
+		job.Status = Failed
+		exitCode = 1
+	}
+	job.ExitCode = &exitCode
+	err = jobStore.Save(job)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
 
-			logFile.WriteString(time.Now().Format("2006-01-02 15:04:05") + ": Command \"" + command + "\" was run in the background.\n") //nolint: errcheck,gosec // TODO
+var jobFields = output.Fields[Job]{
+	"id":      func(j Job) string { return j.ID },
+	"status":  func(j Job) string { return string(j.Status) },
+	"command": func(j Job) string { return j.Command },
+	"created": func(j Job) string { return j.CreatedAt.Format(time.RFC3339) },
+}
 
-			// Write process details to data file
-			processesFile, err := os.OpenFile(logsDir+"/processes.txt", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o666) //nolint: gosec // TODO
+func newCmdLs(t *terminal.Terminal, s BackgroundStore) *cobra.Command {
+	var outputOpts output.Options
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List background jobs",
+		Args:  cmderrors.TransformToValidationError(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunLs(t, s, outputOpts)
 			if err != nil {
-				log.Fatal(err)
-			}
-			defer processesFile.Close() //nolint: errcheck,gosec // TODO
-			_, _ = processesFile.WriteString(fmt.Sprintf("%d,%s,%s\n", c.Process.Pid, time.Now().Format("2006-01-02 15:04:05"), command))
-
-			if stopFlag {
-				// If --stop flag is set, run "brev stop self" at the end
-				defer func() {
-					stopCmd := exec.Command("brev", "stop", "self")
-					err = stopCmd.Run()
-					if err != nil {
-						log.Fatal(err)
-					}
-				}()
+				return breverrors.WrapAndTrace(err)
 			}
-
-			t.Vprintf("Command \"%s\" has been run in the background. Check %s for logs.\n", command, logsDir)
 			return nil
 		},
 	}
-	cmd.Flags().Bool("stop", false, "Stop the workspace after the command is finished")
-	cmd.Flags().Bool("progress", false, "Show progress of the background commands")
-
+	output.AddFlags(cmd, &outputOpts)
 	return cmd
 }
 
-type Process struct {
-	ID        int    `json:"id"`
-	Command   string `json:"command"`
-	LogsDir   string `json:"logsDir"`
-	StartTime string `json:"startTime"`
+func RunLs(t *terminal.Terminal, s BackgroundStore, outputOpts output.Options) error {
+	format, err := outputOpts.Format()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	jobStore, err := getJobStore(s)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	jobs, err := jobStore.List()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	jobs, err = output.Select(jobs, outputOpts, jobFields)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if format.IsMachineReadable() {
+		err = output.WriteList(os.Stdout, format, "BackgroundJobList", jobs, func(j Job) string { return j.ID })
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	if len(jobs) == 0 {
+		t.Vprint(t.Yellow("No background jobs, start one with brev bg <command>"))
+		return nil
+	}
+	displayJobsTable(t, jobs)
+	return nil
 }
 
-type Processes struct {
-	Processes []Process `json:"processes"`
+func displayJobsTable(t *terminal.Terminal, jobs []Job) {
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	options := table.OptionsDefault
+	options.DrawBorder = false
+	options.SeparateColumns = false
+	options.SeparateRows = false
+	options.SeparateHeader = false
+	ta.Style().Options = options
+	ta.AppendHeader(table.Row{"ID", "STATUS", "EXIT", "STARTED", "DURATION", "COMMAND"})
+	for _, j := range jobs {
+		exitCode := "-"
+		if j.ExitCode != nil {
+			exitCode = fmt.Sprint(*j.ExitCode)
+		}
+		started := "-"
+		duration := "-"
+		if j.StartedAt != nil {
+			started = j.StartedAt.Format("2006-01-02 15:04:05")
+			end := time.Now()
+			if j.FinishedAt != nil {
+				end = *j.FinishedAt
+			}
+			duration = end.Sub(*j.StartedAt).Round(time.Second).String()
+		}
+		ta.AppendRow(table.Row{j.ID, colorStatus(t, j.Status), exitCode, started, duration, j.Command})
+	}
+	ta.Render()
 }
 
-func checkProgress() {
-	filePath := fmt.Sprintf("%s/brev-background-logs/processes.txt", os.Getenv("HOME"))
-	file, err := os.Open(filePath) //nolint: gosec // TODO
-	if err != nil {
-		panic(err)
+func colorStatus(t *terminal.Terminal, status JobStatus) string {
+	switch status {
+	case Succeeded:
+		return t.Green(string(status))
+	case Failed, Killed, Lost:
+		return t.Red(string(status))
+	case Pending, Running:
+		return t.Yellow(string(status))
 	}
-	defer file.Close() //nolint: errcheck,gosec // TODO
+	return string(status)
+}
 
-	scanner := bufio.NewScanner(file)
+func newCmdLogs(t *terminal.Terminal, s BackgroundStore) *cobra.Command {
+	var follow bool
+	cmd := &cobra.Command{
+		Use:   "logs <id>",
+		Short: "Print the logs of a background job",
+		Args:  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunLogs(t, s, args[0], follow, os.Stdout)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "keep printing new output until the job finishes")
+	return cmd
+}
 
-	fmt.Println("Running processes:")
-	for scanner.Scan() {
-		processLine := scanner.Text()
-		processData := strings.Split(processLine, ",")
+func RunLogs(_ *terminal.Terminal, s BackgroundStore, jobID string, follow bool, w io.Writer) error {
+	jobStore, err := getJobStore(s)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	job, err := jobStore.Get(jobID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	f, err := os.Open(job.LogPath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer f.Close() //nolint:errcheck,gosec // read only
 
-		startTime, err := time.Parse("2006-01-02 15:04:05", processData[1])
+	for {
+		_, err = io.Copy(w, f)
 		if err != nil {
-			panic(err)
+			return breverrors.WrapAndTrace(err)
 		}
-
-		// Check if the process is still running by its PID
-		processID := processData[0]
-		cmd := fmt.Sprintf("ps -p %s -o comm=", processID)
-		output, err := exec.Command("bash", "-c", cmd).Output() //nolint: gosec // TODO
-
-		if err != nil || strings.TrimSpace(string(output)) == "" {
-			// Process is not running, show a checkmark
-			fmt.Printf("ID: %s \u2713\n", processID)
-		} else {
-			// Process is still running, display its info
-			fmt.Printf("ID: %s\n", processID)
-			fmt.Printf("Command: %s\n", processData[2])
-			fmt.Printf("Start time: %s\n", startTime.Format("2006-01-02 15:04:05"))
+		if !follow || job.IsFinished() {
+			return nil
 		}
+		time.Sleep(500 * time.Millisecond)
+		job, err = jobStore.Get(jobID)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+}
+
+func newCmdKill(t *terminal.Terminal, s BackgroundStore) *cobra.Command {
+	return &cobra.Command{
+		Use:   "kill <id>",
+		Short: "Kill a background job and the processes it started",
+		Args:  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunKill(t, s, args[0])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+}
+
+func RunKill(t *terminal.Terminal, s BackgroundStore, jobID string) error {
+	jobStore, err := getJobStore(s)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	job, err := jobStore.Get(jobID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if job.IsFinished() {
+		return breverrors.NewValidationError(fmt.Sprintf("job %s already %s", job.ID, job.Status))
+	}
+	if job.PID == 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("job %s has not started yet, try again in a moment", job.ID))
+	}
+	// negative pid signals the whole process group
+	err = syscall.Kill(-job.PID, syscall.SIGTERM)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf("Sent SIGTERM to job %s\n", job.ID)
+	return nil
+}
+
+func newCmdWait(t *terminal.Terminal, s BackgroundStore) *cobra.Command {
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "wait <id>",
+		Short: "Wait for a background job to finish and exit with its exit code",
+		Args:  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			err := RunWait(ctx, t, s, args[0])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "how long to wait before giving up, 0 waits forever")
+	return cmd
+}
 
-		fmt.Println()
+func RunWait(ctx context.Context, t *terminal.Terminal, s BackgroundStore, jobID string) error {
+	jobStore, err := getJobStore(s)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	job, err := waitForJob(ctx, jobStore, jobID, time.Second)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if job.Status != Succeeded {
+		return JobFailedError{Job: *job}
 	}
+	t.Vprint(t.Green("job %s succeeded", job.ID))
+	return nil
+}
 
-	if err := scanner.Err(); err != nil {
-		panic(err)
+func waitForJob(ctx context.Context, jobStore *JobStore, jobID string, interval time.Duration) (*Job, error) {
+	for {
+		job, err := jobStore.Get(jobID)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		if job.IsFinished() {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, breverrors.WrapAndTrace(fmt.Errorf("timed out waiting for job %s", jobID))
+		case <-time.After(interval):
+		}
 	}
 }
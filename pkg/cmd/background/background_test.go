@@ -1 +1,122 @@
 package background
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestJobStore(t *testing.T) *JobStore {
+	s := NewJobStore(afero.NewOsFs(), t.TempDir())
+	s.isAlive = func(pid int) bool { return true }
+	return s
+}
+
+func TestJobStoreCreateAllocatesIDs(t *testing.T) {
+	s := newTestJobStore(t)
+	j1, err := s.Create("echo one", false)
+	assert.Nil(t, err)
+	j2, err := s.Create("echo two", true)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", j1.ID)
+	assert.Equal(t, "2", j2.ID)
+	assert.Equal(t, Pending, j2.Status)
+	assert.True(t, j2.StopWhenDone)
+
+	jobs, err := s.List()
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, "echo one", jobs[0].Command)
+
+	_, err = s.Get("3")
+	assert.Error(t, err)
+}
+
+func TestJobStoreMarksLostJobs(t *testing.T) {
+	s := newTestJobStore(t)
+	job, err := s.Create("sleep 100", false)
+	assert.Nil(t, err)
+	job.Status = Running
+	job.SupervisorPID = 1234
+	assert.Nil(t, s.Save(job))
+
+	s.isAlive = func(pid int) bool { return false }
+	job, err = s.Get(job.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, Lost, job.Status)
+
+	hasJobs, err := s.HasUnfinishedJobs("")
+	assert.Nil(t, err)
+	assert.False(t, hasJobs)
+}
+
+func TestRunJobRecordsExitCode(t *testing.T) {
+	s := newTestJobStore(t)
+	job, err := s.Create("echo hello; exit 3", false)
+	assert.Nil(t, err)
+
+	logs := &bytes.Buffer{}
+	err = runJob(s, job, logs)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello\n", logs.String())
+
+	job, err = s.Get(job.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, Failed, job.Status)
+	assert.Equal(t, 3, *job.ExitCode)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, 3, JobFailedError{Job: *job}.ExitCode())
+}
+
+func TestRunJobKilled(t *testing.T) {
+	s := newTestJobStore(t)
+	job, err := s.Create("kill -TERM $$", false)
+	assert.Nil(t, err)
+
+	err = runJob(s, job, &bytes.Buffer{})
+	assert.Nil(t, err)
+	assert.Equal(t, Killed, job.Status)
+	assert.Equal(t, 143, *job.ExitCode)
+}
+
+func TestWaitForJob(t *testing.T) {
+	s := newTestJobStore(t)
+	job, err := s.Create("true", false)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = waitForJob(ctx, s, job.ID, time.Millisecond)
+	assert.Error(t, err)
+
+	assert.Nil(t, runJob(s, job, &bytes.Buffer{}))
+	job, err = waitForJob(context.Background(), s, job.ID, time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, Succeeded, job.Status)
+}
+
+func TestStopRequestedSince(t *testing.T) {
+	s := newTestJobStore(t)
+	stopper, err := s.Create("true", true)
+	assert.Nil(t, err)
+	longer, err := s.Create("true", false)
+	assert.Nil(t, err)
+
+	stop, err := s.StopRequestedSince(longer.CreatedAt)
+	assert.Nil(t, err)
+	assert.False(t, stop)
+
+	err = runJob(s, stopper, &bytes.Buffer{})
+	assert.Nil(t, err)
+	stop, err = s.StopRequestedSince(longer.CreatedAt)
+	assert.Nil(t, err)
+	assert.True(t, stop)
+
+	stop, err = s.StopRequestedSince(time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	assert.False(t, stop)
+}
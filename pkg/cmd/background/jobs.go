@@ -0,0 +1,258 @@
+package background
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+const logsDirName = "brev-background-logs"
+
+type JobStatus string
+
+const (
+	// Pending jobs have been created but their supervisor has not started the command yet
+	Pending   JobStatus = "pending"
+	Running   JobStatus = "running"
+	Succeeded JobStatus = "succeeded"
+	Failed    JobStatus = "failed"
+	Killed    JobStatus = "killed"
+	// Lost jobs were running when their supervisor went away, ex: the machine restarted
+	Lost JobStatus = "lost"
+)
+
+type Job struct {
+	ID            string     `json:"id"`
+	Command       string     `json:"command"`
+	Status        JobStatus  `json:"status"`
+	ExitCode      *int       `json:"exitCode,omitempty"`
+	PID           int        `json:"pid,omitempty"`
+	SupervisorPID int        `json:"supervisorPid,omitempty"`
+	StopWhenDone  bool       `json:"stopWhenDone"`
+	LogPath       string     `json:"logPath"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty"`
+}
+
+func (j Job) IsFinished() bool {
+	return j.Status != Pending && j.Status != Running
+}
+
+// JobFailedError is returned when waiting on a job that did not succeed, so
+// that brev bg wait exits with the job's own exit code
+type JobFailedError struct {
+	Job Job
+}
+
+func (e JobFailedError) Error() string {
+	if e.Job.ExitCode == nil {
+		return fmt.Sprintf("job %s %s", e.Job.ID, e.Job.Status)
+	}
+	return fmt.Sprintf("job %s %s with exit code %d", e.Job.ID, e.Job.Status, *e.Job.ExitCode)
+}
+
+func (e JobFailedError) ExitCode() int {
+	if e.Job.ExitCode == nil || *e.Job.ExitCode == 0 {
+		return 1
+	}
+	return *e.Job.ExitCode
+}
+
+// JobStore keeps one json file and one log file per job in dir
+type JobStore struct {
+	fs      afero.Fs
+	dir     string
+	isAlive func(pid int) bool
+}
+
+func NewJobStore(fs afero.Fs, homeDir string) *JobStore {
+	return &JobStore{
+		fs:      fs,
+		dir:     filepath.Join(homeDir, logsDirName),
+		isAlive: isProcessAlive,
+	}
+}
+
+func (s *JobStore) jobPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *JobStore) logPath(id string) string {
+	return filepath.Join(s.dir, id+".log")
+}
+
+// Create allocates the next job id by exclusively creating its file, so that
+// concurrent invocations never share an id
+func (s *JobStore) Create(command string, stopWhenDone bool) (*Job, error) {
+	err := s.fs.MkdirAll(s.dir, 0o755)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	ids, err := s.ids()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	next := 1
+	if len(ids) > 0 {
+		next = ids[len(ids)-1] + 1
+	}
+	for {
+		id := strconv.Itoa(next)
+		f, err := s.fs.OpenFile(s.jobPath(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			next++
+			continue
+		}
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		_ = f.Close()
+
+		job := &Job{
+			ID:           id,
+			Command:      command,
+			Status:       Pending,
+			StopWhenDone: stopWhenDone,
+			LogPath:      s.logPath(id),
+			CreatedAt:    time.Now(),
+		}
+		err = s.Save(job)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		return job, nil
+	}
+}
+
+// Save writes to a temp file and renames it so readers never see a partial job
+func (s *JobStore) Save(job *Job) error {
+	b, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	tmp := s.jobPath(job.ID) + ".tmp"
+	err = afero.WriteFile(s.fs, tmp, b, 0o644)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = s.fs.Rename(tmp, s.jobPath(job.ID))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (s *JobStore) Get(id string) (*Job, error) {
+	b, err := afero.ReadFile(s.fs, s.jobPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("no background job with id %s, run brev bg ls to see jobs", id))
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	var job Job
+	err = json.Unmarshal(b, &job)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	s.markIfLost(&job)
+	return &job, nil
+}
+
+// List returns all jobs ordered by id, skipping files that can not be read
+// rather than failing the whole listing
+func (s *JobStore) List() ([]Job, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	jobs := []Job{}
+	for _, id := range ids {
+		job, err := s.Get(strconv.Itoa(id))
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+// HasUnfinishedJobs reports whether any job other than exceptID is still running
+func (s *JobStore) HasUnfinishedJobs(exceptID string) (bool, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	for _, j := range jobs {
+		if j.ID != exceptID && !j.IsFinished() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// StopRequestedSince reports whether a job that asked to stop the workspace
+// finished after since, including the job that is asking
+func (s *JobStore) StopRequestedSince(since time.Time) (bool, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	for _, j := range jobs {
+		if j.StopWhenDone && j.IsFinished() && j.FinishedAt != nil && !j.FinishedAt.Before(since) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *JobStore) ids() ([]int, error) {
+	exists, err := afero.DirExists(s.fs, s.dir)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if !exists {
+		return []int{}, nil
+	}
+	entries, err := afero.ReadDir(s.fs, s.dir)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	ids := []int{}
+	for _, e := range entries {
+		id, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// markIfLost flags unfinished jobs whose supervisor is gone, it is not saved
+// so that a supervisor that is only slow to start is not overwritten
+func (s *JobStore) markIfLost(job *Job) {
+	if job.IsFinished() || job.SupervisorPID == 0 {
+		return
+	}
+	if !s.isAlive(job.SupervisorPID) {
+		job.Status = Lost
+	}
+}
+
+func isProcessAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,395 @@
+// Package bulk selects dev environments by name, glob and filters, and runs
+// an operation on all of them with a summary of what happened to each
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+const (
+	CreatedByMe  = "me"
+	CreatedByAll = "all"
+
+	DefaultConcurrency = 4
+)
+
+type SelectorStore interface {
+	GetCurrentUser() (*entity.User, error)
+	GetActiveOrganizationOrDefault() (*entity.Organization, error)
+	GetOrganizations(options *store.GetOrganizationsOptions) ([]entity.Organization, error)
+	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
+}
+
+// Selector picks dev environments. Names may be exact names, ids or globs,
+// and every set filter must match
+type Selector struct {
+	Names     []string
+	CreatedBy string
+	Status    string
+	OlderThan time.Duration
+	Org       string
+	All       bool
+
+	Options
+}
+
+type Options struct {
+	DryRun      bool
+	Yes         bool
+	Concurrency int
+}
+
+// AddFlags registers the selector flags that cmd does not define already, so
+// commands like start can keep their own --org
+func AddFlags(cmd *cobra.Command, s *Selector) {
+	flags := cmd.Flags()
+	if flags.Lookup("created-by") == nil {
+		flags.StringVar(&s.CreatedBy, "created-by", CreatedByMe, "only dev environments created by this user id, me or all")
+	}
+	if flags.Lookup("status") == nil {
+		flags.StringVar(&s.Status, "status", "", "only dev environments with one of these comma separated statuses, ex: RUNNING,STOPPED")
+	}
+	if flags.Lookup("older-than") == nil {
+		flags.DurationVar(&s.OlderThan, "older-than", 0, "only dev environments created longer ago than this, ex: 72h")
+	}
+	if flags.Lookup("org") == nil {
+		flags.StringVar(&s.Org, "org", "", "organization to select from, defaults to the active org")
+	}
+	if flags.Lookup("dry-run") == nil {
+		flags.BoolVar(&s.DryRun, "dry-run", false, "print the dev environments that would be affected and exit")
+	}
+	if flags.Lookup("yes") == nil {
+		flags.BoolVarP(&s.Yes, "yes", "y", false, "do not ask for confirmation")
+	}
+	if flags.Lookup("concurrency") == nil {
+		flags.IntVar(&s.Concurrency, "concurrency", DefaultConcurrency, "how many dev environments to act on at once")
+	}
+}
+
+var selectorFlags = []string{"created-by", "status", "older-than", "org", "dry-run", "yes", "concurrency", "all"}
+
+// ChangedFlags lists the selector flags the user passed, for commands that
+// have modes which can't take a selection
+func ChangedFlags(cmd *cobra.Command) []string {
+	return lo.Filter(selectorFlags, func(name string, _ int) bool {
+		return cmd.Flags().Changed(name)
+	})
+}
+
+func isGlob(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// IsPattern is true when the selection is not just explicit names, so the
+// user may not know exactly what will match
+func (s Selector) IsPattern() bool {
+	if s.All || s.Status != "" || s.OlderThan > 0 || (s.CreatedBy != "" && s.CreatedBy != CreatedByMe) {
+		return true
+	}
+	return lo.SomeBy(s.Names, isGlob)
+}
+
+// NeedsConfirmation is true for patterns, except a bare --all which selects
+// exactly the user's own dev environments, same as --all always has, and so
+// must keep working in scripts without --yes
+func (s Selector) NeedsConfirmation() bool {
+	if !s.IsPattern() {
+		return false
+	}
+	bareAll := s.All && s.Status == "" && s.OlderThan <= 0 &&
+		(s.CreatedBy == "" || s.CreatedBy == CreatedByMe) && !lo.SomeBy(s.Names, isGlob)
+	return !bareAll
+}
+
+// IsBulk is true when the command should go through Resolve and Run rather
+// than its single dev environment path
+func (s Selector) IsBulk() bool {
+	return s.IsPattern() || len(s.Names) > 1 || s.DryRun
+}
+
+func (s Selector) getOrg(selectorStore SelectorStore) (*entity.Organization, error) {
+	if s.Org == "" {
+		org, err := selectorStore.GetActiveOrganizationOrDefault()
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		if org == nil {
+			return nil, breverrors.NewValidationError("no orgs exist")
+		}
+		return org, nil
+	}
+	orgs, err := selectorStore.GetOrganizations(&store.GetOrganizationsOptions{Name: s.Org})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if len(orgs) == 0 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("no org with name %s", s.Org))
+	} else if len(orgs) > 1 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("more than one org with name %s", s.Org))
+	}
+	return &orgs[0], nil
+}
+
+// Resolve returns the matching dev environments sorted by name. An explicit
+// name that matches nothing is an error, a glob that matches nothing is not
+func (s Selector) Resolve(selectorStore SelectorStore) ([]entity.Workspace, error) {
+	if len(s.Names) == 0 && !s.All && !s.IsPattern() {
+		return nil, breverrors.NewValidationError("please provide dev environment names, a glob like 'ml-*', a filter or --all")
+	}
+	org, err := s.getOrg(selectorStore)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	options := &store.GetWorkspacesOptions{}
+	switch s.CreatedBy {
+	case "", CreatedByMe:
+		user, err := selectorStore.GetCurrentUser()
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		options.UserID = user.ID
+	case CreatedByAll:
+	default:
+		options.UserID = s.CreatedBy
+	}
+	workspaces, err := selectorStore.GetWorkspaces(org.ID, options)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	if len(s.Names) > 0 {
+		workspaces, err = matchNames(workspaces, s.Names)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+	}
+	workspaces = lo.Filter(workspaces, func(w entity.Workspace, _ int) bool {
+		return s.matchesStatus(w) && s.matchesAge(w, time.Now())
+	})
+	sort.Slice(workspaces, func(i, j int) bool { return workspaces[i].Name < workspaces[j].Name })
+	return workspaces, nil
+}
+
+func matchNames(workspaces []entity.Workspace, names []string) ([]entity.Workspace, error) {
+	matched := map[string]entity.Workspace{}
+	var allErr error
+	for _, name := range names {
+		found := false
+		for _, w := range workspaces {
+			ok := w.Name == name || w.ID == name
+			if !ok && isGlob(name) {
+				ok, _ = path.Match(name, w.Name)
+			}
+			if ok {
+				matched[w.ID] = w
+				found = true
+			}
+		}
+		if !found && !isGlob(name) {
+			allErr = multierror.Append(allErr, fmt.Errorf("no dev environment named %s", name))
+		}
+	}
+	if allErr != nil {
+		return nil, breverrors.NewValidationError(allErr.Error())
+	}
+	return lo.Values(matched), nil
+}
+
+func (s Selector) matchesStatus(w entity.Workspace) bool {
+	if s.Status == "" {
+		return true
+	}
+	for _, status := range strings.Split(s.Status, ",") {
+		if strings.EqualFold(strings.TrimSpace(status), w.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAge excludes dev environments without a creation time when
+// --older-than is set, rather than guessing
+func (s Selector) matchesAge(w entity.Workspace, now time.Time) bool {
+	if s.OlderThan <= 0 {
+		return true
+	}
+	createdAt, err := w.GetCreatedAt()
+	if err != nil {
+		return false
+	}
+	return now.Sub(createdAt) > s.OlderThan
+}
+
+type Outcome string
+
+const (
+	Succeeded Outcome = "ok"
+	Skipped   Outcome = "skipped"
+	Failed    Outcome = "failed"
+)
+
+type Result struct {
+	Workspace entity.Workspace
+	Outcome   Outcome
+	Message   string
+	Err       error
+}
+
+// ErrSkip is returned by an operation that has nothing to do for a dev
+// environment, ex: stopping one that is already stopped
+type ErrSkip struct {
+	Reason string
+}
+
+func (e ErrSkip) Error() string {
+	return e.Reason
+}
+
+// Operation acts on one dev environment, Verb is used in prompts
+type Operation struct {
+	Verb string
+	Run  func(w entity.Workspace) error
+}
+
+// Confirm prints the selection and asks whether to go ahead when it came from
+// a pattern, explicit names run as before. It returns false on --dry-run, on
+// an empty selection or if the user declines
+func Confirm(t *terminal.Terminal, s Selector, op Operation, workspaces []entity.Workspace) bool {
+	if len(workspaces) == 0 {
+		t.Vprint(t.Yellow("No dev environments matched"))
+		return false
+	}
+	if s.DryRun || s.NeedsConfirmation() {
+		t.Vprintf("Will %s %d dev environments:\n", op.Verb, len(workspaces))
+		for _, w := range workspaces {
+			t.Vprintf("  %s (%s, %s)\n", w.Name, w.ID, w.Status)
+		}
+	}
+	if s.DryRun {
+		t.Vprint(t.Yellow("\nDry run, nothing was changed"))
+		return false
+	}
+	if s.Yes || !s.NeedsConfirmation() {
+		return true
+	}
+	res := terminal.PromptSelectInput(terminal.PromptSelectContent{
+		Label:    fmt.Sprintf("Are you sure you want to %s these dev environments?", op.Verb),
+		ErrorMsg: "error",
+		Items:    []string{"no", "yes"},
+	})
+	return res == "yes"
+}
+
+// Run applies op to every workspace with at most concurrency at once, keeping
+// results in the order of workspaces
+func Run(workspaces []entity.Workspace, concurrency int, op Operation) []Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]Result, len(workspaces))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, w := range workspaces {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, w entity.Workspace) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = toResult(w, op.Run(w))
+		}(i, w)
+	}
+	wg.Wait()
+	return results
+}
+
+func toResult(w entity.Workspace, err error) Result {
+	var skip ErrSkip
+	switch {
+	case err == nil:
+		return Result{Workspace: w, Outcome: Succeeded}
+	case errors.As(err, &skip):
+		return Result{Workspace: w, Outcome: Skipped, Message: skip.Reason}
+	default:
+		return Result{Workspace: w, Outcome: Failed, Message: err.Error(), Err: err}
+	}
+}
+
+// Summarize prints a table of results and returns the failures aggregated
+func Summarize(t *terminal.Terminal, results []Result) error {
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	options := table.OptionsDefault
+	options.DrawBorder = false
+	options.SeparateColumns = false
+	options.SeparateRows = false
+	options.SeparateHeader = false
+	ta.Style().Options = options
+	ta.AppendHeader(table.Row{"NAME", "ID", "RESULT", "MESSAGE"})
+	for _, r := range results {
+		outcome := t.Green(string(r.Outcome))
+		if r.Outcome == Skipped {
+			outcome = t.Yellow(string(r.Outcome))
+		} else if r.Outcome == Failed {
+			outcome = t.Red(string(r.Outcome))
+		}
+		ta.AppendRow(table.Row{r.Workspace.Name, r.Workspace.ID, outcome, r.Message})
+	}
+	ta.Render()
+
+	err := lo.Reduce(
+		results,
+		func(acc error, r Result, _ int) error {
+			if r.Err != nil {
+				return multierror.Append(acc, fmt.Errorf("%s: %w", r.Workspace.Name, r.Err))
+			}
+			return acc
+		},
+		nil,
+	)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// Apply is Resolve, Confirm, Run and Summarize
+func Apply(t *terminal.Terminal, selectorStore SelectorStore, s Selector, op Operation) error {
+	workspaces, err := s.Resolve(selectorStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !Confirm(t, s, op, workspaces) {
+		return nil
+	}
+	results := Run(workspaces, s.Concurrency, op)
+	err = Summarize(t, results)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// RequireStatus skips dev environments that are not in one of statuses
+func RequireStatus(w entity.Workspace, statuses ...string) error {
+	if lo.Contains(statuses, w.Status) {
+		return nil
+	}
+	return ErrSkip{Reason: fmt.Sprintf("is %s", w.Status)}
+}
@@ -0,0 +1,152 @@
+package bulk
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+type fakeSelectorStore struct {
+	workspaces []entity.Workspace
+}
+
+func (f fakeSelectorStore) GetCurrentUser() (*entity.User, error) {
+	return &entity.User{ID: "me"}, nil
+}
+
+func (f fakeSelectorStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
+	return &entity.Organization{ID: "org", Name: "org"}, nil
+}
+
+func (f fakeSelectorStore) GetOrganizations(options *store.GetOrganizationsOptions) ([]entity.Organization, error) {
+	if options.Name == "org" {
+		return []entity.Organization{{ID: "org", Name: "org"}}, nil
+	}
+	return nil, nil
+}
+
+func (f fakeSelectorStore) GetWorkspaces(_ string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error) {
+	res := []entity.Workspace{}
+	for _, w := range f.workspaces {
+		if options.UserID == "" || w.CreatedByUserID == options.UserID {
+			res = append(res, w)
+		}
+	}
+	return res, nil
+}
+
+func newFakeSelectorStore() fakeSelectorStore {
+	old := time.Now().Add(-100 * time.Hour).Format(time.RFC3339)
+	recent := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	return fakeSelectorStore{workspaces: []entity.Workspace{
+		{ID: "1", Name: "ml-train", Status: entity.Running, CreatedByUserID: "me", CreatedAt: old},
+		{ID: "2", Name: "ml-eval", Status: entity.Stopped, CreatedByUserID: "me", CreatedAt: recent},
+		{ID: "3", Name: "web", Status: entity.Running, CreatedByUserID: "me"},
+		{ID: "4", Name: "ml-other", Status: entity.Running, CreatedByUserID: "someone", CreatedAt: old},
+	}}
+}
+
+func names(workspaces []entity.Workspace) []string {
+	res := []string{}
+	for _, w := range workspaces {
+		res = append(res, w.Name)
+	}
+	return res
+}
+
+func TestResolve(t *testing.T) {
+	s := newFakeSelectorStore()
+	tests := []struct {
+		name     string
+		selector Selector
+		want     []string
+		wantErr  bool
+	}{
+		{"glob", Selector{Names: []string{"ml-*"}}, []string{"ml-eval", "ml-train"}, false},
+		{"names and ids", Selector{Names: []string{"web", "2"}}, []string{"ml-eval", "web"}, false},
+		{"duplicates", Selector{Names: []string{"web", "w*"}}, []string{"web"}, false},
+		{"missing name", Selector{Names: []string{"nope"}}, nil, true},
+		{"empty glob", Selector{Names: []string{"nope-*"}}, []string{}, false},
+		{"status", Selector{Status: "running"}, []string{"ml-train", "web"}, false},
+		{"older than skips unknown age", Selector{All: true, OlderThan: 72 * time.Hour}, []string{"ml-train"}, false},
+		{"created by all", Selector{Names: []string{"ml-*"}, CreatedBy: CreatedByAll}, []string{"ml-eval", "ml-other", "ml-train"}, false},
+		{"created by user", Selector{CreatedBy: "someone"}, []string{"ml-other"}, false},
+		{"org", Selector{All: true, Org: "org", Status: "STOPPED"}, []string{"ml-eval"}, false},
+		{"unknown org", Selector{All: true, Org: "nope"}, nil, true},
+		{"nothing selected", Selector{}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.selector.Resolve(s)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, names(got))
+		})
+	}
+}
+
+func TestIsBulk(t *testing.T) {
+	assert.False(t, Selector{Names: []string{"web"}, CreatedBy: CreatedByMe}.IsBulk())
+	assert.True(t, Selector{Names: []string{"web", "ml"}}.IsBulk())
+	assert.True(t, Selector{Names: []string{"ml-*"}}.IsBulk())
+	assert.True(t, Selector{Names: []string{"web"}, Options: Options{DryRun: true}}.IsBulk())
+	assert.True(t, Selector{All: true}.IsBulk())
+	assert.False(t, Selector{Names: []string{"web", "ml"}}.IsPattern())
+}
+
+func TestNeedsConfirmation(t *testing.T) {
+	assert.False(t, Selector{All: true, CreatedBy: CreatedByMe}.NeedsConfirmation())
+	assert.False(t, Selector{Names: []string{"web", "ml"}}.NeedsConfirmation())
+	assert.True(t, Selector{All: true, Status: "RUNNING"}.NeedsConfirmation())
+	assert.True(t, Selector{All: true, CreatedBy: CreatedByAll}.NeedsConfirmation())
+	assert.True(t, Selector{Names: []string{"ml-*"}}.NeedsConfirmation())
+}
+
+func TestRunBoundsConcurrencyAndKeepsOrder(t *testing.T) {
+	workspaces := []entity.Workspace{}
+	for i := 0; i < 10; i++ {
+		workspaces = append(workspaces, entity.Workspace{ID: fmt.Sprint(i), Name: fmt.Sprint(i), Status: entity.Running})
+	}
+	workspaces[3].Status = entity.Stopped
+
+	var running, maxRunning int32
+	results := Run(workspaces, 3, Operation{Verb: "stop", Run: func(w entity.Workspace) error {
+		err := RequireStatus(w, entity.Running)
+		if err != nil {
+			return err
+		}
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if w.ID == "5" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	}})
+
+	assert.LessOrEqual(t, maxRunning, int32(3))
+	assert.Len(t, results, 10)
+	for i, r := range results {
+		assert.Equal(t, fmt.Sprint(i), r.Workspace.ID)
+	}
+	assert.Equal(t, Skipped, results[3].Outcome)
+	assert.Equal(t, "is STOPPED", results[3].Message)
+	assert.Equal(t, Failed, results[5].Outcome)
+	assert.Error(t, results[5].Err)
+	assert.Equal(t, Succeeded, results[0].Outcome)
+}
@@ -0,0 +1,193 @@
+// Package cliconfig manages the profiles in ~/.brev/config.yaml
+package cliconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/output"
+	"github.com/brevdev/brev-cli/pkg/config"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	configLong = `Manage defaults for brev commands, grouped into named profiles so that
+several accounts, ex: staging and prod, can be used side by side.
+
+Values are resolved in order: command flag, environment variable, the active
+profile, then the built in default. The active profile is $BREV_PROFILE if
+set, otherwise the one picked with brev config use-profile.
+
+Keys: ` + strings.Join(config.ProfileKeys, ", ")
+	configExample = `  brev config set workspace-class 4x16
+  brev config set --profile staging api-url https://brevapi.staging.example.com
+  brev config use-profile staging
+  BREV_PROFILE=prod brev ls
+  brev config get org`
+)
+
+type ConfigStore interface {
+	GetConfigFile() (*config.ConfigFile, error)
+	SaveConfigFile(c config.ConfigFile) error
+}
+
+func NewCmdConfig(t *terminal.Terminal, store ConfigStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"housekeeping": ""},
+		Use:                   "config",
+		DisableFlagsInUseLine: true,
+		Short:                 "Manage brev config profiles",
+		Long:                  configLong,
+		Example:               configExample,
+	}
+	cmd.AddCommand(newCmdGet(t, store))
+	cmd.AddCommand(newCmdSet(t, store))
+	cmd.AddCommand(newCmdUseProfile(t, store))
+	return cmd
+}
+
+func newCmdGet(t *terminal.Terminal, store ConfigStore) *cobra.Command {
+	var profileName string
+	cmd := &cobra.Command{
+		Use:   "get [key]",
+		Short: "Print a key, or every key, of a profile",
+		Args:  cmderrors.TransformToValidationError(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := ""
+			if len(args) > 0 {
+				key = args[0]
+			}
+			err := RunGet(t, store, profileName, key)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&profileName, "profile", "", "profile to read, defaults to the active profile")
+	return cmd
+}
+
+func RunGet(t *terminal.Terminal, store ConfigStore, profileName string, key string) error {
+	configFile, err := store.GetConfigFile()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if profileName == "" {
+		profileName = configFile.ActiveProfileName()
+	}
+	profile := configFile.Profiles[profileName]
+	if key != "" {
+		value, err := profile.Get(key)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		t.Vprint(value)
+		return nil
+	}
+
+	t.Vprintf("profile: %s\n", t.Green(profileName))
+	for _, k := range config.ProfileKeys {
+		value, _ := profile.Get(k)
+		if value != "" {
+			t.Vprintf("%s: %s\n", k, value)
+		}
+	}
+	if others := otherProfiles(*configFile, profileName); len(others) > 0 {
+		t.Vprintf("\nother profiles: %s\n", strings.Join(others, ", "))
+	}
+	return nil
+}
+
+func otherProfiles(configFile config.ConfigFile, profileName string) []string {
+	others := []string{}
+	for _, name := range configFile.ProfileNames() {
+		if name != profileName {
+			others = append(others, name)
+		}
+	}
+	return others
+}
+
+func newCmdSet(t *terminal.Terminal, store ConfigStore) *cobra.Command {
+	var profileName string
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a key of a profile, an empty value unsets it",
+		Args:  cmderrors.TransformToValidationError(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunSet(t, store, profileName, args[0], args[1])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&profileName, "profile", "", "profile to change, created if it does not exist. Defaults to the active profile")
+	return cmd
+}
+
+func RunSet(t *terminal.Terminal, store ConfigStore, profileName string, key string, value string) error {
+	if key == "output" {
+		if _, err := output.ParseFormat(value); err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	configFile, err := store.GetConfigFile()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if profileName == "" {
+		profileName = configFile.ActiveProfileName()
+	}
+	err = configFile.Set(profileName, key, value)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = store.SaveConfigFile(*configFile)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf("Set %s to %q in profile %s\n", key, value, t.Green(profileName))
+	return nil
+}
+
+func newCmdUseProfile(t *terminal.Terminal, store ConfigStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use-profile <name>",
+		Short: "Make a profile the active one",
+		Args:  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunUseProfile(t, store, args[0])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func RunUseProfile(t *terminal.Terminal, store ConfigStore, profileName string) error {
+	configFile, err := store.GetConfigFile()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = configFile.UseProfile(profileName)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = store.SaveConfigFile(*configFile)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf("Switched to profile %s\n", t.Green(profileName))
+	if configFile.ActiveProfileName() != profileName {
+		t.Vprint(t.Yellow(fmt.Sprintf("BREV_PROFILE is set to %s and takes precedence in this shell", configFile.ActiveProfileName())))
+	}
+	return nil
+}
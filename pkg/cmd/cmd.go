@@ -3,12 +3,17 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/brevdev/brev-cli/pkg/auth"
+	"github.com/brevdev/brev-cli/pkg/cmd/apply"
 	"github.com/brevdev/brev-cli/pkg/cmd/approve"
 	"github.com/brevdev/brev-cli/pkg/cmd/autostop"
 	"github.com/brevdev/brev-cli/pkg/cmd/background"
 	"github.com/brevdev/brev-cli/pkg/cmd/bmon"
+	"github.com/brevdev/brev-cli/pkg/cmd/cliconfig"
 	"github.com/brevdev/brev-cli/pkg/cmd/clipboard"
 	"github.com/brevdev/brev-cli/pkg/cmd/configureenvvars"
 	"github.com/brevdev/brev-cli/pkg/cmd/connect"
@@ -21,23 +26,28 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/importideconfig"
 	"github.com/brevdev/brev-cli/pkg/cmd/initfile"
 	"github.com/brevdev/brev-cli/pkg/cmd/invite"
+	"github.com/brevdev/brev-cli/pkg/cmd/local"
 	"github.com/brevdev/brev-cli/pkg/cmd/login"
 	"github.com/brevdev/brev-cli/pkg/cmd/logout"
+	"github.com/brevdev/brev-cli/pkg/cmd/logs"
 	"github.com/brevdev/brev-cli/pkg/cmd/ls"
 	"github.com/brevdev/brev-cli/pkg/cmd/open"
 	"github.com/brevdev/brev-cli/pkg/cmd/optimizeinstances"
 	"github.com/brevdev/brev-cli/pkg/cmd/org"
+	"github.com/brevdev/brev-cli/pkg/cmd/output"
 	"github.com/brevdev/brev-cli/pkg/cmd/portforward"
 	"github.com/brevdev/brev-cli/pkg/cmd/postinstall"
 	"github.com/brevdev/brev-cli/pkg/cmd/profile"
 	"github.com/brevdev/brev-cli/pkg/cmd/proxy"
 	"github.com/brevdev/brev-cli/pkg/cmd/recreate"
 	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
+	"github.com/brevdev/brev-cli/pkg/cmd/remoteexec"
 	"github.com/brevdev/brev-cli/pkg/cmd/reset"
 	"github.com/brevdev/brev-cli/pkg/cmd/runtasks"
 	"github.com/brevdev/brev-cli/pkg/cmd/scale"
 	"github.com/brevdev/brev-cli/pkg/cmd/secret"
 	"github.com/brevdev/brev-cli/pkg/cmd/set"
+	"github.com/brevdev/brev-cli/pkg/cmd/setup"
 	"github.com/brevdev/brev-cli/pkg/cmd/setupworkspace"
 	"github.com/brevdev/brev-cli/pkg/cmd/shell"
 	"github.com/brevdev/brev-cli/pkg/cmd/sshkeys"
@@ -46,8 +56,10 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/stop"
 	"github.com/brevdev/brev-cli/pkg/cmd/tasks"
 	"github.com/brevdev/brev-cli/pkg/cmd/test"
+	"github.com/brevdev/brev-cli/pkg/cmd/transfer"
 	"github.com/brevdev/brev-cli/pkg/cmd/updatemodel"
 	"github.com/brevdev/brev-cli/pkg/cmd/upgrade"
+	"github.com/brevdev/brev-cli/pkg/cmd/wait"
 	"github.com/brevdev/brev-cli/pkg/cmd/workspacegroups"
 	"github.com/brevdev/brev-cli/pkg/cmd/writeconnectionevent"
 	"github.com/brevdev/brev-cli/pkg/config"
@@ -70,12 +82,43 @@ func NewDefaultBrevCommand() *cobra.Command {
 	return cmd
 }
 
+// loadConfig makes the active profile of ~/.brev/config.yaml the global
+// config. A broken file is only a warning so brev config can still fix it
+func loadConfig(fsStore *store.FileStore) *config.FileConfig {
+	configFile, err := fsStore.GetConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring brev config: %v\n", err)
+		configFile = &config.ConfigFile{}
+	}
+	config.GlobalConfig = config.NewConstants().WithEnvVars().
+		WithFileConfig(configFile.ActiveProfile()).
+		WithProfileName(configFile.ActiveProfileName())
+	return config.GlobalConfig
+}
+
+// promptCredentialPassphrase asks for the passphrase of the encrypted
+// credential store at most once per command
+func promptCredentialPassphrase() func() (string, error) {
+	var passphrase string
+	return func() (string, error) {
+		if passphrase == "" {
+			passphrase = terminal.PromptGetInput(terminal.PromptContent{
+				Label:    "Passphrase for your brev credentials: ",
+				ErrorMsg: "a passphrase is required",
+				Mask:     '*',
+			})
+		}
+		return passphrase, nil
+	}
+}
+
 func NewBrevCommand() *cobra.Command { //nolint:funlen // define brev command
 	// in io.Reader, out io.Writer, err io.Writer
 	t := terminal.New()
 	var printVersion bool
+	var noCache bool
+	var offline bool
 
-	conf := config.NewConstants()
 	fs := files.AppFs
 	authenticator := auth.Authenticator{
 		Audience:           "https://brevdev.us.auth0.com/api/v2/",
@@ -88,7 +131,9 @@ func NewBrevCommand() *cobra.Command { //nolint:funlen // define brev command
 
 	fsStore := store.
 		NewBasicStore().
-		WithFileSystem(fs)
+		WithFileSystem(fs).
+		WithPassphraseGetter(promptCredentialPassphrase())
+	conf := loadConfig(fsStore)
 	loginAuth := auth.NewLoginAuth(fsStore, authenticator)
 	noLoginAuth := auth.NewNoLoginAuth(fsStore, authenticator)
 
@@ -121,6 +166,11 @@ func NewBrevCommand() *cobra.Command { //nolint:funlen // define brev command
 		noLoginCmdStore.WithStaticHeader("X-Workspace-Group-ID", workspaceGroupID)
 	}
 
+	// read only commands always ask the api but can fall back to the cache,
+	// completions use it whenever it is fresh
+	cachedCmdStore := store.NewCachedStore(loginCmdStore).WithTTL(0).WithStaleHandler(newStaleWarner(t))
+	completionCmdStore := store.NewCachedStore(noLoginCmdStore)
+
 	cmds := &cobra.Command{
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -144,8 +194,9 @@ func NewBrevCommand() *cobra.Command { //nolint:funlen // define brev command
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			breverrors.GetDefaultErrorReporter().AddTag("command", cmd.Name())
 			// version info gets in the way of the output for
-			// configure-env-vars, since shells are going to eval it
-			if featureflag.ShowVersionOnRun() && !printVersion && cmd.Name() != "configure-env-vars" {
+			// configure-env-vars, since shells are going to eval it, and for
+			// anything asked for machine readable output
+			if featureflag.ShowVersionOnRun() && !printVersion && cmd.Name() != "configure-env-vars" && !output.IsMachineReadableCmd(cmd) {
 				v, err := remoteversion.BuildCheckLatestVersionString(t, noLoginCmdStore)
 				// todo this should not be fatal when it errors
 				if err != nil {
@@ -171,6 +222,15 @@ func NewBrevCommand() *cobra.Command { //nolint:funlen // define brev command
 				}
 
 			}
+			cacheMode := store.CacheDefault
+			if offline {
+				cacheMode = store.CacheOffline
+			} else if noCache {
+				cacheMode = store.CacheBypass
+			}
+			cachedCmdStore.SetMode(cacheMode)
+			completionCmdStore.SetMode(cacheMode)
+
 			home, err := fsStore.GetBrevHomePath()
 			if err != nil {
 				fmt.Printf("Warning: %v", err)
@@ -219,15 +279,34 @@ func NewBrevCommand() *cobra.Command { //nolint:funlen // define brev command
 	cmds.SetUsageTemplate(usageTemplate)
 
 	cmds.PersistentFlags().BoolVar(&printVersion, "version", false, "Print version output")
+	cmds.PersistentFlags().BoolVar(&noCache, "no-cache", false, "always ask the brev api instead of using cached dev environments, orgs and user")
+	cmds.PersistentFlags().BoolVar(&offline, "offline", false, "only use cached dev environments, orgs and user, never the brev api")
 
-	createCmdTree(cmds, t, loginCmdStore, noLoginCmdStore, loginAuth)
+	createCmdTree(cmds, t, loginCmdStore, noLoginCmdStore, cachedCmdStore, completionCmdStore, loginAuth)
 
 	return cmds
 }
 
-func createCmdTree(cmd *cobra.Command, t *terminal.Terminal, loginCmdStore *store.AuthHTTPStore, noLoginCmdStore *store.AuthHTTPStore, loginAuth *auth.LoginAuth) {
+// newStaleWarner warns on stderr, once per kind of data, so that output meant
+// for other programs is left alone
+func newStaleWarner(t *terminal.Terminal) store.StaleHandler {
+	var mu sync.Mutex
+	warned := map[string]bool{}
+	return func(kind string, fetchedAt time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		if warned[kind] {
+			return
+		}
+		warned[kind] = true
+		fmt.Fprintln(os.Stderr, t.Yellow("Warning: "+store.FormatStaleWarning(kind, fetchedAt, time.Now())))
+	}
+}
+
+func createCmdTree(cmd *cobra.Command, t *terminal.Terminal, loginCmdStore *store.AuthHTTPStore, noLoginCmdStore *store.AuthHTTPStore, cachedCmdStore *store.CachedStore, completionCmdStore *store.CachedStore, loginAuth *auth.LoginAuth) {
 	cmd.AddCommand(set.NewCmdSet(t, loginCmdStore, noLoginCmdStore))
-	cmd.AddCommand(ls.NewCmdLs(t, loginCmdStore, noLoginCmdStore))
+	cmd.AddCommand(cliconfig.NewCmdConfig(t, noLoginCmdStore))
+	cmd.AddCommand(ls.NewCmdLs(t, cachedCmdStore, completionCmdStore))
 	cmd.AddCommand(org.NewCmdOrg(t, loginCmdStore, noLoginCmdStore))
 	cmd.AddCommand(invite.NewCmdInvite(t, loginCmdStore, noLoginCmdStore))
 	cmd.AddCommand(portforward.NewCmdPortForwardSSH(loginCmdStore, t))
@@ -254,8 +333,8 @@ func createCmdTree(cmd *cobra.Command, t *terminal.Terminal, loginCmdStore *stor
 	cmd.AddCommand(scale.NewCmdScale(t, noLoginCmdStore))
 	cmd.AddCommand(configureenvvars.NewCmdConfigureEnvVars(t, loginCmdStore))
 	cmd.AddCommand(importideconfig.NewCmdImportIDEConfig(t, noLoginCmdStore))
-	cmd.AddCommand(shell.NewCmdShell(t, loginCmdStore, noLoginCmdStore))
-	cmd.AddCommand(open.NewCmdOpen(t, loginCmdStore, noLoginCmdStore))
+	cmd.AddCommand(shell.NewCmdShell(t, cachedCmdStore, completionCmdStore))
+	cmd.AddCommand(open.NewCmdOpen(t, cachedCmdStore, completionCmdStore))
 	cmd.AddCommand(optimizeinstances.NewCmdOptimizeInstances(t, loginCmdStore))
 	cmd.AddCommand(optimizeinstances.NewCmdOptimize(t, loginCmdStore))
 
@@ -263,11 +342,18 @@ func createCmdTree(cmd *cobra.Command, t *terminal.Terminal, loginCmdStore *stor
 	cmd.AddCommand(status.NewCmdStatus(t, loginCmdStore))
 	cmd.AddCommand(secret.NewCmdSecret(loginCmdStore, t))
 	cmd.AddCommand(sshkeys.NewCmdSSHKeys(t, loginCmdStore))
-	cmd.AddCommand(start.NewCmdStart(t, loginCmdStore, noLoginCmdStore))
+	cmd.AddCommand(start.NewCmdStart(t, loginCmdStore, completionCmdStore))
 	cmd.AddCommand(create.NewCmdCreate(t, loginCmdStore))
-	cmd.AddCommand(stop.NewCmdStop(t, loginCmdStore, noLoginCmdStore))
-	cmd.AddCommand(delete.NewCmdDelete(t, loginCmdStore, noLoginCmdStore))
-	cmd.AddCommand(reset.NewCmdReset(t, loginCmdStore, noLoginCmdStore))
+	cmd.AddCommand(apply.NewCmdApply(t, loginCmdStore))
+	cmd.AddCommand(stop.NewCmdStop(t, loginCmdStore, completionCmdStore))
+	cmd.AddCommand(delete.NewCmdDelete(t, loginCmdStore, completionCmdStore))
+	cmd.AddCommand(reset.NewCmdReset(t, loginCmdStore, completionCmdStore))
+	cmd.AddCommand(wait.NewCmdWait(t, loginCmdStore, completionCmdStore))
+	cmd.AddCommand(local.NewCmdLocal(t, loginCmdStore, completionCmdStore))
+	cmd.AddCommand(logs.NewCmdLogs(t, loginCmdStore, completionCmdStore))
+	cmd.AddCommand(remoteexec.NewCmdExec(t, loginCmdStore, completionCmdStore))
+	cmd.AddCommand(transfer.NewCmdCp(t, loginCmdStore))
+	cmd.AddCommand(transfer.NewCmdSync(t, loginCmdStore))
 	cmd.AddCommand(profile.NewCmdProfile(t, loginCmdStore, noLoginCmdStore))
 	cmd.AddCommand(refresh.NewCmdRefresh(t, loginCmdStore))
 	cmd.AddCommand(runtasks.NewCmdRunTasks(t, noLoginCmdStore))
@@ -275,6 +361,7 @@ func createCmdTree(cmd *cobra.Command, t *terminal.Terminal, loginCmdStore *stor
 	cmd.AddCommand(healthcheck.NewCmdHealthcheck(t, noLoginCmdStore))
 
 	cmd.AddCommand(setupworkspace.NewCmdSetupWorkspace(noLoginCmdStore))
+	cmd.AddCommand(setup.NewCmdSetup(t, loginCmdStore, completionCmdStore))
 	cmd.AddCommand(recreate.NewCmdRecreate(t, loginCmdStore))
 	cmd.AddCommand(envsetup.NewCmdEnvSetup(loginCmdStore, loginAuth))
 	cmd.AddCommand(postinstall.NewCmdpostinstall(t, loginCmdStore))
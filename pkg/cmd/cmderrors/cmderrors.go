@@ -49,6 +49,8 @@ func DisplayAndHandleError(err error) {
 		case breverrors.ValidationError:
 			// do not report error
 			prettyErr = (t.Yellow(errors.Cause(err).Error()))
+		case breverrors.ExitCodeError: // expected outcome that scripts branch on, not a bug
+			prettyErr = (t.Yellow(errors.Cause(err).Error()))
 		case breverrors.WorkspaceNotRunning: // report error to track when this occurs, but don't print stacktrace to user unless in dev mode
 			er.ReportError(err)
 			prettyErr = (t.Yellow(errors.Cause(err).Error()))
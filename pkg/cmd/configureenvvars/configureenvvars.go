@@ -6,8 +6,8 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/alessio/shellescape"
 	"github.com/brevdev/brev-cli/pkg/collections" //nolint:typecheck
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	"github.com/spf13/cobra"
 )
@@ -25,15 +25,35 @@ type ConfigureEnvVarsStore interface {
 }
 
 func NewCmdConfigureEnvVars(_ *terminal.Terminal, cevStore ConfigureEnvVarsStore) *cobra.Command {
+	var shell string
+
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"housekeeping": ""},
-		Use:                   "configure-env-vars",
+		Use:                   "configure-env-vars [shell]",
 		DisableFlagsInUseLine: true,
 		Short:                 "configure env vars in supported shells",
 		Long:                  "configure env vars in supported shells",
-		Example:               "",
+		Example: `
+  eval "$(brev configure-env-vars --shell bash)"
+  brev configure-env-vars --shell fish | source
+  brev configure-env-vars --shell json
+		`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: Shells,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			output, err := RunConfigureEnvVars(cevStore)
+			// the shell used to be given as an argument, ex: by the hooks of
+			// older dev environments
+			if shell == "" && len(args) > 0 {
+				shell = args[0]
+			}
+			if shell != "" && !strings.EqualFold(shell, jsonShell) {
+				// nothing is printed, so evaling the output is still harmless
+				_, err := getFormatter(shell)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+			}
+			output, err := RunConfigureEnvVarsForShell(cevStore, shell)
 			if err != nil {
 				// todo bubble up error, but in the meantime make sure there
 				// is no output
@@ -43,72 +63,79 @@ func NewCmdConfigureEnvVars(_ *terminal.Terminal, cevStore ConfigureEnvVarsStore
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&shell, "shell", "", fmt.Sprintf("shell to write the env vars for, one of %s", strings.Join(Shells, "|")))
+	err := cmd.RegisterFlagCompletionFunc("shell", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return Shells, cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		breverrors.GetDefaultErrorReporter().ReportError(breverrors.WrapAndTrace(err))
+	}
 
 	return cmd
 }
 
 func RunConfigureEnvVars(cevStore ConfigureEnvVarsStore) (string, error) {
+	return RunConfigureEnvVarsForShell(cevStore, "bash")
+}
+
+func RunConfigureEnvVarsForShell(cevStore ConfigureEnvVarsStore, shell string) (string, error) {
+	if shell == "" {
+		shell = "bash"
+	}
 	brevEnvsString := os.Getenv(BREV_MANGED_ENV_VARS_KEY)
 	// intentionally ignoring err
 	envFileContents, _ := cevStore.GetFileAsString(BREV_WORKSPACE_ENV_PATH)
 	devplaneContents, _ := cevStore.GetFileAsString(BREV_DEV_PLANE_ENV_PATH)
 	envFileContents = envFileContents + "\n" + devplaneContents
-	return generateExportString(brevEnvsString, envFileContents), nil
+	return generateEnvString(shell, brevEnvsString, envFileContents)
 }
 
 func generateExportString(brevEnvsString, envFileContents string) string {
-	if brevEnvsString == "" && envFileContents == "" {
-		return ""
-	}
-	brevEnvKeys := strings.Split(brevEnvsString, ",")
+	// bash never fails
+	out, _ := generateEnvString("bash", brevEnvsString, envFileContents)
+	return out
+}
 
+// generateEnvString unsets the vars brev set before that are no longer in the
+// env file, then sets the ones that are, and records them in
+// BREV_MANAGED_ENV_VARS for next time
+func generateEnvString(shell, brevEnvsString, envFileContents string) (string, error) {
+	brevEnvKeys := strings.Split(brevEnvsString, ",")
 	envfileEntries := parse(envFileContents)
-	for key, val := range envfileEntries {
-		if !strings.HasPrefix(val, "'") { // already quoted
-			envfileEntries[key] = shellescape.Quote(val)
-		}
-	}
+	// sorted to make tests consistent
 	envFileKeys := keys(envfileEntries)
-	// sort to make tests consistent
-	sort.Slice(envFileKeys, func(i, j int) bool {
-		return envFileKeys[i] < envFileKeys[j]
-	})
 
-	// todo parameterize by shell
-	envCmdOutput := makeEnvCmdOutputLines(brevEnvKeys, envFileKeys, envfileEntries)
+	// json is parsed rather than evaled, so it is always a whole document
+	if strings.EqualFold(shell, jsonShell) {
+		return formatJSON(staleKeys(brevEnvKeys, envFileKeys), envfileEntries, strings.Join(envFileKeys, ","))
+	}
+	f, err := getFormatter(shell)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	if brevEnvsString == "" && envFileContents == "" {
+		return "", nil
+	}
+	envCmdOutput := makeEnvCmdOutputLines(f, brevEnvKeys, envFileKeys, envfileEntries)
 
-	return strings.Join(envCmdOutput, "\n")
+	return strings.Join(envCmdOutput, "\n"), nil
 }
 
-func makeEnvCmdOutputLines(brevEnvKeys, envFileKeys []string, envfileEntries envVars) []string {
+func makeEnvCmdOutputLines(f formatter, brevEnvKeys, envFileKeys []string, envfileEntries envVars) []string {
 	envCmdOutput := []string{}
-	envCmdOutput = addUnsetEntriesToOutput(brevEnvKeys, envFileKeys, envCmdOutput)
-	envCmdOutput = append(envCmdOutput, addExportPrefix(envfileEntries)...)
+	for _, k := range staleKeys(brevEnvKeys, envFileKeys) {
+		envCmdOutput = append(envCmdOutput, f.unset(k))
+	}
+	for _, k := range envFileKeys {
+		envCmdOutput = append(envCmdOutput, f.set(k, envfileEntries[k]))
+	}
 	newBrevEnvKeys := strings.Join(envFileKeys, ",")
-	newBrevEnvKeysEntry := ""
 	if newBrevEnvKeys != "" {
-		newBrevEnvKeysEntry = BREV_MANGED_ENV_VARS_KEY + "=" + newBrevEnvKeys
-	}
-	if newBrevEnvKeysEntry != "" {
-		envCmdOutput = append(envCmdOutput, "export "+newBrevEnvKeysEntry)
+		envCmdOutput = append(envCmdOutput, f.set(BREV_MANGED_ENV_VARS_KEY, newBrevEnvKeys))
 	}
 	return collections.FilterEmpty(envCmdOutput)
 }
 
-func addExportPrefix(envFile envVars) []string {
-	if len(envFile) == 0 {
-		return []string{}
-	}
-	out := []string{}
-
-	// sorted order to make tests consistent
-	envFileKeys := keys(envFile)
-	for _, k := range envFileKeys {
-		out = append(out, fmt.Sprintf("%s %s=%s", "export", k, envFile[k]))
-	}
-	return out
-}
-
 // return map's keys in sorted order
 func keys(m map[string]string) []string {
 	out := []string{}
@@ -121,14 +148,22 @@ func keys(m map[string]string) []string {
 	return out
 }
 
-// this may be a good place to parameterize bby shell
 func addUnsetEntriesToOutput(currentEnvs, newEnvs, output []string) []string {
+	for _, envKey := range staleKeys(currentEnvs, newEnvs) {
+		output = append(output, posixFormatter{}.unset(envKey))
+	}
+	return output
+}
+
+// staleKeys are the vars brev set before that it no longer manages
+func staleKeys(currentEnvs, newEnvs []string) []string {
+	stale := []string{}
 	for _, envKey := range currentEnvs {
 		if !collections.Contains(newEnvs, envKey) && envKey != "" {
-			output = append(output, "unset "+envKey)
+			stale = append(stale, envKey)
 		}
 	}
-	return output
+	return stale
 }
 
 // https://stackoverflow.com/a/38579502
@@ -162,3 +197,30 @@ func parse(content string) envVars {
 
 	return zip(keyValPairs, make(envVars))
 }
+
+// ParseEnvFile reads a dotenv file, ex: for brev secret import. Lines starting
+// with # are comments, and quotes around values are dropped
+func ParseEnvFile(content string) (map[string]string, error) {
+	lines := []string{}
+	for _, line := range strings.Split(content, newline) {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	vars := parse(strings.Join(lines, newline))
+	if vars == nil {
+		return nil, fmt.Errorf("could not parse env file, expected lines of KEY=value")
+	}
+	for k, v := range vars {
+		vars[k] = unquote(v)
+	}
+	return vars, nil
+}
+
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '\'' || v[0] == '"') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
@@ -431,3 +431,24 @@ export export=bob`,
 		})
 	}
 }
+
+func TestParseEnvFile(t *testing.T) {
+	vars, err := ParseEnvFile(`# database
+DB_USER=admin
+export DB_PASS='s3cr=t'
+  # indented comment
+API_URL="https://brev.dev"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"DB_USER": "admin", "DB_PASS": "s3cr=t", "API_URL": "https://brev.dev"}
+	if diff := cmp.Diff(want, vars); diff != "" {
+		t.Fatalf("ParseEnvFile() mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = ParseEnvFile("NOT VALID=x")
+	if err == nil {
+		t.Fatal("expected an error for an invalid key")
+	}
+}
@@ -0,0 +1,146 @@
+package configureenvvars
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/hashicorp/go-multierror"
+)
+
+// hookMarker is in every hook, so that installing them again is a no-op
+const hookMarker = "_brev_hook"
+
+// ShellHook reloads the env vars before every prompt of a shell
+type ShellHook struct {
+	Shell string
+	// Requires must exist for the hook to be installed, ex: the config dir
+	// of the shell, so nothing is written for shells that aren't installed
+	Requires string
+	RCFile   string
+	Snippet  string
+}
+
+const bashHook = `
+_brev_hook() {
+  local previous_exit_status=$?;
+  trap -- '' SIGINT;
+  eval "$(/usr/local/bin/brev configure-env-vars --shell bash)";
+  trap - SIGINT;
+  return $previous_exit_status;
+};
+if ! [[ "${PROMPT_COMMAND:-}" =~ _brev_hook ]]; then
+  PROMPT_COMMAND="_brev_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`
+
+const zshHook = `
+_brev_hook() {
+  trap -- '' SIGINT;
+  eval "$(/usr/local/bin/brev configure-env-vars --shell zsh)";
+  trap - SIGINT;
+}
+typeset -ag precmd_functions;
+if [[ -z "${precmd_functions[(r)_brev_hook]+1}" ]]; then
+  precmd_functions=( _brev_hook ${precmd_functions[@]} )
+fi
+typeset -ag chpwd_functions;
+if [[ -z "${chpwd_functions[(r)_brev_hook]+1}" ]]; then
+  chpwd_functions=( _brev_hook ${chpwd_functions[@]} )
+fi
+`
+
+const fishHook = `
+function _brev_hook --on-event fish_prompt --on-variable PWD
+  /usr/local/bin/brev configure-env-vars --shell fish | source
+end
+`
+
+// nu can't eval the output of a command, so the hook loads the json format
+const nuHook = `
+# _brev_hook
+$env.config = ($env.config | upsert hooks.pre_prompt (($env.config.hooks.pre_prompt? | default []) | append {||
+  let brev = (^/usr/local/bin/brev configure-env-vars --shell json | from json)
+  load-env $brev.set
+  for key in $brev.unset { hide-env --ignore-errors $key }
+}))
+`
+
+const powershellHook = `
+# _brev_hook
+$function:_brev_prompt = $function:prompt
+function global:prompt {
+  /usr/local/bin/brev configure-env-vars --shell powershell | Out-String | Invoke-Expression
+  & $function:_brev_prompt
+}
+`
+
+// ShellHooks are system wide, except for nu which only has a per user config
+func ShellHooks(homeDir string) []ShellHook {
+	nuConfig := filepath.Join(homeDir, ".config", "nushell", "config.nu")
+	return []ShellHook{
+		{Shell: "bash", Requires: "/etc/bash.bashrc", RCFile: "/etc/bash.bashrc", Snippet: bashHook},
+		{Shell: "zsh", Requires: "/etc/zsh", RCFile: "/etc/zsh/zshrc", Snippet: zshHook},
+		{Shell: "fish", Requires: "/etc/fish", RCFile: "/etc/fish/conf.d/brev.fish", Snippet: fishHook},
+		// only appended to, a config created by root would not be the user's
+		{Shell: "nu", Requires: nuConfig, RCFile: nuConfig, Snippet: nuHook},
+		{Shell: "powershell", Requires: "/opt/microsoft/powershell/7", RCFile: "/opt/microsoft/powershell/7/profile.ps1", Snippet: powershellHook},
+	}
+}
+
+type ShellHookStore interface {
+	FileExists(target string) (bool, error)
+	GetFileAsString(path string) (string, error)
+	AppendString(path string, content string) error
+	WriteString(path, data string) error
+}
+
+// InstallShellHooks adds the hook of every installed shell to its rc file,
+// unless it is already there
+func InstallShellHooks(store ShellHookStore, homeDir string) error {
+	var allErr error
+	for _, hook := range ShellHooks(homeDir) {
+		err := installShellHook(store, hook)
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("%s: %w", hook.Shell, err))
+		}
+	}
+	if allErr != nil {
+		return breverrors.WrapAndTrace(allErr)
+	}
+	return nil
+}
+
+func installShellHook(store ShellHookStore, hook ShellHook) error {
+	installed, err := store.FileExists(hook.Requires)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !installed {
+		return nil
+	}
+	exists, err := store.FileExists(hook.RCFile)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !exists {
+		err = store.WriteString(hook.RCFile, hook.Snippet)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	rc, err := store.GetFileAsString(hook.RCFile)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if strings.Contains(rc, hookMarker) {
+		return nil
+	}
+	err = store.AppendString(hook.RCFile, hook.Snippet)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package configureenvvars
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type fakeHookStore struct {
+	files map[string]string
+}
+
+func (f *fakeHookStore) FileExists(target string) (bool, error) {
+	for path := range f.files {
+		if path == target || strings.HasPrefix(path, target+"/") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeHookStore) GetFileAsString(path string) (string, error) {
+	content, ok := f.files[path]
+	if !ok {
+		return "", fmt.Errorf("%s does not exist", path)
+	}
+	return content, nil
+}
+
+func (f *fakeHookStore) AppendString(path string, content string) error {
+	f.files[path] += content
+	return nil
+}
+
+func (f *fakeHookStore) WriteString(path, data string) error {
+	f.files[path] = data
+	return nil
+}
+
+func TestInstallShellHooks(t *testing.T) {
+	store := &fakeHookStore{files: map[string]string{
+		"/etc/bash.bashrc":                     "# system bashrc\n",
+		"/etc/fish/config.fish":                "",
+		"/home/brev/.config/nushell/config.nu": "$env.config = {}\n",
+	}}
+
+	for i := 0; i < 2; i++ {
+		err := InstallShellHooks(store, "/home/brev")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, hook := range map[string]string{
+		"/etc/bash.bashrc":                     bashHook,
+		"/etc/fish/conf.d/brev.fish":           fishHook,
+		"/home/brev/.config/nushell/config.nu": nuHook,
+	} {
+		if n := strings.Count(store.files[path], hook); n != 1 {
+			t.Errorf("%s has the hook %d times, want once", path, n)
+		}
+	}
+	for _, path := range []string{"/etc/zsh/zshrc", "/opt/microsoft/powershell/7/profile.ps1"} {
+		if _, ok := store.files[path]; ok {
+			t.Errorf("%s was written, but the shell is not installed", path)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package configureenvvars
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alessio/shellescape"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// formatter turns env file entries into something a shell can eval. Values
+// are passed as they were written in the env file, ex: still single quoted
+type formatter interface {
+	set(key, value string) string
+	// unset is "" for formats that can't remove a variable, ex: dotenv
+	unset(key string) string
+}
+
+var formatters = map[string]formatter{
+	"bash":       posixFormatter{},
+	"zsh":        posixFormatter{},
+	"sh":         posixFormatter{},
+	"fish":       fishFormatter{},
+	"nu":         nuFormatter{},
+	"powershell": powershellFormatter{},
+	"pwsh":       powershellFormatter{},
+	"dotenv":     dotenvFormatter{},
+}
+
+const jsonShell = "json"
+
+// Shells are the values --shell accepts
+var Shells = []string{"bash", "zsh", "fish", "nu", "powershell", "dotenv", jsonShell}
+
+func getFormatter(shell string) (formatter, error) {
+	f, ok := formatters[strings.ToLower(shell)]
+	if !ok {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("unsupported shell %q, must be one of %s", shell, strings.Join(Shells, "|")))
+	}
+	return f, nil
+}
+
+type posixFormatter struct{}
+
+func (posixFormatter) set(key, value string) string {
+	if !strings.HasPrefix(value, "'") { // already quoted
+		value = shellescape.Quote(unquote(value))
+	}
+	return fmt.Sprintf("export %s=%s", key, value)
+}
+
+func (posixFormatter) unset(key string) string {
+	return "unset " + key
+}
+
+type fishFormatter struct{}
+
+// fish single quotes only treat \ and ' as special
+func (fishFormatter) set(key, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(unquote(value))
+	return fmt.Sprintf("set -gx %s '%s'", key, value)
+}
+
+func (fishFormatter) unset(key string) string {
+	return "set -e " + key
+}
+
+type nuFormatter struct{}
+
+// nu single quoted strings have no escapes at all, so double quotes are used
+func (nuFormatter) set(key, value string) string {
+	return fmt.Sprintf("$env.%s = %s", key, doubleQuote(unquote(value)))
+}
+
+func (nuFormatter) unset(key string) string {
+	return "hide-env --ignore-errors " + key
+}
+
+type powershellFormatter struct{}
+
+// powershell single quotes are escaped by doubling them
+func (powershellFormatter) set(key, value string) string {
+	return fmt.Sprintf("$env:%s = '%s'", key, strings.ReplaceAll(unquote(value), "'", "''"))
+}
+
+func (powershellFormatter) unset(key string) string {
+	return fmt.Sprintf("Remove-Item -ErrorAction SilentlyContinue Env:%s", key)
+}
+
+type dotenvFormatter struct{}
+
+var dotenvSafe = regexp.MustCompile(`^[\w@%+=:,./-]*$`)
+
+func (dotenvFormatter) set(key, value string) string {
+	value = unquote(value)
+	switch {
+	case dotenvSafe.MatchString(value):
+	case !strings.ContainsAny(value, "'\n"):
+		value = "'" + value + "'"
+	default:
+		value = doubleQuote(value)
+	}
+	return fmt.Sprintf("%s=%s", key, value)
+}
+
+func (dotenvFormatter) unset(string) string {
+	return ""
+}
+
+func doubleQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(value) + `"`
+}
+
+// jsonEnv is for shells that can load a record but not eval code, ex: the nu
+// hook
+type jsonEnv struct {
+	Set   map[string]string `json:"set"`
+	Unset []string          `json:"unset"`
+}
+
+func formatJSON(unsetKeys []string, entries envVars, managedKeys string) (string, error) {
+	env := jsonEnv{Set: map[string]string{}, Unset: unsetKeys}
+	for k, v := range entries {
+		env.Set[k] = unquote(v)
+	}
+	if managedKeys != "" {
+		env.Set[BREV_MANGED_ENV_VARS_KEY] = managedKeys
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(b), nil
+}
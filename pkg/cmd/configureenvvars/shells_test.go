@@ -0,0 +1,80 @@
+package configureenvvars
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_generateEnvString(t *testing.T) {
+	const envFile = `export foo="it's"
+bar="a b"`
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{
+			shell: "bash",
+			want: `unset old
+export bar='a b'
+export foo='it'"'"'s'
+export ` + BREV_MANGED_ENV_VARS_KEY + `=bar,foo`,
+		},
+		{
+			shell: "fish",
+			want: `set -e old
+set -gx bar 'a b'
+set -gx foo 'it\'s'
+set -gx ` + BREV_MANGED_ENV_VARS_KEY + ` 'bar,foo'`,
+		},
+		{
+			shell: "nu",
+			want: `hide-env --ignore-errors old
+$env.bar = "a b"
+$env.foo = "it's"
+$env.` + BREV_MANGED_ENV_VARS_KEY + ` = "bar,foo"`,
+		},
+		{
+			shell: "powershell",
+			want: `Remove-Item -ErrorAction SilentlyContinue Env:old
+$env:bar = 'a b'
+$env:foo = 'it''s'
+$env:` + BREV_MANGED_ENV_VARS_KEY + ` = 'bar,foo'`,
+		},
+		{
+			shell: "dotenv",
+			want: `bar='a b'
+foo="it's"
+` + BREV_MANGED_ENV_VARS_KEY + `=bar,foo`,
+		},
+		{
+			shell: "json",
+			want:  `{"set":{"BREV_MANAGED_ENV_VARS":"bar,foo","bar":"a b","foo":"it's"},"unset":["old"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			got, err := generateEnvString(tt.shell, "old,foo", envFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
+func Test_generateEnvStringJSONIsAlwaysADocument(t *testing.T) {
+	got, err := generateEnvString("json", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(`{"set":{},"unset":[]}`, got); diff != "" {
+		t.Fatalf(diff)
+	}
+	_, err = generateEnvString("tcsh", "", "foo=bar")
+	if err == nil {
+		t.Fatal("expected an error for an unsupported shell")
+	}
+}
@@ -1,9 +1,9 @@
 package create
 
 import (
+	"context"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/config"
@@ -11,6 +11,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/featureflag"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/waiter"
 	"github.com/spf13/cobra"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -50,6 +51,7 @@ func NewCmdCreate(t *terminal.Terminal, createStore CreateStore) *cobra.Command
 				name = args[0]
 			}
 
+			cpu, gpu = config.GlobalConfig.ResolveMachine(cpu, gpu)
 			err := runCreateWorkspace(t, CreateOptions{
 				Name:           name,
 				WorkspaceClass: cpu,
@@ -68,7 +70,7 @@ func NewCmdCreate(t *terminal.Terminal, createStore CreateStore) *cobra.Command
 		},
 	}
 	cmd.Flags().BoolVarP(&detached, "detached", "d", false, "run the command in the background instead of blocking the shell")
-	cmd.Flags().StringVarP(&cpu, "cpu", "c", "", "CPU instance type. Defaults to 2x8 [2x8, 4x16, 8x32, 16x32]. See docs.brev.dev/cpu for details")
+	cmd.Flags().StringVarP(&cpu, "cpu", "c", "", "CPU instance type. Defaults to the profile's workspace-class or 2x8 [2x8, 4x16, 8x32, 16x32]. See docs.brev.dev/cpu for details")
 	cmd.Flags().StringVarP(&gpu, "gpu", "g", "", "GPU instance type. See docs.brev.dev/gpu for details")
 	return cmd
 }
@@ -143,7 +145,7 @@ func createEmptyWorkspace(user *entity.User, t *terminal.Terminal, options Creat
 	if options.Detached {
 		return nil
 	} else {
-		err = pollUntil(t, w.ID, entity.Running, createStore, true)
+		_, err = waiter.NewWaiter(createStore, t).WithSafeExitMessage().WaitFor(context.Background(), w.ID, waiter.Running)
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
 		}
@@ -180,27 +182,3 @@ func displayConnectBreadCrumb(t *terminal.Terminal, workspace *entity.Workspace)
 	t.Vprintf(t.Yellow(fmt.Sprintf("\tbrev shell %s\t# brev shell <NAME> -> ssh into dev environment (shortcut)\n", workspace.Name)))
 	// t.Vprintf(t.Yellow(fmt.Sprintf("\tssh %s\t# ssh <SSH-NAME> -> ssh directly to dev environment\n", workspace.GetLocalIdentifier())))
 }
-
-func pollUntil(t *terminal.Terminal, wsid string, state string, createStore CreateStore, canSafelyExit bool) error {
-	s := t.NewSpinner()
-	isReady := false
-	if canSafelyExit {
-		t.Vprintf("You can safely ctrl+c to exit\n")
-	}
-	s.Suffix = " hang tight 🤙"
-	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := createStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  environment is " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Environment is ready!"
-			s.Stop()
-			isReady = true
-		}
-	}
-	return nil
-}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/entity"
@@ -18,7 +19,8 @@ import (
 var (
 	//go:embed doc.md
 	deleteLong    string
-	deleteExample = "brev delete <ws_name>"
+	deleteExample = `  brev delete <ws_name>...
+  brev delete 'scratch-*' --older-than 168h --dry-run`
 )
 
 type DeleteStore interface {
@@ -28,6 +30,7 @@ type DeleteStore interface {
 }
 
 func NewCmdDelete(t *terminal.Terminal, loginDeleteStore DeleteStore, noLoginDeleteStore DeleteStore) *cobra.Command {
+	var selector bulk.Selector
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
 		Use:                   "delete",
@@ -37,6 +40,10 @@ func NewCmdDelete(t *terminal.Terminal, loginDeleteStore DeleteStore, noLoginDel
 		Example:               deleteExample,
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginDeleteStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			selector.Names = args
+			if selector.IsBulk() {
+				return deleteWorkspaces(t, selector, loginDeleteStore)
+			}
 			var allError error
 			for _, workspace := range args {
 				err := deleteWorkspace(workspace, t, loginDeleteStore)
@@ -50,10 +57,28 @@ func NewCmdDelete(t *terminal.Terminal, loginDeleteStore DeleteStore, noLoginDel
 			return nil
 		},
 	}
+	bulk.AddFlags(cmd, &selector)
 
 	return cmd
 }
 
+func deleteWorkspaces(t *terminal.Terminal, selector bulk.Selector, deleteStore DeleteStore) error {
+	err := bulk.Apply(t, deleteStore, selector, bulk.Operation{
+		Verb: "delete",
+		Run: func(w entity.Workspace) error {
+			_, err := deleteStore.DeleteWorkspace(w.ID)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func deleteWorkspace(workspaceName string, t *terminal.Terminal, deleteStore DeleteStore) error {
 	workspace, err := util.GetUserWorkspaceByNameOrIDErr(deleteStore, workspaceName)
 	if err != nil {
@@ -20,11 +20,13 @@ import (
 	_ "embed"
 
 	"github.com/brevdev/brev-cli/pkg/autostartconf"
+	"github.com/brevdev/brev-cli/pkg/cmd/configureenvvars"
 	"github.com/brevdev/brev-cli/pkg/cmd/updatemodel"
 	"github.com/brevdev/brev-cli/pkg/cmd/version"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/featureflag"
+	"github.com/brevdev/brev-cli/pkg/setupprogress"
 	"github.com/brevdev/brev-cli/pkg/setupworkspace"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/util"
@@ -260,7 +262,17 @@ func (e *envInitier) SetupUpdateModel() error {
 	return nil
 }
 
-func (e envInitier) Setup() error { //nolint:funlen,gocyclo // TODO
+func (e envInitier) Setup() error {
+	e.Progress.Start("prepare", "ssh-git", setupprogress.StepRepos, setupprogress.StepExecs, "env-vars", "monitoring", "extras")
+	err := e.setup()
+	e.Progress.Done(err)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (e envInitier) setup() error { //nolint:funlen,gocyclo // TODO
 	var setupErr error
 
 	err := appendLogToFile("setup started", "/var/log/brev-setup-steps.log")
@@ -268,18 +280,25 @@ func (e envInitier) Setup() error { //nolint:funlen,gocyclo // TODO
 		setupErr = multierror.Append(setupErr, breverrors.WrapAndTrace(err))
 	}
 
-	err = setupworkspace.BuildAndRunCmd("systemctl", "stop", "unattended-upgrades")
-	if err != nil {
-		setupErr = multierror.Append(setupErr, breverrors.WrapAndTrace(err))
-	}
-	out, err := setupworkspace.RunCMDWithOutput("apt-get", "-y", "remove", "unattended-upgrades")
-	if err != nil {
-		setupErr = multierror.Append(setupErr,
-			breverrors.WrapAndTrace(err, "apt-get -y remove unattended-upgrades", out))
-	}
+	err = e.Progress.Step("prepare", func() error {
+		var prepareErr error
+		err := setupworkspace.BuildAndRunCmd("systemctl", "stop", "unattended-upgrades")
+		if err != nil {
+			prepareErr = multierror.Append(prepareErr, breverrors.WrapAndTrace(err))
+		}
+		out, err := setupworkspace.RunCMDWithOutput("apt-get", "-y", "remove", "unattended-upgrades")
+		if err != nil {
+			prepareErr = multierror.Append(prepareErr,
+				breverrors.WrapAndTrace(err, "apt-get -y remove unattended-upgrades", out))
+		}
 
-	cmd := setupworkspace.CmdStringBuilder("echo user: $(whoami) && echo pwd: $(pwd)")
-	err = cmd.Run()
+		cmd := setupworkspace.CmdStringBuilder("echo user: $(whoami) && echo pwd: $(pwd)")
+		err = cmd.Run()
+		if err != nil {
+			prepareErr = multierror.Append(prepareErr, breverrors.WrapAndTrace(err))
+		}
+		return prepareErr
+	})
 	if err != nil {
 		setupErr = multierror.Append(setupErr, breverrors.WrapAndTrace(err))
 	}
@@ -291,10 +310,12 @@ func (e envInitier) Setup() error { //nolint:funlen,gocyclo // TODO
 		e.SetupUpdateModel,
 	)
 
-	err = util.RunEAsync(
-		e.SetupSSH,
-		e.SetupGit,
-	).Await()
+	err = e.Progress.Step("ssh-git", func() error {
+		return util.RunEAsync(
+			e.SetupSSH,
+			e.SetupGit,
+		).Await()
+	})
 	if err != nil {
 		setupErr = multierror.Append(setupErr, breverrors.WrapAndTrace(err))
 	}
@@ -303,10 +324,11 @@ func (e envInitier) Setup() error { //nolint:funlen,gocyclo // TODO
 	if err != nil {
 		setupErr = multierror.Append(setupErr, breverrors.WrapAndTrace(err))
 	}
-	err = e.SetupRepos()
+	err = e.Progress.Step(setupprogress.StepRepos, e.SetupRepos)
 	if err != nil {
 		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
 	}
+	// older versions of brev open wait for these markers
 	fmt.Println("------ Git repo cloned ------")
 	err = appendLogToFile("repo setup done", "/var/log/brev-steps.log")
 	if err != nil {
@@ -317,12 +339,12 @@ func (e envInitier) Setup() error { //nolint:funlen,gocyclo // TODO
 	if err != nil {
 		setupErr = multierror.Append(setupErr, breverrors.WrapAndTrace(err))
 	}
-	err = e.RunExecs()
+	err = e.Progress.Step(setupprogress.StepExecs, e.RunExecs)
 	if err != nil {
 		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
 	}
 
-	err = e.SetupEnvVars()
+	err = e.Progress.Step("env-vars", e.SetupEnvVars)
 	if err != nil {
 		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
 	}
@@ -332,19 +354,24 @@ func (e envInitier) Setup() error { //nolint:funlen,gocyclo // TODO
 		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
 	}
 
-	err = e.brevMonConfigurer.Install()
-	if err != nil {
-		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
-	}
-
-	if e.datadogAPIKey != "" {
-		err = e.SetupDatadog()
+	err = e.Progress.Step("monitoring", func() error {
+		err := e.brevMonConfigurer.Install()
 		if err != nil {
-			setupErr = multierror.Append(breverrors.WrapAndTrace(err))
+			return breverrors.WrapAndTrace(err)
 		}
+		if e.datadogAPIKey != "" {
+			err = e.SetupDatadog()
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
 	}
 
-	err = postPrepare.Await()
+	err = e.Progress.Step("extras", postPrepare.Await)
 	if err != nil {
 		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
 	}
@@ -561,38 +588,7 @@ PasswordAuthentication no`, authorizedKeyPath),
 }
 
 func (e envInitier) SetupEnvVars() error {
-	// set env vars
-	err := e.store.AppendString("/etc/bash.bashrc", `
-_brev_hook() {
-  local previous_exit_status=$?;
-  trap -- '' SIGINT;
-  eval "$(/usr/local/bin/brev configure-env-vars bash)";
-  trap - SIGINT;
-  return $previous_exit_status;
-};
-if ! [[ "${PROMPT_COMMAND:-}" =~ _brev_hook ]]; then
-  PROMPT_COMMAND="_brev_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
-fi
-`)
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-
-	err = e.store.AppendString("/etc/zsh/zshrc", `
-_brev_hook() {
-  trap -- '' SIGINT;
-  eval "$(/usr/local/bin/brev configure-env-vars zsh)";
-  trap - SIGINT;
-}
-typeset -ag precmd_functions;
-if [[ -z "${precmd_functions[(r)_brev_hook]+1}" ]]; then
-  precmd_functions=( _brev_hook ${precmd_functions[@]} )
-fi
-typeset -ag chpwd_functions;
-if [[ -z "${chpwd_functions[(r)_brev_hook]+1}" ]]; then
-  chpwd_functions=( _brev_hook ${chpwd_functions[@]} )
-fi
-`)
+	err := configureenvvars.InstallShellHooks(e.store, e.User.HomeDir)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -1,6 +1,13 @@
 package initfile
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/devcontainer"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/mergeshells" //nolint:typecheck // uses generic code
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	"github.com/spf13/cobra"
@@ -11,21 +18,76 @@ type InitFileStore interface {
 }
 
 func NewCmdInitFile(t *terminal.Terminal, store InitFileStore) *cobra.Command {
+	var recognizerDirs []string
 	cmd := &cobra.Command{
 		Use:                   "init",
 		DisableFlagsInUseLine: true,
 		Short:                 "initialize a .brev/setup.sh file if it does not exist",
-		Long:                  "initialize a .brev/setup.sh file if it does not exist",
+		Long: `initialize a .brev/setup.sh file if it does not exist, with installers for the languages and tools the repo uses.
+Repos with a devcontainer.json get its features, env and lifecycle commands instead.
+In-house recognizers are loaded from ~/.brev/recognizers, the repo's .brev/recognizers and --recognizers`,
+		Example: `
+  brev init
+  brev init ./services/api --recognizers ~/team/recognizers
+		`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "."
 			if len(args) > 0 {
-				// then assume it is .
-				mergeshells.ImportPath(t, args[0], store)
-			} else {
-				mergeshells.ImportPath(t, ".", store)
+				path = args[0]
+			}
+			if devContainerPath, ok := devcontainer.Find(path); ok {
+				return initFromDevContainer(t, path, devContainerPath, recognizerDirs)
 			}
+			mergeshells.ImportPath(t, path, store, recognizerDirs...)
 			return nil
 		},
 	}
+	cmd.Flags().StringSliceVar(&recognizerDirs, "recognizers", nil, "directories with in-house recognizers, each a folder with a recognizer.yaml and its installers")
 
 	return cmd
 }
+
+// initFromDevContainer writes .brev/setup.sh from the repo's devcontainer.json
+// and reports what it can't do. Features are installed by the same
+// recognizers as brev init without one
+func initFromDevContainer(t *terminal.Terminal, path string, devContainerPath string, recognizerDirs []string) error {
+	setupScriptPath := filepath.Join(path, ".brev", "setup.sh")
+	if _, err := os.Stat(setupScriptPath); err == nil {
+		fmt.Println(".brev/setup.sh already exists - will not overwrite.")
+		return nil
+	}
+	mergeshells.RegisterRecognizerDirs(path, recognizerDirs...)
+	d, err := devcontainer.Load(devContainerPath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	script, imported := d.SetupScript()
+
+	err = os.MkdirAll(filepath.Dir(setupScriptPath), os.ModePerm)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.WriteFile(setupScriptPath, []byte(script), 0o755) //nolint:gosec // a script
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	fmt.Printf("wrote %s from %s\n", setupScriptPath, devContainerPath)
+	if len(imported.Dependencies) > 0 {
+		fmt.Println("** Found Dependencies **")
+		t.Vprint(t.Yellow("%s", strings.Join(imported.Dependencies, " \n")))
+	}
+	for _, p := range imported.Ports {
+		fmt.Printf("forward port %s with: brev port-forward <dev env> -p %s:%s\n", p, p, p)
+	}
+	if len(imported.IDEConfig.VSCode.Extensions) > 0 {
+		fmt.Printf("vscode extensions: %s\n", strings.Join(imported.ExtensionIDs(), " "))
+	}
+	if len(imported.Unsupported) > 0 {
+		t.Vprint(t.Yellow("** Unsupported **"))
+		for _, u := range imported.Unsupported {
+			t.Vprint(t.Yellow("%s", u))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,481 @@
+// Package local runs dev environments in containers on this machine, set up
+// from the same definition as in the cloud
+package local
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/ssh"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/workspacemanagerv2"
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	localLong = `Run a dev environment in a docker or podman container on this machine.
+
+The container is set up from the same repos and execs as the dev environment
+in the cloud. /home/brev/workspace is kept in ~/.brev/local until brev local rm,
+so stop, start and reset don't lose work. Each container gets an ssh alias,
+the name of the dev environment with -local appended.`
+	localExample = `
+  brev local start my-env
+  ssh my-env-local
+  brev local stop my-env
+  brev local reset my-env
+  brev local rm my-env
+  brev local ls
+	`
+)
+
+// containerUser is the user of brev's dev environment images
+const containerUser = "brev"
+
+type LocalStore interface {
+	util.GetWorkspaceByNameOrIDErrStore
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+	GetEnvSetupParams(workspaceID string) (*store.SetupParamsV0, error)
+	GetLocalWorkspaces() ([]store.LocalWorkspace, error)
+	SaveLocalWorkspaces(workspaces []store.LocalWorkspace) error
+	GetLocalVolumesPath() (string, error)
+	RemoveLocalWorkspaceVolumes(workspaceID string) error
+	GetLocalSSHConfigPath() (string, error)
+	WriteLocalSSHConfig(config string) error
+	GetPrivateKeyPath() (string, error)
+	ssh.UserSSHConfigStore
+}
+
+func NewCmdLocal(t *terminal.Terminal, loginLocalStore LocalStore, completionStore completions.CompletionStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations: map[string]string{"workspace": ""},
+		Use:         "local",
+		Short:       "Run a dev environment in a local container",
+		Long:        localLong,
+		Example:     localExample,
+	}
+	l := &Local{
+		t:     t,
+		store: loginLocalStore,
+	}
+	cmd.PersistentFlags().StringVar(&l.runtime, "runtime", "", fmt.Sprintf("container runtime, one of %s, found automatically by default", strings.Join(workspacemanagerv2.Runtimes, "|")))
+	completion := completions.GetAllWorkspaceNameCompletionHandler(completionStore, t)
+
+	var image string
+	start := &cobra.Command{
+		Use:               "start",
+		Short:             "Create or start the local container of a dev environment",
+		Args:              cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction: completion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := l.connect(cmd.Context())
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return breverrors.WrapAndTrace(l.Start(cmd.Context(), args[0], image))
+		},
+	}
+	start.Flags().StringVar(&image, "image", "", "image to use instead of the dev environment's")
+
+	stop := &cobra.Command{
+		Use:               "stop",
+		Short:             "Stop the local container of a dev environment",
+		Args:              cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction: completion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := l.connect(cmd.Context())
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return breverrors.WrapAndTrace(l.Stop(cmd.Context(), args[0]))
+		},
+	}
+
+	reset := &cobra.Command{
+		Use:               "reset",
+		Short:             "Replace the local container, keeping /home/brev/workspace",
+		Args:              cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction: completion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := l.connect(cmd.Context())
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return breverrors.WrapAndTrace(l.Reset(cmd.Context(), args[0]))
+		},
+	}
+
+	rm := &cobra.Command{
+		Use:               "rm",
+		Short:             "Delete the local container of a dev environment and its volumes",
+		Args:              cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction: completion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := l.connect(cmd.Context())
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return breverrors.WrapAndTrace(l.Remove(cmd.Context(), args[0]))
+		},
+	}
+
+	ls := &cobra.Command{
+		Use:   "ls",
+		Short: "List local dev environments",
+		Args:  cmderrors.TransformToValidationError(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := l.connect(cmd.Context())
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return breverrors.WrapAndTrace(l.List(cmd.Context()))
+		},
+	}
+
+	cmd.AddCommand(start, stop, reset, rm, ls)
+	return cmd
+}
+
+type Local struct {
+	t       *terminal.Terminal
+	store   LocalStore
+	runtime string
+	cm      workspacemanagerv2.ContainerManager
+}
+
+// connect finds docker or podman, only when a command needs it so that
+// brev local --help works without either
+func (l *Local) connect(ctx context.Context) error {
+	cm, err := workspacemanagerv2.NewContainerManager(ctx, l.runtime)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	l.cm = cm
+	return nil
+}
+
+// Start only needs the api the first time, after that the container is
+// started as it is, ex: offline
+func (l Local) Start(ctx context.Context, nameOrID string, image string) error {
+	workspaces, err := l.store.GetLocalWorkspaces()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	local, ok := findLocalWorkspace(workspaces, nameOrID)
+	if ok && image == "" {
+		_, err = l.cm.GetContainer(ctx, local.WorkspaceID)
+		if err == nil {
+			err = l.containerWorkspace(local).Start(ctx)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			l.printRunning(local)
+			return nil
+		}
+		if !workspacemanagerv2.IsContainerNotFound(err) {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+
+	cw, local, err := l.newContainerWorkspace(nameOrID, image, workspaces)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	l.t.Vprintf("creating local container for %s from %s\n", local.Name, local.Image)
+	err = cw.Start(ctx)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = l.save(upsertLocalWorkspace(workspaces, local))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	l.printRunning(local)
+	return nil
+}
+
+func (l Local) Stop(ctx context.Context, nameOrID string) error {
+	local, err := l.getLocalWorkspace(nameOrID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = l.containerWorkspace(*local).Stop(ctx)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	l.t.Vprintf(l.t.Green("%s stopped\n", local.Name))
+	return nil
+}
+
+// Reset gets the latest definition of the dev environment and sets up a new
+// container from it
+func (l Local) Reset(ctx context.Context, nameOrID string) error {
+	workspaces, err := l.store.GetLocalWorkspaces()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	local, ok := findLocalWorkspace(workspaces, nameOrID)
+	if !ok {
+		return notFoundErr(nameOrID)
+	}
+	cw, local, err := l.newContainerWorkspace(local.WorkspaceID, local.Image, workspaces)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = cw.Recreate(ctx)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = l.save(upsertLocalWorkspace(workspaces, local))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	l.printRunning(local)
+	return nil
+}
+
+func (l Local) Remove(ctx context.Context, nameOrID string) error {
+	workspaces, err := l.store.GetLocalWorkspaces()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	local, ok := findLocalWorkspace(workspaces, nameOrID)
+	if !ok {
+		return notFoundErr(nameOrID)
+	}
+	err = l.containerWorkspace(local).Delete(ctx)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = l.store.RemoveLocalWorkspaceVolumes(local.WorkspaceID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = l.save(removeLocalWorkspace(workspaces, local.WorkspaceID))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	l.t.Vprintf(l.t.Green("%s removed\n", local.Name))
+	return nil
+}
+
+func (l Local) List(ctx context.Context) error {
+	workspaces, err := l.store.GetLocalWorkspaces()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(workspaces) == 0 {
+		l.t.Vprint(l.t.Yellow("No local dev environments, create one with brev local start <name>"))
+		return nil
+	}
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.AppendHeader(table.Row{"NAME", "STATUS", "SSH", "IMAGE"})
+	for _, w := range workspaces {
+		status := "not created"
+		c, err := l.cm.GetContainer(ctx, w.WorkspaceID)
+		switch {
+		case err == nil:
+			status = string(c.Status)
+		case !workspacemanagerv2.IsContainerNotFound(err):
+			status = "unknown"
+		}
+		ta.AppendRow(table.Row{w.Name, status, w.SSHAlias(), w.Image})
+	}
+	ta.Render()
+	return nil
+}
+
+func (l Local) getLocalWorkspace(nameOrID string) (*store.LocalWorkspace, error) {
+	workspaces, err := l.store.GetLocalWorkspaces()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	local, ok := findLocalWorkspace(workspaces, nameOrID)
+	if !ok {
+		return nil, notFoundErr(nameOrID)
+	}
+	return &local, nil
+}
+
+// containerWorkspace is enough to start, stop or delete a container that
+// exists, creating one needs newContainerWorkspace
+func (l Local) containerWorkspace(local store.LocalWorkspace) *workspacemanagerv2.ContainerWorkspace {
+	return workspacemanagerv2.NewContainerWorkspace(l.cm, local.WorkspaceID, local.Image, nil).
+		WithPorts(sshPortMapping(local.SSHPort))
+}
+
+// newContainerWorkspace gets the definition of the dev environment from the
+// api. The ssh port of an existing local dev environment is kept so that its
+// ssh alias keeps working
+func (l Local) newContainerWorkspace(nameOrID string, image string, workspaces []store.LocalWorkspace) (*workspacemanagerv2.ContainerWorkspace, store.LocalWorkspace, error) {
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(l.store, nameOrID)
+	if err != nil {
+		return nil, store.LocalWorkspace{}, breverrors.WrapAndTrace(err)
+	}
+	volumesPath, err := l.store.GetLocalVolumesPath()
+	if err != nil {
+		return nil, store.LocalWorkspace{}, breverrors.WrapAndTrace(err)
+	}
+	wm := workspacemanagerv2.NewWorkspaceManager(l.cm, workspaceManagerStore{l.store}).WithLocalVolumes(volumesPath)
+	cw, err := wm.MakeContainerWorkspace(workspace.ID)
+	if err != nil {
+		return nil, store.LocalWorkspace{}, breverrors.WrapAndTrace(err)
+	}
+	if image != "" {
+		cw.Image = image
+	}
+	if cw.Image == "" {
+		return nil, store.LocalWorkspace{}, breverrors.NewValidationError(fmt.Sprintf("%s has no image to run locally, use --image", workspace.Name))
+	}
+
+	local := store.LocalWorkspace{Name: workspace.Name, WorkspaceID: workspace.ID, Image: cw.Image}
+	if existing, ok := findLocalWorkspace(workspaces, workspace.ID); ok {
+		local.SSHPort = existing.SSHPort
+	} else {
+		local.SSHPort, err = freePort()
+		if err != nil {
+			return nil, store.LocalWorkspace{}, breverrors.WrapAndTrace(err)
+		}
+	}
+	cw.WithPorts(sshPortMapping(local.SSHPort))
+	return cw, local, nil
+}
+
+// save records the local dev environments and gives each an ssh alias
+func (l Local) save(workspaces []store.LocalWorkspace) error {
+	err := l.store.SaveLocalWorkspaces(workspaces)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	configPath, err := l.store.GetLocalSSHConfigPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	privateKeyPath, err := l.store.GetPrivateKeyPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	entries := []ssh.SSHConfigEntryV2{}
+	for _, w := range workspaces {
+		entries = append(entries, ssh.SSHConfigEntryV2{
+			Alias:        w.SSHAlias(),
+			IdentityFile: privateKeyPath,
+			User:         containerUser,
+			HostName:     "127.0.0.1",
+			Port:         w.SSHPort,
+		})
+	}
+	conf, err := ssh.MakeLocalSSHConfig(configPath, entries)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = l.store.WriteLocalSSHConfig(conf)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = ssh.EnsureUserConfigIncludes(l.store, configPath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (l Local) printRunning(local store.LocalWorkspace) {
+	l.t.Vprintf(l.t.Green("%s is running locally, setup continues in the container\n", local.Name))
+	l.t.Vprintf("connect with: %s\n", l.t.Yellow("ssh %s", local.SSHAlias()))
+}
+
+func sshPortMapping(port int) string {
+	return fmt.Sprintf("127.0.0.1:%d:22", port)
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	defer listener.Close() //nolint:errcheck // only used to find a port
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func notFoundErr(nameOrID string) error {
+	return breverrors.NewValidationError(fmt.Sprintf("no local dev environment named %s, see brev local ls", nameOrID))
+}
+
+func findLocalWorkspace(workspaces []store.LocalWorkspace, nameOrID string) (store.LocalWorkspace, bool) {
+	for _, w := range workspaces {
+		if w.Name == nameOrID || w.WorkspaceID == nameOrID {
+			return w, true
+		}
+	}
+	return store.LocalWorkspace{}, false
+}
+
+func upsertLocalWorkspace(workspaces []store.LocalWorkspace, local store.LocalWorkspace) []store.LocalWorkspace {
+	return append(removeLocalWorkspace(workspaces, local.WorkspaceID), local)
+}
+
+func removeLocalWorkspace(workspaces []store.LocalWorkspace, workspaceID string) []store.LocalWorkspace {
+	res := []store.LocalWorkspace{}
+	for _, w := range workspaces {
+		if w.WorkspaceID != workspaceID {
+			res = append(res, w)
+		}
+	}
+	return res
+}
+
+// workspaceManagerStore gets what workspacemanagerv2 mounts into the container
+// from the api
+type workspaceManagerStore struct {
+	store LocalStore
+}
+
+var _ workspacemanagerv2.WorkspaceManagerStore = workspaceManagerStore{}
+
+func (w workspaceManagerStore) GetWorkspace(id string) (*entity.Workspace, error) {
+	workspace, err := w.store.GetWorkspace(id)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return workspace, nil
+}
+
+func (w workspaceManagerStore) GetWorkspaceMeta(id string) (*store.WorkspaceMeta, error) {
+	workspace, err := w.store.GetWorkspace(id)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &store.WorkspaceMeta{
+		WorkspaceID:      workspace.ID,
+		WorkspaceGroupID: workspace.WorkspaceGroupID,
+		UserID:           workspace.CreatedByUserID,
+		OrganizationID:   workspace.OrganizationID,
+	}, nil
+}
+
+func (w workspaceManagerStore) GetWorkspaceSetupParams(id string) (*store.SetupParamsV0, error) {
+	params, err := w.store.GetEnvSetupParams(id)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return params, nil
+}
+
+// GetWorkspaceSecretsConfig is empty, secrets are only delivered to dev
+// environments in the cloud
+func (w workspaceManagerStore) GetWorkspaceSecretsConfig(_ string) (string, error) {
+	return "", nil
+}
@@ -27,6 +27,8 @@ import (
 type LoginOptions struct {
 	Auth       Auth
 	LoginStore LoginStore
+	// CredentialStore, when set, is where the tokens go from now on
+	CredentialStore string
 }
 
 type LoginStore interface {
@@ -41,6 +43,7 @@ type LoginStore interface {
 	hello.HelloStore
 	importideconfig.ImportIDEConfigStore
 	UserHomeDir() (string, error)
+	SetCredentialStore(name string) error
 }
 
 type Auth interface {
@@ -99,6 +102,7 @@ func NewCmdLogin(t *terminal.Terminal, loginStore LoginStore, auth Auth) *cobra.
 	}
 	cmd.Flags().StringVarP(&loginToken, "token", "", "", "token provided to auto login")
 	cmd.Flags().BoolVar(&skipBrowser, "skip-browser", false, "print url instead of auto opening browser")
+	cmd.Flags().StringVar(&opts.CredentialStore, "credential-store", "", fmt.Sprintf("where to keep your credentials from now on, one of %s. Defaults to the keyring where there is one", strings.Join(store.CredentialStores, ", ")))
 	return cmd
 }
 
@@ -165,6 +169,12 @@ func (o LoginOptions) RunLogin(t *terminal.Terminal, loginToken string, skipBrow
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if o.CredentialStore != "" {
+		err = o.LoginStore.SetCredentialStore(o.CredentialStore)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
 
 	caretType := color.New(color.FgGreen, color.Bold).SprintFunc()
 	fmt.Print("\n")
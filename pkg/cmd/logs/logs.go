@@ -0,0 +1,364 @@
+// Package logs streams setup and exec logs from a dev environment over ssh
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/setupworkspace"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsLong = `Stream the setup and exec logs of a dev environment.
+
+With no flags every setup log and every exec log is shown. Exits nonzero when
+an exec failed, or was skipped because something it depends on failed.`
+	logsExample = `
+  brev logs my-env
+  brev logs my-env --exec setup.sh
+  brev logs my-env --setup -f
+	`
+)
+
+// setupLogs are written by brev setup and brev setupworkspace
+var setupLogs = []string{
+	"/var/log/brev-setup-steps.log",
+	"/var/log/brev-steps.log",
+	"/var/log/brev-workspace.log",
+}
+
+// reportPollInterval is how often --follow checks if the execs are done
+var reportPollInterval = 2 * time.Second
+
+type LogsStore interface {
+	completions.CompletionStore
+	util.GetWorkspaceByNameOrIDErrStore
+	refresh.RefreshStore
+}
+
+type logsOptions struct {
+	execs  []string
+	setup  bool
+	follow bool
+}
+
+func NewCmdLogs(t *terminal.Terminal, loginLogsStore LogsStore, noLoginLogsStore LogsStore) *cobra.Command {
+	var opts logsOptions
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"ssh": ""},
+		Use:                   "logs",
+		DisableFlagsInUseLine: true,
+		Short:                 "Stream setup and exec logs from a dev environment",
+		Long:                  logsLong,
+		Example:               logsExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginLogsStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunLogs(t, loginLogsStore, args[0], opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVarP(&opts.execs, "exec", "e", nil, "only show the logs of these execs")
+	cmd.Flags().BoolVar(&opts.setup, "setup", false, "only show the setup logs, combine with --exec to show both")
+	cmd.Flags().BoolVarP(&opts.follow, "follow", "f", false, "keep streaming until every exec has finished")
+
+	return cmd
+}
+
+func RunLogs(t *terminal.Terminal, logsStore LogsStore, workspaceNameOrID string, opts logsOptions) error {
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(logsStore, workspaceNameOrID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if workspace.Status != "RUNNING" {
+		return breverrors.WorkspaceNotRunning{Status: workspace.Status}
+	}
+	err = refresh.RunRefresh(logsStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	r := sshRemote{alias: string(workspace.GetLocalIdentifier())}
+
+	report, err := readReport(r)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	sources, err := selectSources(report, opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if opts.follow && report != nil {
+		go func() {
+			waitForExecs(ctx, r)
+			// give tail a moment to print the last lines
+			time.Sleep(reportPollInterval)
+			cancel()
+		}()
+	}
+	streamSources(ctx, t, r, sources, opts.follow)
+
+	report, err = readReport(r)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return failedExecsErr(report, opts.execs)
+}
+
+// remote runs a shell script on the dev environment
+type remote interface {
+	Output(ctx context.Context, script string) ([]byte, error)
+	Stream(ctx context.Context, script string, out io.Writer) error
+}
+
+type sshRemote struct {
+	alias string
+}
+
+func (s sshRemote) command(ctx context.Context, script string) *exec.Cmd {
+	return exec.CommandContext(ctx, "ssh", "-o", "RemoteCommand=none", s.alias, script) //nolint:gosec // the alias comes from the user's ssh config
+}
+
+func (s sshRemote) Output(ctx context.Context, script string) ([]byte, error) {
+	cmd := s.command(ctx, script)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return out, nil
+}
+
+func (s sshRemote) Stream(ctx context.Context, script string, out io.Writer) error {
+	cmd := s.command(ctx, script)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil && ctx.Err() == nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// readReport returns nil for dev environments that were set up before the
+// report existed
+func readReport(r remote) (*setupworkspace.ExecReport, error) {
+	out, err := r.Output(context.Background(), fmt.Sprintf("cat %s 2>/dev/null || true", setupworkspace.ExecReportPath))
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if strings.TrimSpace(string(out)) == "" {
+		return nil, nil
+	}
+	var report setupworkspace.ExecReport
+	err = json.Unmarshal(out, &report)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, "could not read exec report")
+	}
+	return &report, nil
+}
+
+func waitForExecs(ctx context.Context, r remote) {
+	ticker := time.NewTicker(reportPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := readReport(r)
+			if err == nil && report != nil && report.IsDone() {
+				return
+			}
+		}
+	}
+}
+
+type logSource struct {
+	Prefix string
+	Path   string
+}
+
+func selectSources(report *setupworkspace.ExecReport, opts logsOptions) ([]logSource, error) {
+	showSetup := opts.setup || len(opts.execs) == 0
+	showAllExecs := !opts.setup && len(opts.execs) == 0
+
+	sources := []logSource{}
+	if showSetup {
+		for _, p := range setupLogs {
+			sources = append(sources, logSource{Prefix: strings.TrimPrefix(strings.TrimSuffix(path.Base(p), ".log"), "brev-"), Path: p})
+		}
+	}
+	if len(opts.execs) > 0 && report == nil {
+		return nil, breverrors.NewValidationError("this dev environment has no exec report, restart it to use --exec")
+	}
+	if report == nil {
+		return sources, nil
+	}
+
+	byName := map[string]setupworkspace.ExecReportEntry{}
+	for _, e := range report.Execs {
+		byName[e.Name] = e
+	}
+	names := opts.execs
+	if showAllExecs {
+		names = []string{}
+		for _, e := range report.Execs {
+			names = append(names, e.Name)
+		}
+	}
+	for _, n := range names {
+		e, ok := byName[n]
+		if !ok {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("no exec named %s, execs are: %s", n, strings.Join(execNames(report), ", ")))
+		}
+		if e.LogPath == "" {
+			continue
+		}
+		sources = append(sources, logSource{Prefix: n, Path: e.LogPath})
+	}
+	return sources, nil
+}
+
+func execNames(report *setupworkspace.ExecReport) []string {
+	names := []string{}
+	for _, e := range report.Execs {
+		names = append(names, e.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func tailScript(p string, follow bool) string {
+	if follow {
+		// -F waits for files that don't exist yet, ex: execs that have not
+		// started
+		return fmt.Sprintf("tail -n +1 -F %s 2>/dev/null", p)
+	}
+	return fmt.Sprintf("cat %s 2>/dev/null || true", p)
+}
+
+func streamSources(ctx context.Context, t *terminal.Terminal, r remote, sources []logSource, follow bool) {
+	colors := []func(format string, a ...interface{}) string{t.Green, t.Yellow, t.Blue, t.White, t.Red}
+	width := 0
+	for _, s := range sources {
+		if len(s.Prefix) > width {
+			width = len(s.Prefix)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, s := range sources {
+		s := s
+		prefix := colors[i%len(colors)]("%-*s | ", width, s.Prefix)
+		w := NewPrefixWriter(&mu, os.Stdout, prefix)
+		script := tailScript(s.Path, follow)
+		stream := func() {
+			defer wg.Done()
+			err := r.Stream(ctx, script, w)
+			w.Flush()
+			if err != nil {
+				t.Errprint(err, fmt.Sprintf("could not read %s", s.Path))
+			}
+		}
+		wg.Add(1)
+		if follow {
+			go stream()
+		} else {
+			// one after the other so that each log reads top to bottom
+			stream()
+		}
+	}
+	wg.Wait()
+}
+
+// PrefixWriter writes whole lines, each with a prefix. Writers for several
+// logs share a lock so that their lines don't interleave
+type PrefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func NewPrefixWriter(mu *sync.Mutex, out io.Writer, prefix string) *PrefixWriter {
+	return &PrefixWriter{mu: mu, out: out, prefix: prefix}
+}
+
+func (p *PrefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.writeLine(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes what is left once the stream ends without a newline
+func (p *PrefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.writeLine(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *PrefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w := bufio.NewWriter(p.out)
+	_, _ = w.WriteString(p.prefix)
+	_, _ = w.Write(line)
+	_ = w.Flush()
+}
+
+// failedExecsErr only looks at the execs that were asked for, all of them if
+// none were
+func failedExecsErr(report *setupworkspace.ExecReport, execs []string) error {
+	if report == nil {
+		return nil
+	}
+	wanted := map[string]bool{}
+	for _, e := range execs {
+		wanted[e] = true
+	}
+	failed := []string{}
+	for _, e := range report.Failed() {
+		if len(execs) == 0 || wanted[e.Name] {
+			failed = append(failed, fmt.Sprintf("%s (%s)", e.Name, e.Status))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return breverrors.NewValidationError(fmt.Sprintf("execs did not succeed: %s", strings.Join(failed, ", ")))
+}
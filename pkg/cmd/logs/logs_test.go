@@ -0,0 +1,57 @@
+package logs
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/setupworkspace"
+)
+
+var report = &setupworkspace.ExecReport{Execs: []setupworkspace.ExecReportEntry{
+	{Name: "setup.sh", Status: setupworkspace.ExecSucceeded, LogPath: "/w/.brev/logs/setup.log"},
+	{Name: "train", Status: setupworkspace.ExecFailed, LogPath: "/w/.brev/logs/train.log"},
+	{Name: "serve", Status: setupworkspace.ExecSkipped, LogPath: "/w/.brev/logs/serve.log"},
+}}
+
+func TestSelectSources(t *testing.T) {
+	all, err := selectSources(report, logsOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, all, len(setupLogs)+3)
+	assert.Equal(t, logSource{Prefix: "setup-steps", Path: "/var/log/brev-setup-steps.log"}, all[0])
+
+	setup, err := selectSources(report, logsOptions{setup: true})
+	assert.Nil(t, err)
+	assert.Len(t, setup, len(setupLogs))
+
+	one, err := selectSources(report, logsOptions{execs: []string{"train"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []logSource{{Prefix: "train", Path: "/w/.brev/logs/train.log"}}, one)
+
+	_, err = selectSources(report, logsOptions{execs: []string{"nope"}})
+	assert.ErrorContains(t, err, "setup.sh, serve, train")
+
+	_, err = selectSources(nil, logsOptions{execs: []string{"train"}})
+	assert.Error(t, err)
+	old, err := selectSources(nil, logsOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, old, len(setupLogs))
+}
+
+func TestFailedExecsErr(t *testing.T) {
+	assert.ErrorContains(t, failedExecsErr(report, nil), "train (failed), serve (skipped)")
+	assert.Nil(t, failedExecsErr(report, []string{"setup.sh"}))
+	assert.Nil(t, failedExecsErr(nil, nil))
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewPrefixWriter(&sync.Mutex{}, &out, "a | ")
+	_, _ = w.Write([]byte("one\ntw"))
+	_, _ = w.Write([]byte("o\nthree"))
+	assert.Equal(t, "a | one\na | two\n", out.String())
+	w.Flush()
+	assert.Equal(t, "a | one\na | two\na | three\n", out.String())
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmd/hello"
+	"github.com/brevdev/brev-cli/pkg/cmd/output"
 	utilities "github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
 	"github.com/brevdev/brev-cli/pkg/entity"
@@ -35,6 +36,7 @@ type LsStore interface {
 func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore) *cobra.Command {
 	var showAll bool
 	var org string
+	var outputOpts output.Options
 
 	cmd := &cobra.Command{
 		Annotations: map[string]string{"context": ""},
@@ -45,8 +47,13 @@ func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore
   brev ls
   brev ls orgs
   brev ls --org <orgid>
+  brev ls --output json --filter status=RUNNING --sort-by name
+  brev ls orgs --output name
 		`,
 		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if output.IsMachineReadableCmd(cmd) {
+				return nil
+			}
 			if hello.ShouldWeRunOnboardingLSStep(noLoginLsStore) && hello.ShouldWeRunOnboarding(noLoginLsStore) {
 				// Getting the workspaces should go in the hello.go file but then
 				// requires passing in stores and that makes it hard to use in other commands
@@ -91,7 +98,7 @@ func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore
 		Args:      cmderrors.TransformToValidationError(cobra.MinimumNArgs(0)),
 		ValidArgs: []string{"orgs", "workspaces"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := RunLs(t, loginLsStore, args, org, showAll)
+			err := RunLs(t, loginLsStore, args, org, showAll, outputOpts)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -107,6 +114,7 @@ func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore
 	}
 
 	cmd.Flags().BoolVar(&showAll, "all", false, "show all workspaces in org")
+	output.AddFlags(cmd, &outputOpts)
 
 	return cmd
 }
@@ -140,8 +148,12 @@ func getOrgForRunLs(lsStore LsStore, orgflag string) (*entity.Organization, erro
 	return org, nil
 }
 
-func RunLs(t *terminal.Terminal, lsStore LsStore, args []string, orgflag string, showAll bool) error {
-	ls := NewLs(lsStore, t)
+func RunLs(t *terminal.Terminal, lsStore LsStore, args []string, orgflag string, showAll bool, outputOpts output.Options) error {
+	format, err := outputOpts.Format()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	ls := NewLs(lsStore, t).WithOutput(format, outputOpts)
 	user, err := lsStore.GetCurrentUser()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -203,8 +215,10 @@ func handleLsArg(ls *Ls, arg string, user *entity.User, org *entity.Organization
 }
 
 type Ls struct {
-	lsStore  LsStore
-	terminal *terminal.Terminal
+	lsStore    LsStore
+	terminal   *terminal.Terminal
+	format     output.Format
+	outputOpts output.Options
 }
 
 func NewLs(lsStore LsStore, terminal *terminal.Terminal) *Ls {
@@ -214,11 +228,28 @@ func NewLs(lsStore LsStore, terminal *terminal.Terminal) *Ls {
 	}
 }
 
+func (ls *Ls) WithOutput(format output.Format, outputOpts output.Options) *Ls {
+	ls.format = format
+	ls.outputOpts = outputOpts
+	return ls
+}
+
 func (ls Ls) RunOrgs() error {
 	orgs, err := ls.lsStore.GetOrganizations(nil)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	orgs, err = output.Select(orgs, ls.outputOpts, output.OrganizationFields)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if ls.format.IsMachineReadable() {
+		err = output.WriteList(os.Stdout, ls.format, "OrganizationList", orgs, func(o entity.Organization) string { return o.Name })
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 	if len(orgs) == 0 {
 		ls.terminal.Vprint(ls.terminal.Yellow("You don't have any orgs. Create one! https://console.brev.dev"))
 		return nil
@@ -257,6 +288,13 @@ func (ls Ls) RunUser(_ bool) error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if ls.format.IsMachineReadable() {
+		err = output.WriteList(os.Stdout, ls.format, "UserList", users, func(u entity.User) string { return u.ID })
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 	for _, user := range users {
 		fmt.Printf("%s	%s	%s\n", user.ID, user.Name, user.Email)
 	}
@@ -304,7 +342,7 @@ func (ls Ls) displayWorkspacesAndHelp(org *entity.Organization, otherOrgs []enti
 		}
 	} else {
 		ls.terminal.Vprintf("You have %d dev environments in Org "+ls.terminal.Yellow(org.Name)+"\n", len(userWorkspaces))
-		displayWorkspacesTable(ls.terminal, userWorkspaces)
+		displayWorkspacesTable(ls.terminal, userWorkspaces, ls.format == output.Wide)
 
 		fmt.Print("\n")
 
@@ -364,6 +402,23 @@ func (ls Ls) RunWorkspaces(org *entity.Organization, user *entity.User, showAll
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	allWorkspaces, err = output.Select(allWorkspaces, ls.outputOpts, output.WorkspaceFields, func(f output.Filters) output.Filters {
+		return f.WithAlias("createdBy", "me", user.ID)
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if ls.format.IsMachineReadable() {
+		workspaces := allWorkspaces
+		if !showAll {
+			workspaces = store.FilterForUserWorkspaces(allWorkspaces, user.ID)
+		}
+		err = output.WriteList(os.Stdout, ls.format, "WorkspaceList", workspaces, func(w entity.Workspace) string { return w.Name })
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 
 	orgs, err := ls.lsStore.GetOrganizations(nil)
 	if err != nil {
@@ -388,8 +443,19 @@ func (ls Ls) RunHosts(org *entity.Organization) error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	hosts := []string{}
 	for _, workspace := range workspaces {
-		fmt.Println(workspace.GetNodeIdentifierForVPN())
+		hosts = append(hosts, workspace.GetNodeIdentifierForVPN())
+	}
+	if ls.format.IsMachineReadable() {
+		err = output.WriteList(os.Stdout, ls.format, "HostList", hosts, func(h string) string { return h })
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	for _, host := range hosts {
+		fmt.Println(host)
 	}
 	return nil
 }
@@ -422,7 +488,7 @@ func getBrevTableOptions() table.Options {
 	return options
 }
 
-func displayWorkspacesTable(t *terminal.Terminal, workspaces []entity.Workspace) {
+func displayWorkspacesTable(t *terminal.Terminal, workspaces []entity.Workspace, wide bool) {
 	ta := table.NewWriter()
 	ta.SetOutputMirror(os.Stdout)
 	ta.Style().Options = getBrevTableOptions()
@@ -430,6 +496,9 @@ func displayWorkspacesTable(t *terminal.Terminal, workspaces []entity.Workspace)
 	if enableSSHCol {
 		header = table.Row{"Name", "Status", "SSH", "ID", "Machine"}
 	}
+	if wide {
+		header = table.Row{"Name", "Status", "Health", "ID", "Machine", "SSH", "Created By", "Workspace Group", "DNS"}
+	}
 	ta.AppendHeader(header)
 	for _, w := range workspaces {
 		status := getWorkspaceDisplayStatus(w)
@@ -438,6 +507,9 @@ func displayWorkspacesTable(t *terminal.Terminal, workspaces []entity.Workspace)
 		if enableSSHCol {
 			workspaceRow = []table.Row{{w.Name, getStatusColoredText(t, status), w.GetLocalIdentifier(), w.ID, instanceString}}
 		}
+		if wide {
+			workspaceRow = []table.Row{{w.Name, getStatusColoredText(t, w.Status), getStatusColoredText(t, w.HealthStatus), w.ID, instanceString, w.GetLocalIdentifier(), w.CreatedByUserID, w.WorkspaceGroupID, w.GetHostname()}}
+		}
 		ta.AppendRows(workspaceRow)
 	}
 	ta.Render()
@@ -2,6 +2,7 @@ package open
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -15,10 +16,13 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/hello"
 	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/setupprogress"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/waiter"
 	"github.com/brevdev/brev-cli/pkg/writeconnectionevent"
 	"github.com/briandowns/spinner"
 	"github.com/hashicorp/go-multierror"
@@ -60,11 +64,11 @@ func NewCmdOpen(t *terminal.Terminal, store OpenStore, noLoginStartStore OpenSto
 		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginStartStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			setupDoneString := "------ Git repo cloned ------"
+			setupDone := setupDoneMarker{step: setupprogress.StepRepos, legacy: "------ Git repo cloned ------"}
 			if waitForSetupToFinish {
-				setupDoneString = "------ Done running execs ------"
+				setupDone = setupDoneMarker{step: setupprogress.StepExecs, legacy: "------ Done running execs ------"}
 			}
-			err := runOpenCommand(t, store, args[0], setupDoneString, directory)
+			err := runOpenCommand(t, store, args[0], setupDone, directory)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -77,8 +81,16 @@ func NewCmdOpen(t *terminal.Terminal, store OpenStore, noLoginStartStore OpenSto
 	return cmd
 }
 
+// setupDoneMarker is what to wait for before opening. Dev environments set up
+// by older versions don't record their progress, for those the setup log is
+// scanned for the legacy string instead
+type setupDoneMarker struct {
+	step   string
+	legacy string
+}
+
 // Fetch workspace info, then open code editor
-func runOpenCommand(t *terminal.Terminal, tstore OpenStore, wsIDOrName string, setupDoneString string, directory string) error {
+func runOpenCommand(t *terminal.Terminal, tstore OpenStore, wsIDOrName string, setupDone setupDoneMarker, directory string) error {
 	// todo check if workspace is stopped and start if it if it is stopped
 	fmt.Println("finding your dev environment...")
 	res := refresh.RunRefreshAsync(tstore)
@@ -92,7 +104,7 @@ func runOpenCommand(t *terminal.Terminal, tstore OpenStore, wsIDOrName string, s
 			return breverrors.WrapAndTrace(err)
 		}
 	}
-	err = pollUntil(t, workspace.ID, "RUNNING", tstore)
+	_, err = waiter.NewWaiter(tstore, t).WaitFor(context.Background(), workspace.ID, waiter.Running)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -127,7 +139,7 @@ func runOpenCommand(t *terminal.Terminal, tstore OpenStore, wsIDOrName string, s
 	// legacy environments wont support this and cause errrors,
 	// but we don't want to block the user from using vscode
 	_ = writeconnectionevent.WriteWCEOnEnv(tstore, string(localIdentifier))
-	err = openVsCodeWithSSH(t, string(localIdentifier), projPath, tstore, setupDoneString)
+	err = openVsCodeWithSSH(t, string(localIdentifier), projPath, tstore, setupDone)
 	if err != nil {
 		if strings.Contains(err.Error(), `"code": executable file not found in $PATH`) {
 			errMsg := "code\": executable file not found in $PATH\n\nadd 'code' to your $PATH to open VS Code from the terminal\n\texport PATH=\"/Applications/Visual Studio Code.app/Contents/Resources/app/bin:$PATH\""
@@ -149,27 +161,6 @@ func runOpenCommand(t *terminal.Terminal, tstore OpenStore, wsIDOrName string, s
 	return nil
 }
 
-func pollUntil(t *terminal.Terminal, wsid string, state string, openStore OpenStore) error {
-	s := t.NewSpinner()
-	isReady := false
-	s.Suffix = " hang tight 🤙"
-	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := openStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  workspace is currently " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Workspace is ready!"
-			s.Stop()
-			isReady = true
-		}
-	}
-	return nil
-}
-
 func startWorkspaceIfStopped(t *terminal.Terminal, tstore OpenStore, wsIDOrName string, workspace *entity.Workspace) error {
 	startedWorkspace, err := tstore.StartWorkspace(workspace.ID)
 	if err != nil {
@@ -190,7 +181,7 @@ func openVsCodeWithSSH(
 	sshAlias string,
 	path string,
 	tstore OpenStore,
-	setupDoneString string,
+	setupDone setupDoneMarker,
 ) error {
 	// infinite for loop:
 	res := refresh.RunRefreshAsync(tstore)
@@ -206,44 +197,39 @@ func openVsCodeWithSSH(
 		return breverrors.WrapAndTrace(err)
 	}
 
-	waitForLoggerFileToBeAvailable(t, s, sshAlias)
+	progressSource := setupprogress.SSHSource(sshAlias)
+	progress, err := setupprogress.Read(progressSource)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	setupFinished, err := checkSetupFinished(sshAlias, setupDoneString)
+	if progress != nil {
+		s.Stop()
+		progress, err = setupprogress.Wait(t, progressSource, setupDone.step)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		if progress.Done && progress.Error != "" {
+			t.Vprint(t.Yellow("setup did not succeed, run brev logs to see why\n"))
+		}
+		t.Vprint(t.Green("Opening VS Code 🤙\n"))
+		return openVsCodeOrExplain(sshAlias, path, tstore)
+	}
+
+	waitForLoggerFileToBeAvailable(t, s, sshAlias)
+	setupFinished, err := checkSetupFinished(sshAlias, setupDone.legacy)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 	if !setupFinished {
-		err = streamOutput(t, s, sshAlias, path, setupDoneString, tstore)
+		err = streamOutput(t, s, sshAlias, path, setupDone.legacy, tstore)
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
 		}
 	} else {
 		s.Suffix = " Environment is ready. Opening VS Code 🤙"
 		time.Sleep(1 * time.Second)
-		err = openVsCode(sshAlias, path, tstore)
-
+		err = openVsCodeOrExplain(sshAlias, path, tstore)
 		if err != nil {
-			// check if we are in a brev environment, if so transform the error message
-			// to indicate that the user should run brev open locally instead of in
-			// the cloud and that we intend on supporting this in the future
-			// if there is an error getting the workspace, append that error with
-			// multierror,
-			// otherwise, just return the error
-			err = mo.TupleToResult(tstore.IsWorkspace()).Match(
-				func(value bool) (bool, error) {
-					if value {
-						// todo log original error to sentry
-						return true, errors.New("you are in a remote brev environment; brev open is not supported. Please run brev open locally instead")
-					}
-					return false, breverrors.WrapAndTrace(err)
-				},
-				func(err2 error) (bool, error) {
-					return false, multierror.Append(err, err2)
-				},
-			).Error()
-
 			return breverrors.WrapAndTrace(err)
 		}
 	}
@@ -251,6 +237,33 @@ func openVsCodeWithSSH(
 	return nil
 }
 
+func openVsCodeOrExplain(sshAlias string, path string, tstore OpenStore) error {
+	err := openVsCode(sshAlias, path, tstore)
+	if err == nil {
+		return nil
+	}
+	// check if we are in a brev environment, if so transform the error message
+	// to indicate that the user should run brev open locally instead of in
+	// the cloud and that we intend on supporting this in the future
+	// if there is an error getting the workspace, append that error with
+	// multierror,
+	// otherwise, just return the error
+	err = mo.TupleToResult(tstore.IsWorkspace()).Match(
+		func(value bool) (bool, error) {
+			if value {
+				// todo log original error to sentry
+				return true, errors.New("you are in a remote brev environment; brev open is not supported. Please run brev open locally instead")
+			}
+			return false, breverrors.WrapAndTrace(err)
+		},
+		func(err2 error) (bool, error) {
+			return false, multierror.Append(err, err2)
+		},
+	).Error()
+
+	return breverrors.WrapAndTrace(err)
+}
+
 func waitForSSHToBeAvailable(t *terminal.Terminal, s *spinner.Spinner, sshAlias string) error {
 	counter := 0
 	for {
@@ -453,9 +466,15 @@ func tryToOpenVsCodeViaExecutable(sshAlias, path string, vscodepaths []mo.Result
 func openVsCode(sshAlias string, path string, store OpenStore) error {
 	vscodeString := fmt.Sprintf("vscode-remote://ssh-remote+%s%s", sshAlias, path)
 	vscodeString = shellescape.QuoteCommand([]string{vscodeString})
-	cmd := exec.Command("code", "--folder-uri", vscodeString) // #nosec G204
+	// editor is code unless the profile picks a fork that takes the same
+	// flags, ex: code-insiders or cursor
+	editor := config.GlobalConfig.GetEditor()
+	cmd := exec.Command(editor, "--folder-uri", vscodeString) // #nosec G204
 	err := cmd.Run()
 	if err != nil {
+		if editor != "code" {
+			return breverrors.WrapAndTrace(err)
+		}
 		vscodepaths := getCommonVsCodePaths(store)
 		err := tryToOpenVsCodeViaExecutable(sshAlias, path, vscodepaths)
 		if err != nil {
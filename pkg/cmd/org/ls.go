@@ -2,6 +2,7 @@ package org
 
 import (
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/output"
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/terminal"
@@ -10,13 +11,17 @@ import (
 )
 
 func NewCmdOrgLs(t *terminal.Terminal, orgcmdStore OrgCmdStore) *cobra.Command {
+	var outputOpts output.Options
 	cmd := &cobra.Command{
 		Annotations: map[string]string{"context": ""},
 		Use:         "ls",
 		Short:       "List your organizations",
 		Long: `List your organizations, your current org will be prefixed
 with * and highlighted with green`,
-		Example: `brev org ls`,
+		Example: `
+  brev org ls
+  brev org ls --output json
+		`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
 			if err != nil {
@@ -27,12 +32,13 @@ with * and highlighted with green`,
 		Args: cmderrors.TransformToValidationError(cobra.NoArgs),
 		// ValidArgs: []string{"new", "ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := RunOrgs(t, orgcmdStore)
+			err := RunOrgs(t, orgcmdStore, outputOpts)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
 			return nil
 		},
 	}
+	output.AddFlags(cmd, &outputOpts)
 	return cmd
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmd/invite"
+	"github.com/brevdev/brev-cli/pkg/cmd/output"
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -32,6 +33,7 @@ type OrgCmdStore interface {
 }
 
 func NewCmdOrg(t *terminal.Terminal, orgcmdStore OrgCmdStore, noorgcmdStore OrgCmdStore) *cobra.Command {
+	var outputOpts output.Options
 	cmd := &cobra.Command{
 		Annotations: map[string]string{"context": ""},
 		Use:         "org",
@@ -53,13 +55,14 @@ func NewCmdOrg(t *terminal.Terminal, orgcmdStore OrgCmdStore, noorgcmdStore OrgC
 		Args: cmderrors.TransformToValidationError(cobra.NoArgs),
 		// ValidArgs: []string{"new", "ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := RunOrgs(t, orgcmdStore)
+			err := RunOrgs(t, orgcmdStore, outputOpts)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
 			return nil
 		},
 	}
+	output.AddFlags(cmd, &outputOpts)
 
 	cmd.AddCommand(NewCmdOrgSet(t, orgcmdStore, noorgcmdStore))
 	cmd.AddCommand(NewCmdOrgLs(t, orgcmdStore))
@@ -68,11 +71,26 @@ func NewCmdOrg(t *terminal.Terminal, orgcmdStore OrgCmdStore, noorgcmdStore OrgC
 	return cmd
 }
 
-func RunOrgs(t *terminal.Terminal, store OrgCmdStore) error {
+func RunOrgs(t *terminal.Terminal, store OrgCmdStore, outputOpts output.Options) error {
+	format, err := outputOpts.Format()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 	orgs, err := store.GetOrganizations(nil)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	orgs, err = output.Select(orgs, outputOpts, output.OrganizationFields)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if format.IsMachineReadable() {
+		err = output.WriteList(os.Stdout, format, "OrganizationList", orgs, func(o entity.Organization) string { return o.Name })
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 	if len(orgs) == 0 {
 		t.Vprint(t.Yellow("You don't have any orgs. Create one! https://console.brev.dev"))
 		return nil
@@ -0,0 +1,347 @@
+// Package output renders command results in machine readable formats
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// SchemaVersion is bumped whenever the shape of json/yaml output changes in
+// a way that could break scripts consuming it
+const SchemaVersion = "v1"
+
+type Format string
+
+const (
+	Table Format = ""
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	Wide  Format = "wide"
+	Name  Format = "name"
+)
+
+var validFormats = []Format{JSON, YAML, Wide, Name}
+
+func ParseFormat(format string) (Format, error) {
+	if format == "" {
+		return Table, nil
+	}
+	for _, f := range validFormats {
+		if strings.EqualFold(format, string(f)) {
+			return f, nil
+		}
+	}
+	return Table, breverrors.NewValidationError(fmt.Sprintf("invalid output format %q, must be one of json|yaml|wide|name", format))
+}
+
+// IsMachineReadable is true when nothing but the requested data should be
+// written to stdout
+func (f Format) IsMachineReadable() bool {
+	return f == JSON || f == YAML || f == Name
+}
+
+type List[T any] struct {
+	SchemaVersion string `json:"schemaVersion"`
+	Kind          string `json:"kind"`
+	Items         []T    `json:"items"`
+}
+
+type Object[T any] struct {
+	SchemaVersion string `json:"schemaVersion"`
+	Kind          string `json:"kind"`
+	Item          T      `json:"item"`
+}
+
+func NewList[T any](kind string, items []T) List[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return List[T]{
+		SchemaVersion: SchemaVersion,
+		Kind:          kind,
+		Items:         items,
+	}
+}
+
+func NewObject[T any](kind string, item T) Object[T] {
+	return Object[T]{
+		SchemaVersion: SchemaVersion,
+		Kind:          kind,
+		Item:          item,
+	}
+}
+
+// Write serializes v as json or yaml, other formats are rendered by the caller
+func Write(w io.Writer, format Format, v interface{}) error {
+	var out []byte
+	var err error
+	switch format {
+	case JSON:
+		out, err = json.MarshalIndent(v, "", "  ")
+		out = append(out, '\n')
+	case YAML:
+		out, err = yaml.Marshal(v)
+	default:
+		return breverrors.NewValidationError(fmt.Sprintf("format %q can not be serialized", format))
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = w.Write(out)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func WriteNames(w io.Writer, names []string) error {
+	for _, n := range names {
+		_, err := fmt.Fprintln(w, n)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	return nil
+}
+
+// WriteList renders items as json, yaml or one name per line
+func WriteList[T any](w io.Writer, format Format, kind string, items []T, name func(T) string) error {
+	if format == Name {
+		names := []string{}
+		for _, item := range items {
+			names = append(names, name(item))
+		}
+		err := WriteNames(w, names)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	err := Write(w, format, NewList(kind, items))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+type Options struct {
+	Output string
+	Filter string
+	SortBy string
+}
+
+// Format falls back to the output format of the active config profile
+func (o Options) Format() (Format, error) {
+	if o.Output == "" {
+		return ParseFormat(config.GlobalConfig.GetOutputFormat())
+	}
+	return ParseFormat(o.Output)
+}
+
+func AddOutputFlag(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().StringVar(&opts.Output, "output", "", "output format, one of json|yaml|wide|name")
+	err := cmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{string(JSON), string(YAML), string(Wide), string(Name)}, cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		breverrors.GetDefaultErrorReporter().ReportError(breverrors.WrapAndTrace(err))
+	}
+}
+
+func AddFlags(cmd *cobra.Command, opts *Options) {
+	AddOutputFlag(cmd, opts)
+	cmd.Flags().StringVar(&opts.Filter, "filter", "", "comma separated key=value filters, ex: status=RUNNING,createdBy=me")
+	cmd.Flags().StringVar(&opts.SortBy, "sort-by", "", "field to sort by, prefix with - to sort descending")
+}
+
+// IsMachineReadableCmd reports whether cmd was asked to produce output that
+// other programs will parse, so that nothing else gets printed to stdout
+func IsMachineReadableCmd(cmd *cobra.Command) bool {
+	flag := cmd.Flags().Lookup("output")
+	if flag == nil {
+		return false
+	}
+	f, err := Options{Output: flag.Value.String()}.Format()
+	if err != nil {
+		return false
+	}
+	return f.IsMachineReadable()
+}
+
+// Fields maps a field name usable in --filter and --sort-by to its value
+type Fields[T any] map[string]func(T) string
+
+func (f Fields[T]) keys() []string {
+	keys := []string{}
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f Fields[T]) get(key string) (func(T) string, error) {
+	for k, getter := range f {
+		if strings.EqualFold(k, key) {
+			return getter, nil
+		}
+	}
+	return nil, breverrors.NewValidationError(fmt.Sprintf("unknown field %q, must be one of %s", key, strings.Join(f.keys(), ", ")))
+}
+
+var WorkspaceFields = Fields[entity.Workspace]{
+	"name":           func(w entity.Workspace) string { return w.Name },
+	"id":             func(w entity.Workspace) string { return w.ID },
+	"status":         func(w entity.Workspace) string { return w.Status },
+	"healthStatus":   func(w entity.Workspace) string { return w.HealthStatus },
+	"createdBy":      func(w entity.Workspace) string { return w.CreatedByUserID },
+	"organization":   func(w entity.Workspace) string { return w.OrganizationID },
+	"instanceType":   func(w entity.Workspace) string { return w.InstanceType },
+	"class":          func(w entity.Workspace) string { return w.WorkspaceClassID },
+	"workspaceGroup": func(w entity.Workspace) string { return w.WorkspaceGroupID },
+	"dns":            func(w entity.Workspace) string { return w.DNS },
+}
+
+var OrganizationFields = Fields[entity.Organization]{
+	"name": func(o entity.Organization) string { return o.Name },
+	"id":   func(o entity.Organization) string { return o.ID },
+}
+
+var WorkspaceGroupFields = Fields[entity.WorkspaceGroup]{
+	"name":     func(w entity.WorkspaceGroup) string { return w.Name },
+	"id":       func(w entity.WorkspaceGroup) string { return w.ID },
+	"platform": func(w entity.WorkspaceGroup) string { return w.Platform },
+	"region":   func(w entity.WorkspaceGroup) string { return w.PlatformRegion },
+	"status":   func(w entity.WorkspaceGroup) string { return w.Status },
+}
+
+type Filter struct {
+	Key string
+	// Values are alternatives, a field matches if it equals any of them
+	Values []string
+}
+
+type Filters []Filter
+
+// ParseFilters parses "status=RUNNING|STOPPED,createdBy=me"
+func ParseFilters(filter string) (Filters, error) {
+	filters := Filters{}
+	if strings.TrimSpace(filter) == "" {
+		return filters, nil
+	}
+	for _, part := range strings.Split(filter, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("invalid filter %q, expected key=value", part))
+		}
+		filters = append(filters, Filter{
+			Key:    strings.TrimSpace(kv[0]),
+			Values: strings.Split(strings.TrimSpace(kv[1]), "|"),
+		})
+	}
+	return filters, nil
+}
+
+// WithAlias replaces alias in the values of key, ex: createdBy=me -> createdBy=<user id>
+func (fs Filters) WithAlias(key string, alias string, value string) Filters {
+	res := Filters{}
+	for _, f := range fs {
+		if strings.EqualFold(f.Key, key) {
+			values := []string{}
+			for _, v := range f.Values {
+				if v == alias {
+					v = value
+				}
+				values = append(values, v)
+			}
+			f.Values = values
+		}
+		res = append(res, f)
+	}
+	return res
+}
+
+func ApplyFilters[T any](items []T, filters Filters, fields Fields[T]) ([]T, error) {
+	getters := []func(T) string{}
+	for _, f := range filters {
+		getter, err := fields.get(f.Key)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		getters = append(getters, getter)
+	}
+
+	res := []T{}
+	for _, item := range items {
+		if matchesAll(item, filters, getters) {
+			res = append(res, item)
+		}
+	}
+	return res, nil
+}
+
+func matchesAll[T any](item T, filters Filters, getters []func(T) string) bool {
+	for i, f := range filters {
+		value := getters[i](item)
+		matched := false
+		for _, v := range f.Values {
+			if strings.EqualFold(value, v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// SortBy sorts items in place, a leading - sorts descending
+func SortBy[T any](items []T, sortBy string, fields Fields[T]) error {
+	if sortBy == "" {
+		return nil
+	}
+	desc := strings.HasPrefix(sortBy, "-")
+	getter, err := fields.get(strings.TrimPrefix(sortBy, "-"))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if desc {
+			return getter(items[i]) > getter(items[j])
+		}
+		return getter(items[i]) < getter(items[j])
+	})
+	return nil
+}
+
+// Select applies the --filter and --sort-by options to items
+func Select[T any](items []T, opts Options, fields Fields[T], aliases ...func(Filters) Filters) ([]T, error) {
+	filters, err := ParseFilters(opts.Filter)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	for _, a := range aliases {
+		filters = a(filters)
+	}
+	items, err = ApplyFilters(items, filters, fields)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = SortBy(items, opts.SortBy, fields)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return items, nil
+}
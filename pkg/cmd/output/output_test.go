@@ -0,0 +1,77 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+var testWorkspaces = []entity.Workspace{
+	{ID: "1", Name: "b", Status: entity.Running, CreatedByUserID: "u1"},
+	{ID: "2", Name: "a", Status: entity.Stopped, CreatedByUserID: "u2"},
+	{ID: "3", Name: "c", Status: entity.Running, CreatedByUserID: "u2"},
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("JSON")
+	assert.Nil(t, err)
+	assert.Equal(t, JSON, f)
+
+	f, err = ParseFormat("")
+	assert.Nil(t, err)
+	assert.Equal(t, Table, f)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestSelectFilterAndSort(t *testing.T) {
+	res, err := Select(testWorkspaces, Options{Filter: "status=running,createdBy=me", SortBy: "-name"}, WorkspaceFields,
+		func(f Filters) Filters { return f.WithAlias("createdBy", "me", "u2") })
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"3"}, ids(res))
+
+	res, err = Select(testWorkspaces, Options{Filter: "status=RUNNING|STOPPED", SortBy: "name"}, WorkspaceFields)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2", "1", "3"}, ids(res))
+}
+
+func TestSelectInvalid(t *testing.T) {
+	_, err := Select(testWorkspaces, Options{Filter: "status"}, WorkspaceFields)
+	assert.Error(t, err)
+
+	_, err = Select(testWorkspaces, Options{Filter: "color=red"}, WorkspaceFields)
+	assert.Error(t, err)
+
+	_, err = Select(testWorkspaces, Options{SortBy: "color"}, WorkspaceFields)
+	assert.Error(t, err)
+}
+
+func TestWriteList(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := WriteList(buf, JSON, "WorkspaceList", []entity.Workspace{}, func(w entity.Workspace) string { return w.Name })
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"schemaVersion": "v1"`)
+	assert.Contains(t, buf.String(), `"items": []`)
+
+	buf.Reset()
+	err = WriteList(buf, YAML, "WorkspaceList", testWorkspaces[:1], func(w entity.Workspace) string { return w.Name })
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "kind: WorkspaceList")
+	assert.Contains(t, buf.String(), "createdByUserId: u1")
+
+	buf.Reset()
+	err = WriteList(buf, Name, "WorkspaceList", testWorkspaces, func(w entity.Workspace) string { return w.Name })
+	assert.Nil(t, err)
+	assert.Equal(t, "b\na\nc\n", buf.String())
+}
+
+func ids(workspaces []entity.Workspace) []string {
+	res := []string{}
+	for _, w := range workspaces {
+		res = append(res, w.ID)
+	}
+	return res
+}
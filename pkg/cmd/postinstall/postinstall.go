@@ -4,6 +4,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/brevdev/brev-cli/pkg/autostartconf"
+	"github.com/brevdev/brev-cli/pkg/cmd/configureenvvars"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 )
@@ -16,6 +17,7 @@ var (
 
 type postinstallStore interface {
 	autostartconf.AutoStartStore
+	configureenvvars.ShellHookStore
 	RegisterNotificationEmail(string) error
 	WriteEmail(email string) error
 }
@@ -109,5 +111,14 @@ func Runpostinstall(
 		return breverrors.WrapAndTrace(err)
 	}
 
+	home, err := store.UserHomeDir()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = configureenvvars.InstallShellHooks(store, home)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
 	return nil
 }
@@ -2,8 +2,8 @@
 package recreate
 
 import (
+	"context"
 	_ "embed"
-	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,6 +16,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/featureflag"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/waiter"
 	stripmd "github.com/writeas/go-strip-markdown"
 )
 
@@ -123,7 +124,7 @@ func hardResetCreateWorkspaceFromRepo(t *terminal.Terminal, recreateStore recrea
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = pollUntil(t, w.ID, entity.Running, recreateStore, true)
+	_, err = waiter.NewWaiter(recreateStore, t).WithSafeExitMessage().WaitFor(context.Background(), w.ID, waiter.Running)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -172,7 +173,7 @@ func hardResetCreateEmptyWorkspace(t *terminal.Terminal, recreateStore recreateS
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = pollUntil(t, w.ID, entity.Running, recreateStore, true)
+	_, err = waiter.NewWaiter(recreateStore, t).WithSafeExitMessage().WaitFor(context.Background(), w.ID, waiter.Running)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -183,30 +184,6 @@ func hardResetCreateEmptyWorkspace(t *terminal.Terminal, recreateStore recreateS
 	return nil
 }
 
-func pollUntil(t *terminal.Terminal, wsid string, state string, recreateStore recreateStore, canSafelyExit bool) error {
-	s := t.NewSpinner()
-	isReady := false
-	if canSafelyExit {
-		t.Vprintf("You can safely ctrl+c to exit\n")
-	}
-	s.Suffix = " hang tight 🤙"
-	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := recreateStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  workspace is " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Workspace is ready!"
-			s.Stop()
-			isReady = true
-		}
-	}
-	return nil
-}
-
 func resolveWorkspaceUserOptions(options *store.CreateWorkspacesOptions, user *entity.User) *store.CreateWorkspacesOptions {
 	if options.WorkspaceTemplateID == "" {
 		if featureflag.IsAdmin(user.GlobalUserType) {
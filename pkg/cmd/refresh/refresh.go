@@ -3,12 +3,14 @@ package refresh
 
 import (
 	"fmt"
+	"log"
 	"sync"
 
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/ssh"
+	"github.com/brevdev/brev-cli/pkg/tasks"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 
 	"github.com/spf13/cobra"
@@ -19,15 +21,21 @@ type RefreshStore interface {
 	ssh.SSHConfigurerV2Store
 	GetCurrentUser() (*entity.User, error)
 	GetCurrentUserKeys() (*entity.UserKeys, error)
+	GetBrevHomePath() (string, error)
 }
 
 func NewCmdRefresh(t *terminal.Terminal, store RefreshStore) *cobra.Command {
+	var watch bool
+	var install bool
+
 	cmd := &cobra.Command{
 		Annotations: map[string]string{"housekeeping": ""},
 		Use:         "refresh",
 		Short:       "Force a refresh to the ssh config",
 		Long:        "Force a refresh to the ssh config",
-		Example:     `brev refresh`,
+		Example: `brev refresh
+brev refresh --watch
+brev refresh --install`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
 			if err != nil {
@@ -37,6 +45,21 @@ func NewCmdRefresh(t *terminal.Terminal, store RefreshStore) *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if install {
+				err := ssh.NewSSHConfigSyncer(store).Configure()
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				t.Vprintf(t.Green("brev ssh config sync installed as a systemd user service\n"))
+				return nil
+			}
+			if watch {
+				err := RunRefreshWatch(store)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				return nil
+			}
 			fmt.Println("refreshing brev...")
 			err := RunRefresh(store)
 			if err != nil {
@@ -46,21 +69,29 @@ func NewCmdRefresh(t *terminal.Terminal, store RefreshStore) *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "keep the ssh config in sync until interrupted")
+	cmd.Flags().BoolVar(&install, "install", false, "install --watch as a systemd user service")
 
 	return cmd
 }
 
+// RunRefresh only rewrites the ssh configs if a host changed, since it is run
+// in the background of open, shell and port-forward
 func RunRefresh(store RefreshStore) error {
-	cu, err := GetConfigUpdater(store)
+	err := ssh.NewSSHConfigSyncer(store).Run()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = cu.Run()
+	return nil
+}
+
+func RunRefreshWatch(store RefreshStore) error {
+	syncer := ssh.NewSSHConfigSyncer(store).WithLogger(log.Printf)
+	err := tasks.RunTasks([]tasks.Task{syncer})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-
 	return nil
 }
 
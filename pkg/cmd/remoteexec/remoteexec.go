@@ -0,0 +1,301 @@
+// Package remoteexec runs a command on one or many dev environments over ssh
+// without an interactive shell
+package remoteexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/alessio/shellescape"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/logs"
+	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/waiter"
+)
+
+var (
+	execLong = `Run a command on one or more dev environments without an interactive shell.
+
+Dev environments are picked like brev stop picks them: names, ids, globs like
+'ml-*', or --all with filters. The command goes after --. Its arguments are
+passed on as they are, wrap it in bash -c to use pipes, && or globs.
+
+With a single dev environment the output is passed through untouched and brev
+exec exits with the command's exit code. With several, they run --concurrency
+at a time, each line is prefixed with the dev environment's name and a summary
+is printed at the end. brev exec then exits nonzero if the command failed on
+any of them.`
+	execExample = `  brev exec my-env -- nvidia-smi
+  brev exec my-env -- bash -c 'cd ~/my-repo && git pull'
+  brev exec 'ml-*' --start -- df -h /
+  brev exec --all --concurrency 8 -y -- sudo apt-get upgrade -y`
+)
+
+// sshTimeout is how long a dev environment that was just started has to
+// accept ssh connections
+var sshTimeout = 2 * time.Minute
+
+type ExecStore interface {
+	completions.CompletionStore
+	refresh.RefreshStore
+	bulk.SelectorStore
+	StartWorkspace(workspaceID string) (*entity.Workspace, error)
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+}
+
+type execOptions struct {
+	command []string
+	start   bool
+}
+
+func NewCmdExec(t *terminal.Terminal, loginExecStore ExecStore, noLoginExecStore ExecStore) *cobra.Command {
+	var selector bulk.Selector
+	var opts execOptions
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"ssh": ""},
+		Use:                   "exec",
+		DisableFlagsInUseLine: true,
+		Short:                 "Run a command on one or more dev environments",
+		Long:                  execLong,
+		Example:               execExample,
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginExecStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash < 0 || dash == len(args) {
+				return breverrors.NewValidationError("put the command to run after --, ex: brev exec my-env -- ls")
+			}
+			selector.Names = args[:dash]
+			opts.command = args[dash:]
+			err := RunExec(t, loginExecStore, selector, opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&selector.All, "all", "a", false, "run on all of your dev environments")
+	cmd.Flags().BoolVar(&opts.start, "start", false, "start stopped dev environments first and wait for them to be running")
+	bulk.AddFlags(cmd, &selector)
+
+	return cmd
+}
+
+func RunExec(t *terminal.Terminal, execStore ExecStore, selector bulk.Selector, opts execOptions) error {
+	workspaces, err := selector.Resolve(execStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	script := shellescape.QuoteCommand(opts.command)
+	op := bulk.Operation{Verb: fmt.Sprintf("run %s on", script)}
+	if !bulk.Confirm(t, selector, op, workspaces) {
+		return nil
+	}
+
+	e := &executor{script: script, started: map[string]error{}}
+	if opts.start {
+		workspaces = startStopped(t, execStore, workspaces, e.started)
+	}
+	err = refresh.RunRefresh(execStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	if !selector.IsBulk() {
+		err = e.run(workspaces[0], os.Stdout, os.Stderr)
+		var skip bulk.ErrSkip
+		if errors.As(err, &skip) {
+			return breverrors.NewValidationError(fmt.Sprintf("%s %s", workspaces[0].Name, skip.Reason))
+		}
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+
+	e.setPrefixes(t, workspaces)
+	op.Run = func(w entity.Workspace) error {
+		stdout := logs.NewPrefixWriter(&e.mu, os.Stdout, e.prefixes[w.ID])
+		stderr := logs.NewPrefixWriter(&e.mu, os.Stderr, e.prefixes[w.ID])
+		defer stdout.Flush()
+		defer stderr.Flush()
+		return e.run(w, stdout, stderr)
+	}
+	results := bulk.Run(workspaces, selector.Concurrency, op)
+	t.Vprint("")
+	err = bulk.Summarize(t, results)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// startStopped starts the stopped dev environments and waits for them to be
+// running. They are all started before waiting on any, so waiting on them one
+// after the other takes about as long as the slowest. Whether each started is
+// recorded in started, the returned workspaces have their new status
+func startStopped(t *terminal.Terminal, execStore ExecStore, workspaces []entity.Workspace, started map[string]error) []entity.Workspace {
+	stopped := lo.Filter(workspaces, func(w entity.Workspace, _ int) bool {
+		return w.Status == entity.Stopped
+	})
+	if len(stopped) == 0 {
+		return workspaces
+	}
+	for _, w := range stopped {
+		_, err := execStore.StartWorkspace(w.ID)
+		started[w.ID] = breverrors.WrapAndTrace(err)
+		if err == nil {
+			t.Vprintf(t.Yellow("Dev environment %s is starting\n", w.Name))
+		}
+	}
+
+	s := t.NewSpinner()
+	defer s.Stop()
+	updated := map[string]entity.Workspace{}
+	for _, w := range stopped {
+		if started[w.ID] != nil {
+			continue
+		}
+		ws, err := waiter.NewWaiter(execStore, t).
+			WithSpinner(s).
+			WithWaitMessage(fmt.Sprintf(" waiting for %s to be running", w.Name)).
+			WaitFor(context.Background(), w.ID, waiter.Running)
+		if err != nil {
+			started[w.ID] = breverrors.WrapAndTrace(err)
+			continue
+		}
+		updated[w.ID] = *ws
+	}
+	return lo.Map(workspaces, func(w entity.Workspace, _ int) entity.Workspace {
+		if ws, ok := updated[w.ID]; ok {
+			return ws
+		}
+		return w
+	})
+}
+
+type executor struct {
+	script string
+	// started has the dev environments --start started, with the error if
+	// they did not come up
+	started  map[string]error
+	prefixes map[string]string
+	// mu keeps lines from different dev environments from interleaving
+	mu sync.Mutex
+}
+
+// setPrefixes gives each dev environment a colored name padded to the
+// longest one
+func (e *executor) setPrefixes(t *terminal.Terminal, workspaces []entity.Workspace) {
+	colors := []func(format string, a ...interface{}) string{t.Green, t.Yellow, t.Blue, t.White, t.Red}
+	width := 0
+	for _, w := range workspaces {
+		if len(w.Name) > width {
+			width = len(w.Name)
+		}
+	}
+	e.prefixes = map[string]string{}
+	for i, w := range workspaces {
+		e.prefixes[w.ID] = colors[i%len(colors)]("%-*s | ", width, w.Name)
+	}
+}
+
+func (e *executor) run(w entity.Workspace, stdout io.Writer, stderr io.Writer) error {
+	err, wasStarted := e.started[w.ID]
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = checkRunning(w)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	alias := string(w.GetLocalIdentifier())
+	if wasStarted {
+		err = waitForSSH(alias)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	cmd := exec.Command("ssh", sshArgs(alias, e.script)...) //nolint:gosec // the user runs their own command on their own dev environment
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return remoteExitErr(cmd.Run())
+}
+
+// checkRunning skips dev environments that are not running
+func checkRunning(w entity.Workspace) error {
+	err := bulk.RequireStatus(w, entity.Running)
+	if err == nil {
+		return nil
+	}
+	if w.Status == entity.Stopped {
+		return bulk.ErrSkip{Reason: "is STOPPED, pass --start to start it first"}
+	}
+	return breverrors.WrapAndTrace(err)
+}
+
+// sshArgs never allocates a tty or prompts, the command gets no stdin
+func sshArgs(alias string, script string) []string {
+	return []string{"-T", "-o", "BatchMode=yes", "-o", "RemoteCommand=none", alias, "--", script}
+}
+
+func waitForSSH(alias string) error {
+	deadline := time.Now().Add(sshTimeout)
+	for {
+		cmd := exec.Command("ssh", "-o", "ConnectTimeout=3", "-o", "BatchMode=yes", alias, "true") //nolint:gosec // the alias comes from the user's ssh config
+		out, err := cmd.CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("ssh to %s is not available after %s: %s", alias, sshTimeout, out)
+		}
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// sshExitCode is what ssh exits with when it could not connect
+const sshExitCode = 255
+
+// RemoteExitError is returned when the command exits nonzero, brev exec exits
+// with the same code
+type RemoteExitError struct {
+	Code int
+}
+
+func (e RemoteExitError) Error() string {
+	if e.Code == sshExitCode {
+		return fmt.Sprintf("ssh exited %d, the command may not have run", e.Code)
+	}
+	return fmt.Sprintf("command exited %d", e.Code)
+}
+
+func (e RemoteExitError) ExitCode() int {
+	return e.Code
+}
+
+var _ breverrors.ExitCodeError = RemoteExitError{}
+
+func remoteExitErr(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return RemoteExitError{Code: exitErr.ExitCode()}
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package remoteexec
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/alessio/shellescape"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+func TestSSHArgs(t *testing.T) {
+	script := shellescape.QuoteCommand([]string{"ls", "-la", "my dir"})
+	assert.Equal(t, []string{"-T", "-o", "BatchMode=yes", "-o", "RemoteCommand=none", "my-env", "--", "ls -la 'my dir'"}, sshArgs("my-env", script))
+}
+
+func TestRemoteExitErr(t *testing.T) {
+	assert.Nil(t, remoteExitErr(exec.Command("sh", "-c", "exit 0").Run()))
+
+	err := remoteExitErr(exec.Command("sh", "-c", "exit 3").Run())
+	assert.Equal(t, RemoteExitError{Code: 3}, err)
+	assert.Equal(t, 3, breverrors.GetExitCode(breverrors.WrapAndTrace(err)))
+
+	assert.Contains(t, RemoteExitError{Code: 255}.Error(), "may not have run")
+}
+
+func TestCheckRunning(t *testing.T) {
+	assert.Nil(t, checkRunning(entity.Workspace{Status: entity.Running}))
+
+	var skip bulk.ErrSkip
+	assert.True(t, errors.As(checkRunning(entity.Workspace{Status: entity.Stopped}), &skip))
+	assert.Contains(t, skip.Reason, "--start")
+	assert.True(t, errors.As(checkRunning(entity.Workspace{Status: entity.Deploying}), &skip))
+	assert.Equal(t, "is DEPLOYING", skip.Reason)
+}
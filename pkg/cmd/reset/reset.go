@@ -1,10 +1,13 @@
 package reset
 
 import (
+	"context"
 	_ "embed"
+	"fmt"
 	"strings"
 	"time"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/config"
@@ -12,6 +15,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/featureflag"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/waiter"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/spf13/cobra"
@@ -21,7 +25,8 @@ import (
 var (
 	//go:embed doc.md
 	long         string
-	startExample = `brev reset <ws_name>...`
+	startExample = `  brev reset <ws_name>...
+  brev reset --status UNHEALTHY --created-by all --dry-run`
 )
 
 type ResetStore interface {
@@ -37,6 +42,7 @@ type ResetStore interface {
 
 func NewCmdReset(t *terminal.Terminal, loginResetStore ResetStore, noLoginResetStore ResetStore) *cobra.Command {
 	var hardreset bool
+	var selector bulk.Selector
 
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
@@ -47,6 +53,16 @@ func NewCmdReset(t *terminal.Terminal, loginResetStore ResetStore, noLoginResetS
 		Example:               startExample,
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginResetStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			selector.Names = args
+			if hardreset {
+				err := validateHardReset(cmd, selector)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+			}
+			if !hardreset && selector.IsBulk() {
+				return resetWorkspaces(t, selector, loginResetStore)
+			}
 			for _, arg := range args {
 				if hardreset {
 					err := hardResetProcess(arg, t, loginResetStore)
@@ -65,9 +81,42 @@ func NewCmdReset(t *terminal.Terminal, loginResetStore ResetStore, noLoginResetS
 	}
 
 	cmd.Flags().BoolVarP(&hardreset, "hard", "", false, "DEPRECATED: use brev recreate")
+	bulk.AddFlags(cmd, &selector)
 	return cmd
 }
 
+// validateHardReset rejects selections since --hard deletes and recreates,
+// which is too destructive to run on a pattern
+func validateHardReset(cmd *cobra.Command, selector bulk.Selector) error {
+	if flags := bulk.ChangedFlags(cmd); len(flags) > 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("--hard can not be combined with --%s", strings.Join(flags, ", --")))
+	}
+	if selector.IsPattern() {
+		return breverrors.NewValidationError("--hard takes dev environment names, not globs")
+	}
+	if len(selector.Names) == 0 {
+		return breverrors.NewValidationError("please provide a dev environment to reset")
+	}
+	return nil
+}
+
+func resetWorkspaces(t *terminal.Terminal, selector bulk.Selector, resetStore ResetStore) error {
+	err := bulk.Apply(t, resetStore, selector, bulk.Operation{
+		Verb: "reset",
+		Run: func(w entity.Workspace) error {
+			_, err := resetStore.ResetWorkspace(w.ID)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 // hardResetProcess deletes an existing workspace and creates a new one
 func hardResetProcess(workspaceName string, t *terminal.Terminal, resetStore ResetStore) error {
 	t.Vprint(t.Green("Starting hard reset 🤙 " + t.Yellow("This can take a couple of minutes.\n")))
@@ -132,7 +181,7 @@ func hardResetCreateWorkspaceFromRepo(t *terminal.Terminal, resetStore ResetStor
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = pollUntil(t, w.ID, entity.Running, resetStore, true)
+	_, err = waiter.NewWaiter(resetStore, t).WithSafeExitMessage().WaitFor(context.Background(), w.ID, waiter.Running)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -181,7 +230,7 @@ func hardResetCreateEmptyWorkspace(t *terminal.Terminal, resetStore ResetStore,
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = pollUntil(t, w.ID, entity.Running, resetStore, true)
+	_, err = waiter.NewWaiter(resetStore, t).WithSafeExitMessage().WaitFor(context.Background(), w.ID, waiter.Running)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -192,30 +241,6 @@ func hardResetCreateEmptyWorkspace(t *terminal.Terminal, resetStore ResetStore,
 	return nil
 }
 
-func pollUntil(t *terminal.Terminal, wsid string, state string, resetStore ResetStore, canSafelyExit bool) error {
-	s := t.NewSpinner()
-	isReady := false
-	if canSafelyExit {
-		t.Vprintf("You can safely ctrl+c to exit\n")
-	}
-	s.Suffix = " hang tight 🤙"
-	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := resetStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  workspace is " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Workspace is ready!"
-			s.Stop()
-			isReady = true
-		}
-	}
-	return nil
-}
-
 func resolveWorkspaceUserOptions(options *store.CreateWorkspacesOptions, user *entity.User) *store.CreateWorkspacesOptions {
 	if options.WorkspaceTemplateID == "" {
 		if featureflag.IsAdmin(user.GlobalUserType) {
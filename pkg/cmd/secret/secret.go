@@ -1,41 +1,82 @@
-// Package secret lets you add secrests. Language Go makes you write extra.
+// Package secret lets you add, list and remove secrets. Values are only ever
+// sent to the api, they are never printed
 package secret
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/configureenvvars"
+	"github.com/brevdev/brev-cli/pkg/cmd/output"
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/hashicorp/go-multierror"
+	"github.com/jedib0t/go-pretty/v6/table"
 
 	"github.com/spf13/cobra"
 )
 
 type SecretStore interface {
 	CreateSecret(req store.CreateSecretRequest) (*store.CreateSecretRequest, error)
+	GetSecrets(hierarchyType store.HierarchyType, hierarchyID string) ([]store.Secret, error)
+	UpdateSecret(secretID string, req store.CreateSecretRequest) (*store.Secret, error)
+	DeleteSecret(secretID string) error
 	GetCurrentUser() (*entity.User, error)
 	GetActiveOrganizationOrDefault() (*entity.Organization, error)
 }
 
+const defaultFilePath = "/home/brev/workspace/secret.txt"
+
+// stdin is read when the value is piped in, ex: vault read ... | brev secret set
+var (
+	stdin           io.Reader = os.Stdin
+	stdinIsTerminal           = func() bool {
+		fi, err := os.Stdin.Stat()
+		return err == nil && fi.Mode()&os.ModeCharDevice != 0
+	}
+)
+
+type secretOptions struct {
+	envtype        string
+	name           string
+	value          string
+	fromFile       string
+	path           string
+	scope          string
+	nonInteractive bool
+}
+
+// interactive is false in ci, or when the value is piped in and stdin can't
+// answer prompts
+func (o secretOptions) interactive() bool {
+	return !o.nonInteractive && stdinIsTerminal()
+}
+
 func NewCmdSecret(secretStore SecretStore, t *terminal.Terminal) *cobra.Command {
-	var envtype string
-	var name string
-	var value string
-	var path string
-	var scope string
+	var opts secretOptions
 
 	cmd := &cobra.Command{
 		Annotations: map[string]string{"housekeeping": ""},
 		Use:         "secret",
-		Short:       "Add a secret/environment variable",
+		Short:       "Manage secrets/environment variables",
 		Long:        "Add a secret/environment variable to your dev environment, all dev environment in an org, or all of your dev environment",
 		Example: `
   brev secret --name my_value --value my_value --type [file, variable] --file-path --scope [org, user]
   brev secret --name SERVER_URL --value https://brev.sh --type variable --scope [org, user]
   brev secret --name AWS_KEY --value ... --type file --file-path --scope [org, user]
+  brev secret ls
+  brev secret set DB_PASSWORD --scope user
+  vault read -field=key secret/aws | brev secret set AWS_KEY --scope org --non-interactive
+  brev secret set AWS_CREDENTIALS --from-file ~/.aws/credentials --file-path /home/brev/.aws/credentials --scope user
+  brev secret import .env --scope org
+  brev secret rm DB_PASSWORD
 		`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
@@ -45,10 +86,9 @@ func NewCmdSecret(secretStore SecretStore, t *terminal.Terminal) *cobra.Command
 
 			return nil
 		},
-		// Args:      cobra.MinimumNArgs(0),
-		// ValidArgs: []string{"orgs", "workspaces"},
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := addSecret(secretStore, t, envtype, name, value, path, scope)
+			err := addSecret(secretStore, t, opts)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -56,149 +96,481 @@ func NewCmdSecret(secretStore SecretStore, t *terminal.Terminal) *cobra.Command
 		},
 	}
 
-	cmd.Flags().StringVarP(&envtype, "type", "t", "", "type of secret (env var or file)")
-	cmd.Flags().StringVarP(&name, "name", "n", "", "name of environment variable or secret file")
-	cmd.Flags().StringVarP(&value, "value", "v", "", "value of environment variable or secret file")
-	cmd.Flags().StringVarP(&path, "file-path", "p", "", "file path (if secret file)")
-	cmd.Flags().StringVarP(&scope, "scope", "s", "", "scope for env var (org or private)")
+	cmd.Flags().StringVarP(&opts.envtype, "type", "t", "", "type of secret (env var or file)")
+	cmd.Flags().StringVarP(&opts.name, "name", "n", "", "name of environment variable or secret file")
+	addValueFlags(cmd, &opts)
+	cmd.PersistentFlags().StringVarP(&opts.scope, "scope", "s", "", "scope for env var (org or user)")
+	cmd.PersistentFlags().BoolVar(&opts.nonInteractive, "non-interactive", false, "fail instead of prompting for anything missing, ex: in ci")
+
+	registerCompletion(cmd, "type", []string{"file", "variable"})
+	registerCompletion(cmd, "scope", []string{"org", "user"})
+
+	cmd.AddCommand(newCmdLs(secretStore, t, &opts))
+	cmd.AddCommand(newCmdSet(secretStore, t, &opts))
+	cmd.AddCommand(newCmdRm(secretStore, t, &opts))
+	cmd.AddCommand(newCmdImport(secretStore, t, &opts))
+
+	return cmd
+}
+
+func addValueFlags(cmd *cobra.Command, opts *secretOptions) {
+	cmd.Flags().StringVarP(&opts.value, "value", "v", "", "value of environment variable or secret file, read from stdin or a hidden prompt if omitted")
+	cmd.Flags().StringVar(&opts.fromFile, "from-file", "", "read the value from a local file, the secret is a file unless --type says otherwise")
+	cmd.Flags().StringVarP(&opts.path, "file-path", "p", "", "file path (if secret file)")
+}
 
-	err := cmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return []string{"file", "variable"}, cobra.ShellCompDirectiveNoSpace
+func registerCompletion(cmd *cobra.Command, flag string, values []string) {
+	err := cmd.RegisterFlagCompletionFunc(flag, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoSpace
 	})
 	if err != nil {
 		breverrors.GetDefaultErrorReporter().ReportError(breverrors.WrapAndTrace(err))
 		fmt.Print(breverrors.WrapAndTrace(err))
 	}
+}
 
-	err = cmd.RegisterFlagCompletionFunc("scope", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return []string{"org", "private"}, cobra.ShellCompDirectiveNoSpace
-	})
-	if err != nil {
-		breverrors.GetDefaultErrorReporter().ReportError(breverrors.WrapAndTrace(err))
-		fmt.Print(breverrors.WrapAndTrace(err))
+func newCmdSet(secretStore SecretStore, t *terminal.Terminal, opts *secretOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set NAME",
+		Short: "Add a secret, or replace the value of an existing one",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.name = args[0]
+			err := setSecret(secretStore, t, *opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
 	}
+	cmd.Flags().StringVarP(&opts.envtype, "type", "t", "", "type of secret (env var or file), a file if --from-file is given")
+	addValueFlags(cmd, opts)
+	registerCompletion(cmd, "type", []string{"file", "variable"})
+	return cmd
+}
 
+func newCmdLs(secretStore SecretStore, t *terminal.Terminal, opts *secretOptions) *cobra.Command {
+	var outputOpts output.Options
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List secrets, without their values",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputOpts.Format()
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = listSecrets(secretStore, t, *opts, format)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	output.AddOutputFlag(cmd, &outputOpts)
 	return cmd
 }
 
-func addSecret(secretStore SecretStore, t *terminal.Terminal, envtype string, name string, value string, path string, scope string) error { //nolint:funlen, gocyclo // todo simplify me
-	if name == "" || envtype == "" || value == "" || path == "" {
+func newCmdRm(secretStore SecretStore, t *terminal.Terminal, opts *secretOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm NAME",
+		Short: "Remove a secret",
+		Long:  "Remove a secret. --scope is only needed if both your org and you have a secret with this name",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := removeSecret(secretStore, t, *opts, args[0])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+}
+
+func newCmdImport(secretStore SecretStore, t *terminal.Terminal, opts *secretOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "import FILE",
+		Short: "Add every variable of a dotenv file as a secret",
+		Long:  "Add every variable of a dotenv file as an environment variable secret, replacing the values of existing ones",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := importSecrets(secretStore, t, *opts, args[0])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+}
+
+// addSecret is brev secret without a subcommand, it prompts for whatever
+// flags were omitted
+func addSecret(secretStore SecretStore, t *terminal.Terminal, opts secretOptions) error {
+	if opts.interactive() && (opts.name == "" || opts.envtype == "" || opts.scope == "") {
 		t.Vprintf(t.Yellow("\nSome flags omitted, running interactive mode!\n"))
 	}
 
-	if name == "" {
-		name = terminal.PromptGetInput(terminal.PromptContent{
+	if opts.name == "" {
+		if !opts.interactive() {
+			return breverrors.NewValidationError("--name is required")
+		}
+		opts.name = terminal.PromptGetInput(terminal.PromptContent{
 			Label:    "Environment variable/secret name: ",
 			ErrorMsg: "error",
 		})
 	}
 
-	if envtype == "" {
-		envtype = terminal.PromptSelectInput(terminal.PromptSelectContent{
+	if opts.envtype == "" && opts.fromFile == "" && opts.interactive() {
+		opts.envtype = terminal.PromptSelectInput(terminal.PromptSelectContent{
 			Label:    "Type of variable: ",
 			ErrorMsg: "error",
 			Items:    []string{"file", "variable"},
 		})
 	}
 
-	if value == "" {
-		value = terminal.PromptGetInput(terminal.PromptContent{
-			Label:    "Environment variable/secret value: ",
+	err := setSecret(secretStore, t, opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func setSecret(secretStore SecretStore, t *terminal.Terminal, opts secretOptions) error {
+	destType, err := parseType(opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	scope, err := resolveScope(opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	dest, err := resolveDest(opts, destType)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	value, err := readValue(opts, destType)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	hierarchyID, err := getHierarchyID(secretStore, scope)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	s := t.NewSpinner()
+	s.Suffix = "  encrypting and saving secret var"
+	s.Start()
+	existing, err := secretStore.GetSecrets(scope, hierarchyID)
+	if err != nil {
+		s.Stop()
+		return breverrors.WrapAndTrace(err)
+	}
+	updated, err := upsertSecret(secretStore, existing, newSecretRequest(opts.name, scope, hierarchyID, value, dest))
+	s.Stop()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	verb := "added"
+	if updated {
+		verb = "updated"
+	}
+	t.Vprintf(t.Green("\nSecret %s %s for %s\n", opts.name, verb, scope) + t.Yellow("\tNote: It might take up to 2 minutes to load into your environment.\n"))
+	return nil
+}
+
+func newSecretRequest(name string, scope store.HierarchyType, hierarchyID string, value string, dest store.SecretReqDest) store.CreateSecretRequest {
+	return store.CreateSecretRequest{
+		Name:          name,
+		HierarchyType: scope,
+		HierarchyID:   hierarchyID,
+		Src: store.SecretReqSrc{
+			Type: store.KeyValue,
+			Config: store.SrcConfig{
+				Value: value,
+			},
+		},
+		Dest: dest,
+	}
+}
+
+// upsertSecret replaces the secret with the same name in existing, if there is
+// one, so that set and import can be run again to rotate values
+func upsertSecret(secretStore SecretStore, existing []store.Secret, req store.CreateSecretRequest) (bool, error) {
+	for _, e := range existing {
+		if e.Name != req.Name {
+			continue
+		}
+		_, err := secretStore.UpdateSecret(e.ID, req)
+		if err != nil {
+			return false, breverrors.WrapAndTrace(err)
+		}
+		return true, nil
+	}
+	_, err := secretStore.CreateSecret(req)
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	return false, nil
+}
+
+func parseType(opts secretOptions) (store.DestType, error) {
+	switch opts.envtype {
+	case "":
+		if opts.fromFile != "" {
+			return store.File, nil
+		}
+		return store.EnvVariable, nil
+	case "variable", "env":
+		return store.EnvVariable, nil
+	case "file":
+		return store.File, nil
+	default:
+		return "", breverrors.NewValidationError(fmt.Sprintf("invalid type %q, must be file or variable", opts.envtype))
+	}
+}
+
+func parseScope(scope string) (store.HierarchyType, error) {
+	switch scope {
+	case "org":
+		return store.Org, nil
+	case "user", "private":
+		return store.User, nil
+	default:
+		return "", breverrors.NewValidationError(fmt.Sprintf("invalid scope %q, must be org or user", scope))
+	}
+}
+
+func resolveScope(opts secretOptions) (store.HierarchyType, error) {
+	if opts.scope == "" {
+		if !opts.interactive() {
+			return "", breverrors.NewValidationError("--scope is required, org or user")
+		}
+		opts.scope = terminal.PromptSelectInput(terminal.PromptSelectContent{
+			Label:    "Scope: ",
 			ErrorMsg: "error",
+			Items:    []string{"org", "user"},
 		})
 	}
+	return parseScope(opts.scope)
+}
+
+// scopes are the ones given with --scope, both if it was omitted
+func scopes(opts secretOptions) ([]store.HierarchyType, error) {
+	if opts.scope == "" {
+		return []store.HierarchyType{store.Org, store.User}, nil
+	}
+	scope, err := parseScope(opts.scope)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return []store.HierarchyType{scope}, nil
+}
 
-	if path == "" && envtype == "file" {
+func resolveDest(opts secretOptions, destType store.DestType) (store.SecretReqDest, error) {
+	if destType == store.EnvVariable {
+		return store.SecretReqDest{Type: store.EnvVariable, Config: store.DestConfig{Name: opts.name}}, nil
+	}
+	path := opts.path
+	if path == "" {
+		if !opts.interactive() {
+			return store.SecretReqDest{}, breverrors.NewValidationError("--file-path is required for file secrets")
+		}
 		path = terminal.PromptGetInput(terminal.PromptContent{
 			Label:    "Path for the file: ",
 			ErrorMsg: "error",
-			Default:  "/home/brev/workspace/secret.txt",
+			Default:  defaultFilePath,
 		})
 	}
+	return store.SecretReqDest{Type: store.File, Config: store.DestConfig{Path: path}}, nil
+}
 
-	if scope == "" {
-		scope = terminal.PromptSelectInput(terminal.PromptSelectContent{
-			Label:    "Scope: ",
+// readValue takes the value from --value, --from-file, stdin if something is
+// piped in, or else a prompt that hides what is typed
+func readValue(opts secretOptions, destType store.DestType) (string, error) {
+	var value string
+	switch {
+	case opts.value != "" && opts.fromFile != "":
+		return "", breverrors.NewValidationError("use either --value or --from-file, not both")
+	case opts.value != "":
+		value = opts.value
+	case opts.fromFile != "":
+		b, err := ioutil.ReadFile(opts.fromFile) //nolint:gosec // the user asked to read this file
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		value = string(b)
+	case !stdinIsTerminal():
+		b, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		value = string(b)
+		if destType == store.EnvVariable {
+			// echo and most secret managers end their output with a newline
+			value = strings.TrimRight(value, "\r\n")
+		}
+	case opts.nonInteractive:
+		return "", breverrors.NewValidationError("no value given, use --value, --from-file or pipe it on stdin")
+	default:
+		value = terminal.PromptGetInput(terminal.PromptContent{
+			Label:    "Environment variable/secret value: ",
 			ErrorMsg: "error",
-			Items:    []string{"org", "user"},
+			Mask:     '*',
 		})
 	}
-
-	if envtype == "file" {
-		t.Vprintf("brev secret --name %s --value %s --type %s --file-path %s --scope %s\n", name, value, envtype, path, scope)
-	} else {
-		t.Vprintf("brev secret --name %s --value %s --type %s --scope %s\n", name, value, envtype, scope)
+	if value == "" {
+		return "", breverrors.NewValidationError("the value of a secret can't be empty")
 	}
+	return value, nil
+}
 
-	s := t.NewSpinner()
-	s.Suffix = "  encrypting and saving secret var"
-	s.Start()
-
-	iScope := store.Org
-	var hierarchyID string
-	if scope == "user" {
-		iScope = store.User
-		// get user id
+func getHierarchyID(secretStore SecretStore, scope store.HierarchyType) (string, error) {
+	if scope == store.User {
 		me, err := secretStore.GetCurrentUser()
 		if err != nil {
-			return breverrors.WrapAndTrace(err)
+			return "", breverrors.WrapAndTrace(err)
 		}
-		hierarchyID = me.ID
-	} else {
-		// get org id
-		defaultOrg, err := secretStore.GetActiveOrganizationOrDefault()
+		return me.ID, nil
+	}
+	org, err := secretStore.GetActiveOrganizationOrDefault()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	if org == nil {
+		return "", breverrors.NewValidationError("no orgs exist")
+	}
+	return org.ID, nil
+}
+
+func getSecrets(secretStore SecretStore, opts secretOptions) ([]store.Secret, error) {
+	hierarchies, err := scopes(opts)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	secrets := []store.Secret{}
+	for _, scope := range hierarchies {
+		hierarchyID, err := getHierarchyID(secretStore, scope)
 		if err != nil {
-			return breverrors.WrapAndTrace(err)
+			return nil, breverrors.WrapAndTrace(err)
 		}
-		if defaultOrg == nil {
-			return fmt.Errorf("no orgs exist")
+		s, err := secretStore.GetSecrets(scope, hierarchyID)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
 		}
-		hierarchyID = defaultOrg.ID
+		secrets = append(secrets, s...)
 	}
+	sort.SliceStable(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	return secrets, nil
+}
 
-	var configDest store.DestConfig
-	iType := store.File
-	if envtype == "variable" {
-		iType = store.EnvVariable
-		configDest = store.DestConfig{
-			Name: name,
-		}
-	} else {
-		configDest = store.DestConfig{
-			Path: path,
+func listSecrets(secretStore SecretStore, t *terminal.Terminal, opts secretOptions, format output.Format) error {
+	secrets, err := getSecrets(secretStore, opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if format.IsMachineReadable() {
+		err = output.WriteList(os.Stdout, format, "SecretList", secrets, func(s store.Secret) string { return s.Name })
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
 		}
+		return nil
+	}
+	if len(secrets) == 0 {
+		t.Vprint(t.Yellow("You don't have any secrets. Add one with brev secret set NAME"))
+		return nil
+	}
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.AppendHeader(table.Row{"NAME", "SCOPE", "TYPE", "DESTINATION", "UPDATED"})
+	for _, s := range secrets {
+		ta.AppendRow(table.Row{s.Name, s.HierarchyType, s.Dest.Type, destination(s.Dest), s.UpdatedAt})
 	}
+	ta.Render()
+	return nil
+}
 
-	// NOTE: hieararchyID needs to be the org ID user ID
+func destination(dest store.SecretReqDest) string {
+	if dest.Type == store.File {
+		return dest.Config.Path
+	}
+	return "$" + dest.Config.Name
+}
 
-	b := store.CreateSecretRequest{
-		Name:          name,
-		HierarchyType: iScope,
-		HierarchyID:   hierarchyID,
-		Src: store.SecretReqSrc{
-			Type: store.KeyValue,
-			Config: store.SrcConfig{
-				Value: value,
-			},
-		},
-		Dest: store.SecretReqDest{
-			Type:   iType,
-			Config: configDest,
-		},
+func removeSecret(secretStore SecretStore, t *terminal.Terminal, opts secretOptions, name string) error {
+	secrets, err := getSecrets(secretStore, opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	matches := []store.Secret{}
+	for _, s := range secrets {
+		if s.Name == name {
+			matches = append(matches, s)
+		}
 	}
-	asstring, _ := json.MarshalIndent(b, "", "\t")
-	fmt.Print(string(asstring))
-	secret, err := secretStore.CreateSecret(b)
+	switch len(matches) {
+	case 0:
+		return breverrors.NewValidationError(fmt.Sprintf("no secret named %s", name))
+	case 1:
+	default:
+		return breverrors.NewValidationError(fmt.Sprintf("both your org and you have a secret named %s, use --scope to say which to remove", name))
+	}
+	err = secretStore.DeleteSecret(matches[0].ID)
 	if err != nil {
-		s.Stop()
-		t.Vprintf(t.Red(err.Error()))
 		return breverrors.WrapAndTrace(err)
 	}
-	t.Vprintf(secret.Name)
-	s.Suffix = "  environment secret added"
-	s.Stop()
+	t.Vprintf(t.Green("Secret %s removed from %s\n", name, matches[0].HierarchyType))
+	return nil
+}
 
-	t.Vprintf(t.Green("\nEnvironment %s added\n", iType) + t.Yellow("\tNote: It might take up to 2 minutes to load into your environment."))
+func importSecrets(secretStore SecretStore, t *terminal.Terminal, opts secretOptions, path string) error {
+	content, err := ioutil.ReadFile(path) //nolint:gosec // the user asked to import this file
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	vars, err := configureenvvars.ParseEnvFile(string(content))
+	if err != nil {
+		return breverrors.NewValidationError(fmt.Sprintf("%s: %v", path, err))
+	}
+	if len(vars) == 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("%s has no variables", path))
+	}
+	scope, err := resolveScope(opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	hierarchyID, err := getHierarchyID(secretStore, scope)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	existing, err := secretStore.GetSecrets(scope, hierarchyID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var allErr error
+	for _, name := range names {
+		dest := store.SecretReqDest{Type: store.EnvVariable, Config: store.DestConfig{Name: name}}
+		updated, err := upsertSecret(secretStore, existing, newSecretRequest(name, scope, hierarchyID, vars[name], dest))
+		if err != nil {
+			allErr = multierror.Append(allErr, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		verb := "added"
+		if updated {
+			verb = "updated"
+		}
+		t.Vprintf("%s %s\n", t.Green(verb), name)
+	}
+	if allErr != nil {
+		return breverrors.WrapAndTrace(allErr)
+	}
+	t.Vprintf(t.Green("\n%d secrets imported for %s\n", len(names), scope) + t.Yellow("\tNote: It might take up to 2 minutes to load into your environment.\n"))
 	return nil
 }
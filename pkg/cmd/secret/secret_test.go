@@ -1 +1,174 @@
 package secret
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSecretStore struct {
+	secrets []store.Secret
+	values  map[string]string
+	nextID  int
+}
+
+func newFakeSecretStore() *fakeSecretStore {
+	return &fakeSecretStore{values: map[string]string{}}
+}
+
+func (f *fakeSecretStore) CreateSecret(req store.CreateSecretRequest) (*store.CreateSecretRequest, error) {
+	f.nextID++
+	id := fmt.Sprintf("s%d", f.nextID)
+	f.secrets = append(f.secrets, store.Secret{ID: id, Name: req.Name, HierarchyType: req.HierarchyType, HierarchyID: req.HierarchyID, Dest: req.Dest})
+	f.values[id] = req.Src.Config.Value
+	return &req, nil
+}
+
+func (f *fakeSecretStore) GetSecrets(hierarchyType store.HierarchyType, hierarchyID string) ([]store.Secret, error) {
+	secrets := []store.Secret{}
+	for _, s := range f.secrets {
+		if s.HierarchyType == hierarchyType && s.HierarchyID == hierarchyID {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets, nil
+}
+
+func (f *fakeSecretStore) UpdateSecret(secretID string, req store.CreateSecretRequest) (*store.Secret, error) {
+	for i, s := range f.secrets {
+		if s.ID == secretID {
+			f.secrets[i].Dest = req.Dest
+			f.values[secretID] = req.Src.Config.Value
+			return &f.secrets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("not found")
+}
+
+func (f *fakeSecretStore) DeleteSecret(secretID string) error {
+	for i, s := range f.secrets {
+		if s.ID == secretID {
+			f.secrets = append(f.secrets[:i], f.secrets[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("not found")
+}
+
+func (f *fakeSecretStore) GetCurrentUser() (*entity.User, error) {
+	return &entity.User{ID: "me"}, nil
+}
+
+func (f *fakeSecretStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
+	return &entity.Organization{ID: "org"}, nil
+}
+
+func (f *fakeSecretStore) value(name string, scope store.HierarchyType) string {
+	for _, s := range f.secrets {
+		if s.Name == name && s.HierarchyType == scope {
+			return f.values[s.ID]
+		}
+	}
+	return ""
+}
+
+// withStdin pipes input into the command as if stdin was not a terminal
+func withStdin(t *testing.T, input string) {
+	oldStdin, oldIsTerminal := stdin, stdinIsTerminal
+	stdin = strings.NewReader(input)
+	stdinIsTerminal = func() bool { return false }
+	t.Cleanup(func() { stdin, stdinIsTerminal = oldStdin, oldIsTerminal })
+}
+
+func TestSetSecretUpserts(t *testing.T) {
+	withStdin(t, "")
+	s := newFakeSecretStore()
+	term := terminal.New()
+
+	err := setSecret(s, term, secretOptions{name: "TOKEN", value: "one", scope: "user"})
+	assert.Nil(t, err)
+	err = setSecret(s, term, secretOptions{name: "TOKEN", value: "two", scope: "private"})
+	assert.Nil(t, err)
+
+	assert.Len(t, s.secrets, 1)
+	assert.Equal(t, "two", s.value("TOKEN", store.User))
+	assert.Equal(t, "me", s.secrets[0].HierarchyID)
+	assert.Equal(t, store.SecretReqDest{Type: store.EnvVariable, Config: store.DestConfig{Name: "TOKEN"}}, s.secrets[0].Dest)
+}
+
+func TestSetSecretFromStdin(t *testing.T) {
+	withStdin(t, "from-vault\n")
+	s := newFakeSecretStore()
+
+	err := setSecret(s, terminal.New(), secretOptions{name: "TOKEN", scope: "org", nonInteractive: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "from-vault", s.value("TOKEN", store.Org))
+}
+
+func TestSetSecretFromFile(t *testing.T) {
+	withStdin(t, "")
+	path := filepath.Join(t.TempDir(), "credentials")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("[default]\nkey=abc\n"), 0o600))
+	s := newFakeSecretStore()
+
+	err := setSecret(s, terminal.New(), secretOptions{name: "AWS", fromFile: path, scope: "user"})
+	assert.Error(t, err, "file secrets need a path when nothing can be prompted")
+
+	err = setSecret(s, terminal.New(), secretOptions{name: "AWS", fromFile: path, path: "/home/brev/.aws/credentials", scope: "user"})
+	assert.Nil(t, err)
+	assert.Equal(t, "[default]\nkey=abc\n", s.value("AWS", store.User))
+	assert.Equal(t, store.File, s.secrets[0].Dest.Type)
+}
+
+func TestSetSecretNonInteractive(t *testing.T) {
+	withStdin(t, "")
+	s := newFakeSecretStore()
+
+	err := setSecret(s, terminal.New(), secretOptions{name: "TOKEN", value: "x"})
+	assert.Error(t, err, "scope is required")
+	err = setSecret(s, terminal.New(), secretOptions{name: "TOKEN", scope: "org", nonInteractive: true})
+	assert.Error(t, err, "an empty value is not a value")
+	err = setSecret(s, terminal.New(), secretOptions{name: "TOKEN", value: "x", fromFile: "f", scope: "org"})
+	assert.Error(t, err)
+	assert.Empty(t, s.secrets)
+}
+
+func TestRemoveSecret(t *testing.T) {
+	withStdin(t, "")
+	s := newFakeSecretStore()
+	term := terminal.New()
+	assert.Nil(t, setSecret(s, term, secretOptions{name: "TOKEN", value: "a", scope: "org"}))
+	assert.Nil(t, setSecret(s, term, secretOptions{name: "TOKEN", value: "b", scope: "user"}))
+	assert.Nil(t, setSecret(s, term, secretOptions{name: "OTHER", value: "c", scope: "user"}))
+
+	assert.Error(t, removeSecret(s, term, secretOptions{}, "TOKEN"), "ambiguous without --scope")
+	assert.Error(t, removeSecret(s, term, secretOptions{}, "MISSING"))
+	assert.Nil(t, removeSecret(s, term, secretOptions{scope: "user"}, "TOKEN"))
+	assert.Nil(t, removeSecret(s, term, secretOptions{}, "OTHER"))
+
+	assert.Len(t, s.secrets, 1)
+	assert.Equal(t, "a", s.value("TOKEN", store.Org))
+}
+
+func TestImportSecrets(t *testing.T) {
+	withStdin(t, "")
+	s := newFakeSecretStore()
+	term := terminal.New()
+	assert.Nil(t, setSecret(s, term, secretOptions{name: "DB_USER", value: "old", scope: "org"}))
+
+	path := filepath.Join(t.TempDir(), ".env")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("# db\nDB_USER=admin\nDB_PASS='p=ss'\n"), 0o600))
+
+	err := importSecrets(s, term, secretOptions{scope: "org"}, path)
+	assert.Nil(t, err)
+	assert.Len(t, s.secrets, 2)
+	assert.Equal(t, "admin", s.value("DB_USER", store.Org))
+	assert.Equal(t, "p=ss", s.value("DB_PASS", store.Org))
+}
@@ -0,0 +1,189 @@
+package setup
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/output"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/setupworkspace"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintLong = `Check setup params for problems that would otherwise only show up once the dev environment sets up:
+unknown dependsOn, dependency cycles, repos cloned to the same directory, invalid repo urls and branches,
+missing exec paths and execs logging to the same file.
+
+Lint a json or yaml file, a dev environment, or with no argument the dev environment you are in.
+Exits non zero when there are errors, warnings alone don't fail it.`
+	lintExample = `
+  brev setup lint setup.json
+  brev setup lint my-dev-env
+  brev setup lint
+  cat setup.yaml | brev setup lint -
+  brev setup lint setup.json --output json
+	`
+)
+
+type SetupLintStore interface {
+	util.GetWorkspaceByNameOrIDErrStore
+	GetEnvSetupParams(workspaceID string) (*store.SetupParamsV0, error)
+	GetSetupParams() (*store.SetupParamsV0, error)
+}
+
+func NewCmdSetup(t *terminal.Terminal, setupStore SetupLintStore, noLoginStartStore completions.CompletionStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations: map[string]string{"workspace": ""},
+		Use:         "setup",
+		Short:       "Work with the setup of dev environments",
+		Example:     lintExample,
+		Args:        cobra.NoArgs,
+	}
+	cmd.AddCommand(newCmdLint(t, setupStore, noLoginStartStore))
+	return cmd
+}
+
+func newCmdLint(t *terminal.Terminal, setupStore SetupLintStore, noLoginStartStore completions.CompletionStore) *cobra.Command {
+	var outputOpts output.Options
+	cmd := &cobra.Command{
+		Use:               "lint [file|dev env]",
+		Short:             "Check setup params for problems",
+		Long:              lintLong,
+		Example:           lintExample,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completions.GetAllWorkspaceNameCompletionHandler(noLoginStartStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputOpts.Format()
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			target := ""
+			if len(args) > 0 {
+				target = args[0]
+			}
+			err = runLint(t, setupStore, target, format)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	output.AddOutputFlag(cmd, &outputOpts)
+	return cmd
+}
+
+func runLint(t *terminal.Terminal, setupStore SetupLintStore, target string, format output.Format) error {
+	source, params, err := getSetupParams(setupStore, target)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	problems := setupworkspace.LintSetup(*params)
+
+	if format.IsMachineReadable() {
+		err = output.WriteList(os.Stdout, format, "SetupProblem", problems, func(p setupworkspace.SetupProblem) string { return p.Location })
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	} else {
+		displayProblems(t, source, problems)
+	}
+
+	errs := countSeverity(problems, setupworkspace.SeverityError)
+	if errs > 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("%s has %d setup errors", source, errs))
+	}
+	return nil
+}
+
+// getSetupParams reads a file if target is one, stdin for -, and otherwise
+// gets the params of the dev environment named target
+func getSetupParams(setupStore SetupLintStore, target string) (string, *store.SetupParamsV0, error) {
+	switch {
+	case target == "":
+		params, err := setupStore.GetSetupParams()
+		if err != nil {
+			return "", nil, breverrors.WrapAndTrace(err, "not in a dev environment, pass a file or dev environment to lint")
+		}
+		return "/etc/meta/setup_v0.json", params, nil
+	case target == "-":
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", nil, breverrors.WrapAndTrace(err)
+		}
+		params, err := parseSetupParams(b)
+		if err != nil {
+			return "", nil, breverrors.WrapAndTrace(err)
+		}
+		return "stdin", params, nil
+	case isFile(target):
+		b, err := os.ReadFile(target) //nolint:gosec // the user picks which file to lint
+		if err != nil {
+			return "", nil, breverrors.WrapAndTrace(err)
+		}
+		params, err := parseSetupParams(b)
+		if err != nil {
+			return "", nil, breverrors.WrapAndTrace(err)
+		}
+		return target, params, nil
+	}
+
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(setupStore, target)
+	if err != nil {
+		return "", nil, breverrors.WrapAndTrace(err)
+	}
+	params, err := setupStore.GetEnvSetupParams(workspace.ID)
+	if err != nil {
+		return "", nil, breverrors.WrapAndTrace(err)
+	}
+	return workspace.Name, params, nil
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// parseSetupParams takes json or yaml, unknown fields are an error since
+// they are usually a misspelled field envsetup would ignore
+func parseSetupParams(b []byte) (*store.SetupParamsV0, error) {
+	var params store.SetupParamsV0
+	err := yaml.UnmarshalStrict(b, &params)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid setup params: %v", err))
+	}
+	return &params, nil
+}
+
+func displayProblems(t *terminal.Terminal, source string, problems setupworkspace.SetupProblems) {
+	for _, p := range problems {
+		severity := t.Yellow(string(p.Severity))
+		if p.Severity == setupworkspace.SeverityError {
+			severity = t.Red(string(p.Severity))
+		}
+		fmt.Printf("%s:%s: %s: %s\n", source, p.Location, severity, p.Message)
+	}
+	if len(problems) == 0 {
+		t.Vprint(t.Green("%s: no problems found\n", source))
+		return
+	}
+	t.Vprintf("\n%d errors, %d warnings\n", countSeverity(problems, setupworkspace.SeverityError), countSeverity(problems, setupworkspace.SeverityWarning))
+}
+
+func countSeverity(problems setupworkspace.SetupProblems, severity setupworkspace.Severity) int {
+	n := 0
+	for _, p := range problems {
+		if p.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,27 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSetupParams(t *testing.T) {
+	params, err := parseSetupParams([]byte(`
+workspaceProjectRepo: github.com/brevdev/brev-cli
+execsV1:
+  server:
+    type: string
+    execStr: make run
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, "github.com/brevdev/brev-cli", params.WorkspaceProjectRepo)
+	assert.Equal(t, "make run", params.ExecsV1["server"].ExecStr)
+
+	params, err = parseSetupParams([]byte(`{"workspaceProjectRepoBranch": "main"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "main", params.WorkspaceProjectRepoBranch)
+
+	_, err = parseSetupParams([]byte(`{"workspaceProjectRepoBrnach": "main"}`))
+	assert.ErrorContains(t, err, "invalid setup params")
+}
@@ -1,6 +1,7 @@
 package shell
 
 import (
+	"context"
 	"errors"
 	"os"
 	"os/exec"
@@ -14,8 +15,10 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/setupprogress"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/waiter"
 	"github.com/brevdev/brev-cli/pkg/writeconnectionevent"
 	"github.com/briandowns/spinner"
 	"github.com/samber/mo"
@@ -78,7 +81,7 @@ func runShellCommand(t *terminal.Terminal, sstore ShellStore, workspaceNameOrID,
 			return breverrors.WrapAndTrace(err)
 		}
 	}
-	err = pollUntil(s, workspace.ID, "RUNNING", sstore, " waiting for instance to be ready...")
+	_, err = waiter.NewWaiter(sstore, t).WithSpinner(s).WithWaitMessage(" waiting for instance to be ready...").WaitFor(context.Background(), workspace.ID, waiter.Running)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -101,6 +104,11 @@ func runShellCommand(t *terminal.Terminal, sstore ShellStore, workspaceNameOrID,
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	// the project folder only exists once the repos are set up
+	_, err = setupprogress.Wait(t, setupprogress.SSHSource(sshName), setupprogress.StepRepos)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 	// we don't care about the error here but should log with sentry
 	// legacy environments wont support this and cause errrors,
 	// but we don't want to block the user from using the shell
@@ -174,7 +182,7 @@ func startWorkspaceIfStopped(t *terminal.Terminal, s *spinner.Spinner, tstore Sh
 		return breverrors.WrapAndTrace(err)
 	}
 	t.Vprintf(t.Yellow("Dev environment %s is starting. \n\n", startedWorkspace.Name))
-	err = pollUntil(s, workspace.ID, entity.Running, tstore, " hang tight 🤙")
+	_, err = waiter.NewWaiter(tstore, t).WithSpinner(s).WithWaitMessage(" hang tight 🤙").WaitFor(context.Background(), workspace.ID, waiter.Running)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -184,21 +192,3 @@ func startWorkspaceIfStopped(t *terminal.Terminal, s *spinner.Spinner, tstore Sh
 	}
 	return nil
 }
-
-func pollUntil(s *spinner.Spinner, wsid string, state string, shellStore ShellStore, waitMsg string) error {
-	isReady := false
-	s.Suffix = waitMsg
-	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := shellStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = waitMsg
-		if ws.Status == state {
-			isReady = true
-		}
-	}
-	return nil
-}
@@ -2,12 +2,13 @@
 package start
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"path/filepath"
 	"strings"
-	"time"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/config"
@@ -17,6 +18,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	allutil "github.com/brevdev/brev-cli/pkg/util"
+	"github.com/brevdev/brev-cli/pkg/waiter"
 	"github.com/spf13/cobra"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -28,6 +30,8 @@ var (
   brev start <existing_ws_name>
   brev start <git url>
   brev start <git url> --org myFancyOrg
+  brev start ws-1 ws-2
+  brev start 'ml-*' --yes
 	`
 	instanceTypes = []string{"p4d.24xlarge", "p3.2xlarge", "p3.8xlarge", "p3.16xlarge", "p3dn.24xlarge", "p2.xlarge", "p2.8xlarge", "p2.16xlarge", "g5.xlarge", "g5.2xlarge", "g5.4xlarge", "g5.8xlarge", "g5.16xlarge", "g5.12xlarge", "g5.24xlarge", "g5.48xlarge", "g5g.xlarge", "g5g.2xlarge", "g5g.4xlarge", "g5g.8xlarge", "g5g.16xlarge", "g5g.metal", "g4dn.xlarge", "g4dn.2xlarge", "g4dn.4xlarge", "g4dn.8xlarge", "g4dn.16xlarge", "g4dn.12xlarge", "g4dn.metal", "g4ad.xlarge", "g4ad.2xlarge", "g4ad.4xlarge", "g4ad.8xlarge", "g4ad.16xlarge", "g3s.xlarge", "g3.4xlarge", "g3.8xlarge", "g3.16xlarge"}
 )
@@ -45,6 +49,38 @@ type StartStore interface {
 	GetFileAsString(path string) (string, error)
 }
 
+// isBulkStart is false for urls and paths, which create a dev environment
+// rather than select existing ones
+func isBulkStart(selector bulk.Selector) bool {
+	for _, name := range selector.Names {
+		if name == "." || strings.Contains(name, "/") {
+			return false
+		}
+	}
+	return selector.IsBulk()
+}
+
+func runBulkStart(t *terminal.Terminal, selector bulk.Selector, startStore StartStore) error {
+	err := bulk.Apply(t, startStore, selector, bulk.Operation{
+		Verb: "start",
+		Run: func(w entity.Workspace) error {
+			err := bulk.RequireStatus(w, entity.Stopped)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			_, err = startStore.StartWorkspace(w.ID)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func validateInstanceType(instanceType string) bool {
 	for _, v := range instanceTypes {
 		if instanceType == v {
@@ -64,6 +100,7 @@ func NewCmdStart(t *terminal.Terminal, startStore StartStore, noLoginStartStore
 	var setupPath string
 	var gpu string
 	var cpu string
+	var selector bulk.Selector
 
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
@@ -74,11 +111,21 @@ func NewCmdStart(t *terminal.Terminal, startStore StartStore, noLoginStartStore
 		Example:               startExample,
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginStartStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			selector.Names = args
+			selector.Org = org
+			if isBulkStart(selector) {
+				return runBulkStart(t, selector, startStore)
+			}
+
 			repoOrPathOrNameOrID := ""
 			if len(args) > 0 {
 				repoOrPathOrNameOrID = args[0]
 			}
 
+			cpu, gpu = config.GlobalConfig.ResolveMachine(cpu, gpu)
+			if setupRepo == "" && setupPath == "" && setupScript == "" {
+				setupRepo = config.GlobalConfig.GetDefaultSetupRepo()
+			}
 			if gpu != "" {
 				isValid := validateInstanceType(gpu)
 				if !isValid {
@@ -112,13 +159,14 @@ func NewCmdStart(t *terminal.Terminal, startStore StartStore, noLoginStartStore
 	cmd.Flags().BoolVarP(&detached, "detached", "d", false, "run the command in the background instead of blocking the shell")
 	cmd.Flags().BoolVarP(&empty, "empty", "e", false, "create an empty workspace")
 	cmd.Flags().StringVarP(&name, "name", "n", "", "name your workspace when creating a new one")
-	cmd.Flags().StringVarP(&cpu, "cpu", "c", "", "CPU instance type. Defaults to 2x8 [2x8, 4x16, 8x32, 16x32]. See docs.brev.dev/cpu for details")
+	cmd.Flags().StringVarP(&cpu, "cpu", "c", "", "CPU instance type. Defaults to the profile's workspace-class or 2x8 [2x8, 4x16, 8x32, 16x32]. See docs.brev.dev/cpu for details")
 	cmd.Flags().StringVarP(&setupScript, "setup-script", "s", "", "takes a raw gist url to an env setup script")
-	cmd.Flags().StringVarP(&setupRepo, "setup-repo", "r", "", "repo that holds env setup script. you must pass in --setup-path if you use this argument")
+	cmd.Flags().StringVarP(&setupRepo, "setup-repo", "r", "", "repo that holds env setup script, defaults to the profile's setup-repo. you must pass in --setup-path if you use this argument")
 	cmd.Flags().StringVarP(&setupPath, "setup-path", "p", "", "path to env setup script. If you include --setup-repo we will apply this argument to that repo")
 	cmd.Flags().StringVarP(&org, "org", "o", "", "organization (will override active org if creating a workspace)")
 	// GPU options
 	cmd.Flags().StringVarP(&gpu, "gpu", "g", "", "GPU instance type. See docs.brev.dev/gpu for details")
+	bulk.AddFlags(cmd, &selector)
 	err := cmd.RegisterFlagCompletionFunc("org", completions.GetOrgsNameCompletionHandler(noLoginStartStore, t))
 	if err != nil {
 		breverrors.GetDefaultErrorReporter().ReportError(breverrors.WrapAndTrace(err))
@@ -378,7 +426,7 @@ func createEmptyWorkspace(user *entity.User, t *terminal.Terminal, options Start
 	if options.Detached {
 		return nil
 	} else {
-		err = pollUntil(t, w.ID, entity.Running, startStore, true)
+		_, err = waiter.NewWaiter(startStore, t).WithSafeExitMessage().WaitFor(context.Background(), w.ID, waiter.Running)
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
 		}
@@ -433,7 +481,7 @@ func startStopppedWorkspace(workspace *entity.Workspace, startStore StartStore,
 		return nil
 	}
 
-	err = pollUntil(t, workspace.ID, entity.Running, startStore, true)
+	_, err = waiter.NewWaiter(startStore, t).WithSafeExitMessage().WaitFor(context.Background(), workspace.ID, waiter.Running)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -481,7 +529,7 @@ func joinProjectWithNewWorkspace(t *terminal.Terminal, templateWorkspace entity.
 	}
 	s.Stop()
 
-	err = pollUntil(t, w.ID, entity.Running, startStore, true)
+	_, err = waiter.NewWaiter(startStore, t).WithSafeExitMessage().WaitFor(context.Background(), w.ID, waiter.Running)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -648,7 +696,7 @@ func createWorkspace(user *entity.User, t *terminal.Terminal, workspace NewWorks
 	}
 	s.Stop()
 
-	err = pollUntil(t, w.ID, entity.Running, startStore, true)
+	_, err = waiter.NewWaiter(startStore, t).WithSafeExitMessage().WaitFor(context.Background(), w.ID, waiter.Running)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -666,27 +714,3 @@ func displayConnectBreadCrumb(t *terminal.Terminal, workspace *entity.Workspace)
 	t.Vprintf(t.Yellow(fmt.Sprintf("\tbrev shell %s\t# brev shell <NAME> -> ssh into dev environment (shortcut)\n", workspace.Name)))
 	// t.Vprintf(t.Yellow(fmt.Sprintf("\tssh %s\t# ssh <SSH-NAME> -> ssh directly to dev environment\n", workspace.GetLocalIdentifier())))
 }
-
-func pollUntil(t *terminal.Terminal, wsid string, state string, startStore StartStore, canSafelyExit bool) error {
-	s := t.NewSpinner()
-	isReady := false
-	if canSafelyExit {
-		t.Vprintf("You can safely ctrl+c to exit\n")
-	}
-	s.Suffix = " hang tight 🤙"
-	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := startStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  environment is " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Environment is ready!"
-			s.Stop()
-			isReady = true
-		}
-	}
-	return nil
-}
@@ -1,17 +1,27 @@
 package status
 
 import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/output"
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/supervisor"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 )
 
 var (
 	createLong    = "Create a new Brev machine"
 	createExample = `
-  brev create <name>
+  brev status
+  brev status --output json
+  brev status --services
 	`
 	// instanceTypes = []string{"p4d.24xlarge", "p3.2xlarge", "p3.8xlarge", "p3.16xlarge", "p3dn.24xlarge", "p2.xlarge", "p2.8xlarge", "p2.16xlarge", "g5.xlarge", "g5.2xlarge", "g5.4xlarge", "g5.8xlarge", "g5.16xlarge", "g5.12xlarge", "g5.24xlarge", "g5.48xlarge", "g5g.xlarge", "g5g.2xlarge", "g5g.4xlarge", "g5g.8xlarge", "g5g.16xlarge", "g5g.metal", "g4dn.xlarge", "g4dn.2xlarge", "g4dn.4xlarge", "g4dn.8xlarge", "g4dn.16xlarge", "g4dn.12xlarge", "g4dn.metal", "g4ad.xlarge", "g4ad.2xlarge", "g4ad.4xlarge", "g4ad.8xlarge", "g4ad.16xlarge", "g3s.xlarge", "g3.4xlarge", "g3.8xlarge", "g3.16xlarge"}
 )
@@ -26,6 +36,8 @@ type StatusStore interface {
 }
 
 func NewCmdStatus(t *terminal.Terminal, statusStore StatusStore) *cobra.Command {
+	var outputOpts output.Options
+	var services bool
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
 		Use:                   "status",
@@ -34,14 +46,109 @@ func NewCmdStatus(t *terminal.Terminal, statusStore StatusStore) *cobra.Command
 		Long:                  createLong,
 		Example:               createExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			runShowStatus(t, statusStore)
+			format, err := outputOpts.Format()
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			if services {
+				err = outputServices(t, supervisor.NewClient(supervisor.StatusSocketPath), format)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				return nil
+			}
+			if format.IsMachineReadable() {
+				err = outputStatus(statusStore, format)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				return nil
+			}
+			runShowStatus(t, statusStore, format == output.Wide)
 			return nil
 		},
 	}
+	output.AddOutputFlag(cmd, &outputOpts)
+	cmd.Flags().BoolVar(&services, "services", false, "show the supervised execs of this dev environment")
 	return cmd
 }
 
-func runShowStatus(t *terminal.Terminal, statusStore StatusStore) {
+type ServiceStatusGetter interface {
+	Status() ([]supervisor.ServiceStatus, error)
+}
+
+func outputServices(t *terminal.Terminal, getter ServiceStatusGetter, format output.Format) error {
+	statuses, err := getter.Status()
+	if err != nil {
+		return breverrors.WrapAndTrace(err, "no supervised execs, is this a dev environment with execs to supervise?")
+	}
+	if format.IsMachineReadable() {
+		err = output.WriteList(os.Stdout, format, "Service", statuses, func(s supervisor.ServiceStatus) string { return s.Name })
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	if len(statuses) == 0 {
+		t.Vprint(t.Yellow("No supervised execs"))
+		return nil
+	}
+	displayServices(t, statuses)
+	return nil
+}
+
+func displayServices(t *terminal.Terminal, statuses []supervisor.ServiceStatus) {
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	options := table.OptionsDefault
+	options.DrawBorder = false
+	options.SeparateColumns = false
+	options.SeparateRows = false
+	options.SeparateHeader = false
+	ta.Style().Options = options
+	ta.AppendHeader(table.Row{"SERVICE", "STATE", "RESTARTS", "PID", "SINCE", "MESSAGE"})
+	for _, s := range statuses {
+		pid := ""
+		if s.PID != 0 {
+			pid = fmt.Sprint(s.PID)
+		}
+		ta.AppendRow(table.Row{s.Name, getStateColor(t, s.State), s.Restarts, pid, s.Since.Local().Format(time.Kitchen), s.Message})
+	}
+	ta.Render()
+}
+
+func getStateColor(t *terminal.Terminal, state supervisor.State) string {
+	switch state {
+	case supervisor.StateReady, supervisor.StateExited:
+		return t.Green(string(state))
+	case supervisor.StateFailed:
+		return t.Red(string(state))
+	default:
+		return t.Yellow(string(state))
+	}
+}
+
+func outputStatus(statusStore StatusStore, format output.Format) error {
+	wsID, err := statusStore.GetCurrentWorkspaceID()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	ws, err := statusStore.GetWorkspace(wsID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if format == output.Name {
+		fmt.Println(ws.Name)
+		return nil
+	}
+	err = output.Write(os.Stdout, format, output.NewObject("Workspace", ws))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func runShowStatus(t *terminal.Terminal, statusStore StatusStore, wide bool) {
 	terminal.DisplayBrevLogo(t)
 	t.Vprintf("\n")
 	wsID, err := statusStore.GetCurrentWorkspaceID()
@@ -58,4 +165,16 @@ func runShowStatus(t *terminal.Terminal, statusStore StatusStore) {
 	t.Vprintf("\nYou're on environment %s", t.Yellow(ws.Name))
 	t.Vprintf("\n\tID: %s", t.Yellow(ws.ID))
 	t.Vprintf("\n\tMachine: %s", t.Yellow(util.GetInstanceString(*ws)))
+	if wide {
+		t.Vprintf("\n\tStatus: %s", t.Yellow(ws.Status))
+		t.Vprintf("\n\tHealth: %s", t.Yellow(ws.HealthStatus))
+		t.Vprintf("\n\tSSH: %s", t.Yellow(string(ws.GetLocalIdentifier())))
+		t.Vprintf("\n\tDNS: %s", t.Yellow(ws.GetHostname()))
+	}
+	// only dev environments with supervised execs run the supervisor
+	statuses, err := supervisor.NewClient(supervisor.StatusSocketPath).Status()
+	if err == nil && len(statuses) > 0 {
+		t.Vprintf("\n\n")
+		displayServices(t, statuses)
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/entity"
@@ -17,7 +18,9 @@ import (
 
 var (
 	stopLong    = "Stop a Brev machine that's in a running state"
-	stopExample = "brev stop <ws_name>... \nbrev stop --all"
+	stopExample = `  brev stop <ws_name>...
+  brev stop 'ml-*'
+  brev stop --all --older-than 72h --dry-run`
 )
 
 type StopStore interface {
@@ -28,10 +31,11 @@ type StopStore interface {
 	IsWorkspace() (bool, error)
 	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
 	GetCurrentWorkspaceID() (string, error)
+	GetOrganizations(options *store.GetOrganizationsOptions) ([]entity.Organization, error)
 }
 
 func NewCmdStop(t *terminal.Terminal, loginStopStore StopStore, noLoginStopStore StopStore) *cobra.Command {
-	var all bool
+	var selector bulk.Selector
 
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
@@ -43,8 +47,9 @@ func NewCmdStop(t *terminal.Terminal, loginStopStore StopStore, noLoginStopStore
 		// Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs()),
 		ValidArgsFunction: completions.GetAllWorkspaceNameCompletionHandler(noLoginStopStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if all {
-				return stopAllWorkspaces(t, loginStopStore)
+			selector.Names = args
+			if selector.IsBulk() {
+				return stopWorkspaces(t, selector, loginStopStore)
 			} else {
 				if len(args) == 0 {
 					return breverrors.NewValidationError("please provide a workspace to stop")
@@ -63,34 +68,29 @@ func NewCmdStop(t *terminal.Terminal, loginStopStore StopStore, noLoginStopStore
 			return nil
 		},
 	}
-	cmd.Flags().BoolVarP(&all, "all", "a", false, "stop all workspaces")
+	cmd.Flags().BoolVarP(&selector.All, "all", "a", false, "stop all workspaces")
+	bulk.AddFlags(cmd, &selector)
 
 	return cmd
 }
 
-func stopAllWorkspaces(t *terminal.Terminal, stopStore StopStore) error {
-	user, err := stopStore.GetCurrentUser()
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-	org, err := stopStore.GetActiveOrganizationOrDefault()
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-	workspaces, err := stopStore.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{UserID: user.ID})
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-	t.Vprintf("Turning off all of your dev environments")
-	for _, v := range workspaces {
-		if v.Status == entity.Running {
-			_, err = stopStore.StopWorkspace(v.ID)
+func stopWorkspaces(t *terminal.Terminal, selector bulk.Selector, stopStore StopStore) error {
+	err := bulk.Apply(t, stopStore, selector, bulk.Operation{
+		Verb: "stop",
+		Run: func(w entity.Workspace) error {
+			err := bulk.RequireStatus(w, entity.Running)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
-			} else {
-				t.Vprintf(t.Green("\n%s stopped ✓", v.Name))
 			}
-		}
+			_, err = stopStore.StopWorkspace(w.ID)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
 	}
 	return nil
 }
@@ -3,12 +3,15 @@ package tasks
 import (
 	"fmt"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/background"
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/idle"
 	"github.com/brevdev/brev-cli/pkg/k8s"
 	"github.com/brevdev/brev-cli/pkg/ssh"
 	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/supervisor"
 	"github.com/brevdev/brev-cli/pkg/tasks"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	"github.com/hashicorp/go-multierror"
@@ -31,6 +34,7 @@ type TaskStore interface {
 	GetWorkspace(workspaceID string) (*entity.Workspace, error)
 	GetCurrentUser() (*entity.User, error)
 	ssh.ConfigUpaterFactoryStore
+	idle.AutoStopTaskStore
 }
 
 func NewCmdTasks(t *terminal.Terminal, store TaskStore) *cobra.Command {
@@ -60,13 +64,17 @@ func NewCmdConfigure(_ *terminal.Terminal, store TaskStore) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "configure [task to configure]",
 		Short: "configure system startup daemon for task",
-		Long:  "configure system startup daemon for task",
+		Long:  "configure system startup daemon for task, opt-in tasks like autostopd are only configured when named",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// todo if --user flag is not provided and if not run as root, raise
 			// an error
+			toConfigure, err := selectTasksToConfigure(taskMap, args)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
 			fmt.Println("configuring...")
 			var allError error
-			for k, value := range taskMap {
+			for k, value := range toConfigure {
 				fmt.Printf("configuring %s\n", k)
 				err := value.Configure()
 				if err != nil {
@@ -84,6 +92,31 @@ func NewCmdConfigure(_ *terminal.Terminal, store TaskStore) *cobra.Command {
 	return cmd
 }
 
+// optInTasks are left out of a bare brev tasks configure
+var optInTasks = map[string]bool{
+	"autostopd": true,
+}
+
+func selectTasksToConfigure(taskMap TaskMap, names []string) (TaskMap, error) {
+	selected := make(TaskMap)
+	if len(names) == 0 {
+		for k, value := range taskMap {
+			if !optInTasks[k] {
+				selected[k] = value
+			}
+		}
+		return selected, nil
+	}
+	for _, name := range names {
+		task, ok := taskMap[name]
+		if !ok {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("could not find task %s", name))
+		}
+		selected[name] = task
+	}
+	return selected, nil
+}
+
 func NewCmdRun(_ *terminal.Terminal, _ TaskStore, taskMap TaskMap) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "run [task to configure]",
@@ -91,22 +124,16 @@ func NewCmdRun(_ *terminal.Terminal, _ TaskStore, taskMap TaskMap) *cobra.Comman
 		Long:  "run a task",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if all {
-				var allError error
-				for _, value := range taskMap {
-					err := value.Run()
-					if err != nil {
-						allError = multierror.Append(allError, err)
-					}
-				}
-				if allError != nil {
-					return breverrors.WrapAndTrace(allError)
+				err := runAll(taskMap)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
 				}
 			} else {
 				if len(args) == 0 {
 					return fmt.Errorf("provide a task name or --all")
 				}
 				if task, ok := taskMap[args[0]]; ok {
-					err := task.Run()
+					err := runTask(args[0], task)
 					if err != nil {
 						return breverrors.WrapAndTrace(err)
 					}
@@ -120,6 +147,61 @@ func NewCmdRun(_ *terminal.Terminal, _ TaskStore, taskMap TaskMap) *cobra.Comman
 	return cmd
 }
 
+// daemonTasks run on their cron until brev tasks run is stopped, the others
+// run once
+var daemonTasks = map[string]bool{
+	"autostopd":         true,
+	supervisor.TaskName: true,
+}
+
+func runTask(name string, task tasks.Task) error {
+	run := task.Run
+	if daemonTasks[name] {
+		run = func() error { return tasks.RunTasks([]tasks.Task{task}) }
+	}
+	err := run()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// runAll runs the daemons in one task runner next to the other tasks, so that
+// a daemon doesn't keep the rest from running and one signal stops them all
+func runAll(taskMap TaskMap) error {
+	daemons := []tasks.Task{}
+	for k, value := range taskMap {
+		if daemonTasks[k] {
+			daemons = append(daemons, value)
+		}
+	}
+	daemonsDone := make(chan error, 1)
+	if len(daemons) > 0 {
+		go func() { daemonsDone <- tasks.RunTasks(daemons) }()
+	} else {
+		daemonsDone <- nil
+	}
+
+	var allError error
+	for k, value := range taskMap {
+		if daemonTasks[k] {
+			continue
+		}
+		err := value.Run()
+		if err != nil {
+			allError = multierror.Append(allError, err)
+		}
+	}
+	err := <-daemonsDone
+	if err != nil {
+		allError = multierror.Append(allError, err)
+	}
+	if allError != nil {
+		return breverrors.WrapAndTrace(allError)
+	}
+	return nil
+}
+
 func Tasks(_ *terminal.Terminal, _ TaskStore, _ TaskMap) error {
 	return nil
 }
@@ -128,5 +210,16 @@ func getTaskMap(store TaskStore) TaskMap {
 	taskmap := make(TaskMap)
 	sshcd := ssh.NewSSHConfigurerTask(store)
 	taskmap["sshcd"] = sshcd
+	taskmap["autostopd"] = idle.NewAutoStopTask(store, newJobLister(store))
+	taskmap[supervisor.TaskName] = supervisor.NewSupervisorTask()
 	return taskmap
 }
+
+// newJobLister reads brev bg jobs, or nil if there is no home dir to read them from
+func newJobLister(store TaskStore) idle.JobLister {
+	home, err := store.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return background.NewJobStore(afero.NewOsFs(), home)
+}
@@ -0,0 +1,302 @@
+// Package transfer has brev cp and brev sync, which move files between this
+// machine and dev environments over ssh
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/filesync"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	cpLong = `Copy files between this machine and a dev environment, like scp.
+
+Paths on the dev environment are written <dev environment>:<path>, relative
+paths are relative to its home directory. Directories need -r, and anything
+copied to an existing directory goes into it.`
+	cpExample = `  brev cp ./data.csv my-env:~/my-repo/data/
+  brev cp -r ./configs my-env:~/my-repo
+  brev cp my-env:~/my-repo/results.json .
+  brev cp -r my-env:~/my-repo/checkpoints ./checkpoints`
+
+	syncLong = `Make a directory on a dev environment match a local one, like rsync.
+
+Only files whose content changed are sent. Whatever the local .gitignore files
+exclude, and .git, is neither sent nor touched on the dev environment.
+
+brev sync remembers what it sent. A file that was edited on the dev environment
+since is reported as a conflict and left alone, use --force to overwrite it.
+Files that only exist on the dev environment, ex: outputs, are never touched,
+and files deleted locally are only deleted there with --delete.
+
+With --watch it keeps syncing as you edit until ctrl+c.`
+	syncExample = `  brev sync . my-env:~/my-repo
+  brev sync ./src my-env:~/my-repo/src --watch
+  brev sync . my-env:~/my-repo --delete --dry-run`
+)
+
+type TransferStore interface {
+	util.GetWorkspaceByNameOrIDErrStore
+	refresh.RefreshStore
+}
+
+// location is a path on this machine, or on a dev environment when Workspace
+// is set
+type location struct {
+	Workspace string
+	Path      string
+}
+
+// parseLocation reads <dev environment>:<path>. Anything with a slash before
+// the colon, or a windows drive, is a local path
+func parseLocation(arg string) location {
+	if filepath.VolumeName(arg) != "" {
+		return location{Path: arg}
+	}
+	i := strings.Index(arg, ":")
+	if i <= 0 || strings.ContainsAny(arg[:i], `/\`) {
+		return location{Path: arg}
+	}
+	p := arg[i+1:]
+	if p == "" {
+		p = "~"
+	}
+	return location{Workspace: arg[:i], Path: p}
+}
+
+func NewCmdCp(t *terminal.Terminal, loginTransferStore TransferStore) *cobra.Command {
+	var recursive bool
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"ssh": ""},
+		Use:                   "cp",
+		DisableFlagsInUseLine: true,
+		Short:                 "Copy files to or from a dev environment",
+		Long:                  cpLong,
+		Example:               cpExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := runCp(t, loginTransferStore, parseLocation(args[0]), parseLocation(args[1]), recursive)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "copy directories")
+
+	return cmd
+}
+
+func runCp(t *terminal.Terminal, transferStore TransferStore, src location, dst location, recursive bool) error {
+	if (src.Workspace == "") == (dst.Workspace == "") {
+		return breverrors.NewValidationError("exactly one of the paths should be on a dev environment, ex: my-env:~/file")
+	}
+	up := dst.Workspace != ""
+	workspaceName := src.Workspace
+	if up {
+		workspaceName = dst.Workspace
+	}
+	_, remote, err := connect(transferStore, workspaceName)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer remote.Close() //nolint:errcheck // done
+
+	s := t.NewSpinner()
+	s.Suffix = " copying"
+	s.Start()
+	var n int
+	if up {
+		n, err = filesync.CopyUp(remote, src.Path, dst.Path, recursive)
+	} else {
+		n, err = filesync.CopyDown(remote, src.Path, dst.Path, recursive)
+	}
+	s.Stop()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf(t.Green("Copied %d %s\n", n, pluralFiles(n)))
+	return nil
+}
+
+func pluralFiles(n int) string {
+	if n == 1 {
+		return "file"
+	}
+	return "files"
+}
+
+type syncOptions struct {
+	watch    bool
+	interval time.Duration
+	dryRun   bool
+	filesync.PlanOptions
+}
+
+func NewCmdSync(t *terminal.Terminal, loginTransferStore TransferStore) *cobra.Command {
+	var opts syncOptions
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"ssh": ""},
+		Use:                   "sync",
+		DisableFlagsInUseLine: true,
+		Short:                 "Sync a local directory to a dev environment",
+		Long:                  syncLong,
+		Example:               syncExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := runSync(t, loginTransferStore, args[0], parseLocation(args[1]), opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&opts.watch, "watch", "w", false, "keep syncing local changes until ctrl+c")
+	cmd.Flags().DurationVar(&opts.interval, "interval", time.Second, "how often --watch checks for local changes")
+	cmd.Flags().BoolVar(&opts.Delete, "delete", false, "delete files on the dev environment that were synced before and have since been deleted locally")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "overwrite files that were changed on the dev environment instead of reporting conflicts")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "print what would be synced and exit")
+
+	return cmd
+}
+
+func runSync(t *terminal.Terminal, transferStore TransferStore, localDir string, dst location, opts syncOptions) error {
+	if dst.Workspace == "" {
+		return breverrors.NewValidationError("the destination should be on a dev environment, ex: my-env:~/my-repo")
+	}
+	if opts.watch && opts.dryRun {
+		return breverrors.NewValidationError("--watch and --dry-run can't be used together")
+	}
+	localDir, err := filepath.Abs(localDir)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !info.IsDir() {
+		return breverrors.NewValidationError(fmt.Sprintf("%s is not a directory, use brev cp for single files", localDir))
+	}
+	workspace, remote, err := connect(transferStore, dst.Workspace)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer remote.Close() //nolint:errcheck // done
+	brevHome, err := transferStore.GetBrevHomePath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	syncer := &filesync.Syncer{
+		LocalDir:    localDir,
+		RemoteDir:   dst.Path,
+		Workspace:   workspace.ID,
+		Remote:      remote,
+		StatePath:   statePath(brevHome, localDir, workspace.ID, dst.Path),
+		PlanOptions: opts.PlanOptions,
+		DryRun:      opts.dryRun,
+	}
+	target := fmt.Sprintf("%s:%s", workspace.Name, dst.Path)
+
+	if opts.watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		t.Vprintf("Syncing %s to %s, ctrl+c to stop\n", localDir, target)
+		syncer.Watch(ctx, opts.interval, func(result *filesync.Result, err error) {
+			if err != nil {
+				t.Vprint(t.Red("%s sync failed: %s", time.Now().Format(time.Kitchen), err.Error()))
+				return
+			}
+			if len(result.Uploaded)+len(result.Deleted)+len(result.Conflicts) > 0 {
+				printResult(t, result, false)
+				t.Vprintf("%s synced to %s, %s\n", time.Now().Format(time.Kitchen), target, result)
+			}
+		})
+		return nil
+	}
+
+	s := t.NewSpinner()
+	s.Suffix = " syncing"
+	s.Start()
+	result, err := syncer.Once()
+	s.Stop()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	printResult(t, result, opts.dryRun)
+	if opts.dryRun {
+		t.Vprint(t.Yellow("\nDry run, nothing was synced: %s", result))
+	} else {
+		t.Vprintf(t.Green("Synced %s to %s, %s\n", localDir, target, result))
+	}
+	if len(result.Conflicts) > 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("%d files were changed on the dev environment and left alone, use --force to overwrite them", len(result.Conflicts)))
+	}
+	return nil
+}
+
+func printResult(t *terminal.Terminal, result *filesync.Result, dryRun bool) {
+	upload, del := "uploaded", "deleted"
+	if dryRun {
+		upload, del = "upload", "delete"
+	}
+	for _, p := range result.Uploaded {
+		t.Vprintf("  %s %s\n", t.Green("%-8s", upload), p)
+	}
+	for _, p := range result.Deleted {
+		t.Vprintf("  %s %s\n", t.Red("%-8s", del), p)
+	}
+	for _, c := range result.Conflicts {
+		t.Vprintf("  %s %s: %s\n", t.Yellow("%-8s", "conflict"), c.Path, c.Reason)
+	}
+}
+
+// statePath keeps one state per local directory, dev environment and remote
+// directory
+func statePath(brevHome string, localDir string, workspaceID string, remoteDir string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{localDir, workspaceID, remoteDir}, "\n")))
+	return filepath.Join(brevHome, "sync", hex.EncodeToString(sum[:8])+".json")
+}
+
+func connect(transferStore TransferStore, workspaceNameOrID string) (*entity.Workspace, *filesync.SSHRemote, error) {
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(transferStore, workspaceNameOrID)
+	if err != nil {
+		return nil, nil, breverrors.WrapAndTrace(err)
+	}
+	if workspace.Status != entity.Running {
+		return nil, nil, breverrors.WorkspaceNotRunning{Status: workspace.Status}
+	}
+	// writes the private key if this machine doesn't have it yet
+	err = refresh.RunRefresh(transferStore)
+	if err != nil {
+		return nil, nil, breverrors.WrapAndTrace(err)
+	}
+	privateKeyPath, err := transferStore.GetPrivateKeyPath()
+	if err != nil {
+		return nil, nil, breverrors.WrapAndTrace(err)
+	}
+	remote, err := filesync.Dial(*workspace, privateKeyPath)
+	if err != nil {
+		return nil, nil, breverrors.WrapAndTrace(err)
+	}
+	return workspace, remote, nil
+}
@@ -0,0 +1,22 @@
+package transfer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLocation(t *testing.T) {
+	assert.Equal(t, location{Workspace: "my-env", Path: "~/repo"}, parseLocation("my-env:~/repo"))
+	assert.Equal(t, location{Workspace: "my-env", Path: "~"}, parseLocation("my-env:"))
+	assert.Equal(t, location{Path: "./a:b"}, parseLocation("./a:b"))
+	assert.Equal(t, location{Path: "data.csv"}, parseLocation("data.csv"))
+	assert.Equal(t, location{Path: ":x"}, parseLocation(":x"))
+}
+
+func TestStatePath(t *testing.T) {
+	a := statePath("/home/me/.brev", "/src", "ws1", "~/repo")
+	assert.Equal(t, a, statePath("/home/me/.brev", "/src", "ws1", "~/repo"))
+	assert.NotEqual(t, a, statePath("/home/me/.brev", "/src", "ws2", "~/repo"))
+	assert.Contains(t, a, "/home/me/.brev/sync/")
+}
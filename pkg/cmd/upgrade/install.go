@@ -0,0 +1,241 @@
+package upgrade
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const (
+	systemInstallPath = "/usr/local/bin/brev"
+	previousSuffix    = ".previous"
+	// maxManifestSize bounds what is read of checksums and signatures
+	maxManifestSize = 1 << 20
+)
+
+// userInstallPath is where brev goes for users that aren't root, it is on
+// the PATH of most distros
+func userInstallPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return filepath.Join(home, ".local", "bin", "brev"), nil
+}
+
+// installer replaces the binary at Path without ever leaving it half written,
+// and keeps the binary it replaced at Path.previous for Rollback
+type installer struct {
+	Path   string
+	client *http.Client
+}
+
+func (i installer) previousPath() string {
+	return i.Path + previousSuffix
+}
+
+func (i installer) get(url string) (*http.Response, error) {
+	res, err := i.client.Get(url) //nolint:noctx // bounded by the client timeout
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, res.Status)
+	}
+	return res, nil
+}
+
+// fetch reads small assets, ex: checksums
+func (i installer) fetch(url string) ([]byte, error) {
+	res, err := i.get(url)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	defer res.Body.Close() //nolint:errcheck // read only
+	b, err := io.ReadAll(io.LimitReader(res.Body, maxManifestSize))
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return b, nil
+}
+
+// Install downloads the release archive next to Path, checks its sha256,
+// and only then renames the brev binary in it over Path
+func (i installer) Install(archiveURL string, wantSHA256 string) error {
+	dir := filepath.Dir(i.Path)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	// in the same dir so that the rename can't cross file systems
+	archive, err := os.CreateTemp(dir, ".brev-download-*")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer os.Remove(archive.Name()) //nolint:errcheck // temporary
+	defer archive.Close()           //nolint:errcheck // temporary
+
+	res, err := i.get(archiveURL)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer res.Body.Close() //nolint:errcheck // read only
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(archive, h), res.Body)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	got := hex.EncodeToString(h.Sum(nil))
+	if got != wantSHA256 {
+		return fmt.Errorf("checksum mismatch for %s, the download may be truncated or tampered with: expected %s, got %s", path.Base(archiveURL), wantSHA256, got)
+	}
+
+	_, err = archive.Seek(0, io.SeekStart)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	bin, err := extractBinary(archive, dir)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer os.Remove(bin) //nolint:errcheck // gone after the rename
+
+	err = i.keepPrevious()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(bin, i.Path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// extractBinary writes the brev binary of a release archive to a temporary
+// executable in dir
+func extractBinary(archive io.Reader, dir string) (string, error) {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("no brev binary in the release archive")
+		}
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Base(hdr.Name) != "brev" {
+			continue
+		}
+		return writeExecutable(dir, io.LimitReader(tr, hdr.Size))
+	}
+}
+
+func writeExecutable(dir string, r io.Reader) (string, error) {
+	out, err := os.CreateTemp(dir, ".brev-new-*")
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	_, err = io.Copy(out, r)
+	if err == nil {
+		err = out.Sync()
+	}
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(out.Name(), 0o755) //nolint:gosec // an executable
+	}
+	if err != nil {
+		_ = os.Remove(out.Name())
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return out.Name(), nil
+}
+
+// keepPrevious makes Path.previous the binary at Path. It is a hard link, so
+// Path is in place the whole time
+func (i installer) keepPrevious() error {
+	_, err := os.Stat(i.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	tmp, err := linkOrCopy(i.Path, i.Path+".previous-tmp")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(tmp, i.previousPath())
+	if err != nil {
+		_ = os.Remove(tmp)
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// Rollback swaps Path and Path.previous, so rolling back twice undoes it
+func (i installer) Rollback() error {
+	_, err := os.Stat(i.previousPath())
+	if os.IsNotExist(err) {
+		return breverrors.NewValidationError(fmt.Sprintf("there is no previous version of brev to roll back to at %s", i.previousPath()))
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	current, err := linkOrCopy(i.Path, i.Path+".rollback-tmp")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(i.previousPath(), i.Path)
+	if err != nil {
+		_ = os.Remove(current)
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(current, i.previousPath())
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// linkOrCopy hard links src to dst, or copies it where links aren't
+// supported
+func linkOrCopy(src string, dst string) (string, error) {
+	_ = os.Remove(dst) // left over from an interrupted upgrade
+	err := os.Link(src, dst)
+	if err == nil {
+		return dst, nil
+	}
+	in, err := os.Open(src) //nolint:gosec // the brev binary
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	defer in.Close() //nolint:errcheck // read only
+	tmp, err := writeExecutable(filepath.Dir(dst), in)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(tmp, dst)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return dst, nil
+}
@@ -0,0 +1,110 @@
+package upgrade
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+// ReleasePublicKey is the base64 ed25519 key that signs the checksums of
+// releases, set at build time with
+//
+//	-ldflags "-X github.com/brevdev/brev-cli/pkg/cmd/upgrade.ReleasePublicKey=..."
+//
+// When it is set, upgrades require a valid <checksums>.sig asset. Without it
+// the checksums, which come from the same GitHub release, are trusted as is
+var ReleasePublicKey = ""
+
+const (
+	checksumsSuffix = "checksums.txt"
+	signatureSuffix = ".sig"
+)
+
+// releaseAssets are the assets an upgrade needs from a release
+type releaseAssets struct {
+	Archive   store.GithubReleaseAsset
+	Checksums store.GithubReleaseAsset
+	Signature *store.GithubReleaseAsset
+}
+
+// findAssets picks the tar.gz for goos and goarch, named like
+// brev-cli_0.6.250_linux_amd64.tar.gz by goreleaser, and the checksums
+func findAssets(release *store.GithubReleaseMetadata, goos string, goarch string) (*releaseAssets, error) {
+	assets := &releaseAssets{}
+	archiveSuffix := fmt.Sprintf("_%s_%s.tar.gz", goos, goarch)
+	for _, a := range release.Assets {
+		a := a
+		switch {
+		case strings.HasSuffix(a.Name, archiveSuffix):
+			assets.Archive = a
+		case strings.HasSuffix(a.Name, checksumsSuffix):
+			assets.Checksums = a
+		case strings.HasSuffix(a.Name, checksumsSuffix+signatureSuffix):
+			assets.Signature = &a
+		}
+	}
+	if assets.Archive.Name == "" {
+		return nil, fmt.Errorf("release %s has no build for %s/%s", release.TagName, goos, goarch)
+	}
+	if assets.Checksums.Name == "" {
+		return nil, fmt.Errorf("release %s has no checksums, refusing to install it unverified", release.TagName)
+	}
+	return assets, nil
+}
+
+// parseChecksums reads a sha256sum style manifest of "hash  name" lines
+func parseChecksums(manifest []byte) (map[string]string, error) {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected checksums line %q", line)
+		}
+		hash, name := strings.ToLower(fields[0]), strings.TrimPrefix(fields[1], "*")
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 {
+			return nil, fmt.Errorf("invalid sha256 for %s", name)
+		}
+		sums[name] = hash
+	}
+	err := scanner.Err()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return sums, nil
+}
+
+// verifySignature checks a base64 ed25519 signature of the manifest
+func verifySignature(publicKey string, manifest []byte, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid checksums signature: %w", err)
+	}
+	if !ed25519.Verify(key, manifest, sig) {
+		return fmt.Errorf("the checksums signature does not match, refusing to install")
+	}
+	return nil
+}
+
+// normalizeVersion accepts 0.6.250 for v0.6.250, the tags have the v
+func normalizeVersion(v string) string {
+	if v == "" || strings.HasPrefix(v, "v") {
+		return v
+	}
+	return "v" + v
+}
@@ -1,33 +1,51 @@
 package upgrade
 
 import (
+	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/samber/mo"
 	"github.com/spf13/cobra"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/version"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 )
 
 var (
-	short   = "TODO"
-	long    = "TODO"
-	example = "TODO"
+	short = "Upgrade brev to the latest version"
+	long  = `Upgrade brev to the latest version, or the one given with --version.
+
+On macOS brev is upgraded with brew. On linux the release is checked against
+its published sha256 checksums before it replaces brev, which happens in a
+single rename so that an interrupted upgrade never leaves a broken binary.
+The replaced binary is kept next to it for --rollback.
+
+As root brev is installed to /usr/local/bin/brev, otherwise, or with --user,
+to ~/.local/bin/brev.`
+	example = `  brev upgrade
+  brev upgrade --version v0.6.250
+  brev upgrade --user
+  brev upgrade --rollback`
 )
 
 type upgradeStore interface {
 	GetOSUser() string
-	DownloadURL() (string, error)
-	DownloadBrevBinary(url, path string) error
+	GetLatestReleaseMetadata() (*store.GithubReleaseMetadata, error)
+	GetReleaseMetadata(tag string) (*store.GithubReleaseMetadata, error)
 }
 
 type uFunc func(ucmd upgradeCMD) error
 
 func NewCmdUpgrade(t *terminal.Terminal, store upgradeStore) *cobra.Command {
 	var debugger bool
+	var opts upgradeOptions
 	cmd := &cobra.Command{
 		Use:                   "upgrade",
 		DisableFlagsInUseLine: true,
@@ -40,15 +58,19 @@ func NewCmdUpgrade(t *terminal.Terminal, store upgradeStore) *cobra.Command {
 				args:     args,
 				store:    store,
 				debugger: debugger,
+				opts:     opts,
 			})
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
-			t.Green("Upgrade complete!")
 			return nil
 		},
 	}
 	cmd.Flags().BoolVarP(&debugger, "debugger", "d", false, "indicates command is being run in debugger") // todo remove -d
+	_ = cmd.Flags().MarkDeprecated("debugger", "use --user to upgrade without root")
+	cmd.Flags().StringVar(&opts.version, "version", "", "install this version instead of the latest, ex: v0.6.250")
+	cmd.Flags().BoolVar(&opts.rollback, "rollback", false, "go back to the version brev upgrade replaced")
+	cmd.Flags().BoolVar(&opts.user, "user", false, "install to ~/.local/bin/brev, even as root")
 	return cmd
 }
 
@@ -81,34 +103,17 @@ func runcmd(c string, args ...string) error {
 
 var upgradeFuncs = map[string]uFunc{
 	"darwin": func(ucmd upgradeCMD) error {
-		err := runcmd("brew", "upgrade", "brevdev/homebrew-brev/brev")
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-
-		return nil
-	},
-	"linux": func(ucmd upgradeCMD) error {
-		uid := ucmd.store.GetOSUser()
-		if uid != "0" && !ucmd.debugger { // root is uid 0 almost always
-			return breverrors.New("You must be root to upgrade, re run with: sudo brev upgrade")
+		if ucmd.opts.version != "" || ucmd.opts.rollback || ucmd.opts.user {
+			return breverrors.NewValidationError("brew manages brev on macOS, --version, --rollback and --user are linux only")
 		}
-		// get cli download url
-		url, err := ucmd.store.DownloadURL()
+		err := runcmd("brew", "upgrade", "brevdev/homebrew-brev/brev")
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
 		}
-		// download binary
-		err = ucmd.store.DownloadBrevBinary(
-			url,
-			"/usr/local/bin/brev",
-		)
 
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
 		return nil
 	},
+	"linux": upgradeLinux,
 }
 
 func getUpgradeFunc() uFunc {
@@ -121,6 +126,13 @@ type upgradeCMD struct {
 	args     []string
 	store    upgradeStore
 	debugger bool
+	opts     upgradeOptions
+}
+
+type upgradeOptions struct {
+	version  string
+	rollback bool
+	user     bool
 }
 
 func RunUpgrade(ucmd upgradeCMD) error {
@@ -136,3 +148,114 @@ func RunUpgrade(ucmd upgradeCMD) error {
 
 	return nil
 }
+
+func upgradeLinux(ucmd upgradeCMD) error {
+	t := ucmd.t
+	target, err := ucmd.installPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	i := installer{Path: target, client: &http.Client{Timeout: 10 * time.Minute}}
+
+	if ucmd.opts.rollback {
+		err = i.Rollback()
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		t.Vprint(t.Green("Rolled %s back to the version it replaced, run brev --version to see which", target))
+		return nil
+	}
+
+	release, err := ucmd.release()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if ucmd.opts.version == "" && release.TagName == version.Version {
+		t.Vprintf("brev %s is the latest version\n", version.Version)
+		return nil
+	}
+	assets, err := findAssets(release, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	sums, err := i.checksums(*assets)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	want, ok := sums[assets.Archive.Name]
+	if !ok {
+		return fmt.Errorf("%s is not in the checksums of release %s, refusing to install it unverified", assets.Archive.Name, release.TagName)
+	}
+
+	s := t.NewSpinner()
+	s.Suffix = fmt.Sprintf(" downloading brev %s", release.TagName)
+	s.Start()
+	err = i.Install(assets.Archive.BrowserDownloadURL, want)
+	s.Stop()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprint(t.Green("Upgraded %s to %s, brev upgrade --rollback goes back to %s", target, release.TagName, version.Version))
+	warnIfShadowed(t, target)
+	return nil
+}
+
+// installPath is /usr/local/bin/brev for root, which the install script
+// uses, and ~/.local/bin/brev for everyone else
+func (ucmd upgradeCMD) installPath() (string, error) {
+	if ucmd.store.GetOSUser() == "0" && !ucmd.opts.user { // root is uid 0 almost always
+		return systemInstallPath, nil
+	}
+	return userInstallPath()
+}
+
+func (ucmd upgradeCMD) release() (*store.GithubReleaseMetadata, error) {
+	var release *store.GithubReleaseMetadata
+	var err error
+	if ucmd.opts.version != "" {
+		release, err = ucmd.store.GetReleaseMetadata(normalizeVersion(ucmd.opts.version))
+	} else {
+		release, err = ucmd.store.GetLatestReleaseMetadata()
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return release, nil
+}
+
+// checksums downloads the checksums of a release, and verifies their
+// signature when brev was built with a ReleasePublicKey
+func (i installer) checksums(assets releaseAssets) (map[string]string, error) {
+	manifest, err := i.fetch(assets.Checksums.BrowserDownloadURL)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if ReleasePublicKey != "" {
+		if assets.Signature == nil {
+			return nil, fmt.Errorf("%s is not signed, refusing to install it", assets.Checksums.Name)
+		}
+		sig, err := i.fetch(assets.Signature.BrowserDownloadURL)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		err = verifySignature(ReleasePublicKey, manifest, sig)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+	}
+	return parseChecksums(manifest)
+}
+
+// warnIfShadowed points out when the brev on the PATH is not the one that was
+// upgraded, ex: ~/.local/bin is not on the PATH or comes after /usr/local/bin
+func warnIfShadowed(t *terminal.Terminal, target string) {
+	onPath, err := exec.LookPath("brev")
+	if err != nil {
+		t.Vprint(t.Yellow("%s is not on your PATH, add %s to it", filepath.Base(target), filepath.Dir(target)))
+		return
+	}
+	onPath, _ = filepath.Abs(onPath)
+	if onPath != target {
+		t.Vprint(t.Yellow("brev on your PATH is %s, not the upgraded %s", onPath, target))
+	}
+}
@@ -0,0 +1,135 @@
+package upgrade
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+func releaseArchive(t *testing.T, binary string) []byte {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{"README.md": "readme", "brev": binary} {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		assert.Nil(t, err)
+		_, err = tw.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gz.Close())
+	return b.Bytes()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFindAssets(t *testing.T) {
+	release := &store.GithubReleaseMetadata{TagName: "v0.6.250", Assets: []store.GithubReleaseAsset{
+		{Name: "brev-cli_0.6.250_darwin_arm64.tar.gz"},
+		{Name: "brev-cli_0.6.250_linux_amd64.tar.gz"},
+		{Name: "brev-cli_0.6.250_checksums.txt"},
+		{Name: "brev-cli_0.6.250_checksums.txt.sig"},
+	}}
+	assets, err := findAssets(release, "linux", "amd64")
+	assert.Nil(t, err)
+	assert.Equal(t, "brev-cli_0.6.250_linux_amd64.tar.gz", assets.Archive.Name)
+	assert.Equal(t, "brev-cli_0.6.250_checksums.txt", assets.Checksums.Name)
+	assert.Equal(t, "brev-cli_0.6.250_checksums.txt.sig", assets.Signature.Name)
+
+	_, err = findAssets(release, "linux", "arm64")
+	assert.Error(t, err)
+
+	release.Assets = release.Assets[:2]
+	_, err = findAssets(release, "linux", "amd64")
+	assert.Error(t, err)
+}
+
+func TestParseChecksums(t *testing.T) {
+	hash := sha256Hex([]byte("x"))
+	sums, err := parseChecksums([]byte(hash + "  brev_linux_amd64.tar.gz\n\n" + hash + " *brev_darwin_arm64.tar.gz\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"brev_linux_amd64.tar.gz": hash, "brev_darwin_arm64.tar.gz": hash}, sums)
+
+	_, err = parseChecksums([]byte("abc  brev_linux_amd64.tar.gz\n"))
+	assert.Error(t, err)
+}
+
+func TestVerifySignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	key := base64.StdEncoding.EncodeToString(public)
+	manifest := []byte("checksums")
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, manifest)))
+
+	assert.Nil(t, verifySignature(key, manifest, sig))
+	assert.Error(t, verifySignature(key, []byte("tampered"), sig))
+	assert.Error(t, verifySignature("not a key", manifest, sig))
+}
+
+func TestInstallAndRollback(t *testing.T) {
+	archive := releaseArchive(t, "new")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "bin", "brev")
+	assert.Nil(t, os.MkdirAll(filepath.Dir(target), 0o755))
+	assert.Nil(t, os.WriteFile(target, []byte("old"), 0o755))
+	i := installer{Path: target, client: server.Client()}
+
+	err := i.Install(server.URL+"/brev.tar.gz", sha256Hex([]byte("something else")))
+	assert.ErrorContains(t, err, "checksum mismatch")
+	assertFile(t, target, "old")
+	assert.NoFileExists(t, i.previousPath())
+
+	err = i.Install(server.URL+"/brev.tar.gz", sha256Hex(archive))
+	assert.Nil(t, err)
+	assertFile(t, target, "new")
+	assertFile(t, i.previousPath(), "old")
+	info, err := os.Stat(target)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	assert.Nil(t, i.Rollback())
+	assertFile(t, target, "old")
+	assertFile(t, i.previousPath(), "new")
+	assert.Nil(t, i.Rollback())
+	assertFile(t, target, "new")
+
+	entries, err := os.ReadDir(filepath.Dir(target))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2, "temporary files are cleaned up")
+}
+
+func TestRollbackWithoutPrevious(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "brev")
+	assert.Nil(t, os.WriteFile(target, []byte("old"), 0o755))
+	err := installer{Path: target}.Rollback()
+	assert.Error(t, err)
+	assertFile(t, target, "old")
+}
+
+func assertFile(t *testing.T, path string, content string) {
+	b, err := os.ReadFile(path) //nolint:gosec // test
+	assert.Nil(t, err)
+	assert.Equal(t, content, string(b))
+}
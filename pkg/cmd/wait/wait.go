@@ -0,0 +1,93 @@
+// Package wait blocks until a dev environment reaches a state
+package wait
+
+import (
+	"context"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/waiter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	waitLong = `Wait for a dev environment to reach a state.
+
+Exit codes:
+  0  the dev environment reached the state
+  1  an error occurred
+  2  timed out before the state was reached
+  3  the dev environment failed or is being deleted`
+	waitExample = `
+  brev wait my-env
+  brev wait my-env --for STOPPED
+  brev wait my-env --for HEALTHY --timeout 10m
+	`
+)
+
+type WaitStore interface {
+	completions.CompletionStore
+	util.GetWorkspaceByNameOrIDErrStore
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+}
+
+func NewCmdWait(t *terminal.Terminal, loginWaitStore WaitStore, noLoginWaitStore WaitStore) *cobra.Command {
+	var state string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "wait",
+		DisableFlagsInUseLine: true,
+		Short:                 "Wait for a dev environment to reach a state",
+		Long:                  waitLong,
+		Example:               waitExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginWaitStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunWait(t, loginWaitStore, args[0], state, timeout)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&state, "for", string(waiter.Running), "state to wait for, one of RUNNING|STOPPED|HEALTHY")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "how long to wait before giving up, 0 waits forever")
+
+	return cmd
+}
+
+func RunWait(t *terminal.Terminal, waitStore WaitStore, workspaceNameOrID string, stateFlag string, timeout time.Duration) error {
+	state, err := waiter.ParseState(stateFlag)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(waitStore, workspaceNameOrID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	workspace, err = waiter.NewWaiter(waitStore, t).
+		WithPollImmediately().
+		WithBackoff(2*time.Second, 15*time.Second).
+		WaitFor(ctx, workspace.ID, state)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf(t.Green("%s is %s\n", workspace.Name, state))
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/output"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/terminal"
@@ -17,6 +18,7 @@ type WorkspaceGroupsStore interface {
 }
 
 func NewCmdWorkspaceGroups(t *terminal.Terminal, store WorkspaceGroupsStore) *cobra.Command {
+	var outputOpts output.Options
 	cmd := &cobra.Command{
 		Use:                   "workspacegroups",
 		DisableFlagsInUseLine: true,
@@ -24,17 +26,22 @@ func NewCmdWorkspaceGroups(t *terminal.Terminal, store WorkspaceGroupsStore) *co
 		Long:                  "TODO",
 		Example:               "TODO",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := RunWorkspaceGroups(t, args, store)
+			err := RunWorkspaceGroups(t, args, store, outputOpts)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
 			return nil
 		},
 	}
+	output.AddFlags(cmd, &outputOpts)
 	return cmd
 }
 
-func RunWorkspaceGroups(_ *terminal.Terminal, _ []string, store WorkspaceGroupsStore) error {
+func RunWorkspaceGroups(_ *terminal.Terminal, _ []string, store WorkspaceGroupsStore, outputOpts output.Options) error {
+	format, err := outputOpts.Format()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 	org, err := store.GetActiveOrganizationOrDefault()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -43,16 +50,35 @@ func RunWorkspaceGroups(_ *terminal.Terminal, _ []string, store WorkspaceGroupsS
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	wsgs, err = output.Select(wsgs, outputOpts, output.WorkspaceGroupFields)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if format.IsMachineReadable() {
+		err = output.WriteList(os.Stdout, format, "WorkspaceGroupList", wsgs, func(w entity.WorkspaceGroup) string { return w.Name })
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 
 	ta := table.NewWriter()
 	ta.SetOutputMirror(os.Stdout)
 	ta.Style().Options = getBrevTableOptions()
 	header := table.Row{"NAME", "PLATFORM ID", "PLATFORM TYPE"}
+	if format == output.Wide {
+		header = table.Row{"NAME", "ID", "PLATFORM ID", "PLATFORM TYPE", "REGION", "STATUS", "VERSION"}
+	}
 	ta.AppendHeader(header)
 	for _, w := range wsgs {
 		workspaceRow := []table.Row{{
 			w.Name, w.PlatformID, w.Platform,
 		}}
+		if format == output.Wide {
+			workspaceRow = []table.Row{{
+				w.Name, w.ID, w.PlatformID, w.Platform, w.PlatformRegion, w.Status, w.Version,
+			}}
+		}
 		ta.AppendRows(workspaceRow)
 	}
 	ta.Render()
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 )
 
@@ -14,6 +15,17 @@ const (
 	defaultWorkspaceClass    EnvVarName = "DEFAULT_WORKSPACE_CLASS"
 	defaultWorkspaceTemplate EnvVarName = "DEFAULT_WORKSPACE_TEMPLATE"
 	sentryURL                EnvVarName = "DEFAULT_SENTRY_URL"
+	defaultOrg               EnvVarName = "BREV_ORG"
+	defaultInstanceType      EnvVarName = "BREV_INSTANCE_TYPE"
+	defaultSetupRepo         EnvVarName = "BREV_SETUP_REPO"
+	editor                   EnvVarName = "BREV_EDITOR"
+	outputFormat             EnvVarName = "BREV_OUTPUT"
+	CredentialStoreEnvVar    EnvVarName = "BREV_CREDENTIAL_STORE"
+)
+
+const (
+	defaultBrevAPIURL = "https://brevapi.us-west-2-prod.control-plane.brev.dev"
+	defaultEditor     = "code"
 )
 
 type ConstantsConfig struct{}
@@ -23,7 +35,7 @@ func NewConstants() *ConstantsConfig {
 }
 
 func (c ConstantsConfig) GetBrevAPIURl() string {
-	return getEnvOrDefault(brevAPIURL, "https://brevapi.us-west-2-prod.control-plane.brev.dev")
+	return getEnvOrDefault(brevAPIURL, defaultBrevAPIURL)
 }
 
 func (c ConstantsConfig) GetServiceMeshCoordServerURL() string {
@@ -51,6 +63,26 @@ func (c ConstantsConfig) GetSentryURL() string {
 	return getEnvOrDefault(sentryURL, "https://4f3dca96f17e4c7995588dda4a31b37f@o410659.ingest.sentry.io/6383105")
 }
 
+func (c ConstantsConfig) GetDefaultOrg() string {
+	return getEnvOrDefault(defaultOrg, "")
+}
+
+func (c ConstantsConfig) GetDefaultInstanceType() string {
+	return getEnvOrDefault(defaultInstanceType, "")
+}
+
+func (c ConstantsConfig) GetDefaultSetupRepo() string {
+	return getEnvOrDefault(defaultSetupRepo, "")
+}
+
+func (c ConstantsConfig) GetEditor() string {
+	return getEnvOrDefault(editor, defaultEditor)
+}
+
+func (c ConstantsConfig) GetOutputFormat() string {
+	return getEnvOrDefault(outputFormat, "")
+}
+
 func getEnvOrDefault(envVarName EnvVarName, defaultVal string) string {
 	val := os.Getenv(string(envVarName))
 	if val == "" {
@@ -59,7 +91,9 @@ func getEnvOrDefault(envVarName EnvVarName, defaultVal string) string {
 	return val
 }
 
-var GlobalConfig = NewConstants()
+// GlobalConfig is replaced with the active profile once the config file has
+// been read, see NewBrevCommand
+var GlobalConfig = NewConstants().WithEnvVars().WithFileConfig(Profile{})
 
 type EnvVarConfig struct {
 	ConstantsConfig
@@ -69,12 +103,97 @@ func (c *ConstantsConfig) WithEnvVars() *EnvVarConfig {
 	return &EnvVarConfig{*c}
 }
 
+// FileConfig resolves env > profile > built in default
 type FileConfig struct {
 	EnvVarConfig
+	profile     Profile
+	profileName string
+}
+
+func (c *EnvVarConfig) WithFileConfig(profile Profile) *FileConfig {
+	return &FileConfig{EnvVarConfig: *c, profile: profile}
+}
+
+func (c *FileConfig) WithProfileName(name string) *FileConfig {
+	c.profileName = name
+	return c
+}
+
+// GetProfileName scopes credentials and the active org, so that profiles
+// pointing at different apis don't overwrite each other's login
+func (c FileConfig) GetProfileName() string {
+	return firstNonEmpty(c.profileName, DefaultProfileName)
+}
+
+// GetDefaultOrgOverride says what pins the org, if anything does, ex: so that
+// brev set can explain why it has no effect
+func (c FileConfig) GetDefaultOrgOverride() (source string, org string) {
+	if org := os.Getenv(string(defaultOrg)); org != "" {
+		return string(defaultOrg), org
+	}
+	if c.profile.Org != "" {
+		return fmt.Sprintf("the org of profile %s", c.GetProfileName()), c.profile.Org
+	}
+	return "", ""
 }
 
-func (c *EnvVarConfig) WithFileConfig() *FileConfig {
-	return &FileConfig{*c}
+// WithProfile swaps the profile, ex: after brev login changed it
+func (c FileConfig) WithProfile(profile Profile) *FileConfig {
+	c.profile = profile
+	return &c
+}
+
+func (c FileConfig) GetBrevAPIURl() string {
+	return getEnvOrDefault(brevAPIURL, firstNonEmpty(c.profile.APIURL, defaultBrevAPIURL))
+}
+
+func (c FileConfig) GetDefaultWorkspaceClass() string {
+	return getEnvOrDefault(defaultWorkspaceClass, c.profile.WorkspaceClass)
+}
+
+func (c FileConfig) GetDefaultOrg() string {
+	return getEnvOrDefault(defaultOrg, c.profile.Org)
+}
+
+func (c FileConfig) GetDefaultInstanceType() string {
+	return getEnvOrDefault(defaultInstanceType, c.profile.InstanceType)
+}
+
+func (c FileConfig) GetDefaultSetupRepo() string {
+	return getEnvOrDefault(defaultSetupRepo, c.profile.SetupRepo)
+}
+
+func (c FileConfig) GetEditor() string {
+	return getEnvOrDefault(editor, firstNonEmpty(c.profile.Editor, defaultEditor))
+}
+
+func (c FileConfig) GetOutputFormat() string {
+	return getEnvOrDefault(outputFormat, c.profile.Output)
+}
+
+// GetCredentialStore is empty when the default for this machine should be
+// used
+func (c FileConfig) GetCredentialStore() string {
+	return getEnvOrDefault(CredentialStoreEnvVar, c.profile.CredentialStore)
+}
+
+// ResolveMachine fills in the default workspace class and instance type only
+// when neither was given as a flag, so --cpu is never paired with a profile's
+// gpu instance type
+func (c FileConfig) ResolveMachine(workspaceClass string, instanceType string) (string, string) {
+	if workspaceClass != "" || instanceType != "" {
+		return workspaceClass, instanceType
+	}
+	return c.GetDefaultWorkspaceClass(), c.GetDefaultInstanceType()
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
 }
 
 type FlagsConfig struct {
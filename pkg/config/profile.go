@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const (
+	ConfigFileName     = "config.yaml"
+	DefaultProfileName = "default"
+
+	profileEnvVar EnvVarName = "BREV_PROFILE"
+)
+
+// Profile holds defaults for one brev account or environment, ex: staging and
+// prod. Every field is optional, empty means use the built in default
+type Profile struct {
+	APIURL         string `json:"apiURL,omitempty"`
+	Org            string `json:"org,omitempty"`
+	InstanceType   string `json:"instanceType,omitempty"`
+	WorkspaceClass string `json:"workspaceClass,omitempty"`
+	SetupRepo      string `json:"setupRepo,omitempty"`
+	Editor         string `json:"editor,omitempty"`
+	Output         string `json:"output,omitempty"`
+	// CredentialStore is keyring, encrypted or plaintext, see brev login
+	// --credential-store
+	CredentialStore string `json:"credentialStore,omitempty"`
+}
+
+// ConfigFile is $HOME/.brev/config.yaml, ex:
+//
+//	currentProfile: staging
+//	profiles:
+//	  default:
+//	    workspaceClass: 4x16
+//	  staging:
+//	    apiURL: https://brevapi.staging.brev.dev
+//	    org: my-staging-org
+type ConfigFile struct {
+	CurrentProfile string             `json:"currentProfile,omitempty"`
+	Profiles       map[string]Profile `json:"profiles,omitempty"`
+}
+
+// ActiveProfileName is $BREV_PROFILE, then currentProfile, then default
+func (c ConfigFile) ActiveProfileName() string {
+	if name := os.Getenv(string(profileEnvVar)); name != "" {
+		return name
+	}
+	if c.CurrentProfile != "" {
+		return c.CurrentProfile
+	}
+	return DefaultProfileName
+}
+
+// ActiveProfile returns an empty profile when the active one is not defined,
+// so a missing config file behaves like before profiles existed
+func (c ConfigFile) ActiveProfile() Profile {
+	return c.Profiles[c.ActiveProfileName()]
+}
+
+func (c ConfigFile) ProfileNames() []string {
+	names := []string{}
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseProfile makes name the current profile, it must already exist unless it
+// is the default profile
+func (c *ConfigFile) UseProfile(name string) error {
+	if _, ok := c.Profiles[name]; !ok && name != DefaultProfileName {
+		return breverrors.NewValidationError(fmt.Sprintf("no profile named %s, create it with brev config set --profile %s <key> <value>", name, name))
+	}
+	c.CurrentProfile = name
+	return nil
+}
+
+// Set sets key on the named profile, creating the profile if needed. An empty
+// value unsets the key
+func (c *ConfigFile) Set(profileName string, key string, value string) error {
+	if c.Profiles == nil {
+		c.Profiles = map[string]Profile{}
+	}
+	p := c.Profiles[profileName]
+	field, err := p.field(key)
+	if err != nil {
+		return err
+	}
+	*field = value
+	c.Profiles[profileName] = p
+	return nil
+}
+
+// ProfileKeys are the keys accepted by brev config get and set
+var ProfileKeys = []string{"api-url", "org", "instance-type", "workspace-class", "setup-repo", "editor", "output", "credential-store"}
+
+func (p *Profile) field(key string) (*string, error) {
+	switch key {
+	case "api-url":
+		return &p.APIURL, nil
+	case "org":
+		return &p.Org, nil
+	case "instance-type":
+		return &p.InstanceType, nil
+	case "workspace-class":
+		return &p.WorkspaceClass, nil
+	case "setup-repo":
+		return &p.SetupRepo, nil
+	case "editor":
+		return &p.Editor, nil
+	case "output":
+		return &p.Output, nil
+	case "credential-store":
+		return &p.CredentialStore, nil
+	default:
+		return nil, breverrors.NewValidationError(fmt.Sprintf("unknown key %s, must be one of %s", key, strings.Join(ProfileKeys, ", ")))
+	}
+}
+
+func (p Profile) Get(key string) (string, error) {
+	field, err := p.field(key)
+	if err != nil {
+		return "", err
+	}
+	return *field, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+func TestConfigFileProfiles(t *testing.T) {
+	t.Setenv(string(profileEnvVar), "")
+	c := ConfigFile{}
+	assert.Equal(t, DefaultProfileName, c.ActiveProfileName())
+	assert.Equal(t, Profile{}, c.ActiveProfile())
+
+	assert.Nil(t, c.Set("staging", "api-url", "https://staging"))
+	assert.Nil(t, c.Set(DefaultProfileName, "workspace-class", "4x16"))
+	assert.IsType(t, breverrors.ValidationError{}, c.Set("staging", "nope", "x"))
+	assert.IsType(t, breverrors.ValidationError{}, c.UseProfile("prod"))
+
+	assert.Nil(t, c.UseProfile("staging"))
+	assert.Equal(t, "https://staging", c.ActiveProfile().APIURL)
+	assert.Equal(t, []string{"default", "staging"}, c.ProfileNames())
+
+	t.Setenv(string(profileEnvVar), DefaultProfileName)
+	v, err := c.ActiveProfile().Get("workspace-class")
+	assert.Nil(t, err)
+	assert.Equal(t, "4x16", v)
+}
+
+func TestFileConfigPrecedence(t *testing.T) {
+	t.Setenv(string(brevAPIURL), "")
+	t.Setenv(string(defaultWorkspaceClass), "")
+	t.Setenv(string(defaultInstanceType), "")
+	t.Setenv(string(editor), "")
+
+	c := NewConstants().WithEnvVars().WithFileConfig(Profile{APIURL: "https://profile", WorkspaceClass: "8x32", InstanceType: "g5.xlarge"})
+	assert.Equal(t, "https://profile", c.GetBrevAPIURl())
+	assert.Equal(t, defaultEditor, c.GetEditor())
+
+	t.Setenv(string(brevAPIURL), "https://env")
+	assert.Equal(t, "https://env", c.GetBrevAPIURl())
+
+	cpu, gpu := c.ResolveMachine("", "")
+	assert.Equal(t, "8x32", cpu)
+	assert.Equal(t, "g5.xlarge", gpu)
+	cpu, gpu = c.ResolveMachine("2x8", "")
+	assert.Equal(t, "2x8", cpu)
+	assert.Equal(t, "", gpu)
+
+	t.Setenv(string(brevAPIURL), "")
+	empty := NewConstants().WithEnvVars().WithFileConfig(Profile{})
+	assert.Equal(t, defaultBrevAPIURL, empty.GetBrevAPIURl())
+}
+
+func TestDefaultOrgOverride(t *testing.T) {
+	t.Setenv(string(defaultOrg), "")
+
+	c := NewConstants().WithEnvVars().WithFileConfig(Profile{})
+	assert.Equal(t, DefaultProfileName, c.GetProfileName())
+	source, _ := c.GetDefaultOrgOverride()
+	assert.Equal(t, "", source)
+
+	pinned := NewConstants().WithEnvVars().WithFileConfig(Profile{Org: "my-org"}).WithProfileName("staging")
+	assert.Equal(t, "staging", pinned.GetProfileName())
+	source, org := pinned.GetDefaultOrgOverride()
+	assert.Equal(t, "the org of profile staging", source)
+	assert.Equal(t, "my-org", org)
+
+	t.Setenv(string(defaultOrg), "env-org")
+	source, org = pinned.GetDefaultOrgOverride()
+	assert.Equal(t, string(defaultOrg), source)
+	assert.Equal(t, "env-org", org)
+}
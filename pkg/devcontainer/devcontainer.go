@@ -0,0 +1,229 @@
+// Package devcontainer translates a repo's devcontainer.json into brev's
+// setup: execs, env vars, ports and the IDEConfig, reporting what has no
+// equivalent
+package devcontainer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// Paths are where devcontainer.json is looked for in a repo, in order
+var Paths = []string{
+	filepath.Join(".devcontainer", "devcontainer.json"),
+	".devcontainer.json",
+}
+
+// Find returns the path of the devcontainer.json of the repo at repoPath
+func Find(repoPath string) (string, bool) {
+	for _, p := range Paths {
+		info, err := os.Stat(filepath.Join(repoPath, p))
+		if err == nil && !info.IsDir() {
+			return filepath.Join(repoPath, p), true
+		}
+	}
+	return "", false
+}
+
+// DevContainer is the part of the spec brev understands, the rest of the
+// fields are kept in Other to be reported as unsupported
+type DevContainer struct {
+	Image             string                            `json:"image,omitempty"`
+	Features          map[string]map[string]interface{} `json:"features,omitempty"`
+	OnCreateCommand   Command                           `json:"onCreateCommand,omitempty"`
+	UpdateContent     Command                           `json:"updateContentCommand,omitempty"`
+	PostCreateCommand Command                           `json:"postCreateCommand,omitempty"`
+	PostStartCommand  Command                           `json:"postStartCommand,omitempty"`
+	ForwardPorts      []interface{}                     `json:"forwardPorts,omitempty"`
+	ContainerEnv      map[string]string                 `json:"containerEnv,omitempty"`
+	RemoteEnv         map[string]string                 `json:"remoteEnv,omitempty"`
+	Customizations    map[string]json.RawMessage        `json:"customizations,omitempty"`
+	// Extensions is where extensions were before customizations.vscode
+	Extensions []string `json:"extensions,omitempty"`
+
+	Other map[string]json.RawMessage `json:"-"`
+}
+
+// Command is a lifecycle command: a shell command, the args of one, or
+// commands by name that run in parallel
+type Command struct {
+	Shell    string
+	Args     []string
+	Parallel map[string]Command
+}
+
+func (c *Command) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &c.Shell); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(b, &c.Args); err == nil {
+		return nil
+	}
+	err := json.Unmarshal(b, &c.Parallel)
+	if err != nil {
+		return fmt.Errorf("a command is a string, an array of args or an object of commands")
+	}
+	return nil
+}
+
+func (c Command) IsEmpty() bool {
+	return c.Shell == "" && len(c.Args) == 0 && len(c.Parallel) == 0
+}
+
+// script is the shell script that runs the command
+func (c Command) script() string {
+	if len(c.Args) > 0 {
+		quoted := []string{}
+		for _, a := range c.Args {
+			quoted = append(quoted, shellQuote(a))
+		}
+		return strings.Join(quoted, " ")
+	}
+	return c.Shell
+}
+
+var knownFields = map[string]bool{
+	"name": true, "$schema": true, "image": true, "features": true, "onCreateCommand": true,
+	"updateContentCommand": true, "postCreateCommand": true, "postStartCommand": true, "forwardPorts": true,
+	"containerEnv": true, "remoteEnv": true, "customizations": true, "extensions": true,
+}
+
+// Load reads and parses the devcontainer.json at path
+func Load(path string) (*DevContainer, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // the repo's devcontainer.json
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	d, err := Parse(b)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, path)
+	}
+	return d, nil
+}
+
+// Parse reads devcontainer.json, which is json with comments and trailing
+// commas
+func Parse(b []byte) (*DevContainer, error) {
+	b = standardize(b)
+	var d DevContainer
+	err := json.Unmarshal(b, &d)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid devcontainer.json: %v", err))
+	}
+	all := map[string]json.RawMessage{}
+	err = json.Unmarshal(b, &all)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid devcontainer.json: %v", err))
+	}
+	d.Other = map[string]json.RawMessage{}
+	for k, v := range all {
+		if !knownFields[k] {
+			d.Other[k] = v
+		}
+	}
+	return &d, nil
+}
+
+// standardize removes comments, then trailing commas, outside of strings
+func standardize(b []byte) []byte {
+	return removeTrailingCommas(removeComments(b))
+}
+
+func removeComments(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	inString, escaped := false, false
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if inString {
+			out = append(out, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			end := bytes.IndexByte(b[i:], '\n')
+			if end < 0 {
+				return out
+			}
+			i += end - 1
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := bytes.Index(b[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func removeTrailingCommas(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	inString, escaped := false, false
+	for i, c := range b {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		} else if c == '"' {
+			inString = true
+		} else if c == ',' {
+			next := bytes.TrimLeft(b[i+1:], " \t\r\n")
+			if len(next) > 0 && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// sortedKeys are the keys of a map with string keys, in order
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// extensionMetadata is the IDEConfig form of an extension id, ex:
+// ms-python.python
+func extensionMetadata(id string) (entity.VscodeExtensionMetadata, bool) {
+	publisher, name, ok := strings.Cut(id, ".")
+	if !ok || publisher == "" || name == "" {
+		return entity.VscodeExtensionMetadata{}, false
+	}
+	return entity.VscodeExtensionMetadata{Publisher: publisher, Name: name}, true
+}
@@ -0,0 +1,152 @@
+package devcontainer
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+const devContainerJSON = `{
+	// https://containers.dev/implementors/json_reference/
+	"name": "api",
+	"image": "mcr.microsoft.com/devcontainers/python:1-3.11-bullseye",
+	"features": {
+		"ghcr.io/devcontainers/features/node:1": {"version": "18", "nvmVersion": "0.39"},
+		"ghcr.io/devcontainers/features/common-utils:2": {},
+		"ghcr.io/devcontainers/features/aws-cli:1": {},
+	},
+	/* commands */
+	"onCreateCommand": ["pip", "install", "-r", "requirements.txt"],
+	"postCreateCommand": {
+		"web": "cd web && npm ci",
+		"db": "make db // not a comment",
+	},
+	"postStartCommand": "make serve",
+	"forwardPorts": [8000, "3000", "db:5432"],
+	"containerEnv": {
+		"PYTHONPATH": "${containerWorkspaceFolder}/src",
+		"TOKEN": "${localEnv:TOKEN}",
+	},
+	"customizations": {
+		"vscode": {
+			"extensions": ["ms-python.python", "-ms-python.black-formatter"],
+			"settings": {"editor.formatOnSave": true},
+		},
+		"codespaces": {},
+	},
+	"remoteUser": "vscode",
+	"postAttachCommand": "echo attached",
+}`
+
+func decodeExec(t *testing.T, e entity.ExecV1) string {
+	b, err := base64.StdEncoding.DecodeString(e.ExecStr)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func fields(unsupported []Unsupported) []string {
+	out := []string{}
+	for _, u := range unsupported {
+		out = append(out, u.Field)
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	d, err := Parse([]byte(devContainerJSON))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"pip", "install", "-r", "requirements.txt"}, d.OnCreateCommand.Args)
+	assert.Equal(t, "make db // not a comment", d.PostCreateCommand.Parallel["db"].Shell)
+	assert.Equal(t, "make serve", d.PostStartCommand.Shell)
+	assert.True(t, d.UpdateContent.IsEmpty())
+	assert.Equal(t, []string{"postAttachCommand", "remoteUser"}, sortedKeys(d.Other))
+
+	_, err = Parse([]byte(`{"postStartCommand": 1}`))
+	assert.Error(t, err)
+}
+
+func TestFind(t *testing.T) {
+	repo := t.TempDir()
+	_, ok := Find(repo)
+	assert.False(t, ok)
+
+	assert.Nil(t, os.WriteFile(filepath.Join(repo, ".devcontainer.json"), []byte("{}"), 0o644))
+	path, ok := Find(repo)
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(repo, ".devcontainer.json"), path)
+}
+
+func TestTranslate(t *testing.T) {
+	d, err := Parse([]byte(devContainerJSON))
+	if !assert.Nil(t, err) {
+		return
+	}
+	imported := d.Translate("/home/brev/workspace/api", "api")
+
+	assert.Equal(t, []string{"python-3.11", "node-18"}, imported.Dependencies)
+	assert.Equal(t, map[string]string{"PYTHONPATH": "/home/brev/workspace/api/src"}, imported.Env)
+	assert.Equal(t, []string{"8000", "3000"}, imported.Ports)
+	assert.Equal(t, []string{"ms-python.python"}, imported.ExtensionIDs())
+
+	assert.Len(t, imported.Execs, 5)
+	features := imported.Execs["api-devcontainer-features"]
+	assert.Equal(t, entity.BuildStage, *features.Stage)
+	assert.Contains(t, decodeExec(t, features), "#!/bin/bash")
+
+	onCreate := imported.Execs["api-devcontainer-onCreateCommand"]
+	assert.Equal(t, []entity.ExecName{"api-devcontainer-features"}, onCreate.DependsOn)
+	assert.Equal(t, "/home/brev/workspace/api", *onCreate.ExecWorkDir)
+	assert.Contains(t, decodeExec(t, onCreate), "export PYTHONPATH='/home/brev/workspace/api/src'\n'pip' 'install' '-r' 'requirements.txt'\n")
+
+	web := imported.Execs["api-devcontainer-postCreateCommand-web"]
+	assert.Equal(t, entity.BuildStage, *web.Stage)
+	assert.Equal(t, []entity.ExecName{"api-devcontainer-onCreateCommand"}, web.DependsOn)
+
+	postStart := imported.Execs["api-devcontainer-postStartCommand"]
+	assert.Equal(t, entity.StartStage, *postStart.Stage)
+	assert.Equal(t, []entity.ExecName{"api-devcontainer-postCreateCommand-db", "api-devcontainer-postCreateCommand-web"}, postStart.DependsOn)
+
+	assert.Equal(t, []string{
+		"image",
+		"features.ghcr.io/devcontainers/features/aws-cli:1",
+		"features.ghcr.io/devcontainers/features/node:1.nvmVersion",
+		"containerEnv.TOKEN",
+		"forwardPorts[2]",
+		"customizations.codespaces",
+		"customizations.vscode.settings",
+		"postAttachCommand",
+		"remoteUser",
+	}, fields(imported.Unsupported))
+}
+
+func TestTranslateSubstitutesShellEnv(t *testing.T) {
+	d, err := Parse([]byte(`{"postCreateCommand": "echo ${localEnv:HOME:/root} ${containerWorkspaceFolderBasename}"}`))
+	if !assert.Nil(t, err) {
+		return
+	}
+	imported := d.Translate("/home/ubuntu/api", "")
+	assert.Contains(t, decodeExec(t, imported.Execs["devcontainer-postCreateCommand"]), "echo ${HOME:-/root} api\n")
+	assert.Empty(t, imported.Unsupported)
+}
+
+func TestSetupScript(t *testing.T) {
+	d, err := Parse([]byte(devContainerJSON))
+	if !assert.Nil(t, err) {
+		return
+	}
+	script, imported := d.SetupScript()
+
+	assert.Contains(t, script, `export PYTHONPATH=''"${REPO_PATH}"'/src'`)
+	assert.Contains(t, script, `tee -a ~/.bashrc ~/.zshrc`)
+	assert.Less(t, strings.Index(script, "python3.11"), strings.Index(script, "set -e\n"))
+	assert.Less(t, strings.Index(script, "##### onCreateCommand #####"), strings.Index(script, "##### postStartCommand #####"))
+	assert.Contains(t, script, "make db // not a comment\ncd web && npm ci\n")
+	assert.Contains(t, fields(imported.Unsupported), "postStartCommand")
+}
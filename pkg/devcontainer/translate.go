@@ -0,0 +1,490 @@
+package devcontainer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/mergeshells" //nolint:typecheck // uses generic code
+)
+
+// Unsupported is a field of devcontainer.json with no brev equivalent,
+// Field is its json path, ex: features.ghcr.io/devcontainers/features/sshd:1
+type Unsupported struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (u Unsupported) String() string {
+	return fmt.Sprintf("%s: %s", u.Field, u.Reason)
+}
+
+// Import is a devcontainer.json translated to brev's setup
+type Import struct {
+	// Execs are the features as a build stage exec, then onCreateCommand,
+	// updateContentCommand and postCreateCommand in the build stage and
+	// postStartCommand in the start stage, each depending on the one before
+	Execs entity.ExecsV1
+	// Dependencies are the installers of the features, for mergeshells
+	Dependencies []string
+	Env          map[string]string
+	// Ports are the forwarded ports, for brev port-forward
+	Ports       []string
+	IDEConfig   entity.IDEConfig
+	Unsupported []Unsupported
+}
+
+// ExtensionIDs are the vscode extensions to install
+func (i Import) ExtensionIDs() []string {
+	ids := []string{}
+	for _, e := range i.IDEConfig.VSCode.Extensions {
+		ids = append(ids, e.GetID())
+	}
+	return ids
+}
+
+// featureDependencies are the features brev has installers for, by feature
+// id without its registry or version, ex: ghcr.io/devcontainers/features/go:1
+// is go, with the option that has the version
+var featureDependencies = map[string]struct{ dependency, versionOption string }{
+	"node":        {"node", "version"},
+	"python":      {"python", "version"},
+	"go":          {"golang", "version"},
+	"rust":        {"rust", "version"},
+	"java":        {"java", "version"},
+	"dotnet":      {"dotnet", "version"},
+	"ruby":        {"ruby", "version"},
+	"deno":        {"deno", "version"},
+	"bun":         {"bun", "version"},
+	"elixir":      {"elixir", "version"},
+	"conda":       {"conda", "version"},
+	"nvidia-cuda": {"cuda", "cudaVersion"},
+}
+
+// providedFeatures are already in every dev environment
+var providedFeatures = map[string]bool{
+	"common-utils": true, "git": true, "sshd": true, "docker-in-docker": true, "docker-outside-of-docker": true,
+}
+
+// javaOptions are the options of the java feature for gradle and maven
+var javaOptions = map[string]bool{
+	"installGradle": true, "gradleVersion": true, "installMaven": true, "mavenVersion": true,
+}
+
+// imageLanguages are the devcontainers images of a language, the language is
+// installed instead, ex: mcr.microsoft.com/devcontainers/python:3.11
+var imageLanguages = map[string]string{
+	"python": "python", "go": "golang", "rust": "rust", "java": "java", "dotnet": "dotnet",
+	"ruby": "ruby", "javascript-node": "node", "typescript-node": "node", "miniconda": "conda", "anaconda": "conda",
+}
+
+var unsupportedReasons = map[string]string{
+	"build":                       "dev environments use brev's image, add what the Dockerfile installs to .brev/setup.sh",
+	"dockerFile":                  "dev environments use brev's image, add what the Dockerfile installs to .brev/setup.sh",
+	"dockerComposeFile":           "there is no docker compose equivalent, start the services in a postStartCommand",
+	"initializeCommand":           "it runs on the local machine, which brev setup doesn't",
+	"postAttachCommand":           "there is no attach step, use postStartCommand",
+	"workspaceFolder":             "repos are cloned to the dev environment's workspace directory",
+	"remoteUser":                  "setup runs as the dev environment's user",
+	"containerUser":               "setup runs as the dev environment's user",
+	"hostRequirements":            "pick the instance type when creating the dev environment",
+	"portsAttributes":             "ports are forwarded without attributes",
+	"runArgs":                     "dev environments aren't containers brev runs",
+	"mounts":                      "dev environments aren't containers brev runs",
+	"privileged":                  "dev environments aren't containers brev runs",
+	"capAdd":                      "dev environments aren't containers brev runs",
+	"securityOpt":                 "dev environments aren't containers brev runs",
+	"overrideFeatureInstallOrder": "features are installed in brev's order",
+}
+
+// variableRe is a ${...} variable of devcontainer.json
+var variableRe = regexp.MustCompile(`\$\{([A-Za-z]+)(?::([^}]*))?\}`)
+
+// Translate turns d into brev's setup. repoPath is where the repo is cloned,
+// namePrefix keeps the exec names of several repos apart
+func (d DevContainer) Translate(repoPath string, namePrefix string) Import {
+	t := newTranslator(repoPath, filepath.Base(repoPath), namePrefix)
+	t.translate(d)
+	return t.imp
+}
+
+func newTranslator(repoPath string, repoName string, prefix string) *translator {
+	return &translator{
+		repoPath: repoPath,
+		repoName: repoName,
+		prefix:   prefix,
+		imp:      Import{Execs: entity.ExecsV1{}, Env: map[string]string{}, Ports: []string{}, Unsupported: []Unsupported{}},
+	}
+}
+
+func (t *translator) translate(d DevContainer) {
+	t.image(d.Image)
+	t.features(d.Features)
+	t.env("containerEnv", d.ContainerEnv)
+	t.env("remoteEnv", d.RemoteEnv)
+	t.lifecycle(d)
+	t.ports(d.ForwardPorts)
+	t.customizations(d.Customizations, d.Extensions)
+	for _, k := range sortedKeys(d.Other) {
+		reason, ok := unsupportedReasons[k]
+		if !ok {
+			reason = "not supported by brev"
+		}
+		t.unsupported(k, "%s", reason)
+	}
+}
+
+type translator struct {
+	repoPath string
+	repoName string
+	prefix   string
+	imp      Import
+}
+
+func (t *translator) unsupported(field string, format string, a ...interface{}) {
+	t.imp.Unsupported = append(t.imp.Unsupported, Unsupported{Field: field, Reason: fmt.Sprintf(format, a...)})
+}
+
+func (t *translator) addDependency(field string, name string, constraint string) {
+	dep, ok := mergeshells.Dependency(name, constraint)
+	if !ok {
+		t.unsupported(field, "brev has no installer for %s", name)
+		return
+	}
+	t.imp.Dependencies = append(t.imp.Dependencies, dep)
+}
+
+func (t *translator) image(image string) {
+	if image == "" {
+		return
+	}
+	name, tag, _ := strings.Cut(image[strings.LastIndex(image, "/")+1:], ":")
+	if !strings.Contains(image, "devcontainers/") {
+		t.unsupported("image", "dev environments use brev's image, add what %s has to .brev/setup.sh", image)
+		return
+	}
+	if language, ok := imageLanguages[name]; ok {
+		t.unsupported("image", "dev environments use brev's image, installing %s instead", language)
+		t.addDependency("image", language, imageTagVersion(tag))
+		return
+	}
+	if name != "base" && name != "universal" {
+		t.unsupported("image", "dev environments use brev's image, add what %s has to .brev/setup.sh", image)
+	}
+}
+
+// imageTagVersion is the language version of a devcontainers image tag, ex:
+// 1-3.11-bullseye is 3.11
+func imageTagVersion(tag string) string {
+	version := ""
+	for _, part := range strings.Split(tag, "-") {
+		if part != "" && part[0] >= '0' && part[0] <= '9' {
+			version = part
+		}
+	}
+	return version
+}
+
+func (t *translator) features(features map[string]map[string]interface{}) {
+	for _, id := range sortedKeys(features) {
+		field := "features." + id
+		name := featureName(id)
+		if providedFeatures[name] {
+			continue
+		}
+		f, ok := featureDependencies[name]
+		if !ok {
+			t.unsupported(field, "brev has no installer for this feature, add it to .brev/setup.sh")
+			continue
+		}
+		options := features[id]
+		version := fmt.Sprint(valueOr(options[f.versionOption], ""))
+		// os-provided is the system's, which dev environments already have
+		if version == "none" || version == "os-provided" {
+			continue
+		}
+		t.addDependency(field, f.dependency, version)
+		if name == "java" {
+			if install, _ := options["installGradle"].(bool); install {
+				t.addDependency(field, "gradle", fmt.Sprint(valueOr(options["gradleVersion"], "")))
+			}
+			if install, _ := options["installMaven"].(bool); install {
+				t.addDependency(field, "maven", fmt.Sprint(valueOr(options["mavenVersion"], "")))
+			}
+		}
+		for _, k := range sortedKeys(options) {
+			if k != f.versionOption && !(name == "java" && javaOptions[k]) {
+				t.unsupported(field+"."+k, "only the version of features is supported")
+			}
+		}
+	}
+}
+
+// featureName is the id of a feature without its registry or version, ex:
+// ghcr.io/devcontainers/features/node:1 is node
+func featureName(id string) string {
+	id = id[strings.LastIndex(id, "/")+1:]
+	if i := strings.IndexAny(id, ":@"); i >= 0 {
+		id = id[:i]
+	}
+	return id
+}
+
+func valueOr(v interface{}, or interface{}) interface{} {
+	if v == nil {
+		return or
+	}
+	return v
+}
+
+func (t *translator) env(field string, env map[string]string) {
+	for _, k := range sortedKeys(env) {
+		value, err := t.substitute(env[k], false)
+		if err != nil {
+			t.unsupported(field+"."+k, "%v", err)
+			continue
+		}
+		if strings.Contains(value, "'") {
+			t.unsupported(field+"."+k, "values with ' can't be set by brev")
+			continue
+		}
+		t.imp.Env[k] = value
+	}
+}
+
+// substitute replaces devcontainer.json variables. Env vars of the
+// container are shell vars in commands, and unsupported in env values since
+// those aren't expanded
+func (t *translator) substitute(s string, inShell bool) (string, error) {
+	var err error
+	out := variableRe.ReplaceAllStringFunc(s, func(v string) string {
+		m := variableRe.FindStringSubmatch(v)
+		switch m[1] {
+		case "containerWorkspaceFolder", "localWorkspaceFolder":
+			return t.repoPath
+		case "containerWorkspaceFolderBasename", "localWorkspaceFolderBasename":
+			return t.repoName
+		case "containerEnv", "localEnv":
+			if inShell {
+				name, def, ok := strings.Cut(m[2], ":")
+				if ok {
+					return "${" + name + ":-" + def + "}"
+				}
+				return "${" + name + "}"
+			}
+		}
+		err = fmt.Errorf("%s has no equivalent in a dev environment", v)
+		return v
+	})
+	return out, err
+}
+
+func (t *translator) lifecycle(d DevContainer) {
+	previous := []entity.ExecName{}
+	if len(t.imp.Dependencies) > 0 {
+		name := t.execName("features")
+		script := mergeshells.DependenciesToShell("bash", t.imp.Dependencies...)
+		t.addExec(name, entity.BuildStage, script, previous)
+		previous = []entity.ExecName{name}
+	}
+	for _, c := range []struct {
+		field   string
+		command Command
+		stage   entity.ExecStage
+	}{
+		{"onCreateCommand", d.OnCreateCommand, entity.BuildStage},
+		{"updateContentCommand", d.UpdateContent, entity.BuildStage},
+		{"postCreateCommand", d.PostCreateCommand, entity.BuildStage},
+		{"postStartCommand", d.PostStartCommand, entity.StartStage},
+	} {
+		if c.command.IsEmpty() {
+			continue
+		}
+		commands := map[string]Command{"": c.command}
+		if len(c.command.Parallel) > 0 {
+			commands = c.command.Parallel
+		}
+		names := []entity.ExecName{}
+		for _, k := range sortedKeys(commands) {
+			name := t.execName(strings.Trim(c.field+"-"+k, "-"))
+			script, err := t.substitute(commands[k].script(), true)
+			if err != nil {
+				t.unsupported(c.field, "%v", err)
+				continue
+			}
+			t.addExec(name, c.stage, "#!/bin/bash\nset -e\n"+t.exports()+script+"\n", previous)
+			names = append(names, name)
+		}
+		if len(names) > 0 {
+			previous = names
+		}
+	}
+}
+
+func (t *translator) execName(name string) entity.ExecName {
+	if t.prefix == "" {
+		return entity.ExecName("devcontainer-" + name)
+	}
+	return entity.ExecName(t.prefix + "-devcontainer-" + name)
+}
+
+func (t *translator) addExec(name entity.ExecName, stage entity.ExecStage, script string, dependsOn []entity.ExecName) {
+	workDir := t.repoPath
+	t.imp.Execs[name] = entity.ExecV1{
+		Type:  entity.StringExecType,
+		Stage: &stage,
+		ExecOptions: entity.ExecOptions{
+			ExecWorkDir: &workDir,
+			DependsOn:   append([]entity.ExecName{}, dependsOn...),
+		},
+		// base64 so that the script is never mistaken for it
+		StringExec: entity.StringExec{ExecStr: base64.StdEncoding.EncodeToString([]byte(script))},
+	}
+}
+
+// exports sets the env of the devcontainer for the commands, env files are
+// only read by interactive shells
+func (t *translator) exports() string {
+	out := ""
+	for _, k := range sortedKeys(t.imp.Env) {
+		out += fmt.Sprintf("export %s='%s'\n", k, t.imp.Env[k])
+	}
+	return out
+}
+
+// Ports are the forwarded ports, without translating the rest
+func (d DevContainer) Ports() []string {
+	t := &translator{}
+	t.ports(d.ForwardPorts)
+	return t.imp.Ports
+}
+
+func (t *translator) ports(ports []interface{}) {
+	for i, p := range ports {
+		switch port := p.(type) {
+		case float64:
+			t.imp.Ports = append(t.imp.Ports, strconv.Itoa(int(port)))
+		case string:
+			if _, err := strconv.Atoi(port); err == nil {
+				t.imp.Ports = append(t.imp.Ports, port)
+				continue
+			}
+			t.unsupported(fmt.Sprintf("forwardPorts[%d]", i), "%s is the port of another container, only ports of the dev environment can be forwarded", port)
+		default:
+			t.unsupported(fmt.Sprintf("forwardPorts[%d]", i), "a port is a number or host:port")
+		}
+	}
+}
+
+func (t *translator) customizations(customizations map[string]json.RawMessage, legacyExtensions []string) {
+	extensions := legacyExtensions
+	for _, tool := range sortedKeys(customizations) {
+		if tool != "vscode" {
+			t.unsupported("customizations."+tool, "only vscode customizations are supported")
+			continue
+		}
+		var vscode map[string]json.RawMessage
+		err := json.Unmarshal(customizations[tool], &vscode)
+		if err != nil {
+			t.unsupported("customizations.vscode", "not an object")
+			continue
+		}
+		for _, k := range sortedKeys(vscode) {
+			if k != "extensions" {
+				t.unsupported("customizations.vscode."+k, "only extensions are supported")
+				continue
+			}
+			var ids []string
+			err := json.Unmarshal(vscode[k], &ids)
+			if err != nil {
+				t.unsupported("customizations.vscode.extensions", "not a list of extension ids")
+				continue
+			}
+			extensions = append(extensions, ids...)
+		}
+	}
+	for _, id := range extensions {
+		// -id removes an extension a feature would install
+		if strings.HasPrefix(id, "-") {
+			continue
+		}
+		e, ok := extensionMetadata(strings.SplitN(id, "@", 2)[0])
+		if !ok {
+			t.unsupported("customizations.vscode.extensions", "%s is not a publisher.name extension id", id)
+			continue
+		}
+		t.imp.IDEConfig.VSCode.Extensions = append(t.imp.IDEConfig.VSCode.Extensions, e)
+	}
+}
+
+// setup.sh runs in the repo, which is cloned somewhere else than where brev
+// init runs
+const (
+	setupRepoPath = "${REPO_PATH}"
+	setupRepoName = "${REPO_NAME}"
+)
+
+// SetupScript is .brev/setup.sh for brev init: the features, the env and
+// the lifecycle commands in order, with the rest of the translation.
+// postStartCommand only runs once there, so it is reported
+func (d DevContainer) SetupScript() (string, Import) {
+	t := newTranslator(setupRepoPath, setupRepoName, "")
+	t.translate(d)
+	lines := []string{"#!/bin/bash", "", `REPO_PATH="$(pwd)"`, `REPO_NAME="$(basename "${REPO_PATH}")"`, ""}
+	if len(t.imp.Dependencies) > 0 {
+		lines = append(lines, mergeshells.MergeShells(t.imp.Dependencies...), "")
+	}
+	// the installers are written to keep going, the devcontainer.json's
+	// commands stop at the first failure
+	lines = append(lines, "set -e", `cd "${REPO_PATH}"`, "")
+	for _, k := range sortedKeys(t.imp.Env) {
+		lines = append(lines,
+			fmt.Sprintf("export %s=%s", k, setupValue(t.imp.Env[k])),
+			fmt.Sprintf(`echo "export %[1]s=$(printf '%%q' "$%[1]s")" | tee -a ~/.bashrc ~/.zshrc`, k),
+		)
+	}
+	for _, c := range []struct {
+		field   string
+		command Command
+	}{
+		{"onCreateCommand", d.OnCreateCommand},
+		{"updateContentCommand", d.UpdateContent},
+		{"postCreateCommand", d.PostCreateCommand},
+		{"postStartCommand", d.PostStartCommand},
+	} {
+		if c.command.IsEmpty() {
+			continue
+		}
+		commands := map[string]Command{"": c.command}
+		if len(c.command.Parallel) > 0 {
+			commands = c.command.Parallel
+		}
+		lines = append(lines, "", "##### "+c.field+" #####")
+		for _, k := range sortedKeys(commands) {
+			// already reported by translate when it fails
+			script, err := t.substitute(commands[k].script(), true)
+			if err == nil {
+				lines = append(lines, script)
+			}
+		}
+	}
+	if !d.PostStartCommand.IsEmpty() {
+		t.unsupported("postStartCommand", "runs once in .brev/setup.sh, add it as a start stage exec to run it on every start")
+	}
+	return strings.Join(lines, "\n") + "\n", t.imp
+}
+
+// setupValue quotes v for setup.sh, leaving its repo variables unquoted so
+// that they expand
+func setupValue(v string) string {
+	quoted := shellQuote(v)
+	for _, name := range []string{setupRepoPath, setupRepoName} {
+		quoted = strings.ReplaceAll(quoted, name, `'"`+name+`"'`)
+	}
+	return quoted
+}
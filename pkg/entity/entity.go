@@ -118,8 +118,26 @@ type (
 		LogPath        *string    `json:"logPath"`
 		LogArchivePath *string    `json:"logArchivePath"`
 		DependsOn      []ExecName `json:"dependsOn"`
+		// Supervise keeps a start stage exec running after setup, ex: a dev
+		// server, instead of running it once
+		Supervise *ExecSupervision `json:"supervise,omitempty"`
+	}
+	ExecsV1         map[ExecName]ExecV1
+	ExecSupervision struct {
+		RestartPolicy  string     `json:"restartPolicy,omitempty"` // never, on-failure, always // default=on-failure
+		ReadinessProbe *ExecProbe `json:"readinessProbe,omitempty"`
+	}
+	// ExecProbe is one of HTTPGet, TCPSocket or Exec
+	ExecProbe struct {
+		HTTPGet          string `json:"httpGet,omitempty"`          // url, ready below 400
+		TCPSocket        string `json:"tcpSocket,omitempty"`        // host:port
+		Exec             string `json:"exec,omitempty"`             // ready when it exits 0
+		PeriodSeconds    int    `json:"periodSeconds,omitempty"`    // default=5
+		TimeoutSeconds   int    `json:"timeoutSeconds,omitempty"`   // default=2
+		FailureThreshold int    `json:"failureThreshold,omitempty"` // default=3
+		// ReadyTimeoutSeconds is how long setup waits for the exec to be ready
+		ReadyTimeoutSeconds int `json:"readyTimeoutSeconds,omitempty"` // default=300
 	}
-	ExecsV1 map[ExecName]ExecV1
 )
 
 type ExecType string
@@ -307,12 +325,20 @@ type Workspace struct {
 	SSHPort           int       `json:"sshPort"`
 	// PrimaryApplicationId         string `json:"primaryApplicationId,omitempty"`
 	// LastOnlineAt         string `json:"lastOnlineAt,omitempty"`
-	// CreatedAt         string `json:"createdAt,omitempty"`
 	// UpdatedAt         string `json:"updatedAt,omitempty"`
 	HealthStatus  string        `json:"healthStatus"`
 	IsStoppable   bool          `json:"isStoppable"` // used for autopstop only
 	StatusMessage string        `json:"statusMessage"`
 	StopTimeout   time.Duration `json:"stopTimeout"`
+	CreatedAt     string        `json:"createdAt,omitempty"` // RFC3339
+}
+
+func (w Workspace) GetCreatedAt() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, w.CreatedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid createdAt %q on %s: %w", w.CreatedAt, w.Name, err)
+	}
+	return t, nil
 }
 
 func (w Workspace) GetStopTimeout() time.Duration {
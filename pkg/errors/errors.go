@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/version"
-	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/featureflag"
 	"github.com/getsentry/sentry-go"
 	"github.com/pkg/errors"
@@ -40,6 +39,10 @@ func GetDefaultErrorReporter() ErrorReporter {
 	return SentryErrorReporter{}
 }
 
+// SentryURL is where errors are reported, set by main from config, which
+// can't be imported here since it returns validation errors
+var SentryURL string
+
 type SentryErrorReporter struct{}
 
 var _ ErrorReporter = SentryErrorReporter{}
@@ -47,7 +50,7 @@ var _ ErrorReporter = SentryErrorReporter{}
 func (s SentryErrorReporter) Setup() func() {
 	if !featureflag.IsDev() {
 		err := sentry.Init(sentry.ClientOptions{
-			Dsn:     config.GlobalConfig.GetSentryURL(),
+			Dsn:     SentryURL,
 			Release: version.Version,
 		})
 		if err != nil {
@@ -173,6 +176,21 @@ func (e WorkspaceNotRunning) Error() string {
 	return fmt.Sprintf("workspace status %s is not RUNNING", e.Status)
 }
 
+// ExitCodeError lets an error choose the exit code of the cli so that scripts
+// can branch on why a command failed
+type ExitCodeError interface {
+	error
+	ExitCode() int
+}
+
+func GetExitCode(err error) int {
+	var exitCodeErr ExitCodeError
+	if errors.As(err, &exitCodeErr) {
+		return exitCodeErr.ExitCode()
+	}
+	return 1
+}
+
 func New(message string) error { // TODO: maybe this isn't the right type of error - it is from dev-plane so probably not relevant to the cli
 	return errors.New(message)
 }
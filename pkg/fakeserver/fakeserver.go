@@ -0,0 +1,725 @@
+// Package fakeserver is an in-memory stand-in for the Brev API that serves the
+// endpoints AuthHTTPStore calls, so the CLI can run with no network access
+package fakeserver
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/uri"
+)
+
+const (
+	DefaultTransitionDelay = 2 * time.Second
+	DefaultWorkspaceGroup  = entity.WorkspaceGroupDevPlane
+	tokenSigningKey        = "brev-fake-server"
+)
+
+// transition is a status change that happens once its time has passed
+type transition struct {
+	status       string
+	healthStatus string
+	at           time.Time
+	remove       bool
+}
+
+type workspaceRecord struct {
+	workspace entity.Workspace
+	pending   *transition
+}
+
+type Server struct {
+	mu sync.Mutex
+
+	router          *gin.Engine
+	transitionDelay time.Duration
+	now             func() time.Time
+	nextID          int
+
+	currentUserID   string
+	users           map[string]*entity.User
+	orgs            []*entity.Organization
+	workspaces      map[string]*workspaceRecord
+	workspaceGroups []entity.WorkspaceGroup
+	secrets         []*secretRecord
+}
+
+// secretRecord keeps the value, which the API never sends back
+type secretRecord struct {
+	secret store.Secret
+	value  string
+}
+
+// NewServer makes a server seeded with one user, one org and one workspace group
+func NewServer() *Server {
+	s := &Server{
+		transitionDelay: DefaultTransitionDelay,
+		now:             time.Now,
+		users:           map[string]*entity.User{},
+		workspaces:      map[string]*workspaceRecord{},
+	}
+	user := s.AddUser(entity.User{
+		Username:       "fake-user",
+		Name:           "Fake User",
+		Email:          "fake-user@example.com",
+		GlobalUserType: entity.Standard,
+	})
+	s.currentUserID = user.ID
+	s.AddOrganization(entity.Organization{Name: "fake-org"})
+	s.workspaceGroups = []entity.WorkspaceGroup{{
+		ID:       DefaultWorkspaceGroup,
+		Name:     DefaultWorkspaceGroup,
+		BaseDNS:  "brev.sh",
+		Status:   "READY",
+		Platform: "fake",
+	}}
+	s.router = s.makeRouter()
+	return s
+}
+
+// WithTransitionDelay sets how long workspaces stay in transitional states
+// such as DEPLOYING or STOPPING, 0 makes transitions happen on the next read
+func (s *Server) WithTransitionDelay(delay time.Duration) *Server {
+	s.transitionDelay = delay
+	return s
+}
+
+func (s *Server) WithClock(now func() time.Time) *Server {
+	s.now = now
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// Token returns an access token that the CLI accepts with brev login --token
+func (s *Server) Token() (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": s.currentUserID})
+	signed, err := token.SignedString([]byte(tokenSigningKey))
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return signed, nil
+}
+
+func (s *Server) CurrentUser() entity.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.users[s.currentUserID]
+}
+
+func (s *Server) AddUser(user entity.User) entity.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user.ID == "" {
+		user.ID = s.makeID()
+	}
+	s.users[user.ID] = &user
+	return user
+}
+
+func (s *Server) AddOrganization(org entity.Organization) entity.Organization {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if org.ID == "" {
+		org.ID = s.makeID()
+	}
+	s.orgs = append(s.orgs, &org)
+	return org
+}
+
+// AddWorkspace seeds a workspace as is, defaulting the fields the API would set
+func (s *Server) AddWorkspace(workspace entity.Workspace) entity.Workspace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addWorkspace(workspace).workspace
+}
+
+// addWorkspace caller must hold the lock
+func (s *Server) addWorkspace(workspace entity.Workspace) *workspaceRecord {
+	if workspace.ID == "" {
+		workspace.ID = s.makeID()
+	}
+	if workspace.Status == "" {
+		workspace.Status = entity.Running
+	}
+	if workspace.CreatedByUserID == "" {
+		workspace.CreatedByUserID = s.currentUserID
+	}
+	if workspace.WorkspaceGroupID == "" {
+		workspace.WorkspaceGroupID = DefaultWorkspaceGroup
+	}
+	if workspace.DNS == "" {
+		workspace.DNS = fmt.Sprintf("%s.brev.sh", workspace.ID)
+	}
+	if workspace.CreatedAt == "" {
+		workspace.CreatedAt = s.now().UTC().Format(time.RFC3339)
+	}
+	r := &workspaceRecord{workspace: workspace}
+	s.workspaces[workspace.ID] = r
+	return r
+}
+
+// Secrets returns every secret with its value, for tests to check what was
+// stored
+func (s *Server) Secrets() []store.CreateSecretRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets := []store.CreateSecretRequest{}
+	for _, r := range s.secrets {
+		secrets = append(secrets, store.CreateSecretRequest{
+			Name:          r.secret.Name,
+			HierarchyType: r.secret.HierarchyType,
+			HierarchyID:   r.secret.HierarchyID,
+			Src:           store.SecretReqSrc{Type: store.KeyValue, Config: store.SrcConfig{Value: r.value}},
+			Dest:          r.secret.Dest,
+		})
+	}
+	return secrets
+}
+
+// makeID returns a 9 char id like the API's, caller must hold the lock
+func (s *Server) makeID() string {
+	s.nextID++
+	return fmt.Sprintf("fake%05d", s.nextID)
+}
+
+func (s *Server) makeRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	r.GET("/api/health", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
+	r.POST("/api/users", s.createUser)
+
+	authed := r.Group("/api", s.requireAuth)
+	authed.GET("/me", s.getMe)
+	authed.GET("/me/keys", s.getMeKeys)
+	authed.GET("/users", s.getUsers)
+	authed.PUT("/users/:id", s.updateUser)
+	authed.POST("/users/:id/approve", s.approveUser)
+
+	authed.GET("/organizations", s.getOrganizations)
+	authed.POST("/organizations", s.createOrganization)
+	authed.GET("/organizations/:id", s.getOrganization)
+	authed.GET("/organizations/:id/invite", s.getInvite)
+	authed.GET("/organizations/:id/workspaces", s.getWorkspaces)
+	authed.POST("/organizations/:id/workspaces", s.createWorkspace)
+	authed.GET("/workspace_groups", s.getWorkspaceGroups)
+
+	authed.GET("/workspaces/:id", s.getWorkspace)
+	authed.PUT("/workspaces/:id", s.modifyWorkspace)
+	authed.DELETE("/workspaces/:id", s.deleteWorkspace)
+	authed.PUT("/workspaces/:id/start", s.startWorkspace)
+	authed.PUT("/workspaces/:id/stop", s.stopWorkspace)
+	authed.PUT("/workspaces/:id/reset", s.resetWorkspace)
+	authed.GET("/workspaces/:id/setup", s.getSetupParams)
+	authed.GET("/workspaces/:id/metadata", s.getWorkspaceMetadata)
+
+	authed.GET("/secrets", s.getSecrets)
+	authed.POST("/secrets", s.createSecret)
+	authed.PUT("/secrets/:id", s.updateSecret)
+	authed.DELETE("/secrets/:id", s.deleteSecret)
+	return r
+}
+
+func (s *Server) requireAuth(c *gin.Context) {
+	if !strings.HasPrefix(c.GetHeader("Authorization"), "Bearer ") {
+		abortWithError(c, http.StatusUnauthorized, "missing bearer token")
+		return
+	}
+	c.Next()
+}
+
+func abortWithError(c *gin.Context, code int, message string) {
+	c.AbortWithStatusJSON(code, gin.H{"message": message})
+}
+
+func (s *Server) createUser(c *gin.Context) {
+	if c.GetHeader("Identity") == "" {
+		abortWithError(c, http.StatusBadRequest, "missing identity token")
+		return
+	}
+	user := s.CurrentUser()
+	c.JSON(http.StatusOK, store.UserCreateResponse{User: user})
+}
+
+func (s *Server) getMe(c *gin.Context) {
+	c.JSON(http.StatusOK, s.CurrentUser())
+}
+
+func (s *Server) getMeKeys(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := entity.UserKeys{
+		PrivateKey: "fake-private-key",
+		PublicKey:  "ssh-ed25519 AAAAfake fake-user@example.com",
+	}
+	for _, wg := range s.workspaceGroups {
+		keys.WorkspaceGroups = append(keys.WorkspaceGroups, entity.WorkspaceGroupKeys{GroupID: wg.ID})
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+func (s *Server) getUsers(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := []entity.User{}
+	for _, u := range s.users {
+		if email := c.Query("email"); email != "" && u.Email != email {
+			continue
+		}
+		users = append(users, *u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	c.JSON(http.StatusOK, users)
+}
+
+func (s *Server) updateUser(c *gin.Context) {
+	var req entity.UpdateUser
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[c.Param("id")]
+	if !ok {
+		abortWithError(c, http.StatusNotFound, "user not found")
+		return
+	}
+	if req.Username != "" {
+		user.Username = req.Username
+	}
+	if req.Name != "" {
+		user.Name = req.Name
+	}
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+	if req.BaseWorkspaceRepo != "" {
+		user.BaseWorkspaceRepo = req.BaseWorkspaceRepo
+	}
+	if req.OnboardingData != nil {
+		user.OnboardingData = req.OnboardingData
+	}
+	c.JSON(http.StatusOK, *user)
+}
+
+func (s *Server) approveUser(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[c.Param("id")]
+	if !ok {
+		abortWithError(c, http.StatusNotFound, "user not found")
+		return
+	}
+	c.JSON(http.StatusOK, *user)
+}
+
+func (s *Server) getOrganizations(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orgs := []entity.Organization{}
+	for _, o := range s.orgs {
+		orgs = append(orgs, *o)
+	}
+	c.JSON(http.StatusOK, orgs)
+}
+
+func (s *Server) createOrganization(c *gin.Context) {
+	var req store.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == "" {
+		abortWithError(c, http.StatusBadRequest, "name is required")
+		return
+	}
+	org := s.AddOrganization(entity.Organization{Name: req.Name})
+	c.JSON(http.StatusOK, org)
+}
+
+// findOrg caller must hold the lock
+func (s *Server) findOrg(id string) *entity.Organization {
+	for _, o := range s.orgs {
+		if o.ID == id {
+			return o
+		}
+	}
+	return nil
+}
+
+func (s *Server) getOrganization(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	org := s.findOrg(c.Param("id"))
+	if org == nil {
+		abortWithError(c, http.StatusNotFound, "organization not found")
+		return
+	}
+	c.JSON(http.StatusOK, *org)
+}
+
+func (s *Server) getInvite(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findOrg(c.Param("id")) == nil {
+		abortWithError(c, http.StatusNotFound, "organization not found")
+		return
+	}
+	c.JSON(http.StatusOK, fmt.Sprintf("fake-invite-%s", c.Param("id")))
+}
+
+func (s *Server) getWorkspaceGroups(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.JSON(http.StatusOK, s.workspaceGroups)
+}
+
+// advance applies transitions that are due, caller must hold the lock
+func (s *Server) advance() {
+	now := s.now()
+	for id, r := range s.workspaces {
+		if r.pending == nil || now.Before(r.pending.at) {
+			continue
+		}
+		if r.pending.remove {
+			delete(s.workspaces, id)
+			continue
+		}
+		r.workspace.Status = r.pending.status
+		r.workspace.HealthStatus = r.pending.healthStatus
+		r.pending = nil
+	}
+}
+
+// transitionTo sets an intermediate status that becomes final after the
+// transition delay, caller must hold the lock
+func (s *Server) transitionTo(r *workspaceRecord, intermediate string, final string) {
+	r.workspace.Status = intermediate
+	r.workspace.HealthStatus = entity.Unavailable
+	health := entity.Unavailable
+	if final == entity.Running {
+		health = entity.Healthy
+	}
+	r.pending = &transition{
+		status:       final,
+		healthStatus: health,
+		at:           s.now().Add(s.transitionDelay),
+	}
+}
+
+// getRecord advances state and looks up a workspace, writing a 404 if it is
+// missing, caller must hold the lock
+func (s *Server) getRecord(c *gin.Context) *workspaceRecord {
+	s.advance()
+	r, ok := s.workspaces[c.Param("id")]
+	if !ok {
+		abortWithError(c, http.StatusNotFound, "workspace not found")
+		return nil
+	}
+	return r
+}
+
+func (s *Server) getWorkspaces(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findOrg(c.Param("id")) == nil {
+		abortWithError(c, http.StatusNotFound, "organization not found")
+		return
+	}
+	s.advance()
+	workspaces := []entity.Workspace{}
+	for _, r := range s.workspaces {
+		if r.workspace.OrganizationID == c.Param("id") {
+			workspaces = append(workspaces, r.workspace)
+		}
+	}
+	sort.Slice(workspaces, func(i, j int) bool { return workspaces[i].ID < workspaces[j].ID })
+	c.JSON(http.StatusOK, workspaces)
+}
+
+func (s *Server) createWorkspace(c *gin.Context) {
+	var req store.CreateWorkspacesOptions
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Name == "" {
+		abortWithError(c, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findOrg(c.Param("id")) == nil {
+		abortWithError(c, http.StatusNotFound, "organization not found")
+		return
+	}
+
+	workspace := entity.Workspace{
+		Name:              req.Name,
+		WorkspaceGroupID:  req.WorkspaceGroupID,
+		OrganizationID:    c.Param("id"),
+		WorkspaceClassID:  req.WorkspaceClassID,
+		InstanceType:      req.InstanceType,
+		GitRepo:           req.GitRepo,
+		WorkspaceTemplate: entity.WorkspaceTemplate{ID: req.WorkspaceTemplateID},
+		StartupScriptPath: req.StartupScriptPath,
+		ReposV0:           req.Repos,
+		ExecsV0:           req.Execs,
+	}
+	if req.IsStoppable != nil {
+		workspace.IsStoppable = *req.IsStoppable
+	}
+	if req.IDEConfig != nil {
+		workspace.IDEConfig = *req.IDEConfig
+	}
+	r := s.addWorkspace(workspace)
+	s.transitionTo(r, entity.Deploying, entity.Running)
+	c.JSON(http.StatusOK, r.workspace)
+}
+
+func (s *Server) getWorkspace(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.getRecord(c)
+	if r == nil {
+		return
+	}
+	c.JSON(http.StatusOK, r.workspace)
+}
+
+func (s *Server) modifyWorkspace(c *gin.Context) {
+	var req store.ModifyWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.getRecord(c)
+	if r == nil {
+		return
+	}
+	w := &r.workspace
+	if req.Name != "" {
+		w.Name = req.Name
+	}
+	if req.WorkspaceClassID != "" {
+		w.WorkspaceClassID = req.WorkspaceClassID
+	}
+	if req.InstanceType != "" {
+		w.InstanceType = req.InstanceType
+	}
+	if req.IsStoppable != nil {
+		w.IsStoppable = *req.IsStoppable
+	}
+	if req.StartupScriptPath != "" {
+		w.StartupScriptPath = req.StartupScriptPath
+	}
+	if req.IDEConfig != nil {
+		w.IDEConfig = *req.IDEConfig
+	}
+	if req.Repos != nil {
+		w.ReposV0 = req.Repos
+	}
+	if req.Execs != nil {
+		w.ExecsV0 = req.Execs
+	}
+	if req.ReposV1 != nil {
+		w.ReposV1 = req.ReposV1
+	}
+	if req.ExecsV1 != nil {
+		w.ExecsV1 = req.ExecsV1
+	}
+	c.JSON(http.StatusOK, *w)
+}
+
+func (s *Server) deleteWorkspace(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.getRecord(c)
+	if r == nil {
+		return
+	}
+	r.workspace.Status = entity.Deleting
+	r.workspace.HealthStatus = entity.Unavailable
+	r.pending = &transition{at: s.now().Add(s.transitionDelay), remove: true}
+	c.JSON(http.StatusOK, r.workspace)
+}
+
+func (s *Server) startWorkspace(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.getRecord(c)
+	if r == nil {
+		return
+	}
+	if r.workspace.Status != entity.Stopped {
+		abortWithError(c, http.StatusBadRequest, fmt.Sprintf("workspace is %s, only STOPPED workspaces can be started", r.workspace.Status))
+		return
+	}
+	s.transitionTo(r, entity.Starting, entity.Running)
+	c.JSON(http.StatusOK, r.workspace)
+}
+
+func (s *Server) stopWorkspace(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.getRecord(c)
+	if r == nil {
+		return
+	}
+	if r.workspace.Status != entity.Running {
+		abortWithError(c, http.StatusBadRequest, fmt.Sprintf("workspace is %s, only RUNNING workspaces can be stopped", r.workspace.Status))
+		return
+	}
+	if c.Query("autoStop") == "true" && !r.workspace.IsStoppable {
+		abortWithError(c, http.StatusBadRequest, "workspace is not stoppable")
+		return
+	}
+	s.transitionTo(r, entity.Stopping, entity.Stopped)
+	c.JSON(http.StatusOK, r.workspace)
+}
+
+func (s *Server) resetWorkspace(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.getRecord(c)
+	if r == nil {
+		return
+	}
+	if r.workspace.Status != entity.Running {
+		abortWithError(c, http.StatusBadRequest, fmt.Sprintf("workspace is %s, only RUNNING workspaces can be reset", r.workspace.Status))
+		return
+	}
+	s.transitionTo(r, entity.Deploying, entity.Running)
+	c.JSON(http.StatusOK, r.workspace)
+}
+
+func (s *Server) getSetupParams(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.getRecord(c)
+	if r == nil {
+		return
+	}
+	user := s.users[r.workspace.CreatedByUserID]
+	if user == nil {
+		user = s.users[s.currentUserID]
+	}
+	w := r.workspace
+	c.JSON(http.StatusOK, store.SetupParamsV0{
+		WorkspaceHost:        uri.Host(w.DNS),
+		WorkspacePort:        w.GetPort(),
+		WorkspaceBaseRepo:    user.BaseWorkspaceRepo,
+		WorkspaceProjectRepo: w.GitRepo,
+		WorkspaceUsername:    user.Username,
+		WorkspaceEmail:       user.Email,
+		WorkspacePassword:    w.Password,
+		WorkspaceKeyPair: &store.KeyPair{
+			PublicKeyData:  "ssh-ed25519 AAAAfake fake-user@example.com",
+			PrivateKeyData: "fake-private-key",
+		},
+		ProjectFolderName:    entity.GetDefaultProjectFolderNameFromRepo(w.GitRepo),
+		ProjectSetupExecPath: w.StartupScriptPath,
+		ReposV0:              w.ReposV0,
+		ExecsV0:              w.ExecsV0,
+	})
+}
+
+func (s *Server) getWorkspaceMetadata(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.getRecord(c)
+	if r == nil {
+		return
+	}
+	c.JSON(http.StatusOK, entity.WorkspaceMetaData{
+		PodName:       fmt.Sprintf("%s-pod", r.workspace.ID),
+		NamespaceName: r.workspace.OrganizationID,
+	})
+}
+
+func (s *Server) getSecrets(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets := []store.Secret{}
+	for _, r := range s.secrets {
+		if string(r.secret.HierarchyType) == c.Query("hierarchyType") && r.secret.HierarchyID == c.Query("hierarchyID") {
+			secrets = append(secrets, r.secret)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	c.JSON(http.StatusOK, secrets)
+}
+
+func (s *Server) createSecret(c *gin.Context) {
+	var req store.CreateSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == "" {
+		abortWithError(c, http.StatusBadRequest, "name is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.secrets {
+		if r.secret.Name == req.Name && r.secret.HierarchyType == req.HierarchyType && r.secret.HierarchyID == req.HierarchyID {
+			abortWithError(c, http.StatusConflict, "secret already exists")
+			return
+		}
+	}
+	s.secrets = append(s.secrets, &secretRecord{
+		secret: store.Secret{
+			ID:            s.makeID(),
+			Name:          req.Name,
+			HierarchyType: req.HierarchyType,
+			HierarchyID:   req.HierarchyID,
+			Dest:          req.Dest,
+			UpdatedAt:     s.now().UTC().Format(time.RFC3339),
+		},
+		value: req.Src.Config.Value,
+	})
+	c.JSON(http.StatusOK, req)
+}
+
+func (s *Server) updateSecret(c *gin.Context) {
+	var req store.CreateSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.secrets {
+		if r.secret.ID == c.Param("id") {
+			r.secret.Dest = req.Dest
+			r.secret.UpdatedAt = s.now().UTC().Format(time.RFC3339)
+			r.value = req.Src.Config.Value
+			c.JSON(http.StatusOK, r.secret)
+			return
+		}
+	}
+	abortWithError(c, http.StatusNotFound, "secret not found")
+}
+
+func (s *Server) deleteSecret(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range s.secrets {
+		if r.secret.ID == c.Param("id") {
+			s.secrets = append(s.secrets[:i], s.secrets[i+1:]...)
+			c.JSON(http.StatusOK, r.secret)
+			return
+		}
+	}
+	abortWithError(c, http.StatusNotFound, "secret not found")
+}
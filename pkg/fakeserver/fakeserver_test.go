@@ -0,0 +1,199 @@
+package fakeserver
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+type staticAuth struct{}
+
+func (staticAuth) GetAccessToken() (string, error) {
+	return "fake-token", nil
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestStore(t *testing.T) (*Server, *store.AuthHTTPStore, *fakeClock) {
+	gin.SetMode(gin.TestMode)
+	clock := &fakeClock{now: time.Now()}
+	server := NewServer().WithTransitionDelay(time.Minute).WithClock(clock.Now)
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	s := store.NewBasicStore().
+		WithFileSystem(afero.NewMemMapFs()).
+		WithNoAuthHTTPClient(store.NewNoAuthHTTPClient(ts.URL)).
+		WithAuth(staticAuth{})
+	return server, s, clock
+}
+
+func TestWorkspaceLifecycle(t *testing.T) {
+	server, s, clock := newTestStore(t)
+
+	orgs, err := s.GetOrganizations(nil)
+	if !assert.Nil(t, err) || !assert.Len(t, orgs, 1) {
+		return
+	}
+	org := orgs[0]
+
+	options := store.NewCreateWorkspacesOptions(DefaultWorkspaceGroup, "my-ws").WithGitRepo("github.com:brevdev/brev-cli.git")
+	ws, err := s.CreateWorkspace(org.ID, options)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, entity.Deploying, ws.Status)
+	assert.Equal(t, server.CurrentUser().ID, ws.CreatedByUserID)
+
+	clock.Advance(time.Minute)
+	ws, err = s.GetWorkspace(ws.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, entity.Running, ws.Status)
+	assert.Equal(t, entity.Healthy, ws.HealthStatus)
+
+	ws, err = s.StopWorkspace(ws.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, entity.Stopping, ws.Status)
+
+	_, err = s.StartWorkspace(ws.ID)
+	assert.Error(t, err)
+
+	clock.Advance(time.Minute)
+	ws, err = s.StartWorkspace(ws.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, entity.Starting, ws.Status)
+
+	clock.Advance(time.Minute)
+	ws, err = s.ModifyWorkspace(ws.ID, &store.ModifyWorkspaceRequest{WorkspaceClassID: "4x16"})
+	assert.Nil(t, err)
+	assert.Equal(t, entity.Running, ws.Status)
+	assert.Equal(t, "4x16", ws.WorkspaceClassID)
+
+	params, err := s.GetEnvSetupParams(ws.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "brev-cli", params.ProjectFolderName)
+
+	ws, err = s.DeleteWorkspace(ws.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, entity.Deleting, ws.Status)
+
+	clock.Advance(time.Minute)
+	workspaces, err := s.GetWorkspaces(org.ID, nil)
+	assert.Nil(t, err)
+	assert.Len(t, workspaces, 0)
+	_, err = s.GetWorkspace(ws.ID)
+	assert.Error(t, err)
+}
+
+func TestUsersOrgsAndSecrets(t *testing.T) {
+	server, s, _ := newTestStore(t)
+
+	user, err := s.GetCurrentUser()
+	assert.Nil(t, err)
+	assert.Equal(t, server.CurrentUser(), *user)
+
+	keys, err := s.GetCurrentUserKeys()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, keys.PublicKey)
+
+	org, err := s.CreateOrganization(store.CreateOrganizationRequest{Name: "other-org"})
+	assert.Nil(t, err)
+	orgs, err := s.GetOrganizations(&store.GetOrganizationsOptions{Name: "other-org"})
+	assert.Nil(t, err)
+	assert.Equal(t, []entity.Organization{*org}, orgs)
+
+	groups, err := s.GetWorkspaceGroups(org.ID)
+	assert.Nil(t, err)
+	assert.Len(t, groups, 1)
+
+	_, err = s.CreateSecret(store.CreateSecretRequest{Name: "TOKEN", HierarchyType: store.User, HierarchyID: user.ID})
+	assert.Nil(t, err)
+	assert.Len(t, server.Secrets(), 1)
+
+	assert.Nil(t, s.Healthcheck())
+}
+
+func TestSecrets(t *testing.T) {
+	server, s, _ := newTestStore(t)
+	user, err := s.GetCurrentUser()
+	assert.Nil(t, err)
+
+	req := store.CreateSecretRequest{
+		Name:          "TOKEN",
+		HierarchyType: store.User,
+		HierarchyID:   user.ID,
+		Src:           store.SecretReqSrc{Type: store.KeyValue, Config: store.SrcConfig{Value: "one"}},
+		Dest:          store.SecretReqDest{Type: store.EnvVariable, Config: store.DestConfig{Name: "TOKEN"}},
+	}
+	_, err = s.CreateSecret(req)
+	assert.Nil(t, err)
+	_, err = s.CreateSecret(req)
+	assert.Error(t, err)
+
+	secrets, err := s.GetSecrets(store.User, user.ID)
+	assert.Nil(t, err)
+	if !assert.Len(t, secrets, 1) {
+		return
+	}
+	assert.Equal(t, "TOKEN", secrets[0].Name)
+	none, err := s.GetSecrets(store.Org, user.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, none)
+
+	req.Src.Config.Value = "two"
+	_, err = s.UpdateSecret(secrets[0].ID, req)
+	assert.Nil(t, err)
+	assert.Equal(t, "two", server.Secrets()[0].Src.Config.Value)
+
+	assert.Nil(t, s.DeleteSecret(secrets[0].ID))
+	assert.Empty(t, server.Secrets())
+	assert.Error(t, s.DeleteSecret(secrets[0].ID))
+}
+
+func TestRequiresAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ts := httptest.NewServer(NewServer())
+	defer ts.Close()
+
+	s := store.NewBasicStore().
+		WithFileSystem(afero.NewMemMapFs()).
+		WithNoAuthHTTPClient(store.NewNoAuthHTTPClient(ts.URL)).
+		WithAuth(noAuth{})
+	_, err := s.GetCurrentUser()
+	assert.Error(t, err)
+}
+
+type noAuth struct{}
+
+func (noAuth) GetAccessToken() (string, error) {
+	return "", nil
+}
+
+func TestToken(t *testing.T) {
+	token, err := NewServer().Token()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, token)
+}
@@ -93,6 +93,19 @@ func GetActiveOrgsPath(home string) string {
 	return fpath
 }
 
+// GetProfileFilePath keeps the default profile's files where they always
+// were, other profiles get their own directory, ex: ~/.brev/profiles/staging
+func GetProfileFilePath(home string, profile string, filename string) string {
+	if profile == "" || profile == "default" {
+		return makeBrevFilePath(filename, home)
+	}
+	return filepath.Join(GetBrevHome(home), "profiles", profile, filename)
+}
+
+func GetProfileActiveOrgsPath(home string, profile string) string {
+	return GetProfileFilePath(home, profile, activeOrgFile)
+}
+
 func GetPersonalSettingsCachePath(home string) string {
 	fpath := makeBrevFilePath(personalSettingsCache, home)
 	return fpath
@@ -114,6 +127,15 @@ func GetBrevSSHConfigPath(home string) string {
 	return brevSSHConfigPath
 }
 
+// GetLocalPath is where brev local keeps its containers' state and volumes
+func GetLocalPath(home string) string {
+	return filepath.Join(GetBrevHome(home), "local")
+}
+
+func GetLocalSSHConfigPath(home string) string {
+	return filepath.Join(GetLocalPath(home), "ssh_config")
+}
+
 func GetOnboardingStepPath(home string) string {
 	path := GetBrevHome(home)
 	brevOnboardingFilePath := filepath.Join(path, "onboarding_step.json")
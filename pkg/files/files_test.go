@@ -23,6 +23,12 @@ func (s *filesTestSuite) TestGetUserSSHConfigPath() {
 	s.Nil(err)
 }
 
+func (s *filesTestSuite) TestGetProfileFilePath() {
+	s.Equal("/home/me/.brev/credentials.json", GetProfileFilePath("/home/me", "default", "credentials.json"))
+	s.Equal("/home/me/.brev/credentials.json", GetProfileFilePath("/home/me", "", "credentials.json"))
+	s.Equal("/home/me/.brev/profiles/staging/active_org.json", GetProfileActiveOrgsPath("/home/me", "staging"))
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestFiles(t *testing.T) {
@@ -0,0 +1,116 @@
+package filesync
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// CopyUp copies the local file or directory src to dst on the remote, the
+// way scp does: a directory needs recursive, and anything copied to an
+// existing directory goes into it. It returns the number of files copied
+func CopyUp(remote Remote, src string, dst string, recursive bool) (int, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	_, dstIsDir, err := remote.Stat(dst)
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	dstIsDir = dstIsDir || strings.HasSuffix(dst, "/")
+	name := filepath.Base(filepath.Clean(src))
+
+	if !info.IsDir() {
+		dir, to := path.Dir(dst), path.Base(dst)
+		if dstIsDir {
+			dir, to = dst, name
+		}
+		err = remote.Upload(dir, []Transfer{{From: src, To: to, Mode: info.Mode().Perm()}})
+		if err != nil {
+			return 0, breverrors.WrapAndTrace(err)
+		}
+		return 1, nil
+	}
+	if !recursive {
+		return 0, breverrors.NewValidationError(fmt.Sprintf("%s is a directory, use -r to copy it", src))
+	}
+	target := dst
+	if dstIsDir {
+		target = path.Join(dst, name)
+	}
+	files, _, err := walk(src, false)
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	transfers := []Transfer{}
+	for _, p := range sortedPaths(files) {
+		transfers = append(transfers, Transfer{From: filepath.Join(src, filepath.FromSlash(p)), To: p, Mode: files[p].Mode})
+	}
+	err = remote.Upload(target, transfers)
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	return len(transfers), nil
+}
+
+// CopyDown copies the remote file or directory src to the local dst, like
+// CopyUp the other way around
+func CopyDown(remote Remote, src string, dst string, recursive bool) (int, error) {
+	exists, srcIsDir, err := remote.Stat(src)
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	if !exists {
+		return 0, breverrors.NewValidationError(fmt.Sprintf("%s does not exist on the dev environment", src))
+	}
+	info, err := os.Stat(dst)
+	dstIsDir := (err == nil && info.IsDir()) || strings.HasSuffix(dst, string(filepath.Separator))
+	name := path.Base(path.Clean(src))
+
+	if !srcIsDir {
+		to := dst
+		if dstIsDir {
+			to = filepath.Join(dst, name)
+		}
+		err = remote.Download(path.Dir(src), []Transfer{{From: name, To: to}})
+		if err != nil {
+			return 0, breverrors.WrapAndTrace(err)
+		}
+		return 1, nil
+	}
+	if !recursive {
+		return 0, breverrors.NewValidationError(fmt.Sprintf("%s is a directory, use -r to copy it", src))
+	}
+	target := dst
+	if dstIsDir {
+		target = filepath.Join(dst, name)
+	}
+	files, err := remote.List(src)
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	transfers := []Transfer{}
+	for _, f := range files {
+		transfers = append(transfers, Transfer{From: f.Path, To: filepath.Join(target, filepath.FromSlash(f.Path)), Mode: f.Mode})
+	}
+	err = remote.Download(src, transfers)
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	return len(transfers), nil
+}
+
+func sortedPaths(files map[string]FileInfo) []string {
+	paths := []string{}
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
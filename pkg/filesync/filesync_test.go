@@ -0,0 +1,338 @@
+package filesync
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dirRemote is a Remote in a local directory. It goes through the same tar
+// streams as SSHRemote
+type dirRemote struct {
+	root string
+}
+
+var _ Remote = dirRemote{}
+
+func (d dirRemote) abs(p string) string {
+	return filepath.Join(d.root, filepath.FromSlash(p))
+}
+
+func (d dirRemote) List(dir string) ([]FileInfo, error) {
+	if _, err := os.Stat(d.abs(dir)); os.IsNotExist(err) {
+		return nil, nil
+	}
+	files, _, err := walk(d.abs(dir), false)
+	if err != nil {
+		return nil, err
+	}
+	list := []FileInfo{}
+	for _, f := range files {
+		list = append(list, f)
+	}
+	return list, nil
+}
+
+func (d dirRemote) Hash(dir string, paths []string) (map[string]string, error) {
+	hashes := map[string]string{}
+	for _, p := range paths {
+		h, err := hashFile(filepath.Join(d.abs(dir), filepath.FromSlash(p)))
+		if err != nil {
+			return nil, err
+		}
+		hashes[p] = h
+	}
+	return hashes, nil
+}
+
+func (d dirRemote) Stat(p string) (bool, bool, error) {
+	info, err := os.Stat(d.abs(p))
+	if os.IsNotExist(err) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, info.IsDir(), nil
+}
+
+func (d dirRemote) Upload(dir string, transfers []Transfer) error {
+	var buf bytes.Buffer
+	err := writeTar(&buf, transfers)
+	if err != nil {
+		return err
+	}
+	received := []Transfer{}
+	for _, t := range transfers {
+		received = append(received, Transfer{From: t.To, To: filepath.Join(d.abs(dir), filepath.FromSlash(t.To))})
+	}
+	return readTar(&buf, received)
+}
+
+func (d dirRemote) Download(dir string, transfers []Transfer) error {
+	sent := []Transfer{}
+	for _, t := range transfers {
+		from := filepath.Join(d.abs(dir), filepath.FromSlash(t.From))
+		info, err := os.Stat(from)
+		if err != nil {
+			return err
+		}
+		sent = append(sent, Transfer{From: from, To: t.From, Mode: info.Mode()})
+	}
+	var buf bytes.Buffer
+	err := writeTar(&buf, sent)
+	if err != nil {
+		return err
+	}
+	return readTar(&buf, transfers)
+}
+
+func (d dirRemote) Remove(dir string, paths []string) error {
+	for _, p := range paths {
+		err := os.Remove(filepath.Join(d.abs(dir), filepath.FromSlash(p)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d dirRemote) Close() error {
+	return nil
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for p, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(p))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		assert.Nil(t, os.WriteFile(full, []byte(content), 0o644))
+	}
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func TestIgnore(t *testing.T) {
+	ig := NewIgnore()
+	assert.Nil(t, ig.Add(".", strings.NewReader("# comment\n*.log\n!keep.log\nbuild/\n/top.txt\ndocs/**/*.pdf\n")))
+	assert.Nil(t, ig.Add("sub", strings.NewReader("local.txt\n")))
+
+	cases := map[string]bool{
+		"a.log":              true,
+		"dir/b.log":          true,
+		"keep.log":           false,
+		"build/out.bin":      true,
+		"src/build/out.bin":  true,
+		"build":              false, // a file named build
+		"top.txt":            true,
+		"dir/top.txt":        false,
+		"docs/a/b/c.pdf":     true,
+		"docs/c.pdf":         true,
+		"sub/local.txt":      true,
+		"local.txt":          false,
+		".git/config":        true,
+		"main.go":            false,
+		"node_modules/x.js":  false,
+		"sub/dir/local.txt":  true,
+		"other/sub/local.go": false,
+	}
+	for p, want := range cases {
+		assert.Equal(t, want, ig.Match(p, false), p)
+	}
+	assert.True(t, ig.Match("build", true))
+}
+
+func TestPlan(t *testing.T) {
+	f := func(hash string) FileInfo { return FileInfo{Hash: hash, Mode: 0o644} }
+	local := map[string]FileInfo{
+		"new":            f("a"),
+		"same":           f("a"),
+		"local-edit":     f("b"),
+		"remote-edit":    f("a"),
+		"both-edit":      f("b"),
+		"never-synced":   f("b"),
+		"remote-deleted": f("a"),
+	}
+	remote := map[string]FileInfo{
+		"same":           f("a"),
+		"local-edit":     f("a"),
+		"remote-edit":    f("c"),
+		"both-edit":      f("c"),
+		"never-synced":   f("a"),
+		"local-deleted":  f("a"),
+		"changed-remote": f("c"),
+		"remote-only":    f("a"),
+	}
+	last := map[string]string{
+		"same":           "a",
+		"local-edit":     "a",
+		"remote-edit":    "a",
+		"both-edit":      "a",
+		"remote-deleted": "a",
+		"local-deleted":  "a",
+		"changed-remote": "a",
+	}
+	kinds := func(actions []Action) map[string]ActionKind {
+		m := map[string]ActionKind{}
+		for _, a := range actions {
+			m[a.Path] = a.Kind
+		}
+		return m
+	}
+
+	assert.Equal(t, map[string]ActionKind{
+		"new":            Upload,
+		"local-edit":     Upload,
+		"remote-edit":    Conflict,
+		"both-edit":      Conflict,
+		"never-synced":   Conflict,
+		"remote-deleted": Conflict,
+	}, kinds(Plan(local, remote, last, PlanOptions{})))
+
+	assert.Equal(t, map[string]ActionKind{
+		"new":            Upload,
+		"local-edit":     Upload,
+		"remote-edit":    Conflict,
+		"both-edit":      Conflict,
+		"never-synced":   Conflict,
+		"remote-deleted": Conflict,
+		"local-deleted":  Delete,
+		"changed-remote": Conflict,
+	}, kinds(Plan(local, remote, last, PlanOptions{Delete: true})))
+
+	forced := kinds(Plan(local, remote, last, PlanOptions{Delete: true, Force: true}))
+	assert.Equal(t, Upload, forced["both-edit"])
+	assert.Equal(t, Delete, forced["changed-remote"])
+	assert.NotContains(t, forced, "remote-only")
+}
+
+func TestSyncer(t *testing.T) {
+	localDir, remoteDir := t.TempDir(), t.TempDir()
+	remote := dirRemote{root: remoteDir}
+	writeFiles(t, localDir, map[string]string{
+		".gitignore":       "*.ckpt\n",
+		"train.py":         "v1",
+		"data/a.csv":       "1",
+		"model.ckpt":       "local weights",
+		".git/HEAD":        "ref",
+		"sub/.gitignore":   "out/\n",
+		"sub/out/junk.txt": "junk",
+	})
+	writeFiles(t, remoteDir, map[string]string{
+		"project/model.ckpt": "remote weights",
+		"project/results":    "keep me",
+	})
+	s := &Syncer{LocalDir: localDir, RemoteDir: "project", Remote: remote, StatePath: filepath.Join(t.TempDir(), "state.json")}
+
+	result, err := s.Once()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{".gitignore", "data/a.csv", "sub/.gitignore", "train.py"}, result.Uploaded)
+	assert.Empty(t, result.Conflicts)
+	assert.Equal(t, "v1", readFile(t, filepath.Join(remoteDir, "project/train.py")))
+	assert.Equal(t, "remote weights", readFile(t, filepath.Join(remoteDir, "project/model.ckpt")))
+	assert.Equal(t, "", readFile(t, filepath.Join(remoteDir, "project/.git/HEAD")))
+	assert.Equal(t, "", readFile(t, filepath.Join(remoteDir, "project/sub/out/junk.txt")))
+
+	// unchanged, nothing to do
+	result, err = s.Once()
+	assert.Nil(t, err)
+	assert.Empty(t, result.Uploaded)
+
+	// a local edit is uploaded, a remote edit is a conflict
+	writeFiles(t, localDir, map[string]string{"train.py": "v2 longer"})
+	writeFiles(t, remoteDir, map[string]string{"project/data/a.csv": "edited remotely"})
+	writeFiles(t, localDir, map[string]string{"data/a.csv": "edited locally"})
+	result, err = s.Once()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"train.py"}, result.Uploaded)
+	if assert.Len(t, result.Conflicts, 1) {
+		assert.Equal(t, "data/a.csv", result.Conflicts[0].Path)
+	}
+	assert.Equal(t, "edited remotely", readFile(t, filepath.Join(remoteDir, "project/data/a.csv")))
+
+	// still a conflict on the next run
+	result, err = s.Once()
+	assert.Nil(t, err)
+	assert.Len(t, result.Conflicts, 1)
+
+	// deletes only what was synced, and only with Delete
+	assert.Nil(t, os.Remove(filepath.Join(localDir, "train.py")))
+	s.Delete = true
+	s.DryRun = true
+	result, err = s.Once()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"train.py"}, result.Deleted)
+	assert.Equal(t, "v2 longer", readFile(t, filepath.Join(remoteDir, "project/train.py")))
+
+	s.DryRun = false
+	s.Force = true
+	result, err = s.Once()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"train.py"}, result.Deleted)
+	assert.Equal(t, []string{"data/a.csv"}, result.Uploaded)
+	assert.Equal(t, "", readFile(t, filepath.Join(remoteDir, "project/train.py")))
+	assert.Equal(t, "edited locally", readFile(t, filepath.Join(remoteDir, "project/data/a.csv")))
+	assert.Equal(t, "keep me", readFile(t, filepath.Join(remoteDir, "project/results")))
+}
+
+func TestCopy(t *testing.T) {
+	localDir, remoteDir := t.TempDir(), t.TempDir()
+	remote := dirRemote{root: remoteDir}
+	writeFiles(t, localDir, map[string]string{"src/a.txt": "a", "src/nested/b.txt": "b", "one.txt": "1"})
+	assert.Nil(t, os.MkdirAll(filepath.Join(remoteDir, "existing"), 0o755))
+
+	_, err := CopyUp(remote, filepath.Join(localDir, "src"), "existing", false)
+	assert.ErrorContains(t, err, "use -r")
+
+	n, err := CopyUp(remote, filepath.Join(localDir, "src"), "existing", true)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "b", readFile(t, filepath.Join(remoteDir, "existing/src/nested/b.txt")))
+
+	_, err = CopyUp(remote, filepath.Join(localDir, "src"), "renamed", true)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", readFile(t, filepath.Join(remoteDir, "renamed/a.txt")))
+
+	_, err = CopyUp(remote, filepath.Join(localDir, "one.txt"), "existing", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", readFile(t, filepath.Join(remoteDir, "existing/one.txt")))
+
+	_, err = CopyUp(remote, filepath.Join(localDir, "one.txt"), "two.txt", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", readFile(t, filepath.Join(remoteDir, "two.txt")))
+
+	down := t.TempDir()
+	n, err = CopyDown(remote, "existing", down, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "b", readFile(t, filepath.Join(down, "existing/src/nested/b.txt")))
+
+	_, err = CopyDown(remote, "two.txt", filepath.Join(down, "copy.txt"), false)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", readFile(t, filepath.Join(down, "copy.txt")))
+
+	_, err = CopyDown(remote, "missing", down, false)
+	assert.ErrorContains(t, err, "does not exist")
+}
+
+func TestReadTarRejectsUnexpectedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a": "a"})
+	var buf bytes.Buffer
+	assert.Nil(t, writeTar(&buf, []Transfer{{From: filepath.Join(dir, "a"), To: "../../etc/passwd", Mode: 0o644}}))
+	err := readTar(&buf, []Transfer{{From: "a", To: filepath.Join(dir, "b")}})
+	assert.ErrorContains(t, err, "unexpected file")
+}
@@ -0,0 +1,167 @@
+package filesync
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// alwaysIgnored is never synced, whatever the .gitignore files say
+const alwaysIgnored = ".git"
+
+// Ignore matches paths against .gitignore files. The patterns of a .gitignore
+// apply to its directory and below and, like git, the last matching pattern
+// wins, so ! can re-include what an earlier pattern excluded
+type Ignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	// base is the directory of the .gitignore, "" for the root
+	base    string
+	negate  bool
+	dirOnly bool
+	// anchored patterns have a slash and match the path below base, the
+	// others match the name at any depth
+	anchored bool
+	re       *regexp.Regexp
+}
+
+func NewIgnore() *Ignore {
+	return &Ignore{}
+}
+
+// Add reads the patterns of the .gitignore in dir, a slash separated path
+// relative to the root
+func (ig *Ignore) Add(dir string, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		rule, ok := parseIgnoreLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		if dir != "." {
+			rule.base = dir
+		}
+		ig.rules = append(ig.rules, rule)
+	}
+	return scanner.Err() //nolint:wrapcheck // read errors of the caller's reader
+}
+
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{}
+	switch {
+	case strings.HasPrefix(line, `\#`), strings.HasPrefix(line, `\!`):
+		line = line[1:]
+	case strings.HasPrefix(line, "!"):
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.anchored = strings.Contains(line, "/")
+	re, err := regexp.Compile(globToRegexp(strings.TrimPrefix(line, "/")))
+	if err != nil {
+		// git ignores patterns it can't make sense of too
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// globToRegexp translates the gitignore flavour of globs, where * does not
+// cross a slash and ** does
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(rel, r.base+"/")
+	}
+	if !r.anchored {
+		return r.re.MatchString(path.Base(rel))
+	}
+	return r.re.MatchString(rel)
+}
+
+// Match reports whether rel, a slash separated path relative to the root, is
+// ignored. A path in an ignored directory is ignored too
+func (ig *Ignore) Match(rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		last := i == len(parts)-1
+		if ig.matchOne(strings.Join(parts[:i+1], "/"), !last || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ig *Ignore) matchOne(rel string, isDir bool) bool {
+	if path.Base(rel) == alwaysIgnored {
+		return true
+	}
+	ignored := false
+	for _, r := range ig.rules {
+		if r.matches(rel, isDir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
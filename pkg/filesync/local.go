@@ -0,0 +1,172 @@
+package filesync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// FileInfo is a regular file in a synced tree. Hash is the hex sha256 of its
+// content, it is left empty by listings that don't read content
+type FileInfo struct {
+	// Path is slash separated and relative to the root of the tree
+	Path    string
+	Mode    fs.FileMode
+	Size    int64
+	ModTime time.Time
+	Hash    string
+}
+
+// Scanner lists the files of a local directory that are not ignored by its
+// .gitignore files. It remembers hashes, so a file is only read again once
+// its size or modification time changed
+type Scanner struct {
+	root  string
+	cache map[string]FileInfo
+}
+
+func NewScanner(root string) *Scanner {
+	return &Scanner{root: root, cache: map[string]FileInfo{}}
+}
+
+// Scan returns the files by path, and the .gitignore patterns that applied so
+// that the same paths can be left alone on the other side
+func (s *Scanner) Scan() (map[string]FileInfo, *Ignore, error) {
+	files, ignore, err := walk(s.root, true)
+	if err != nil {
+		return nil, nil, breverrors.WrapAndTrace(err)
+	}
+	for p, f := range files {
+		cached, ok := s.cache[p]
+		if ok && cached.Size == f.Size && cached.ModTime.Equal(f.ModTime) {
+			f.Hash = cached.Hash
+		} else {
+			f.Hash, err = hashFile(filepath.Join(s.root, filepath.FromSlash(p)))
+			if err != nil {
+				return nil, nil, breverrors.WrapAndTrace(err)
+			}
+		}
+		files[p] = f
+	}
+	s.cache = files
+	return files, ignore, nil
+}
+
+// Stamps is a cheap fingerprint of the tree, which only stats the files
+func (s *Scanner) Stamps() (map[string]FileInfo, error) {
+	files, _, err := walk(s.root, true)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return files, nil
+}
+
+// walk lists the regular files under root, skipping symlinks. With
+// useIgnore it reads the .gitignore of each directory before its entries
+func walk(root string, useIgnore bool) (map[string]FileInfo, *Ignore, error) {
+	ignore := NewIgnore()
+	files := map[string]FileInfo{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && useIgnore && ignore.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if useIgnore {
+				return addGitignore(ignore, p, rel)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		files[rel] = FileInfo{Path: rel, Mode: info.Mode().Perm(), Size: info.Size(), ModTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, breverrors.WrapAndTrace(err)
+	}
+	return files, ignore, nil
+}
+
+func addGitignore(ignore *Ignore, dir string, rel string) error {
+	f, err := os.Open(filepath.Join(dir, ".gitignore")) //nolint:gosec // the user's own tree
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer f.Close() //nolint:errcheck // read only
+	err = ignore.Add(rel, f)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p) //nolint:gosec // the user's own tree
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	defer f.Close() //nolint:errcheck // read only
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeFileAtomic writes r to p through a temporary file in the same
+// directory, so that p is never left half written
+func writeFileAtomic(p string, mode fs.FileMode, r io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(p), 0o755)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".brev-*")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // gone after the rename
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		_ = tmp.Close()
+		return breverrors.WrapAndTrace(err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Chmod(tmp.Name(), mode)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(tmp.Name(), p)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
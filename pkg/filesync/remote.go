@@ -0,0 +1,127 @@
+package filesync
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// Remote is the dev environment side of a copy or sync. Paths may be
+// absolute, relative to the home directory, or start with ~/
+type Remote interface {
+	// List returns the regular files under dir without their hashes, and
+	// nothing if dir does not exist
+	List(dir string) ([]FileInfo, error)
+	// Hash returns the hashes of the files at paths relative to dir
+	Hash(dir string, paths []string) (map[string]string, error)
+	// Stat tells whether path exists and whether it is a directory
+	Stat(path string) (exists bool, isDir bool, err error)
+	// Upload copies local files to paths relative to dir, creating dir and
+	// the directories in between
+	Upload(dir string, transfers []Transfer) error
+	// Download copies the files at paths relative to dir to local files
+	Download(dir string, transfers []Transfer) error
+	// Remove deletes the files at paths relative to dir
+	Remove(dir string, paths []string) error
+	Close() error
+}
+
+// Transfer copies the file at From to To. The remote side of a transfer is
+// a slash separated path relative to the dir it is made in
+type Transfer struct {
+	From string
+	To   string
+	Mode os.FileMode
+}
+
+// writeTar archives the local files of upload transfers under their remote
+// names
+func writeTar(w io.Writer, transfers []Transfer) error {
+	tw := tar.NewWriter(w)
+	for _, t := range transfers {
+		err := writeTarFile(tw, t)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, t Transfer) error {
+	f, err := os.Open(t.From) //nolint:gosec // the user's own files
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer f.Close() //nolint:errcheck // read only
+	info, err := f.Stat()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     t.To,
+		Mode:     int64(t.Mode.Perm()),
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	// a file that grows while it is copied would corrupt the archive
+	_, err = io.CopyN(tw, f, info.Size())
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// readTar writes the files of an archive to the local paths of download
+// transfers. Entries that were not asked for are an error, so a remote can't
+// write anywhere else
+func readTar(r io.Reader, transfers []Transfer) error {
+	byName := map[string]Transfer{}
+	for _, t := range transfers {
+		byName[t.From] = t
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		t, ok := byName[hdr.Name]
+		if !ok {
+			return fmt.Errorf("unexpected file %s from the dev environment", hdr.Name)
+		}
+		delete(byName, hdr.Name)
+		err = writeFileAtomic(t.To, os.FileMode(hdr.Mode).Perm(), tr)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	if len(byName) > 0 {
+		missing := []string{}
+		for name := range byName {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("not sent by the dev environment: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
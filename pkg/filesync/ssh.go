@@ -0,0 +1,301 @@
+package filesync
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alessio/shellescape"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// SSHRemote runs coreutils, find and tar on the dev environment over a single
+// ssh connection. It reconnects once when the connection was dropped, ex: by
+// a laptop going to sleep during brev sync --watch
+type SSHRemote struct {
+	dial func() (*ssh.Client, error)
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+var _ Remote = &SSHRemote{}
+
+// Dial connects to a dev environment with the same host, port, user and key
+// as its entry in the brev ssh config
+func Dial(w entity.Workspace, privateKeyPath string) (*SSHRemote, error) {
+	if w.IsLegacy() {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("%s is a legacy dev environment, copy files with scp %s:<path> instead", w.Name, w.GetLocalIdentifier()))
+	}
+	key, err := os.ReadFile(privateKeyPath) //nolint:gosec // the brev private key
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, "unable to read private key")
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, "unable to parse private key")
+	}
+	config := &ssh.ClientConfig{
+		User: w.GetUsername(),
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		// the brev ssh config doesn't check host keys either
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), //nolint:gosec // see above
+		Timeout:         10 * time.Second,
+	}
+	addr := net.JoinHostPort(w.GetHostname(), strconv.Itoa(w.GetPort()))
+	r := &SSHRemote{dial: func() (*ssh.Client, error) {
+		client, err := ssh.Dial("tcp", addr, config)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err, "unable to connect to "+w.Name)
+		}
+		return client, nil
+	}}
+	r.client, err = r.dial()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return r, nil
+}
+
+func (r *SSHRemote) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.client.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (r *SSHRemote) session() (*ssh.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, err := r.client.NewSession()
+	if err == nil {
+		return session, nil
+	}
+	_ = r.client.Close()
+	r.client, err = r.dial()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	session, err = r.client.NewSession()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return session, nil
+}
+
+// run runs script with sh, whatever the user's login shell is
+func (r *SSHRemote) run(script string, stdin io.Reader, stdout io.Writer) error {
+	session, err := r.session()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer session.Close() //nolint:errcheck // closed after Run anyway
+	var stderr bytes.Buffer
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = &stderr
+	err = session.Run("sh -c " + shellescape.Quote(script))
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// quotePath quotes p for sh, leaving a leading ~ to expand to the home
+// directory
+func quotePath(p string) string {
+	switch {
+	case p == "~":
+		return `"$HOME"`
+	case strings.HasPrefix(p, "~/"):
+		return `"$HOME"/` + shellescape.Quote(strings.TrimPrefix(p, "~/"))
+	default:
+		return shellescape.Quote(p)
+	}
+}
+
+// nulList is the stdin of xargs -0 and tar --null. Paths get a ./ so that
+// none of them is read as an option
+func nulList(paths []string, dotSlash bool) io.Reader {
+	var b bytes.Buffer
+	for _, p := range paths {
+		if dotSlash {
+			b.WriteString("./")
+		}
+		b.WriteString(p)
+		b.WriteByte(0)
+	}
+	return &b
+}
+
+func (r *SSHRemote) List(dir string) ([]FileInfo, error) {
+	var out bytes.Buffer
+	script := fmt.Sprintf(`cd %s 2>/dev/null || exit 0; find . -type f -printf '%%m %%s %%T@ %%P\0'`, quotePath(dir))
+	err := r.run(script, nil, &out)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return parseFindOutput(out.Bytes())
+}
+
+// parseFindOutput reads find's "mode size mtime path" records
+func parseFindOutput(out []byte) ([]FileInfo, error) {
+	files := []FileInfo{}
+	for _, rec := range bytes.Split(out, []byte{0}) {
+		if len(rec) == 0 {
+			continue
+		}
+		fields := strings.SplitN(string(rec), " ", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected find output %q", rec)
+		}
+		mode, err := strconv.ParseUint(fields[0], 8, 32)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		mtime, err := parseFindTime(fields[2])
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		files = append(files, FileInfo{
+			Path:    fields[3],
+			Mode:    os.FileMode(mode).Perm(),
+			Size:    size,
+			ModTime: mtime,
+		})
+	}
+	return files, nil
+}
+
+// parseFindTime reads %T@, seconds with a fraction that a float64 would
+// round
+func parseFindTime(s string) (time.Time, error) {
+	secs, frac, _ := strings.Cut(s, ".")
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, breverrors.WrapAndTrace(err)
+	}
+	frac = (frac + "000000000")[:9]
+	nsec, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return time.Time{}, breverrors.WrapAndTrace(err)
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+func (r *SSHRemote) Hash(dir string, paths []string) (map[string]string, error) {
+	hashes := map[string]string{}
+	if len(paths) == 0 {
+		return hashes, nil
+	}
+	var out bytes.Buffer
+	script := fmt.Sprintf("cd %s && xargs -0 -r sha256sum -z --", quotePath(dir))
+	err := r.run(script, nulList(paths, true), &out)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return parseSHA256SumOutput(out.Bytes())
+}
+
+// parseSHA256SumOutput reads the "hash  ./path" records of sha256sum -z,
+// which does not escape names
+func parseSHA256SumOutput(out []byte) (map[string]string, error) {
+	hashes := map[string]string{}
+	for _, rec := range bytes.Split(out, []byte{0}) {
+		if len(rec) == 0 {
+			continue
+		}
+		line := string(rec)
+		if len(line) < 67 {
+			return nil, fmt.Errorf("unexpected sha256sum output %q", line)
+		}
+		hashes[strings.TrimPrefix(line[66:], "./")] = line[:64]
+	}
+	return hashes, nil
+}
+
+func (r *SSHRemote) Stat(p string) (bool, bool, error) {
+	var out bytes.Buffer
+	script := fmt.Sprintf("if [ -d %[1]s ]; then echo dir; elif [ -e %[1]s ]; then echo file; fi", quotePath(p))
+	err := r.run(script, nil, &out)
+	if err != nil {
+		return false, false, breverrors.WrapAndTrace(err)
+	}
+	kind := strings.TrimSpace(out.String())
+	return kind != "", kind == "dir", nil
+}
+
+func (r *SSHRemote) Upload(dir string, transfers []Transfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(writeTar(pw, transfers))
+	}()
+	script := fmt.Sprintf("mkdir -p %[1]s && tar -x -p --no-same-owner -f - -C %[1]s", quotePath(dir))
+	err := r.run(script, pr, io.Discard)
+	_ = pr.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (r *SSHRemote) Download(dir string, transfers []Transfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+	names := []string{}
+	for _, t := range transfers {
+		names = append(names, t.From)
+	}
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := readTar(bufio.NewReader(pr), transfers)
+		// drain so that tar is not blocked writing what we did not read
+		_, _ = io.Copy(io.Discard, pr)
+		done <- err
+	}()
+	script := fmt.Sprintf("cd %s && tar -c -f - --null --verbatim-files-from -T -", quotePath(dir))
+	err := r.run(script, nulList(names, false), pw)
+	_ = pw.CloseWithError(err)
+	readErr := <-done
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if readErr != nil {
+		return breverrors.WrapAndTrace(readErr)
+	}
+	return nil
+}
+
+func (r *SSHRemote) Remove(dir string, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	script := fmt.Sprintf("cd %s && xargs -0 -r rm -f --", quotePath(dir))
+	err := r.run(script, nulList(paths, true), io.Discard)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package filesync
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFindOutput(t *testing.T) {
+	files, err := parseFindOutput([]byte("644 3 1700000000.5000000000 a b.txt\x00755 0 1700000000.0000000000 dir/run.sh\x00"))
+	assert.Nil(t, err)
+	assert.Equal(t, []FileInfo{
+		{Path: "a b.txt", Mode: 0o644, Size: 3, ModTime: time.Unix(1700000000, 500000000)},
+		{Path: "dir/run.sh", Mode: 0o755, Size: 0, ModTime: time.Unix(1700000000, 0)},
+	}, files)
+
+	_, err = parseFindOutput([]byte("garbage\x00"))
+	assert.Error(t, err)
+}
+
+func TestParseSHA256SumOutput(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	hashes, err := parseSHA256SumOutput([]byte(hash + "  ./a\nb\x00" + hash + "  ./c\x00"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a\nb": hash, "c": hash}, hashes)
+}
+
+func TestQuotePath(t *testing.T) {
+	assert.Equal(t, `"$HOME"`, quotePath("~"))
+	assert.Equal(t, `"$HOME"/'my dir'`, quotePath("~/my dir"))
+	assert.Equal(t, `/tmp/x`, quotePath("/tmp/x"))
+}
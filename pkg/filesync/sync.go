@@ -0,0 +1,323 @@
+// Package filesync copies files to and from dev environments, and keeps a
+// remote directory in sync with a local one
+package filesync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+type ActionKind string
+
+const (
+	Upload   ActionKind = "upload"
+	Delete   ActionKind = "delete"
+	Conflict ActionKind = "conflict"
+)
+
+type Action struct {
+	Kind   ActionKind
+	Path   string
+	Reason string
+}
+
+type PlanOptions struct {
+	// Delete removes remote files that were synced before and have since
+	// been deleted locally
+	Delete bool
+	// Force overwrites remote changes instead of reporting conflicts
+	Force bool
+}
+
+// Plan compares the local files, the remote files and the hashes the last
+// sync left on the remote, sorted by path. A remote file that changed since
+// the last sync is a conflict unless Force is set, remote files that were
+// never synced are left alone. Remote hashes are only needed for paths that
+// exist locally or were synced before
+func Plan(local map[string]FileInfo, remote map[string]FileInfo, last map[string]string, opts PlanOptions) []Action {
+	paths := map[string]bool{}
+	for p := range local {
+		paths[p] = true
+	}
+	for p := range remote {
+		paths[p] = true
+	}
+	sorted := []string{}
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	actions := []Action{}
+	for _, p := range sorted {
+		action, ok := planPath(p, local, remote, last, opts)
+		if ok {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+func planPath(p string, local map[string]FileInfo, remote map[string]FileInfo, last map[string]string, opts PlanOptions) (Action, bool) {
+	l, inLocal := local[p]
+	r, inRemote := remote[p]
+	synced, wasSynced := last[p]
+	upload := Action{Kind: Upload, Path: p}
+	conflict := func(reason string) (Action, bool) {
+		if opts.Force {
+			return upload, true
+		}
+		return Action{Kind: Conflict, Path: p, Reason: reason}, true
+	}
+
+	switch {
+	case inLocal && !inRemote:
+		if wasSynced && l.Hash == synced {
+			return conflict("deleted on the dev environment since the last sync")
+		}
+		return upload, true
+	case inLocal && inRemote:
+		if l.Hash == r.Hash {
+			if l.Mode != r.Mode {
+				return upload, true
+			}
+			return Action{}, false
+		}
+		switch {
+		case wasSynced && r.Hash == synced:
+			return upload, true
+		case wasSynced && l.Hash == synced:
+			return conflict("changed on the dev environment since the last sync")
+		case wasSynced:
+			return conflict("changed locally and on the dev environment")
+		default:
+			return conflict("differs on the dev environment, which brev sync did not write")
+		}
+	case !inLocal && inRemote && wasSynced && opts.Delete:
+		if r.Hash != synced && !opts.Force {
+			return Action{Kind: Conflict, Path: p, Reason: "deleted locally but changed on the dev environment"}, true
+		}
+		return Action{Kind: Delete, Path: p}, true
+	}
+	return Action{}, false
+}
+
+// State is what a sync left on the remote, so that the next one can tell
+// remote edits from local ones
+type State struct {
+	Local     string            `json:"local"`
+	Workspace string            `json:"workspace"`
+	Remote    string            `json:"remote"`
+	Files     map[string]string `json:"files"`
+}
+
+func loadState(p string) (*State, error) {
+	state := &State{Files: map[string]string{}}
+	b, err := os.ReadFile(p) //nolint:gosec // in the brev home
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = json.Unmarshal(b, state)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, "could not read sync state "+p)
+	}
+	if state.Files == nil {
+		state.Files = map[string]string{}
+	}
+	return state, nil
+}
+
+func saveState(p string, state *State) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.MkdirAll(filepath.Dir(p), 0o700)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = writeFileAtomic(p, 0o600, bytes.NewReader(b))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// Syncer makes RemoteDir on the remote match LocalDir, leaving out what the
+// local .gitignore files exclude
+type Syncer struct {
+	LocalDir  string
+	RemoteDir string
+	// Workspace is recorded in the state, for whoever reads it
+	Workspace string
+	Remote    Remote
+	// StatePath is where the hashes of the last sync are kept
+	StatePath string
+	PlanOptions
+	DryRun bool
+
+	scanner *Scanner
+}
+
+type Result struct {
+	Uploaded  []string
+	Deleted   []string
+	Conflicts []Action
+}
+
+// Once syncs, or with DryRun only works out what it would do
+func (s *Syncer) Once() (*Result, error) {
+	if s.scanner == nil {
+		s.scanner = NewScanner(s.LocalDir)
+	}
+	local, ignore, err := s.scanner.Scan()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	state, err := loadState(s.StatePath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	remote, err := s.listRemote(local, ignore, state)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	actions := Plan(local, remote, state.Files, s.PlanOptions)
+	result := &Result{}
+	uploads := []Transfer{}
+	deletes := []string{}
+	for _, a := range actions {
+		switch a.Kind {
+		case Upload:
+			result.Uploaded = append(result.Uploaded, a.Path)
+			uploads = append(uploads, Transfer{
+				From: filepath.Join(s.LocalDir, filepath.FromSlash(a.Path)),
+				To:   a.Path,
+				Mode: local[a.Path].Mode,
+			})
+		case Delete:
+			result.Deleted = append(result.Deleted, a.Path)
+			deletes = append(deletes, a.Path)
+		case Conflict:
+			result.Conflicts = append(result.Conflicts, a)
+		}
+	}
+	if s.DryRun {
+		return result, nil
+	}
+
+	err = s.Remote.Upload(s.RemoteDir, uploads)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = s.Remote.Remove(s.RemoteDir, deletes)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	// conflicts keep what was synced before, so they stay conflicts until
+	// one side is changed to match or --force is used
+	for p, l := range local {
+		r, ok := remote[p]
+		if ok && r.Hash == l.Hash {
+			state.Files[p] = l.Hash
+		}
+	}
+	for _, t := range uploads {
+		state.Files[t.To] = local[t.To].Hash
+	}
+	for _, p := range deletes {
+		delete(state.Files, p)
+	}
+	for p := range state.Files {
+		if _, ok := remote[p]; !ok {
+			if _, ok := local[p]; !ok {
+				delete(state.Files, p)
+			}
+		}
+	}
+	state.Local, state.Workspace, state.Remote = s.LocalDir, s.Workspace, s.RemoteDir
+	err = saveState(s.StatePath, state)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return result, nil
+}
+
+// listRemote lists the remote files that aren't ignored, with hashes for the
+// ones Plan compares
+func (s *Syncer) listRemote(local map[string]FileInfo, ignore *Ignore, state *State) (map[string]FileInfo, error) {
+	files, err := s.Remote.List(s.RemoteDir)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	remote := map[string]FileInfo{}
+	toHash := []string{}
+	for _, f := range files {
+		if ignore.Match(f.Path, false) {
+			continue
+		}
+		remote[f.Path] = f
+		_, inLocal := local[f.Path]
+		_, wasSynced := state.Files[f.Path]
+		if inLocal || wasSynced {
+			toHash = append(toHash, f.Path)
+		}
+	}
+	hashes, err := s.Remote.Hash(s.RemoteDir, toHash)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	for p, h := range hashes {
+		f := remote[p]
+		f.Hash = h
+		remote[p] = f
+	}
+	return remote, nil
+}
+
+// Watch syncs, then polls the local directory and syncs again each time
+// something in it changes, until ctx is done. Errors are reported rather
+// than returned, so that a dropped connection doesn't end the watch
+func (s *Syncer) Watch(ctx context.Context, interval time.Duration, report func(*Result, error)) {
+	if s.scanner == nil {
+		s.scanner = NewScanner(s.LocalDir)
+	}
+	var last map[string]FileInfo
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		stamps, err := s.scanner.Stamps()
+		if err != nil {
+			report(nil, breverrors.WrapAndTrace(err))
+		} else if !reflect.DeepEqual(stamps, last) {
+			result, err := s.Once()
+			report(result, err)
+			if err == nil {
+				last = stamps
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%d uploaded, %d deleted, %d conflicts", len(r.Uploaded), len(r.Deleted), len(r.Conflicts))
+}
@@ -0,0 +1,74 @@
+package idle
+
+import (
+	"time"
+)
+
+// Decision is the outcome of one check of all signals
+type Decision struct {
+	Time       time.Time     `json:"time"`
+	IdleFor    time.Duration `json:"idleFor"`
+	Timeout    time.Duration `json:"timeout"`
+	ShouldStop bool          `json:"shouldStop"`
+	Activity   []Activity    `json:"activity"`
+}
+
+func (d Decision) ActiveSignals() []string {
+	active := []string{}
+	for _, a := range d.Activity {
+		if a.Active {
+			active = append(active, a.Signal)
+		}
+	}
+	return active
+}
+
+// Detector tracks when any signal was last active. It is idle once every
+// signal has been quiet for the policy's idle timeout
+type Detector struct {
+	policy     Policy
+	signals    []Signal
+	now        func() time.Time
+	lastActive time.Time
+}
+
+func NewDetector(policy Policy, signals []Signal) *Detector {
+	return &Detector{
+		policy:     policy,
+		signals:    signals,
+		now:        time.Now,
+		lastActive: time.Now(),
+	}
+}
+
+func (d *Detector) WithClock(now func() time.Time) *Detector {
+	d.now = now
+	d.lastActive = now()
+	return d
+}
+
+// Check evaluates every signal. A signal that errors counts as active, so
+// that a broken probe can keep a machine up but never stop it
+func (d *Detector) Check() Decision {
+	now := d.now()
+	decision := Decision{Time: now, Timeout: d.policy.GetIdleTimeout()}
+	for _, s := range d.signals {
+		activity, err := s.Check()
+		if err != nil {
+			activity = Activity{Signal: s.Name(), Active: true, Detail: "error: " + err.Error()}
+		}
+		decision.Activity = append(decision.Activity, activity)
+		if activity.Active {
+			d.lastActive = now
+		}
+	}
+	decision.IdleFor = now.Sub(d.lastActive)
+	decision.ShouldStop = decision.IdleFor >= decision.Timeout
+	return decision
+}
+
+// Reset restarts the idle timer, ex: after stopping so the next check does
+// not try to stop again
+func (d *Detector) Reset() {
+	d.lastActive = d.now()
+}
@@ -0,0 +1,232 @@
+package idle
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/autostartconf"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy([]byte(`
+idleTimeout: 1h
+cpu:
+  disabled: true
+probes:
+  - name: busy
+    command: "true"
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Hour, p.GetIdleTimeout())
+	assert.True(t, p.CPU.Disabled)
+	assert.Equal(t, 22, p.SSH.Port)
+	assert.Len(t, p.Probes, 1)
+
+	_, err = ParsePolicy([]byte("idleTimeout: soon"))
+	assert.Error(t, err)
+	_, err = ParsePolicy([]byte("idleTimeoutt: 1h"))
+	assert.Error(t, err)
+	_, err = ParsePolicy([]byte("probes: [{name: x}]"))
+	assert.Error(t, err)
+}
+
+const procNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1 1 0000000000000000 100 0 0 10 0
+   1: 0200000A:0016 0100000A:C350 01 00000000:00000000 00:00000000 00000000     0        0 2 1 0000000000000000 20 4 30 10 -1
+   2: 0200000A:1F90 0100000A:C351 01 00000000:00000000 00:00000000 00000000     0        0 3 1 0000000000000000 20 4 30 10 -1
+`
+
+func TestSSHSignal(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "/proc/net/tcp", []byte(procNetTCP), 0o644))
+
+	a, err := NewSSHSignal(fs, 22).Check()
+	assert.Nil(t, err)
+	assert.True(t, a.Active)
+	assert.Equal(t, "1 ssh sessions", a.Detail)
+
+	a, err = NewSSHSignal(fs, 2222).Check()
+	assert.Nil(t, err)
+	assert.False(t, a.Active)
+}
+
+func TestCPULoadSignal(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "/proc/loadavg", []byte("0.75 0.50 0.25 1/100 1234\n"), 0o644))
+
+	a, err := NewCPULoadSignal(fs, 0.5).Check()
+	assert.Nil(t, err)
+	assert.True(t, a.Active)
+
+	a, err = NewCPULoadSignal(fs, 1).Check()
+	assert.Nil(t, err)
+	assert.False(t, a.Active)
+}
+
+func writeProcStat(fs afero.Fs, pid int, comm string, utime int, stime int) {
+	stat := fmt.Sprintf("%d (%s) S 1 1 1 0 -1 4194560 100 0 0 0 %d %d 0 0 20 0 1 0 100 1000 100\n", pid, comm, utime, stime)
+	_ = afero.WriteFile(fs, fmt.Sprintf("/proc/%d/stat", pid), []byte(stat), 0o644)
+}
+
+func TestProcessSignal(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeProcStat(fs, 10, "python train.py", 100, 0)
+	writeProcStat(fs, 11, "sshd", 0, 0)
+
+	now := time.Now()
+	s := NewProcessSignal(fs, 0.5, []string{"sshd"})
+	s.now = func() time.Time { return now }
+
+	a, err := s.Check()
+	assert.Nil(t, err)
+	assert.False(t, a.Active)
+
+	// 10s later python used 10s of cpu, one full core
+	now = now.Add(10 * time.Second)
+	writeProcStat(fs, 10, "python train.py", 600, 500)
+	writeProcStat(fs, 11, "sshd", 100000, 0)
+	a, err = s.Check()
+	assert.Nil(t, err)
+	assert.True(t, a.Active)
+	assert.Equal(t, "1.00 cores", a.Detail)
+
+	now = now.Add(10 * time.Second)
+	a, err = s.Check()
+	assert.Nil(t, err)
+	assert.False(t, a.Active)
+}
+
+func TestParseProcStatWithParensInComm(t *testing.T) {
+	comm, ticks, ok := parseProcStat("42 (a (b) c) R 1 1 1 0 -1 0 0 0 0 0 7 3 0 0 20 0 1 0 1 1 1")
+	assert.True(t, ok)
+	assert.Equal(t, "a (b) c", comm)
+	assert.Equal(t, uint64(10), ticks)
+}
+
+func TestProbeSignal(t *testing.T) {
+	a, err := NewProbeSignal(ProbePolicy{Name: "yes", Command: "exit 0"}).Check()
+	assert.Nil(t, err)
+	assert.True(t, a.Active)
+
+	a, err = NewProbeSignal(ProbePolicy{Name: "no", Command: "exit 1"}).Check()
+	assert.Nil(t, err)
+	assert.False(t, a.Active)
+
+	_, err = NewProbeSignal(ProbePolicy{Name: "slow", Command: "sleep 5", Timeout: "10ms"}).Check()
+	assert.Error(t, err)
+}
+
+type fakeSignal struct {
+	active bool
+	err    error
+}
+
+func (f *fakeSignal) Name() string { return "fake" }
+
+func (f *fakeSignal) Check() (Activity, error) {
+	return Activity{Signal: "fake", Active: f.active}, f.err
+}
+
+func TestDetector(t *testing.T) {
+	now := time.Now()
+	signal := &fakeSignal{active: true}
+	policy := DefaultPolicy()
+	d := NewDetector(policy, []Signal{signal}).WithClock(func() time.Time { return now })
+
+	now = now.Add(time.Hour)
+	assert.False(t, d.Check().ShouldStop)
+
+	signal.active = false
+	now = now.Add(29 * time.Minute)
+	decision := d.Check()
+	assert.False(t, decision.ShouldStop)
+	assert.Equal(t, 29*time.Minute, decision.IdleFor)
+
+	now = now.Add(time.Minute)
+	assert.True(t, d.Check().ShouldStop)
+
+	// an erroring signal keeps the machine up
+	signal.err = fmt.Errorf("boom")
+	now = now.Add(time.Hour)
+	decision = d.Check()
+	assert.False(t, decision.ShouldStop)
+	assert.Equal(t, []string{"fake"}, decision.ActiveSignals())
+}
+
+type fakeJobs struct{ running bool }
+
+func (f fakeJobs) HasUnfinishedJobs(_ string) (bool, error) { return f.running, nil }
+
+func TestJobsSignal(t *testing.T) {
+	a, err := NewJobsSignal(fakeJobs{running: true}).Check()
+	assert.Nil(t, err)
+	assert.True(t, a.Active)
+}
+
+type fakeTaskStore struct {
+	autostartconf.AutoStartStore
+	stopped []string
+}
+
+func (f *fakeTaskStore) AutoStopWorkspace(workspaceID string) (*entity.Workspace, error) {
+	f.stopped = append(f.stopped, workspaceID)
+	return &entity.Workspace{ID: workspaceID}, nil
+}
+
+func (f *fakeTaskStore) GetCurrentWorkspaceID() (string, error) { return "ws1", nil }
+func (f *fakeTaskStore) IsWorkspace() (bool, error)             { return true, nil }
+func (f *fakeTaskStore) GetBrevHomePath() (string, error)       { return "/home/ubuntu/.brev", nil }
+
+func TestAutoStopTaskStopsAndAudits(t *testing.T) {
+	store := &fakeTaskStore{}
+	task := NewAutoStopTask(store, fakeJobs{})
+	task.fs = afero.NewMemMapFs()
+
+	now := time.Now()
+	signal := &fakeSignal{}
+	task.detector = NewDetector(DefaultPolicy(), []Signal{signal}).WithClock(func() time.Time { return now })
+
+	assert.Nil(t, task.check())
+	assert.Empty(t, store.stopped)
+
+	now = now.Add(31 * time.Minute)
+	assert.Nil(t, task.check())
+	assert.Equal(t, []string{"ws1"}, store.stopped)
+
+	audit, err := afero.ReadFile(task.fs, "/home/ubuntu/.brev/"+AuditLogFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, strings.Count(string(audit), "\n"))
+	assert.Contains(t, string(audit), `"workspaceId":"ws1"`)
+
+	// the timer is reset after stopping
+	assert.Nil(t, task.check())
+	assert.Len(t, store.stopped, 1)
+}
+
+func TestAutoStopTaskStopsOnSignal(t *testing.T) {
+	store := &fakeTaskStore{}
+	task := NewAutoStopTask(store, fakeJobs{})
+	task.fs = afero.NewMemMapFs()
+	signal := &fakeSignal{}
+	task.detector = NewDetector(DefaultPolicy(), []Signal{signal})
+
+	tr := tasks.NewTaskRunner([]tasks.Task{task})
+	done := make(chan error, 1)
+	go func() { done <- tr.Run() }()
+	time.Sleep(50 * time.Millisecond)
+	tr.SendStop()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the task runner did not return after one stop")
+	}
+	assert.Empty(t, store.stopped)
+}
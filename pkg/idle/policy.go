@@ -0,0 +1,121 @@
+// Package idle decides when a dev environment has been idle long enough to be
+// autostopped, by combining activity signals under a configurable policy
+package idle
+
+import (
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const PolicyFileName = "autostop.yaml"
+
+// Policy is read from $HOME/.brev/autostop.yaml, every field is optional and
+// falls back to DefaultPolicy, ex:
+//
+//	idleTimeout: 30m
+//	cpu:
+//	  loadThreshold: 0.5
+//	processes:
+//	  ignore: [jupyter-lab]
+//	probes:
+//	  - name: kernels-busy
+//	    command: curl -s localhost:8888/api/status | grep -q busy
+type Policy struct {
+	// IdleTimeout is how long every signal must be quiet before stopping
+	IdleTimeout string        `json:"idleTimeout"`
+	DryRun      bool          `json:"dryRun,omitempty"`
+	SSH         SSHPolicy     `json:"ssh"`
+	CPU         CPUPolicy     `json:"cpu"`
+	Processes   ProcessPolicy `json:"processes"`
+	Jobs        JobsPolicy    `json:"jobs"`
+	Probes      []ProbePolicy `json:"probes,omitempty"`
+}
+
+type SSHPolicy struct {
+	Disabled bool `json:"disabled,omitempty"`
+	Port     int  `json:"port"`
+}
+
+type CPUPolicy struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// LoadThreshold is the 1 minute load average above which the machine is busy
+	LoadThreshold float64 `json:"loadThreshold"`
+}
+
+type ProcessPolicy struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// CPUThreshold is how many cores worth of cpu time processes must use
+	// between checks to count as activity
+	CPUThreshold float64 `json:"cpuThreshold"`
+	// Ignore lists process names whose cpu time never counts, ex: daemons
+	// that poll in the background. Setting it replaces the default list
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+type JobsPolicy struct {
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// ProbePolicy runs Command with bash, exiting 0 means the machine is active
+type ProbePolicy struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		IdleTimeout: "30m",
+		SSH:         SSHPolicy{Port: 22},
+		CPU:         CPUPolicy{LoadThreshold: 0.5},
+		Processes: ProcessPolicy{
+			CPUThreshold: 0.1,
+			Ignore:       []string{"brev", "brevmon", "sshd", "systemd", "systemd-journal", "containerd", "dockerd"},
+		},
+	}
+}
+
+// ParsePolicy overlays contents onto DefaultPolicy
+func ParsePolicy(contents []byte) (*Policy, error) {
+	p := DefaultPolicy()
+	err := yaml.UnmarshalStrict(contents, &p)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid autostop policy: %v", err))
+	}
+	err = p.Validate()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &p, nil
+}
+
+func (p Policy) Validate() error {
+	d, err := time.ParseDuration(p.IdleTimeout)
+	if err != nil || d <= 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("idleTimeout %q must be a positive duration like 30m", p.IdleTimeout))
+	}
+	for i, probe := range p.Probes {
+		if probe.Name == "" || probe.Command == "" {
+			return breverrors.NewValidationError(fmt.Sprintf("probes[%d]: name and command are required", i))
+		}
+		if probe.Timeout != "" {
+			if _, err := time.ParseDuration(probe.Timeout); err != nil {
+				return breverrors.NewValidationError(fmt.Sprintf("probes[%d]: invalid timeout %q", i, probe.Timeout))
+			}
+		}
+	}
+	return nil
+}
+
+// GetIdleTimeout assumes the policy is valid
+func (p Policy) GetIdleTimeout() time.Duration {
+	d, err := time.ParseDuration(p.IdleTimeout)
+	if err != nil {
+		return 30 * time.Minute
+	}
+	return d
+}
@@ -0,0 +1,321 @@
+package idle
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// Activity is the result of checking one signal
+type Activity struct {
+	Signal string `json:"signal"`
+	Active bool   `json:"active"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type Signal interface {
+	Name() string
+	Check() (Activity, error)
+}
+
+// SSHSignal is active while there are established connections to the ssh port
+type SSHSignal struct {
+	fs   afero.Fs
+	port int
+}
+
+var _ Signal = &SSHSignal{}
+
+func NewSSHSignal(fs afero.Fs, port int) *SSHSignal {
+	return &SSHSignal{fs: fs, port: port}
+}
+
+func (s SSHSignal) Name() string {
+	return "ssh"
+}
+
+const tcpEstablished = "01"
+
+func (s SSHSignal) Check() (Activity, error) {
+	sessions := 0
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		b, err := afero.ReadFile(s.fs, path)
+		if err != nil {
+			if path == "/proc/net/tcp6" { // ipv6 may be disabled
+				continue
+			}
+			return Activity{}, breverrors.WrapAndTrace(err)
+		}
+		sessions += countEstablished(b, s.port)
+	}
+	return Activity{
+		Signal: s.Name(),
+		Active: sessions > 0,
+		Detail: fmt.Sprintf("%d ssh sessions", sessions),
+	}, nil
+}
+
+// countEstablished parses /proc/net/tcp, where addresses are hex ip:port
+func countEstablished(procNetTCP []byte, port int) int {
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(procNetTCP))
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[3] != tcpEstablished {
+			continue
+		}
+		local := fields[1]
+		i := strings.LastIndex(local, ":")
+		if i < 0 {
+			continue
+		}
+		p, err := strconv.ParseInt(local[i+1:], 16, 32)
+		if err == nil && int(p) == port {
+			count++
+		}
+	}
+	return count
+}
+
+// CPULoadSignal is active while the 1 minute load average is above threshold
+type CPULoadSignal struct {
+	fs        afero.Fs
+	threshold float64
+}
+
+var _ Signal = &CPULoadSignal{}
+
+func NewCPULoadSignal(fs afero.Fs, threshold float64) *CPULoadSignal {
+	return &CPULoadSignal{fs: fs, threshold: threshold}
+}
+
+func (c CPULoadSignal) Name() string {
+	return "cpu"
+}
+
+func (c CPULoadSignal) Check() (Activity, error) {
+	b, err := afero.ReadFile(c.fs, "/proc/loadavg")
+	if err != nil {
+		return Activity{}, breverrors.WrapAndTrace(err)
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return Activity{}, fmt.Errorf("empty /proc/loadavg")
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Activity{}, breverrors.WrapAndTrace(err)
+	}
+	return Activity{
+		Signal: c.Name(),
+		Active: load > c.threshold,
+		Detail: fmt.Sprintf("load %.2f", load),
+	}, nil
+}
+
+// clockTicksPerSecond is USER_HZ, which is 100 on every linux we run on
+const clockTicksPerSecond = 100
+
+// ProcessSignal is active when processes used more than threshold cores of
+// cpu since the last check. It catches cpu bound work that does not touch the
+// gpu or the network, ex: data preprocessing
+type ProcessSignal struct {
+	fs        afero.Fs
+	threshold float64
+	ignore    map[string]bool
+	now       func() time.Time
+
+	lastTicks uint64
+	lastCheck time.Time
+}
+
+var _ Signal = &ProcessSignal{}
+
+func NewProcessSignal(fs afero.Fs, threshold float64, ignore []string) *ProcessSignal {
+	ignoreMap := map[string]bool{}
+	for _, name := range ignore {
+		ignoreMap[name] = true
+	}
+	return &ProcessSignal{fs: fs, threshold: threshold, ignore: ignoreMap, now: time.Now}
+}
+
+func (p ProcessSignal) Name() string {
+	return "processes"
+}
+
+func (p *ProcessSignal) Check() (Activity, error) {
+	ticks, err := p.totalTicks()
+	if err != nil {
+		return Activity{}, breverrors.WrapAndTrace(err)
+	}
+	now := p.now()
+	lastTicks, lastCheck := p.lastTicks, p.lastCheck
+	p.lastTicks, p.lastCheck = ticks, now
+
+	if lastCheck.IsZero() || ticks < lastTicks {
+		// nothing to compare against yet, or processes exited and took
+		// their cpu time with them
+		return Activity{Signal: p.Name(), Detail: "no previous sample"}, nil
+	}
+	elapsed := now.Sub(lastCheck).Seconds()
+	if elapsed <= 0 {
+		return Activity{Signal: p.Name()}, nil
+	}
+	cores := float64(ticks-lastTicks) / clockTicksPerSecond / elapsed
+	return Activity{
+		Signal: p.Name(),
+		Active: cores > p.threshold,
+		Detail: fmt.Sprintf("%.2f cores", cores),
+	}, nil
+}
+
+func (p ProcessSignal) totalTicks() (uint64, error) {
+	entries, err := afero.ReadDir(p.fs, "/proc")
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	var total uint64
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil {
+			continue
+		}
+		b, err := afero.ReadFile(p.fs, filepath.Join("/proc", e.Name(), "stat"))
+		if err != nil {
+			continue // process exited
+		}
+		comm, ticks, ok := parseProcStat(string(b))
+		if !ok || p.ignore[comm] {
+			continue
+		}
+		total += ticks
+	}
+	return total, nil
+}
+
+// parseProcStat returns the command name and utime+stime from /proc/pid/stat.
+// comm is wrapped in parens and may itself contain spaces or parens
+func parseProcStat(stat string) (string, uint64, bool) {
+	open := strings.Index(stat, "(")
+	closing := strings.LastIndex(stat, ")")
+	if open < 0 || closing < open {
+		return "", 0, false
+	}
+	comm := stat[open+1 : closing]
+	// fields after comm start at state, utime and stime are 12 and 13 from there
+	fields := strings.Fields(stat[closing+1:])
+	if len(fields) < 13 {
+		return "", 0, false
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return comm, utime + stime, true
+}
+
+type JobLister interface {
+	HasUnfinishedJobs(exceptID string) (bool, error)
+}
+
+// JobsSignal is active while brev bg jobs are running
+type JobsSignal struct {
+	jobs JobLister
+}
+
+var _ Signal = JobsSignal{}
+
+func NewJobsSignal(jobs JobLister) JobsSignal {
+	return JobsSignal{jobs: jobs}
+}
+
+func (j JobsSignal) Name() string {
+	return "jobs"
+}
+
+func (j JobsSignal) Check() (Activity, error) {
+	running, err := j.jobs.HasUnfinishedJobs("")
+	if err != nil {
+		return Activity{}, breverrors.WrapAndTrace(err)
+	}
+	detail := "no background jobs running"
+	if running {
+		detail = "background jobs running"
+	}
+	return Activity{Signal: j.Name(), Active: running, Detail: detail}, nil
+}
+
+const defaultProbeTimeout = 30 * time.Second
+
+// ProbeSignal runs a user script, exiting 0 means active and any other exit
+// code means idle
+type ProbeSignal struct {
+	name    string
+	command string
+	timeout time.Duration
+}
+
+var _ Signal = ProbeSignal{}
+
+func NewProbeSignal(probe ProbePolicy) ProbeSignal {
+	timeout := defaultProbeTimeout
+	if d, err := time.ParseDuration(probe.Timeout); err == nil {
+		timeout = d
+	}
+	return ProbeSignal{name: probe.Name, command: probe.Command, timeout: timeout}
+}
+
+func (p ProbeSignal) Name() string {
+	return "probe:" + p.name
+}
+
+func (p ProbeSignal) Check() (Activity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	err := exec.CommandContext(ctx, "bash", "-c", p.command).Run() // #nosec G204
+	if ctx.Err() != nil {
+		return Activity{}, fmt.Errorf("probe %s timed out after %s", p.name, p.timeout)
+	}
+	if _, ok := err.(*exec.ExitError); ok { //nolint:errorlint // Run returns it unwrapped
+		return Activity{Signal: p.Name(), Detail: err.Error()}, nil
+	}
+	if err != nil {
+		return Activity{}, breverrors.WrapAndTrace(err)
+	}
+	return Activity{Signal: p.Name(), Active: true, Detail: "probe exited 0"}, nil
+}
+
+// NewSignals builds the signals enabled by policy
+func NewSignals(policy Policy, fs afero.Fs, jobs JobLister) []Signal {
+	signals := []Signal{}
+	if !policy.SSH.Disabled {
+		signals = append(signals, NewSSHSignal(fs, policy.SSH.Port))
+	}
+	if !policy.CPU.Disabled {
+		signals = append(signals, NewCPULoadSignal(fs, policy.CPU.LoadThreshold))
+	}
+	if !policy.Processes.Disabled {
+		signals = append(signals, NewProcessSignal(fs, policy.Processes.CPUThreshold, policy.Processes.Ignore))
+	}
+	if !policy.Jobs.Disabled && jobs != nil {
+		signals = append(signals, NewJobsSignal(jobs))
+	}
+	for _, probe := range policy.Probes {
+		signals = append(signals, NewProbeSignal(probe))
+	}
+	return signals
+}
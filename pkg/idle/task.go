@@ -0,0 +1,172 @@
+package idle
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/brevdev/brev-cli/pkg/autostartconf"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+)
+
+const AuditLogFileName = "autostop_audit.log"
+
+type AutoStopTaskStore interface {
+	autostartconf.AutoStartStore
+	AutoStopWorkspace(workspaceID string) (*entity.Workspace, error)
+	GetCurrentWorkspaceID() (string, error)
+	IsWorkspace() (bool, error)
+	GetBrevHomePath() (string, error)
+}
+
+// AuditEntry is appended as a json line to the audit log every time the task
+// stops, or fails to stop, the workspace
+type AuditEntry struct {
+	Decision
+	WorkspaceID string `json:"workspaceId"`
+	DryRun      bool   `json:"dryRun,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// AutoStopTask is the idle detector daemon, run with brev tasks run autostopd.
+// It is opt-in, install it with brev tasks configure autostopd
+type AutoStopTask struct {
+	Store    AutoStopTaskStore
+	Jobs     JobLister
+	fs       afero.Fs
+	detector *Detector
+}
+
+var _ tasks.Task = &AutoStopTask{}
+
+func NewAutoStopTask(store AutoStopTaskStore, jobs JobLister) *AutoStopTask {
+	return &AutoStopTask{Store: store, Jobs: jobs, fs: afero.NewOsFs()}
+}
+
+// GetTaskSpec checks every minute. The detector lives as long as the daemon
+// does, since idle time is measured from when it started
+func (a *AutoStopTask) GetTaskSpec() tasks.TaskSpec {
+	return tasks.TaskSpec{RunCronImmediately: true, Cron: "@every 1m"}
+}
+
+// LoadPolicy reads the policy file from brev home, using DefaultPolicy when
+// there is none
+func LoadPolicy(fs afero.Fs, brevHome string) (*Policy, error) {
+	contents, err := afero.ReadFile(fs, filepath.Join(brevHome, PolicyFileName))
+	if os.IsNotExist(err) {
+		p := DefaultPolicy()
+		return &p, nil
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	policy, err := ParsePolicy(contents)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return policy, nil
+}
+
+// Run checks for idleness once, stopping the workspace when it is idle
+func (a *AutoStopTask) Run() error {
+	err := a.check()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (a *AutoStopTask) check() error {
+	isWorkspace, err := a.Store.IsWorkspace()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !isWorkspace {
+		return nil
+	}
+	brevHome, err := a.Store.GetBrevHomePath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if a.detector == nil {
+		// policy changes need a daemon restart, same as the other tasks' config
+		policy, err := LoadPolicy(a.fs, brevHome)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		a.detector = NewDetector(*policy, NewSignals(*policy, a.fs, a.Jobs))
+	}
+
+	decision := a.detector.Check()
+	if !decision.ShouldStop {
+		return nil
+	}
+
+	workspaceID, err := a.Store.GetCurrentWorkspaceID()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	entry := AuditEntry{Decision: decision, WorkspaceID: workspaceID, DryRun: a.detector.policy.DryRun}
+	if !entry.DryRun {
+		_, err = a.Store.AutoStopWorkspace(workspaceID)
+		if err != nil {
+			entry.Error = err.Error()
+		}
+	}
+	a.detector.Reset()
+	log.Printf("idle for %s, stopping %s (dry run: %t)", decision.IdleFor.Round(time.Second), workspaceID, entry.DryRun)
+
+	auditErr := appendAuditEntry(a.fs, filepath.Join(brevHome, AuditLogFileName), entry)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if auditErr != nil {
+		return breverrors.WrapAndTrace(auditErr)
+	}
+	return nil
+}
+
+func appendAuditEntry(fs afero.Fs, path string, entry AuditEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	f, err := fs.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer f.Close() //nolint:errcheck,gosec // write is checked
+	_, err = fmt.Fprintln(f, string(b))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// Configure installs the daemon, only on workspaces since there is nothing
+// to autostop on a laptop
+func (a *AutoStopTask) Configure() error {
+	isWorkspace, err := a.Store.IsWorkspace()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !isWorkspace {
+		return nil
+	}
+	daemonConfigurer := autostartconf.NewAutoStopConfigurer(a.Store)
+	if daemonConfigurer == nil {
+		return nil
+	}
+	err = daemonConfigurer.Install()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+//go:build !codeanalysis
+
+package mergeshells
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+func builtinRecognizers() []Recognizer {
+	return []Recognizer{
+		{Name: "node", Recognize: nodeVersion, Transforms: []func(string) string{transformVersion, truncateVersion(1)}, DefaultVersion: "20"},
+		{Name: "gatsby", Recognize: gatsbyVersion},
+		{Name: "rust", Recognize: rustVersion},
+		{Name: "golang", Recognize: goVersion, Transforms: []func(string) string{transformVersion, transformGoVersion}},
+		{Name: "python", Recognize: pythonVersion, Transforms: []func(string) string{transformVersion, truncateVersion(2), padVersion(2)}, DefaultVersion: "3.11"},
+		{Name: "java", Recognize: javaVersion, Transforms: []func(string) string{transformVersion, truncateVersion(1)}, DefaultVersion: "17"},
+		{Name: "gradle", Recognize: gradleVersion, DefaultVersion: "8.5"},
+		{Name: "maven", Recognize: mavenVersion, Transforms: []func(string) string{transformVersion, padVersion(3)}, DefaultVersion: "3.9.6"},
+		{Name: "dotnet", Recognize: dotnetVersion, Transforms: []func(string) string{transformVersion, truncateVersion(2), padVersion(2)}, DefaultVersion: "8.0"},
+		{Name: "ruby", Recognize: rubyVersion, Transforms: []func(string) string{transformVersion, padVersion(3)}, DefaultVersion: "3.2.2"},
+		{Name: "elixir", Recognize: elixirVersion, Transforms: []func(string) string{transformVersion, padVersion(3)}, DefaultVersion: "1.15.7"},
+		{Name: "deno", Recognize: denoVersion, Transforms: []func(string) string{transformVersion, padVersion(3)}, DefaultVersion: "1.39.1"},
+		{Name: "bun", Recognize: bunVersion, Transforms: []func(string) string{transformVersion, padVersion(3)}, DefaultVersion: "1.0.21"},
+		{Name: "conda", Recognize: condaVersion, DefaultVersion: "latest"},
+		// the cuda apt packages are versioned like cuda-toolkit-12-1
+		{Name: "cuda", Recognize: cudaVersion, Transforms: []func(string) string{transformVersion, truncateVersion(2), padVersion(2), func(v string) string { return strings.ReplaceAll(v, ".", "-") }}},
+	}
+}
+
+// versionSource is a file that may have a version constraint in it
+type versionSource struct {
+	File    string
+	Extract func(contents string) string
+}
+
+// matching extracts the first group of re
+func matching(re string) func(string) string {
+	compiled := regexp.MustCompile(re)
+	return func(contents string) string {
+		return firstGroup(compiled, contents)
+	}
+}
+
+// toolVersions is the version asdf is set to use for tool
+func toolVersions(tool string) versionSource {
+	return versionSource{File: `^\.tool-versions$`, Extract: matching(`(?m)^` + regexp.QuoteMeta(tool) + `\s+(\S+)`)}
+}
+
+// recognize is for dependencies used when any of filenames is in the repo,
+// with the constraint resolved from all of the sources
+func recognize(path string, filenames []string, sources ...versionSource) *string {
+	if len(recursivelyFindFile(filenames, path)) == 0 {
+		return nil
+	}
+	constraints := []string{}
+	for _, s := range sources {
+		constraints = append(constraints, findConstraint(path, []string{s.File}, s.Extract))
+	}
+	constraint := resolveConstraints(constraints...)
+	return &constraint
+}
+
+func pythonVersion(path string) *string {
+	return recognize(path,
+		[]string{`^pyproject\.toml$`, `^requirements.*\.txt$`, `^setup\.py$`, `^Pipfile$`, `^\.python-version$`},
+		versionSource{File: `^\.python-version$`, Extract: matching(`^\s*(\S+)`)},
+		versionSource{File: `^pyproject\.toml$`, Extract: matching(`(?m)^\s*(?:requires-)?python\s*=\s*["']([^"']+)["']`)},
+		versionSource{File: `^Pipfile$`, Extract: matching(`(?m)^\s*python_(?:full_)?version\s*=\s*["']([^"']+)["']`)},
+		versionSource{File: `^runtime\.txt$`, Extract: matching(`python-(\S+)`)},
+		toolVersions("python"),
+	)
+}
+
+// javaVersion is the major version, 1.8 is 8
+func javaVersion(path string) *string {
+	return recognize(path,
+		[]string{`^pom\.xml$`, `^build\.gradle(\.kts)?$`, `^\.java-version$`},
+		versionSource{File: `^\.java-version$`, Extract: matching(`^\s*(?:1\.)?(\d+)`)},
+		versionSource{File: `^pom\.xml$`, Extract: matching(`<(?:java\.version|maven\.compiler\.release|maven\.compiler\.source|release)>\s*(?:1\.)?(\d+)`)},
+		versionSource{File: `^build\.gradle(\.kts)?$`, Extract: matching(`JavaLanguageVersion\.of\((\d+)\)`)},
+		versionSource{File: `^build\.gradle(\.kts)?$`, Extract: matching(`(?:sourceCompatibility|JavaVersion\.VERSION_)\s*=?\s*['"]?(?:1[._])?(\d+)`)},
+	)
+}
+
+func gradleVersion(path string) *string {
+	return recognize(path,
+		[]string{`^build\.gradle(\.kts)?$`, `^settings\.gradle(\.kts)?$`},
+		versionSource{File: `^gradle-wrapper\.properties$`, Extract: matching(`distributionUrl=.*gradle-([\d.]+)-(?:bin|all)\.zip`)},
+	)
+}
+
+func mavenVersion(path string) *string {
+	return recognize(path,
+		[]string{`^pom\.xml$`},
+		versionSource{File: `^maven-wrapper\.properties$`, Extract: matching(`distributionUrl=.*apache-maven-([\d.]+)-bin`)},
+	)
+}
+
+// dotnetVersion is the sdk channel, ex: 8.0, from global.json or the newest
+// target framework
+func dotnetVersion(path string) *string {
+	return recognize(path,
+		[]string{`\.(cs|fs|vb)proj$`, `\.sln$`, `^global\.json$`},
+		versionSource{File: `^global\.json$`, Extract: func(contents string) string { return gjson.Get(contents, "sdk.version").String() }},
+		versionSource{File: `\.(cs|fs|vb)proj$`, Extract: matching(`<TargetFrameworks?>\s*net(?:coreapp)?(\d+\.\d+)`)},
+	)
+}
+
+func rubyVersion(path string) *string {
+	return recognize(path,
+		[]string{`^Gemfile$`, `^Gemfile\.lock$`, `^\.ruby-version$`, `\.gemspec$`},
+		versionSource{File: `^\.ruby-version$`, Extract: matching(`^\s*(?:ruby-)?(\S+)`)},
+		versionSource{File: `^Gemfile$`, Extract: matching(`(?m)^\s*ruby\s+["']([^"']+)["']`)},
+		versionSource{File: `^Gemfile\.lock$`, Extract: matching(`RUBY VERSION\s+ruby (\S+)`)},
+		toolVersions("ruby"),
+	)
+}
+
+func elixirVersion(path string) *string {
+	return recognize(path,
+		[]string{`^mix\.exs$`},
+		versionSource{File: `^mix\.exs$`, Extract: matching(`elixir:\s*"([^"]+)"`)},
+		toolVersions("elixir"),
+	)
+}
+
+func denoVersion(path string) *string {
+	return recognize(path,
+		[]string{`^deno\.jsonc?$`, `^deno\.lock$`},
+		versionSource{File: `^\.dvmrc$`, Extract: matching(`^\s*(\S+)`)},
+		toolVersions("deno"),
+	)
+}
+
+func bunVersion(path string) *string {
+	return recognize(path,
+		[]string{`^bun\.lockb?$`, `^bunfig\.toml$`},
+		versionSource{File: `^package\.json$`, Extract: func(contents string) string {
+			if pm := gjson.Get(contents, "packageManager").String(); strings.HasPrefix(pm, "bun@") {
+				return strings.TrimPrefix(pm, "bun@")
+			}
+			return gjson.Get(contents, "engines.bun").String()
+		}},
+		toolVersions("bun"),
+	)
+}
+
+var condaEnvFiles = []string{`^environment\.ya?ml$`}
+
+// condaVersion is always the default, the versions in environment.yml are
+// installed by conda itself
+func condaVersion(path string) *string {
+	return recognize(path, condaEnvFiles)
+}
+
+// cudaVersion is only recognized from the cuda a conda environment pins
+func cudaVersion(path string) *string {
+	constraint := findConstraint(path, condaEnvFiles, matching(`(?m)^\s*-\s*(?:cudatoolkit|cuda-version|cuda-toolkit|pytorch-cuda)\s*[=<>~]*\s*(\d+(?:\.\d+)*)`))
+	if constraint == "" {
+		return nil
+	}
+	return &constraint
+}
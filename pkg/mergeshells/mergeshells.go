@@ -6,9 +6,11 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -36,61 +38,25 @@ func DependenciesToShell(shell string, deps ...string) string {
 	return shellString
 }
 
+// GetDependencies runs the recognizers on path. To add a new recognizer for a
+// language, write a method that returns *string, where nil means this does
+// not recognize, "" means use the default version, and any non-empty string
+// is a version constraint for us to resolve. Then add it to
+// builtinRecognizers under the same name as the folder you can find the
+// installer within under the templates/ directory:
+// 1) add a folder of the same name under templates/
+// 2) add any direct versions we support using their version number as file name
+// 3) add a 'generic' fallback if we don't recognize a specific version, using
+// the name as file name, where ${version} is replaced with the version found.
+// In-house recognizers are loaded the same way with RegisterRecognizersFromDir
 func GetDependencies(path string) []string {
-	var deps []string
-
-	// to add a new recognizer for a language,
-	// write a method that returns *string,
-	// where nil means this does not recognize
-	// "" means use default
-	// and any non-empty string returned is a version number constraint for us to use
-	// then add it to the dictionary below under the same key
-	// as the folder you can find the installer within
-	// when looking under the templates/ directory.
-	// if there is or a pre-existing recognizer, append it to the end of the {} list
-	// if there is not a pre-existing recognizer, make certain to
-	// 1) add a folder of the same name under templates/
-	// 2) add any direct versions we support using their version number as file name
-	// 3) add a 'generic' fallback if we don't recognize a specific version, using key as filename
-	// TODO -- if there is no specific version installer for the version found
-	//         then try to replace ${version} in the generic installer
-	//              with the version number before importing it
-	supportedDependencyMap := map[string][]func(string) *string{
-		"node":   {nodeVersion},
-		"gatsby": {gatsbyVersion},
-		"rust":   {rustVersion},
-		"golang": {goVersion},
-	}
-
-	// these will be applied, in left-to-right order, to the version string returned by your version function
-	// before passing it to the finder / splicer of the install shell script for your dependency
-	processVersionMap := map[string][]func(string) string{
-		"golang":  {transformGoVersion},
-		"default": {transformVersion},
-	}
-
-	for dep, fs := range supportedDependencyMap {
-		versions := collections.Filter(func(x *string) bool {
-			if x == nil {
-				return false
-			}
-			return true
-		}, collections.Fanout(fs, path))
-		if len(versions) > 0 {
-			version := versions[0]
-			if len(*version) > 0 {
-				versionTransforms := processVersionMap["default"]
-				if len(processVersionMap[dep]) > 0 {
-					versionTransforms = processVersionMap[dep]
-				}
-
-				finalVersion := collections.S(versionTransforms...)(*version)
-				dep = strings.Join([]string{dep, finalVersion}, "-")
-			}
-			deps = append(deps, dep)
+	deps := []string{}
+	for _, r := range recognizers {
+		version := r.Recognize(path)
+		if version != nil {
+			deps = append(deps, r.dependency(*version))
 		}
 	}
-
 	return deps
 }
 
@@ -110,7 +76,9 @@ type MergeShellStore interface {
 	GetFileAsString(path string) (string, error)
 }
 
-func ImportPath(t *terminal.Terminal, path string, store MergeShellStore) {
+// ImportPath writes .brev/setup.sh for the repo at path. extraRecognizerDirs
+// are loaded after the user's and the repo's in-house recognizers, so they win
+func ImportPath(t *terminal.Terminal, path string, store MergeShellStore, extraRecognizerDirs ...string) {
 	pathExists := dirExists(path)
 	if !pathExists {
 		fmt.Println(strings.Join([]string{"Path:", path, "does not exist."}, " "))
@@ -139,12 +107,35 @@ func ImportPath(t *terminal.Terminal, path string, store MergeShellStore) {
 		return
 	}
 	if !dirExists(filepath.Join(path, ".brev", "setup.sh")) {
+		RegisterRecognizerDirs(path, extraRecognizerDirs...)
 		WriteBrevFile(t, GetDependencies(path), gitURL, path)
 	} else {
 		fmt.Println(".brev/setup.sh already exists - will not overwrite.")
 	}
 }
 
+// RegisterRecognizerDirs loads the in-house recognizers of the repo at path
+// and then extraDirs, so the ones loaded last win
+func RegisterRecognizerDirs(path string, extraDirs ...string) {
+	for _, dir := range append(recognizerDirs(path), extraDirs...) {
+		err := RegisterRecognizersFromDir(dir)
+		if err != nil {
+			fmt.Println(strings.Join([]string{"Could not load recognizers from", dir + ":", err.Error()}, " "))
+		}
+	}
+}
+
+// recognizerDirs are the user's in-house recognizers, then the repo's, so
+// the repo's win
+func recognizerDirs(path string) []string {
+	dirs := []string{}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		dirs = append(dirs, filepath.Join(home, ".brev", "recognizers"))
+	}
+	return append(dirs, filepath.Join(path, ".brev", "recognizers"))
+}
+
 func GenerateLogs(script string) string {
 	fragments := fromSh(script)
 	return strings.Join(collections.Fmap(extractInstallLine, fragments), "\n")
@@ -189,29 +180,10 @@ func transformGoVersion(version string) string {
 	return version
 }
 
+// transformVersion resolves a constraint, ex: ^14.2 or >=3.8,<3.12, to the
+// lowest version it allows
 func transformVersion(version string) string {
-	if len(version) > 0 {
-		switch version[0:0] {
-		case "~":
-			return version[1:]
-		case "^":
-			return version[1:]
-		case ">":
-			if version[0:1] == ">=" {
-				return version[2:]
-			}
-			return version[1:]
-		case "<":
-			if version[0:1] == "<=" {
-				return version[2:]
-			}
-			return version[1:]
-		case "=":
-			return version[1:]
-		}
-		return version
-	}
-	return version
+	return lowerBound(version)
 }
 
 func WriteBrevFile(t *terminal.Terminal, deps []string, gitURL string, path string) *error {
@@ -314,40 +286,43 @@ func fromSh(shScript string) []ShellFragment {
 }
 
 func importFile(nameVersion string) ([]ShellFragment, error) {
-	// split the name string into two with - -- left hand side is package, right hand side is version
-	// read from the generated path
+	// split the name string into two at the first - -- left hand side is package, right hand side is version
+	// read from the first template source with a folder for the package
 	// generate ShellFragment from it (fromSh) and return it
-	subPaths := strings.Split(nameVersion, "-")
-	noversion := false
-	if len(subPaths) == 1 {
-		noversion = true
-		subPaths = collections.Duplicate(subPaths[0])
-	}
-	path := filepath.Join(collections.Concat([]string{"templates"}, subPaths)...) //nolint:typecheck
-	script, err := templateFs.Open(path)
-	if err != nil && !noversion {
-		if subPaths[0] != subPaths[1] {
-			path = filepath.Join(collections.Concat([]string{"templates"}, collections.Duplicate(subPaths[0]))...) //nolint:typecheck
-			script, err = templateFs.Open(path)
-			if err != nil {
-				return []ShellFragment{}, err
-			}
-		} else {
-			return []ShellFragment{}, errors.New(strings.Join([]string{"Path does not exist:", path}, " "))
-		}
-	}
-	out, err := ioutil.ReadAll(script)
-	stringScript := string(out)
-	if !noversion {
-		stringScript = strings.ReplaceAll(stringScript, "${version}", subPaths[1])
-	}
-	// fmt.Println(stringScript)
+	name, version, hasVersion := strings.Cut(nameVersion, "-")
+	out, err := readTemplate(name, version)
 	if err != nil {
 		return []ShellFragment{}, err
 	}
+	stringScript := string(out)
+	if hasVersion {
+		stringScript = strings.ReplaceAll(stringScript, "${version}", version)
+	}
 	return fromSh(stringScript), nil
 }
 
+// readTemplate reads the installer for version, or the generic one named
+// after the package when there isn't one
+func readTemplate(name string, version string) ([]byte, error) {
+	for _, source := range templateSources {
+		if _, err := fs.Stat(source, name); err != nil {
+			continue
+		}
+		if version != "" {
+			script, err := fs.ReadFile(source, path.Join(name, version))
+			if err == nil {
+				return script, nil
+			}
+		}
+		script, err := fs.ReadFile(source, path.Join(name, name))
+		if err != nil {
+			return nil, errors.New(strings.Join([]string{"Path does not exist:", path.Join(name, name)}, " "))
+		}
+		return script, nil
+	}
+	return nil, errors.New(strings.Join([]string{"No installer for", name}, " "))
+}
+
 func toSh(script []ShellFragment) string {
 	// collections.Flatmap across generating the script from all of the component shell bits
 	return strings.Join(collections.Flatmap(func(frag ShellFragment) []string { //nolint:typecheck
@@ -550,24 +525,6 @@ func goVersion(path string) *string {
 	return nil
 }
 
-func isRuby(path string) bool {
-	paths := recursivelyFindFile([]string{"Gemfile.lock", "Gemfile"}, path)
-
-	if len(paths) > 0 {
-		return true
-	}
-	return false
-}
-
-func isPython(path string) bool {
-	paths := recursivelyFindFile([]string{"Gemfile.lock", "Gemfile"}, path)
-
-	if len(paths) > 0 {
-		return true
-	}
-	return false
-}
-
 func appendPath(a string, b string) string {
 	if a == "." {
 		return b
@@ -575,6 +532,10 @@ func appendPath(a string, b string) string {
 	return a + "/" + b
 }
 
+// skippedDirs have other projects' files, ex: the package.json of every
+// dependency
+var skippedDirs = map[string]bool{".git": true, "node_modules": true, ".venv": true, "vendor": true}
+
 // Returns list of paths to file
 func recursivelyFindFile(filenames []string, path string) []string {
 	var paths []string
@@ -608,7 +569,7 @@ func recursivelyFindFile(filenames []string, path string) []string {
 				}
 			}
 
-			if dir.IsDir() {
+			if dir.IsDir() && !skippedDirs[f.Name()] {
 				paths = append(paths, recursivelyFindFile(filenames, appendPath(path, f.Name()))...)
 			}
 		}
@@ -0,0 +1,242 @@
+//go:build !codeanalysis
+
+package mergeshells
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"sigs.k8s.io/yaml"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+)
+
+// Recognizer finds a dependency of a repo. Name is the folder with its
+// installers, under templates/ or the plugin directory it was loaded from
+type Recognizer struct {
+	Name string
+	// Recognize returns nil when path doesn't use the dependency, "" to use
+	// DefaultVersion, and otherwise a version constraint
+	Recognize func(path string) *string
+	// Transforms turn the constraint into the version the installer takes,
+	// in left to right order. transformVersion when there are none
+	Transforms []func(string) string
+	// DefaultVersion is left empty for installers without versions
+	DefaultVersion string
+}
+
+func (r Recognizer) dependency(constraint string) string {
+	transforms := r.Transforms
+	if len(transforms) == 0 {
+		transforms = []func(string) string{transformVersion}
+	}
+	version := constraint
+	for _, transform := range transforms {
+		if version == "" {
+			break
+		}
+		version = transform(version)
+	}
+	if version == "" {
+		version = r.DefaultVersion
+	}
+	if version == "" {
+		return r.Name
+	}
+	return r.Name + "-" + version
+}
+
+// recognizers are run in order, so generated scripts are the same every time
+var recognizers = builtinRecognizers()
+
+// RegisterRecognizer adds a recognizer, replacing the one with the same name
+func RegisterRecognizer(r Recognizer) {
+	for i := range recognizers {
+		if recognizers[i].Name == r.Name {
+			recognizers[i] = r
+			return
+		}
+	}
+	recognizers = append(recognizers, r)
+}
+
+// Dependency is what GetDependencies would return for the recognizer called
+// name finding constraint, ex: python and ^3.10 is python-3.10. False when
+// there is no recognizer called name
+func Dependency(name string, constraint string) (string, bool) {
+	for _, r := range recognizers {
+		if r.Name == name {
+			return r.dependency(constraint), true
+		}
+	}
+	return "", false
+}
+
+// templateSources are where installers are looked up, the first one with a
+// folder for a dependency has its installers
+var templateSources = []fs.FS{builtinTemplates()}
+
+func builtinTemplates() fs.FS {
+	templates, err := fs.Sub(templateFs, "templates")
+	if err != nil {
+		panic(err) // templates/ is embedded
+	}
+	return templates
+}
+
+const recognizerSpecFile = "recognizer.yaml"
+
+// recognizerSpec is the recognizer.yaml of a plugin, ex:
+//
+//	files: ["^WORKSPACE$", "^MODULE\\.bazel$"]
+//	version:
+//	  file: "^\\.bazelversion$"
+//	  regexp: "(\\S+)"
+//	versionParts: 3
+//	defaultVersion: 7.0.0
+type recognizerSpec struct {
+	// Files are regexps of file names, any of them means the repo uses it
+	Files []string `json:"files"`
+	// Version is where the version constraint is, when there is one
+	Version *versionSpec `json:"version,omitempty"`
+	// VersionParts truncates or pads the version to this many parts
+	VersionParts   int    `json:"versionParts,omitempty"`
+	DefaultVersion string `json:"defaultVersion,omitempty"`
+}
+
+type versionSpec struct {
+	// File is a regexp of the file names with the constraint
+	File string `json:"file"`
+	// JSONPath is a gjson path to the constraint in json files
+	JSONPath string `json:"jsonPath,omitempty"`
+	// Regexp has the constraint as its first group
+	Regexp string `json:"regexp,omitempty"`
+}
+
+// RegisterRecognizersFromDir loads in-house recognizers. Each folder in dir
+// with a recognizer.yaml is one, named after the folder, with its installers
+// next to it the way they are under templates/: a generic installer named
+// after the folder and optionally one per version, where ${version} is
+// replaced with the version found. Folder names can't have a -, since that is
+// what separates the name from the version in a dependency
+func RegisterRecognizersFromDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	found := false
+	for _, entry := range entries {
+		specPath := filepath.Join(dir, entry.Name(), recognizerSpecFile)
+		if !entry.IsDir() || !dirExists(specPath) {
+			continue
+		}
+		if strings.Contains(entry.Name(), "-") {
+			return breverrors.NewValidationError(fmt.Sprintf("invalid recognizer %s: the name can't contain -, ex: %s", filepath.Join(dir, entry.Name()), strings.ReplaceAll(entry.Name(), "-", "_")))
+		}
+		b, err := os.ReadFile(specPath) //nolint:gosec // plugins are the user's
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		var spec recognizerSpec
+		err = yaml.UnmarshalStrict(b, &spec)
+		if err != nil {
+			return breverrors.NewValidationError(fmt.Sprintf("invalid %s: %v", specPath, err))
+		}
+		r, err := spec.recognizer(entry.Name())
+		if err != nil {
+			return breverrors.NewValidationError(fmt.Sprintf("invalid %s: %v", specPath, err))
+		}
+		RegisterRecognizer(r)
+		found = true
+	}
+	if found {
+		templateSources = append([]fs.FS{os.DirFS(dir)}, templateSources...)
+	}
+	return nil
+}
+
+func (s recognizerSpec) recognizer(name string) (Recognizer, error) {
+	if len(s.Files) == 0 {
+		return Recognizer{}, fmt.Errorf("files is required")
+	}
+	for _, f := range s.Files {
+		_, err := regexp.Compile(f)
+		if err != nil {
+			return Recognizer{}, fmt.Errorf("files: %w", err)
+		}
+	}
+	var contentRe *regexp.Regexp
+	if s.Version != nil {
+		_, err := regexp.Compile(s.Version.File)
+		if err != nil {
+			return Recognizer{}, fmt.Errorf("version.file: %w", err)
+		}
+		if s.Version.Regexp != "" {
+			contentRe, err = regexp.Compile(s.Version.Regexp)
+			if err != nil {
+				return Recognizer{}, fmt.Errorf("version.regexp: %w", err)
+			}
+		}
+	}
+
+	r := Recognizer{Name: name, DefaultVersion: s.DefaultVersion}
+	if s.VersionParts > 0 {
+		r.Transforms = []func(string) string{transformVersion, truncateVersion(s.VersionParts), padVersion(s.VersionParts)}
+	}
+	r.Recognize = func(path string) *string {
+		if len(recursivelyFindFile(s.Files, path)) == 0 {
+			return nil
+		}
+		constraint := ""
+		if s.Version != nil {
+			constraint = findConstraint(path, []string{s.Version.File}, func(contents string) string {
+				if s.Version.JSONPath != "" {
+					return gjson.Get(contents, s.Version.JSONPath).String()
+				}
+				if s.Version.Regexp != "" {
+					return firstGroup(contentRe, contents)
+				}
+				return contents
+			})
+		}
+		return &constraint
+	}
+	return r, nil
+}
+
+// findConstraint resolves the constraints extract finds in all the files
+// named like one of filenames under path, ignoring files without one
+func findConstraint(path string, filenames []string, extract func(contents string) string) string {
+	paths := recursivelyFindFile(filenames, path)
+	sort.Strings(paths)
+	constraints := []string{}
+	for _, p := range paths {
+		contents, err := files.CatFile(p)
+		if err != nil {
+			continue
+		}
+		if c := extract(contents); c != "" {
+			constraints = append(constraints, c)
+		}
+	}
+	return resolveConstraints(constraints...)
+}
+
+func firstGroup(re *regexp.Regexp, contents string) string {
+	match := re.FindStringSubmatch(contents)
+	if len(match) < 2 {
+		return ""
+	}
+	return match[1]
+}
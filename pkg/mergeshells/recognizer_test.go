@@ -0,0 +1,136 @@
+//go:build !codeanalysis
+
+package mergeshells
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, dir string, contents map[string]string) {
+	for name, content := range contents {
+		p := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		assert.Nil(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+}
+
+func TestResolveConstraints(t *testing.T) {
+	for constraint, want := range map[string]string{
+		"3.11":           "3.11",
+		"v18.17.0":       "18.17.0",
+		">=3.8,<3.12":    "3.8",
+		"^3.10":          "3.10",
+		"~> 1.15":        "1.15",
+		"==3.11.*":       "3.11",
+		"^16 || ^18":     "16",
+		"14.x":           "14",
+		"<3.12":          "",
+		"lts/*":          "",
+		">= 2.7, != 3.0": "2.7",
+	} {
+		assert.Equal(t, want, lowerBound(constraint), constraint)
+	}
+	assert.Equal(t, "3.10.2", resolveConstraints(">=3.9", "3.10.2", "^3.10", ""))
+	assert.Equal(t, "", resolveConstraints())
+}
+
+func TestGetDependencies(t *testing.T) {
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		"services/api/pyproject.toml":                              "[project]\nrequires-python = \">=3.9\"\n",
+		"services/ml/.python-version":                              "3.10.12\n",
+		"services/ml/environment.yml":                              "dependencies:\n  - python=3.10\n  - pytorch-cuda=12.1\n",
+		"services/web/package.json":                                `{"engines": {"node": "^18.17.0"}}`,
+		"services/web/node_modules/x/package.json":                 `{"engines": {"node": ">=22"}}`,
+		"services/billing/pom.xml":                                 "<properties><maven.compiler.release>17</maven.compiler.release></properties>",
+		"services/legacy/build.gradle":                             "java { sourceCompatibility = JavaVersion.VERSION_1_8 }",
+		"services/legacy/gradle/wrapper/gradle-wrapper.properties": "distributionUrl=https\\://services.gradle.org/distributions/gradle-8.4-bin.zip\n",
+		"services/reports/Reports.csproj":                          "<Project><PropertyGroup><TargetFramework>net6.0</TargetFramework></PropertyGroup></Project>",
+		"tools/Gemfile":                                            "source \"https://rubygems.org\"\nruby \"~> 3.1\"\n",
+		"tools/notify/mix.exs":                                     "def project do [app: :notify, elixir: \"~> 1.15\"] end",
+		"apps/edge/deno.json":                                      "{}",
+		"apps/script/bun.lockb":                                    "",
+		"apps/script/package.json":                                 `{"packageManager": "bun@1.0.3"}`,
+	})
+
+	assert.Equal(t, []string{
+		"node-18", "python-3.10", "java-17", "gradle-8.4", "maven-3.9.6", "dotnet-6.0",
+		"ruby-3.1.0", "elixir-1.15.0", "deno-1.39.1", "bun-1.0.3", "conda-latest", "cuda-12-1",
+	}, GetDependencies(repo))
+}
+
+func TestMergeShellsInstallers(t *testing.T) {
+	script := MergeShells("gradle-8.4", "java-17")
+	assert.Contains(t, script, "sudo apt-get install -y openjdk-17-jdk")
+	assert.Contains(t, script, "gradle-8.4-bin.zip")
+	assert.Less(t, strings.Index(script, "openjdk-17-jdk"), strings.Index(script, "gradle-8.4-bin.zip"), "java is installed before gradle")
+
+	for _, r := range builtinRecognizers() {
+		dep := r.dependency("")
+		script := MergeShells(dep)
+		assert.NotEmpty(t, strings.TrimSpace(script), dep)
+		if r.DefaultVersion != "" {
+			assert.NotContains(t, script, "${version}", dep)
+		}
+	}
+}
+
+func TestRegisterRecognizersFromDir(t *testing.T) {
+	previousRecognizers, previousSources := recognizers, templateSources
+	t.Cleanup(func() { recognizers, templateSources = previousRecognizers, previousSources })
+	recognizers = builtinRecognizers()
+
+	plugins := t.TempDir()
+	writeFiles(t, plugins, map[string]string{
+		"bazel/recognizer.yaml": "files: [\"^WORKSPACE$\"]\nversion:\n  file: \"^\\\\.bazelversion$\"\n  regexp: \"(\\\\S+)\"\nversionParts: 3\ndefaultVersion: 7.0.0\n",
+		"bazel/bazel":           "# bazel ${version}\n# installing Bazel ${version} with bazelisk\nUSE_BAZEL_VERSION=${version} bazelisk version\n",
+		"notes/README.md":       "not a recognizer",
+	})
+	assert.Nil(t, RegisterRecognizersFromDir(plugins))
+	assert.Nil(t, RegisterRecognizersFromDir(filepath.Join(plugins, "missing")))
+
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{"WORKSPACE": "", ".bazelversion": "6.4\n"})
+	assert.Equal(t, []string{"bazel-6.4.0"}, GetDependencies(repo))
+	assert.Contains(t, MergeShells("bazel-6.4.0"), "USE_BAZEL_VERSION=6.4.0 bazelisk version")
+	assert.Contains(t, MergeShells("python-3.11"), "python3.11", "built in installers are still found")
+
+	invalid := t.TempDir()
+	writeFiles(t, invalid, map[string]string{"broken/recognizer.yaml": "filez: [\"x\"]\n"})
+	assert.ErrorContains(t, RegisterRecognizersFromDir(invalid), "broken/recognizer.yaml")
+
+	dashed := t.TempDir()
+	writeFiles(t, dashed, map[string]string{
+		"my-tool/recognizer.yaml": "files: [\"^my-tool\\.toml$\"]\n",
+		"my-tool/my-tool":         "# my-tool\n# installing my-tool\nmy-tool install\n",
+	})
+	assert.ErrorContains(t, RegisterRecognizersFromDir(dashed), "my_tool")
+	_, ok := Dependency("my-tool", "")
+	assert.False(t, ok)
+}
+
+func TestRegisterRecognizerDirsLoadsExtraDirsLast(t *testing.T) {
+	previousRecognizers, previousSources := recognizers, templateSources
+	t.Cleanup(func() { recognizers, templateSources = previousRecognizers, previousSources })
+	recognizers = builtinRecognizers()
+	t.Setenv("HOME", t.TempDir())
+
+	repo := t.TempDir()
+	extra := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		"WORKSPACE": "",
+		".brev/recognizers/bazel/recognizer.yaml": "files: [\"^WORKSPACE$\"]\ndefaultVersion: repo\n",
+		".brev/recognizers/bazel/bazel":           "# bazel ${version}\n# installing bazel ${version}\necho ${version}\n",
+	})
+	writeFiles(t, extra, map[string]string{
+		"bazel/recognizer.yaml": "files: [\"^WORKSPACE$\"]\ndefaultVersion: extra\n",
+		"bazel/bazel":           "# bazel ${version}\n# installing bazel ${version}\necho ${version}\n",
+	})
+	RegisterRecognizerDirs(repo, extra)
+	assert.Equal(t, []string{"bazel-extra"}, GetDependencies(repo))
+}
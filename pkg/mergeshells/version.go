@@ -0,0 +1,104 @@
+//go:build !codeanalysis
+
+package mergeshells
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	versionRe = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)`)
+	// operatorRe is an operator spaced from its version, ex: >= 3.8
+	operatorRe = regexp.MustCompile(`([<>=!~^]+)\s+`)
+)
+
+// lowerBound is the lowest version a constraint allows, ex: >=3.8,<3.12 is
+// 3.8, ~> 1.15 is 1.15 and 3.11.* is 3.11. For alternatives, ^16 || ^18,
+// the first one wins. A constraint with only upper bounds, or none, is ""
+func lowerBound(constraint string) string {
+	alternative := operatorRe.ReplaceAllString(strings.Split(constraint, "||")[0], "$1")
+	bound := ""
+	for _, clause := range strings.FieldsFunc(alternative, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if strings.HasPrefix(clause, "<") || strings.HasPrefix(clause, "!=") {
+			continue
+		}
+		version := versionRe.FindStringSubmatch(strings.TrimLeft(clause, "=~^>"))
+		if version == nil {
+			continue
+		}
+		if compareVersions(version[1], bound) > 0 {
+			bound = version[1]
+		}
+	}
+	return bound
+}
+
+// resolveConstraints picks one version for all of the constraints found in a
+// repo, the highest lower bound, since that is the one every package of a
+// monorepo that only sets a minimum can run on
+func resolveConstraints(constraints ...string) string {
+	resolved := ""
+	for _, c := range constraints {
+		bound := lowerBound(c)
+		if compareVersions(bound, resolved) > 0 {
+			resolved = bound
+		}
+	}
+	return resolved
+}
+
+// compareVersions compares dotted versions numerically, "" is the lowest
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	if a == "" {
+		as = nil
+	}
+	if b == "" {
+		bs = nil
+	}
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := -1, -1
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// truncateVersion keeps the first n parts of a version, for installers that
+// only take ex: a major version
+func truncateVersion(n int) func(string) string {
+	return func(version string) string {
+		parts := strings.Split(version, ".")
+		if len(parts) > n {
+			parts = parts[:n]
+		}
+		return strings.Join(parts, ".")
+	}
+}
+
+// padVersion fills in missing parts with 0 for installers that only take
+// full versions, ex: 3.2 is 3.2.0
+func padVersion(n int) func(string) string {
+	return func(version string) string {
+		if version == "" {
+			return version
+		}
+		parts := strings.Split(version, ".")
+		for len(parts) < n {
+			parts = append(parts, "0")
+		}
+		return strings.Join(parts, ".")
+	}
+}
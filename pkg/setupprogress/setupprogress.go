@@ -0,0 +1,236 @@
+// Package setupprogress records the steps of a dev environment's setup as json
+// lines, so the cli can show real progress instead of scanning the setup log
+package setupprogress
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Path is on the dev environment, next to the other setup logs
+const Path = "/var/log/brev-setup-progress.jsonl"
+
+// steps the cli waits for
+const (
+	StepRepos = "repos"
+	StepExecs = "execs"
+)
+
+type EventType string
+
+const (
+	// Planned lists every step the setup is going to run, it is always the
+	// first event
+	Planned  EventType = "planned"
+	Started  EventType = "started"
+	Finished EventType = "finished"
+	Failed   EventType = "failed"
+	// Done is the last event, Error is set if any step failed
+	Done EventType = "done"
+)
+
+type Event struct {
+	Time     time.Time     `json:"time"`
+	Type     EventType     `json:"type"`
+	Step     string        `json:"step,omitempty"`
+	Steps    []string      `json:"steps,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Recorder appends events to the progress file. Writing is best effort, the
+// setup never fails because its progress could not be recorded. A nil
+// Recorder just runs the steps
+type Recorder struct {
+	path string
+	now  func() time.Time
+	mu   sync.Mutex
+}
+
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path, now: time.Now}
+}
+
+// Start truncates the progress of a previous setup, ex: before a restart
+func (r *Recorder) Start(steps ...string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = os.WriteFile(r.path, nil, 0o644) //nolint:gosec // read by the user over ssh
+	r.writeLocked(Event{Type: Planned, Steps: steps})
+}
+
+// Step records fn starting and finishing, and returns its error
+func (r *Recorder) Step(name string, fn func() error) error {
+	if r == nil {
+		return fn()
+	}
+	start := r.now()
+	r.write(Event{Type: Started, Step: name})
+	err := fn()
+	ev := Event{Type: Finished, Step: name, Duration: r.now().Sub(start)}
+	if err != nil {
+		ev.Type = Failed
+		ev.Error = err.Error()
+	}
+	r.write(ev)
+	return err
+}
+
+func (r *Recorder) Done(err error) {
+	if r == nil {
+		return
+	}
+	ev := Event{Type: Done}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	r.write(ev)
+}
+
+func (r *Recorder) write(ev Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeLocked(ev)
+}
+
+func (r *Recorder) writeLocked(ev Event) {
+	ev.Time = r.now()
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644) //nolint:gosec // read by the user over ssh
+	if err != nil {
+		return
+	}
+	defer f.Close() //nolint:errcheck // best effort
+	_, _ = f.Write(append(b, '\n'))
+}
+
+// Parse skips lines that aren't events, ex: a line that is still being
+// written
+func Parse(data []byte) []Event {
+	events := []Event{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var ev Event
+		if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &ev) != nil {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+type StepStatus string
+
+const (
+	StepPending  StepStatus = "pending"
+	StepRunning  StepStatus = "running"
+	StepFinished StepStatus = "finished"
+	StepFailed   StepStatus = "failed"
+)
+
+type Step struct {
+	Name     string
+	Status   StepStatus
+	Duration time.Duration
+	Error    string
+}
+
+// Progress is the state of the setup after a list of events
+type Progress struct {
+	Steps []Step
+	Done  bool
+	Error string
+	// Events is how many events there were, it only grows while setup runs
+	Events int
+}
+
+func Summarize(events []Event) Progress {
+	p := Progress{Events: len(events)}
+	index := map[string]int{}
+	step := func(name string) *Step {
+		i, ok := index[name]
+		if !ok {
+			i = len(p.Steps)
+			index[name] = i
+			p.Steps = append(p.Steps, Step{Name: name, Status: StepPending})
+		}
+		return &p.Steps[i]
+	}
+	for _, ev := range events {
+		switch ev.Type {
+		case Planned:
+			for _, s := range ev.Steps {
+				step(s)
+			}
+		case Started:
+			step(ev.Step).Status = StepRunning
+		case Finished:
+			s := step(ev.Step)
+			s.Status = StepFinished
+			s.Duration = ev.Duration
+		case Failed:
+			s := step(ev.Step)
+			s.Status = StepFailed
+			s.Duration = ev.Duration
+			s.Error = ev.Error
+		case Done:
+			p.Done = true
+			p.Error = ev.Error
+		}
+	}
+	return p
+}
+
+// Percent is the share of steps that have finished, failed or not
+func (p Progress) Percent() int {
+	if p.Done {
+		return 100
+	}
+	if len(p.Steps) == 0 {
+		return 0
+	}
+	ended := 0
+	for _, s := range p.Steps {
+		if s.Status == StepFinished || s.Status == StepFailed {
+			ended++
+		}
+	}
+	return ended * 100 / len(p.Steps)
+}
+
+// Reached is true once a step has ended, or the whole setup has. A setup
+// without the step, ex: from another version of brev, reaches it once any
+// step has started, since it is never going to run
+func (p Progress) Reached(name string) bool {
+	if p.Done {
+		return true
+	}
+	started := false
+	for _, s := range p.Steps {
+		if s.Name == name {
+			return s.Status == StepFinished || s.Status == StepFailed
+		}
+		if s.Status != StepPending {
+			started = true
+		}
+	}
+	return started
+}
+
+// Running is the step to show while waiting, "" if none is running
+func (p Progress) Running() string {
+	for _, s := range p.Steps {
+		if s.Status == StepRunning {
+			return s.Name
+		}
+	}
+	return ""
+}
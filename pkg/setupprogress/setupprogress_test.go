@@ -0,0 +1,90 @@
+package setupprogress
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorderAndSummarize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.jsonl")
+	r := NewRecorder(path)
+	r.Start(StepRepos, StepExecs, "extras")
+	assert.Nil(t, r.Step(StepRepos, func() error { return nil }))
+
+	b, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	p := Summarize(Parse(b))
+	assert.True(t, p.Reached(StepRepos))
+	assert.False(t, p.Reached(StepExecs))
+	assert.Equal(t, 33, p.Percent())
+	assert.Equal(t, "", p.Running())
+
+	assert.Error(t, r.Step(StepExecs, func() error { return errors.New("boom") }))
+	r.Done(errors.New("boom"))
+
+	b, err = os.ReadFile(path)
+	assert.Nil(t, err)
+	// a half written line is ignored
+	p = Summarize(Parse(append(b, []byte(`{"type":"sta`)...)))
+	assert.True(t, p.Done)
+	assert.Equal(t, 100, p.Percent())
+	assert.Equal(t, []StepStatus{StepFinished, StepFailed, StepPending}, []StepStatus{p.Steps[0].Status, p.Steps[1].Status, p.Steps[2].Status})
+	assert.Equal(t, "boom", p.Steps[1].Error)
+
+	// a new setup starts from scratch
+	r.Start(StepRepos)
+	b, err = os.ReadFile(path)
+	assert.Nil(t, err)
+	p = Summarize(Parse(b))
+	assert.False(t, p.Done)
+	assert.Len(t, p.Steps, 1)
+}
+
+func TestNilRecorderRunsSteps(t *testing.T) {
+	var r *Recorder
+	r.Start(StepRepos)
+	ran := false
+	assert.Nil(t, r.Step(StepRepos, func() error { ran = true; return nil }))
+	assert.True(t, ran)
+	r.Done(nil)
+}
+
+func TestRead(t *testing.T) {
+	p, err := Read(func() ([]byte, error) { return nil, nil })
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+}
+
+func TestReachedWithoutTheStep(t *testing.T) {
+	p := Summarize([]Event{{Type: Planned, Steps: []string{"prepare", StepExecs}}})
+	assert.False(t, p.Reached(StepRepos))
+	p = Summarize([]Event{{Type: Planned, Steps: []string{"prepare", StepExecs}}, {Type: Started, Step: StepExecs}})
+	assert.True(t, p.Reached(StepRepos), "a setup without the step is never going to run it")
+}
+
+func TestWaitStopsWhenSetupStalls(t *testing.T) {
+	pollInterval, stalledPolls := PollInterval, StalledPolls
+	t.Cleanup(func() { PollInterval, StalledPolls = pollInterval, stalledPolls })
+	PollInterval, StalledPolls = time.Millisecond, 3
+
+	// setup was killed while cloning
+	data := []byte(`{"type":"planned","steps":["repos","execs"]}` + "\n" + `{"type":"started","step":"repos"}` + "\n")
+	done := make(chan *Progress, 1)
+	go func() {
+		p, err := Wait(terminal.New(), func() ([]byte, error) { return data, nil }, StepRepos)
+		assert.Nil(t, err)
+		done <- p
+	}()
+	select {
+	case p := <-done:
+		assert.False(t, p.Reached(StepRepos))
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not stop")
+	}
+}
@@ -0,0 +1,144 @@
+package setupprogress
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+// Source reads the progress file, it returns nil data if there is none
+type Source func() ([]byte, error)
+
+// SSHSource reads the progress file of the dev environment behind an ssh
+// alias. Dev environments set up by older versions don't have one
+func SSHSource(sshAlias string) Source {
+	return func() ([]byte, error) {
+		out, err := exec.Command("ssh", "-o", "RemoteCommand=none", sshAlias, fmt.Sprintf("cat %s 2>/dev/null || true", Path)).Output() //nolint:gosec // the alias comes from the user's ssh config
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		return out, nil
+	}
+}
+
+// Read returns nil if the dev environment does not record its progress
+func Read(src Source) (*Progress, error) {
+	data, err := src()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	events := Parse(data)
+	if len(events) == 0 {
+		return nil, nil
+	}
+	p := Summarize(events)
+	return &p, nil
+}
+
+var (
+	PollInterval = 2 * time.Second
+	// StalledPolls without a new event mean setup is no longer running, ex:
+	// it crashed or the dev environment was restarted
+	StalledPolls = 150
+	// WaitTimeout is the longest Wait waits, whatever the progress
+	WaitTimeout = 30 * time.Minute
+)
+
+// Wait shows a progress bar until step has ended, then a summary of every
+// step so far. Nothing is shown if the step already ended. It does not fail
+// when a step failed, the caller decides what that means. It also stops
+// waiting, with a warning, on ctrl-c, when setup stalls and after
+// WaitTimeout, so that the caller can go on
+func Wait(t *terminal.Terminal, src Source, step string) (*Progress, error) {
+	p, err := Read(src)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if p == nil {
+		return nil, nil
+	}
+	if p.Reached(step) {
+		return p, nil
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	deadline := time.Now().Add(WaitTimeout)
+	stalled := 0
+	warning := ""
+
+	bar := t.NewProgressBar(describe(*p), nil)
+	for warning == "" && !p.Reached(step) {
+		bar.Describe(describe(*p))
+		bar.AdvanceTo(p.Percent())
+		select {
+		case <-interrupt:
+			warning = "stopped waiting for setup"
+			continue
+		case <-time.After(PollInterval):
+		}
+		next, err := Read(src)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		if next != nil && next.Events != p.Events {
+			p = next
+			stalled = 0
+		} else {
+			stalled++
+		}
+		switch {
+		case stalled >= StalledPolls:
+			warning = fmt.Sprintf("setup has not made progress in %s", (time.Duration(stalled) * PollInterval).Round(time.Second))
+		case time.Now().After(deadline):
+			warning = fmt.Sprintf("setup is still running after %s", WaitTimeout)
+		}
+	}
+	bar.AdvanceTo(p.Percent())
+	t.Vprint("\n")
+	if warning != "" {
+		t.Vprint(t.Yellow("%s, continuing without it, run brev logs to follow it\n", warning))
+	}
+	t.Vprint(FormatSummary(t, *p))
+	return p, nil
+}
+
+func describe(p Progress) string {
+	if running := p.Running(); running != "" {
+		return fmt.Sprintf("setting up: %s", running)
+	}
+	return "setting up"
+}
+
+// FormatSummary has a line per step, failed steps with their error
+func FormatSummary(t *terminal.Terminal, p Progress) string {
+	width := 0
+	for _, s := range p.Steps {
+		if len(s.Name) > width {
+			width = len(s.Name)
+		}
+	}
+	var b strings.Builder
+	for _, s := range p.Steps {
+		line := fmt.Sprintf("%-*s", width, s.Name)
+		switch s.Status {
+		case StepFinished:
+			line = t.Green("  ✓ %s  %s", line, s.Duration.Round(time.Second))
+		case StepFailed:
+			line = t.Red("  ✗ %s  %s  %s", line, s.Duration.Round(time.Second), s.Error)
+		case StepRunning:
+			line = t.Yellow("  … %s  running", line)
+		default:
+			line = fmt.Sprintf("    %s  pending", line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
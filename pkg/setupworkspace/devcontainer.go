@@ -0,0 +1,178 @@
+package setupworkspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/devcontainer"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// repoPaths are where each repo is cloned, by name
+func (w WorkspaceIniter) repoPaths() map[string]string {
+	paths := map[string]string{}
+	for n, r := range w.ReposV1 {
+		p, err := w.GetRepoPath(r)
+		if err == nil {
+			paths[string(n)] = p
+		}
+	}
+	for n, r := range w.ReposV0 {
+		paths[string(n)] = w.BuildWorkspacePath(r.Directory)
+	}
+	return paths
+}
+
+// importDevContainers translates the devcontainer.json of each repo that has
+// one. It returns the execs with the imported ones, where the execs of the
+// setup params win, and the vscode extensions that aren't installed already
+func (w WorkspaceIniter) importDevContainers() (entity.ExecsV1, []string, error) {
+	execs := entity.ExecsV1{}
+	for n, e := range w.ExecsV1 {
+		execs[n] = e
+	}
+	installed := map[string]bool{}
+	for _, id := range w.VscodeExtensionIDs {
+		installed[id] = true
+	}
+	extensionIDs := []string{}
+	env := map[string]string{}
+
+	paths := w.repoPaths()
+	names := []string{}
+	for n := range paths {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		path, ok := devcontainer.Find(paths[n])
+		if !ok {
+			continue
+		}
+		d, err := devcontainer.Load(path)
+		if err != nil {
+			fmt.Printf("could not import %s: %v\n", path, err)
+			continue
+		}
+		imported := d.Translate(paths[n], n)
+		fmt.Printf("imported %s\n", path)
+		for _, u := range imported.Unsupported {
+			fmt.Printf("  unsupported %s\n", u)
+		}
+		for name, e := range imported.Execs {
+			if _, ok := execs[name]; !ok {
+				execs[name] = e
+			}
+		}
+		for _, id := range imported.ExtensionIDs() {
+			if !installed[id] {
+				installed[id] = true
+				extensionIDs = append(extensionIDs, id)
+			}
+		}
+		for k, v := range imported.Env {
+			env[k] = v
+		}
+	}
+
+	err := w.addEnvVars(env)
+	if err != nil {
+		return execs, extensionIDs, breverrors.WrapAndTrace(err)
+	}
+	return execs, extensionIDs, nil
+}
+
+// envPath is the env file shells of the dev environment load, see brev
+// configure-env-vars
+func (w WorkspaceIniter) envPath() string {
+	if w.WorkspaceDir == w.User.HomeDir {
+		return w.BuildHomePath(".brev", ".env")
+	}
+	return w.BuildWorkspacePath(".env")
+}
+
+var envKeyRe = regexp.MustCompile(`(?m)^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)=`)
+
+// addEnvVars appends the vars that aren't in the env file yet, so that ones
+// set for the dev environment win
+func (w WorkspaceIniter) addEnvVars(env map[string]string) error {
+	if len(env) == 0 {
+		return nil
+	}
+	envPath := w.envPath()
+	existing := map[string]bool{}
+	contents, err := os.ReadFile(envPath) //nolint:gosec // the dev environment's env file
+	if err != nil && !os.IsNotExist(err) {
+		return breverrors.WrapAndTrace(err)
+	}
+	for _, m := range envKeyRe.FindAllStringSubmatch(string(contents), -1) {
+		existing[m[1]] = true
+	}
+
+	keys := []string{}
+	for k := range env {
+		if !existing[k] {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	lines := []string{}
+	if len(contents) > 0 && !strings.HasSuffix(string(contents), "\n") {
+		lines = append(lines, "")
+	}
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("export %s='%s'", k, env[k]))
+	}
+
+	err = os.MkdirAll(filepath.Dir(envPath), 0o775) //nolint:gosec // occurs in safe area
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = AppendToOrCreateFile(envPath, strings.Join(lines, "\n")+"\n")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = ChownFilePathToUser(envPath, w.User)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// writeDevContainerPorts writes ports.yaml from the forwarded ports of the
+// devcontainer.json of the repo at repoPath, when it has any
+func (w WorkspaceIniter) writeDevContainerPorts(repoPath string, portsYamlPath string) error {
+	path, ok := devcontainer.Find(repoPath)
+	if !ok {
+		return nil
+	}
+	d, err := devcontainer.Load(path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	ports := d.Ports()
+	if len(ports) == 0 {
+		return nil
+	}
+	contents := "ports:\n"
+	for _, p := range ports {
+		contents += fmt.Sprintf("  - \"%s:%s\"\n", p, p)
+	}
+	err = os.WriteFile(portsYamlPath, []byte(contents), 0o644) //nolint:gosec // not a secret
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = ChownFilePathToUser(portsYamlPath, w.User)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
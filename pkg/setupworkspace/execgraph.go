@@ -0,0 +1,347 @@
+package setupworkspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/hashicorp/go-multierror"
+)
+
+type ExecStatus string
+
+const (
+	// ExecPending is for execs that have not finished yet
+	ExecPending   ExecStatus = "pending"
+	ExecSucceeded ExecStatus = "success"
+	ExecFailed    ExecStatus = "failed"
+	// ExecSkipped is for execs that did not run because something they
+	// depend on did not succeed
+	ExecSkipped  ExecStatus = "skipped"
+	ExecDisabled ExecStatus = "disabled"
+	// ExecBuilt is for build stage execs that already ran on a previous start
+	ExecBuilt ExecStatus = "built"
+)
+
+// IsDone is false while an exec may still run
+func (s ExecStatus) IsDone() bool {
+	return s != "" && s != ExecPending
+}
+
+// IsFailure is true for execs that failed or could not run because of a
+// failure
+func (s ExecStatus) IsFailure() bool {
+	return s == ExecFailed || s == ExecSkipped
+}
+
+// satisfies is true for statuses that let dependents run
+func (s ExecStatus) satisfies() bool {
+	return s == ExecSucceeded || s == ExecDisabled || s == ExecBuilt
+}
+
+// setupNode is anything in the setup that can depend on something else, ex:
+// an exec or a repo's setup script
+type setupNode struct {
+	Name      string
+	Stage     entity.ExecStage
+	DependsOn []string
+	Disabled  bool
+	// LogPath is where the output of Run ends up, if anywhere
+	LogPath string
+	Run     func() error
+}
+
+type ExecResult struct {
+	Name     string
+	Stage    entity.ExecStage
+	Status   ExecStatus
+	Duration time.Duration
+	Err      error
+}
+
+type setupGraph map[string]setupNode
+
+func newSetupGraph(nodes ...setupNode) (setupGraph, error) {
+	g := setupGraph{}
+	for _, n := range nodes {
+		if _, ok := g[n.Name]; ok {
+			return nil, fmt.Errorf("%s is defined more than once", n.Name)
+		}
+		if n.Stage == "" {
+			n.Stage = entity.StartStage
+		}
+		g[n.Name] = n
+	}
+	err := g.validate()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return g, nil
+}
+
+func (g setupGraph) names() []string {
+	names := make([]string, 0, len(g))
+	for n := range g {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate checks that every dependency exists, that build stage execs don't
+// wait on start stage ones, and that there are no cycles
+func (g setupGraph) validate() error {
+	for _, name := range g.names() {
+		n := g[name]
+		for _, d := range n.DependsOn {
+			dep, ok := g[d]
+			if !ok {
+				return fmt.Errorf("%s depends on %s, which does not exist", name, d)
+			}
+			if n.Stage == entity.BuildStage && dep.Stage != entity.BuildStage {
+				return fmt.Errorf("%s is in the build stage, it can't depend on %s in the %s stage", name, d, dep.Stage)
+			}
+		}
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		path = append(path, name)
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, d := range g[name].DependsOn {
+			if err := visit(d, path); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+	for _, name := range g.names() {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stage returns the nodes of one stage. Dependencies on other stages are kept,
+// run resolves them from the statuses it is given
+func (g setupGraph) stage(stage entity.ExecStage) setupGraph {
+	s := setupGraph{}
+	for n, node := range g {
+		if node.Stage == stage {
+			s[n] = node
+		}
+	}
+	return s
+}
+
+// run starts every node as soon as what it depends on has finished, so
+// independent nodes run in parallel. prior has the statuses of nodes outside
+// the graph, ex: the build stage when running the start stage. onDone, if
+// given, is called as each node finishes
+func (g setupGraph) run(prior map[string]ExecStatus, onDone func(ExecResult)) []ExecResult {
+	done := map[string]chan struct{}{}
+	for n := range g {
+		done[n] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	statuses := map[string]ExecStatus{}
+	for n, s := range prior {
+		statuses[n] = s
+	}
+	results := make([]ExecResult, 0, len(g))
+
+	var wg sync.WaitGroup
+	for _, node := range g {
+		wg.Add(1)
+		go func(node setupNode) {
+			defer wg.Done()
+			defer close(done[node.Name])
+			for _, d := range node.DependsOn {
+				if c, ok := done[d]; ok {
+					<-c
+				}
+			}
+			res := g.runNode(node, func(name string) ExecStatus {
+				mu.Lock()
+				defer mu.Unlock()
+				return statuses[name]
+			})
+			mu.Lock()
+			statuses[node.Name] = res.Status
+			results = append(results, res)
+			mu.Unlock()
+			if onDone != nil {
+				onDone(res)
+			}
+		}(node)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+func (g setupGraph) runNode(node setupNode, statusOf func(string) ExecStatus) ExecResult {
+	res := ExecResult{Name: node.Name, Stage: node.Stage}
+	for _, d := range node.DependsOn {
+		if s := statusOf(d); !s.satisfies() {
+			res.Status = ExecSkipped
+			res.Err = fmt.Errorf("%s did not succeed", d)
+			return res
+		}
+	}
+	if node.Disabled {
+		res.Status = ExecDisabled
+		return res
+	}
+	fmt.Printf("running %s\n", node.Name)
+	start := time.Now()
+	err := node.Run()
+	res.Duration = time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Printf("exec failed %s\n", node.Name)
+		res.Status = ExecFailed
+		res.Err = err
+		return res
+	}
+	fmt.Printf("exec success %s\n", node.Name)
+	res.Status = ExecSucceeded
+	return res
+}
+
+// FormatExecReport is written to the setup log once every exec has finished
+func FormatExecReport(results []ExecResult) string {
+	var b strings.Builder
+	b.WriteString("------ Exec Report ------\n")
+	for _, r := range results {
+		line := fmt.Sprintf("%-8s %-8s %s", r.Stage, r.Status, r.Name)
+		if r.Duration > 0 {
+			line += fmt.Sprintf(" (%s)", r.Duration)
+		}
+		if r.Err != nil && r.Status == ExecSkipped {
+			line += fmt.Sprintf(": %v", r.Err)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// ExecReportPath has the status and log file of every exec, so that brev logs
+// knows what to stream and whether the setup failed
+const ExecReportPath = "/var/log/brev-execs.json"
+
+type ExecReportEntry struct {
+	Name    string           `json:"name"`
+	Stage   entity.ExecStage `json:"stage"`
+	Status  ExecStatus       `json:"status"`
+	LogPath string           `json:"logPath,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type ExecReport struct {
+	Execs []ExecReportEntry `json:"execs"`
+}
+
+// IsDone is true once every exec has finished
+func (r ExecReport) IsDone() bool {
+	for _, e := range r.Execs {
+		if !e.Status.IsDone() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r ExecReport) Failed() []ExecReportEntry {
+	failed := []ExecReportEntry{}
+	for _, e := range r.Execs {
+		if e.Status.IsFailure() {
+			failed = append(failed, e)
+		}
+	}
+	return failed
+}
+
+// execReportWriter rewrites the report as execs finish. It is best effort,
+// the setup does not fail because the report could not be written
+type execReportWriter struct {
+	path   string
+	mu     sync.Mutex
+	report ExecReport
+}
+
+func newExecReportWriter(path string, g setupGraph) *execReportWriter {
+	w := &execReportWriter{path: path}
+	for _, n := range g.names() {
+		node := g[n]
+		w.report.Execs = append(w.report.Execs, ExecReportEntry{Name: n, Stage: node.Stage, Status: ExecPending, LogPath: node.LogPath})
+	}
+	w.write()
+	return w
+}
+
+func (w *execReportWriter) update(res ExecResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, e := range w.report.Execs {
+		if e.Name != res.Name {
+			continue
+		}
+		w.report.Execs[i].Status = res.Status
+		if res.Err != nil {
+			w.report.Execs[i].Error = res.Err.Error()
+		}
+	}
+	w.writeLocked()
+}
+
+func (w *execReportWriter) write() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writeLocked()
+}
+
+func (w *execReportWriter) writeLocked() {
+	b, err := json.MarshalIndent(w.report, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := w.path + ".tmp"
+	err = ioutil.WriteFile(tmp, b, 0o644) //nolint:gosec // read by the user over ssh
+	if err != nil {
+		fmt.Printf("could not write exec report: %v\n", err)
+		return
+	}
+	_ = os.Rename(tmp, w.path)
+}
+
+func resultsErr(results []ExecResult) error {
+	var err error
+	for _, r := range results {
+		if r.Status.IsFailure() {
+			err = multierror.Append(err, breverrors.WrapAndTrace(r.Err, fmt.Sprintf("%s %s", r.Status, r.Name)))
+		}
+	}
+	return err
+}
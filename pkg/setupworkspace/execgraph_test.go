@@ -0,0 +1,160 @@
+package setupworkspace
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+type runLog struct {
+	mu  sync.Mutex
+	ran []string
+}
+
+func (l *runLog) node(name string, err error, dependsOn ...string) setupNode {
+	return setupNode{Name: name, DependsOn: dependsOn, Run: func() error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.ran = append(l.ran, name)
+		return err
+	}}
+}
+
+func TestSetupGraphValidate(t *testing.T) {
+	l := &runLog{}
+	_, err := newSetupGraph(l.node("a", nil, "b"), l.node("b", nil, "c"), l.node("c", nil, "a"))
+	assert.ErrorContains(t, err, "dependency cycle: a -> b -> c -> a")
+
+	_, err = newSetupGraph(l.node("a", nil, "missing"))
+	assert.ErrorContains(t, err, "does not exist")
+
+	build := l.node("build", nil, "start")
+	build.Stage = entity.BuildStage
+	_, err = newSetupGraph(build, l.node("start", nil))
+	assert.ErrorContains(t, err, "build stage")
+
+	_, err = newSetupGraph(l.node("a", nil), l.node("a", nil))
+	assert.Error(t, err)
+}
+
+func TestSetupGraphRunOrderAndSkips(t *testing.T) {
+	l := &runLog{}
+	g, err := newSetupGraph(
+		l.node("deps", nil),
+		l.node("install", nil, "deps"),
+		l.node("broken", errors.New("boom")),
+		l.node("after-broken", nil, "broken"),
+		l.node("after-after", nil, "after-broken", "install"),
+	)
+	assert.Nil(t, err)
+
+	results := g.run(nil, nil)
+	statuses := map[string]ExecStatus{}
+	for _, r := range results {
+		statuses[r.Name] = r.Status
+	}
+	assert.Equal(t, map[string]ExecStatus{
+		"deps":         ExecSucceeded,
+		"install":      ExecSucceeded,
+		"broken":       ExecFailed,
+		"after-broken": ExecSkipped,
+		"after-after":  ExecSkipped,
+	}, statuses)
+	assert.NotContains(t, l.ran, "after-broken")
+	assert.Less(t, indexOf(l.ran, "deps"), indexOf(l.ran, "install"))
+	assert.Error(t, resultsErr(results))
+}
+
+func TestSetupGraphStages(t *testing.T) {
+	l := &runLog{}
+	build := l.node("build", nil)
+	build.Stage = entity.BuildStage
+	g, err := newSetupGraph(build, l.node("serve", nil, "build"))
+	assert.Nil(t, err)
+
+	// on restart the build stage is not run again
+	results := g.stage(entity.StartStage).run(map[string]ExecStatus{"build": ExecBuilt}, nil)
+	assert.Equal(t, []string{"serve"}, l.ran)
+	assert.Equal(t, ExecSucceeded, results[0].Status)
+
+	results = g.stage(entity.StartStage).run(map[string]ExecStatus{"build": ExecFailed}, nil)
+	assert.Equal(t, ExecSkipped, results[0].Status)
+}
+
+func TestRunBuildStageOnlySkipsBuiltExecs(t *testing.T) {
+	markerPath := filepath.Join(t.TempDir(), buildStageMarker)
+	buildNode := func(l *runLog, name string, err error, dependsOn ...string) setupNode {
+		n := l.node(name, err, dependsOn...)
+		n.Stage = entity.BuildStage
+		return n
+	}
+
+	l := &runLog{}
+	g, err := newSetupGraph(buildNode(l, "deps", nil), buildNode(l, "broken", errors.New("boom")))
+	assert.Nil(t, err)
+	runBuildStage(g, markerPath, nil)
+	assert.ElementsMatch(t, []string{"deps", "broken"}, l.ran)
+
+	// an exec added since, and the one that failed, still run on restart
+	l = &runLog{}
+	g, err = newSetupGraph(buildNode(l, "deps", nil), buildNode(l, "broken", nil), buildNode(l, "added", nil, "deps"))
+	assert.Nil(t, err)
+	results := runBuildStage(g, markerPath, nil)
+	assert.ElementsMatch(t, []string{"broken", "added"}, l.ran)
+	statuses := map[string]ExecStatus{}
+	for _, r := range results {
+		statuses[r.Name] = r.Status
+	}
+	assert.Equal(t, map[string]ExecStatus{"deps": ExecBuilt, "broken": ExecSucceeded, "added": ExecSucceeded}, statuses)
+
+	l = &runLog{}
+	results = runBuildStage(g, markerPath, nil)
+	assert.Empty(t, l.ran)
+	assert.Len(t, results, 3)
+}
+
+func TestRunBuildStageRebuildsWithAnOldMarker(t *testing.T) {
+	markerPath := filepath.Join(t.TempDir(), buildStageMarker)
+	assert.Nil(t, os.WriteFile(markerPath, []byte("2024-01-02T03:04:05Z"), 0o644))
+	l := &runLog{}
+	build := l.node("deps", nil)
+	build.Stage = entity.BuildStage
+	g, err := newSetupGraph(build)
+	assert.Nil(t, err)
+	runBuildStage(g, markerPath, nil)
+	assert.Equal(t, []string{"deps"}, l.ran)
+}
+
+func indexOf(s []string, v string) int {
+	for i, x := range s {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestExecReportWriter(t *testing.T) {
+	l := &runLog{}
+	g, err := newSetupGraph(l.node("a", nil), l.node("b", errors.New("boom"), "a"))
+	assert.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), "execs.json")
+	w := newExecReportWriter(path, g)
+	assert.False(t, w.report.IsDone())
+
+	g.run(nil, w.update)
+	var report ExecReport
+	b, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(b, &report))
+	assert.True(t, report.IsDone())
+	assert.Equal(t, []ExecReportEntry{{Name: "b", Stage: entity.StartStage, Status: ExecFailed, Error: "boom"}}, report.Failed())
+}
@@ -2,6 +2,7 @@ package setupworkspace
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/fs"
@@ -12,14 +13,17 @@ import (
 	"os/user"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/version"
+	"github.com/brevdev/brev-cli/pkg/devcontainer"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/setupprogress"
 	"github.com/brevdev/parse/pkg/parse"
 
 	"github.com/brevdev/brev-cli/pkg/store"
@@ -137,6 +141,7 @@ type WorkspaceIniter struct {
 	ReposV1            entity.ReposV1
 	ExecsV1            entity.ExecsV1
 	VscodeExtensionIDs []string
+	Progress           *setupprogress.Recorder
 }
 
 func NewWorkspaceIniter(workspaceDir string, user *user.User, params *store.SetupParamsV0) *WorkspaceIniter {
@@ -172,6 +177,7 @@ func NewWorkspaceIniter(workspaceDir string, user *user.User, params *store.Setu
 		ReposV1:            params.ReposV1,
 		ExecsV1:            params.ExecsV1,
 		VscodeExtensionIDs: vscodeExtensionIDs,
+		Progress:           setupprogress.NewRecorder(setupprogress.Path),
 	}
 }
 
@@ -328,13 +334,24 @@ func (w WorkspaceIniter) BuildWorkspacePath(suffix ...string) string {
 }
 
 func (w WorkspaceIniter) Setup() error {
-	cmd := CmdStringBuilder("echo user: $(whoami) && echo pwd: $(pwd)")
-	err := cmd.Run()
+	w.Progress.Start("prepare", "ssh", "git", "application-scripts", setupprogress.StepRepos, setupprogress.StepExecs, "code-server")
+	err := w.setup()
+	w.Progress.Done(err)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	return nil
+}
 
-	err = w.PrepareWorkspace()
+func (w WorkspaceIniter) setup() error {
+	err := w.Progress.Step("prepare", func() error {
+		cmd := CmdStringBuilder("echo user: $(whoami) && echo pwd: $(pwd)")
+		err := cmd.Run()
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return w.PrepareWorkspace()
+	})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -348,37 +365,47 @@ func (w WorkspaceIniter) Setup() error {
 			return nil
 		},
 		func() error {
-			err = w.SetupCodeServer(w.Params.WorkspacePassword, fmt.Sprintf("127.0.0.1:%d", w.Params.WorkspacePort), string(w.Params.WorkspaceHost))
-			if err != nil {
-				return breverrors.WrapAndTrace(err)
-			}
-			return nil
+			return w.Progress.Step("code-server", func() error {
+				err := w.SetupCodeServer(w.Params.WorkspacePassword, fmt.Sprintf("127.0.0.1:%d", w.Params.WorkspacePort), string(w.Params.WorkspaceHost))
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				return nil
+			})
 		},
 	)
 
-	err = w.SetupSSH(w.Params.WorkspaceKeyPair)
+	err = w.Progress.Step("ssh", func() error { return w.SetupSSH(w.Params.WorkspaceKeyPair) })
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = w.SetupGit()
+	err = w.Progress.Step("git", w.SetupGit)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = w.RunApplicationScripts(w.Params.WorkspaceApplicationStartScripts)
+	err = w.Progress.Step("application-scripts", func() error {
+		return w.RunApplicationScripts(w.Params.WorkspaceApplicationStartScripts)
+	})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
 	var setupErr error
 
-	err = w.SetupRepos()
+	err = w.Progress.Step(setupprogress.StepRepos, w.SetupRepos)
 	if err != nil {
 		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
 	}
 
-	err = w.RunExecs()
+	execs, extensionIDs, err := w.importDevContainers()
+	if err != nil {
+		fmt.Println(err)
+	}
+	w.ExecsV1 = execs
+
+	err = w.Progress.Step(setupprogress.StepExecs, w.RunExecs)
 	if err != nil {
 		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
 	}
@@ -392,6 +419,13 @@ func (w WorkspaceIniter) Setup() error {
 		return breverrors.WrapAndTrace(err)
 	}
 
+	if len(extensionIDs) > 0 {
+		err = w.installVsCodeExtensions(extensionIDs)
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+
 	return nil
 }
 
@@ -407,52 +441,160 @@ func (w WorkspaceIniter) SetupRepos() error {
 			fmt.Printf("setup success %s\n", n)
 		}
 	}
+
+	nodes := []setupNode{}
 	for n, r := range w.ReposV0 {
-		fmt.Printf("setting up repo v0 %s\n", n)
-		err := w.setupRepoV0(r)
-		if err != nil {
-			fmt.Printf("setup failed %s\n", n)
-			setupErr = multierror.Append(breverrors.WrapAndTrace(err, fmt.Sprintf("setup failed %s", n)))
-		} else {
-			fmt.Printf("setup success %s\n", n)
-		}
+		r := r
+		nodes = append(nodes, setupNode{
+			Name:      string(n),
+			DependsOn: r.DependsOn,
+			Run:       func() error { return w.setupRepoV0(r) },
+		})
+	}
+	graph, err := newSetupGraph(nodes...)
+	if err != nil {
+		return multierror.Append(setupErr, breverrors.WrapAndTrace(err, "invalid repo dependencies"))
+	}
+	results := graph.run(nil, nil)
+	if err := resultsErr(results); err != nil {
+		setupErr = multierror.Append(setupErr, err)
 	}
+
 	if setupErr != nil {
 		return breverrors.WrapAndTrace(setupErr)
 	}
 	return nil
 }
 
+// buildStageMarker has the build stage execs that succeeded, so that restarts
+// only run the start stage and the build stage execs added since
+const buildStageMarker = ".build-stage-complete"
+
+type buildStageMarkerFile struct {
+	BuiltAt time.Time `json:"builtAt"`
+	Execs   []string  `json:"execs"`
+}
+
+// RunExecs runs the build stage execs that didn't already succeed on a
+// previous start, then the start stage. Within a stage execs run as soon as
+// what they depend on succeeded, execs whose dependencies failed are skipped
 func (w WorkspaceIniter) RunExecs() error {
 	dotBrev := filepath.Join(w.BuildWorkspacePath(), ".brev")
 	err := w.setupDotBrev(dotBrev)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	var execErr error
-	for n, e := range w.ExecsV0 {
-		err := w.runExecV0(n, e)
-		if err != nil {
-			fmt.Printf("exec failed %s\n", n)
-			execErr = multierror.Append(breverrors.WrapAndTrace(err, fmt.Sprintf("exec failed %s", n)))
-		} else {
-			fmt.Printf("exec success %s\n", n)
+	graph, err := newSetupGraph(w.execNodes()...)
+	if err != nil {
+		return breverrors.WrapAndTrace(err, "invalid exec dependencies")
+	}
+
+	report := newExecReportWriter(ExecReportPath, graph)
+	results := runBuildStage(graph.stage(entity.BuildStage), filepath.Join(dotBrev, buildStageMarker), report.update)
+	prior := map[string]ExecStatus{}
+	for _, r := range results {
+		prior[r.Name] = r.Status
+	}
+	results = append(results, graph.stage(entity.StartStage).run(prior, report.update)...)
+
+	fmt.Print(FormatExecReport(results))
+	err = resultsErr(results)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// runBuildStage runs the execs of build that aren't in the marker at
+// markerPath, and then records the ones that succeeded in it
+func runBuildStage(build setupGraph, markerPath string, onDone func(ExecResult)) []ExecResult {
+	built := readBuildStageMarker(markerPath)
+	results := []ExecResult{}
+	prior := map[string]ExecStatus{}
+	toBuild := setupGraph{}
+	for _, n := range build.names() {
+		if !built[n] {
+			toBuild[n] = build[n]
+			continue
+		}
+		res := ExecResult{Name: n, Stage: entity.BuildStage, Status: ExecBuilt}
+		prior[n] = res.Status
+		results = append(results, res)
+		if onDone != nil {
+			onDone(res)
 		}
 	}
-	for n, e := range w.ExecsV1 {
-		_ = e
-		err := w.runExecV1(n, e)
-		if err != nil {
-			fmt.Printf("exec failed %s\n", n)
-			execErr = multierror.Append(breverrors.WrapAndTrace(err, fmt.Sprintf("exec failed %s", n)))
-		} else {
-			fmt.Printf("exec success %s\n", n)
+	if len(toBuild) == 0 {
+		return results
+	}
+
+	results = append(results, toBuild.run(prior, onDone)...)
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	marker := buildStageMarkerFile{BuiltAt: time.Now().UTC(), Execs: []string{}}
+	for _, r := range results {
+		if r.Status == ExecBuilt || r.Status == ExecSucceeded {
+			marker.Execs = append(marker.Execs, r.Name)
 		}
 	}
-	if execErr != nil {
-		return breverrors.WrapAndTrace(execErr)
+	b, err := json.Marshal(marker)
+	if err == nil {
+		err = ioutil.WriteFile(markerPath, b, 0o644) //nolint:gosec // not a secret
 	}
-	return nil
+	if err != nil {
+		fmt.Printf("could not mark build stage complete: %v\n", err)
+	}
+	return results
+}
+
+// readBuildStageMarker returns the build stage execs that already succeeded.
+// Markers from before they were recorded have none, so everything is rebuilt
+func readBuildStageMarker(markerPath string) map[string]bool {
+	built := map[string]bool{}
+	b, err := ioutil.ReadFile(markerPath) //nolint:gosec // in the workspace's .brev
+	if err != nil {
+		return built
+	}
+	var marker buildStageMarkerFile
+	if json.Unmarshal(b, &marker) != nil {
+		return built
+	}
+	for _, n := range marker.Execs {
+		built[n] = true
+	}
+	return built
+}
+
+func (w WorkspaceIniter) execNodes() []setupNode {
+	nodes := []setupNode{}
+	for n, e := range w.ExecsV0 {
+		n, e := n, e
+		nodes = append(nodes, setupNode{
+			Name:      string(n),
+			DependsOn: e.DependsOn,
+			LogPath:   ExecLogFile(filepath.Join(w.BuildWorkspacePath(), ".brev", "logs"), string(n)),
+			Run:       func() error { return w.runExecV0(n, e) },
+		})
+	}
+	for n, e := range w.ExecsV1 {
+		n, e := n, e
+		stage := entity.StartStage
+		if e.Stage != nil && *e.Stage != "" {
+			stage = *e.Stage
+		}
+		dependsOn := []string{}
+		for _, d := range e.DependsOn {
+			dependsOn = append(dependsOn, string(d))
+		}
+		nodes = append(nodes, setupNode{
+			Name:      string(n),
+			Stage:     stage,
+			DependsOn: dependsOn,
+			Disabled:  e.IsDisabled,
+			LogPath:   w.getExecLogFile(n, e),
+			Run:       func() error { return w.runExecV1(n, e) },
+		})
+	}
+	return nodes
 }
 
 func (w WorkspaceIniter) runExecV1(name entity.ExecName, exec entity.ExecV1) error {
@@ -460,6 +602,33 @@ func (w WorkspaceIniter) runExecV1(name entity.ExecName, exec entity.ExecV1) err
 		fmt.Printf("exec %s disabled, not running", name)
 		return nil
 	}
+	if exec.Supervise != nil {
+		if exec.Stage == nil || *exec.Stage != entity.BuildStage {
+			return w.superviseExecV1(name, exec)
+		}
+		fmt.Printf("exec %s is in the build stage, running it once instead of supervising it\n", name)
+	}
+	prepared, err := w.prepareExecV1(name, exec)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = RunSetupScript(prepared.logPath, prepared.workDir, prepared.execPath, w.User, prepared.logArchivePath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+type preparedExec struct {
+	execPath       string
+	workDir        string
+	logPath        string
+	logArchivePath string
+}
+
+// prepareExecV1 resolves the paths of an exec and writes string execs to a
+// file, so that they can be run like path execs
+func (w WorkspaceIniter) prepareExecV1(name entity.ExecName, exec entity.ExecV1) (*preparedExec, error) {
 	execWorkDir := ""
 	if exec.ExecWorkDir != nil {
 		execWorkDir = *exec.ExecWorkDir
@@ -471,29 +640,24 @@ func (w WorkspaceIniter) runExecV1(name entity.ExecName, exec entity.ExecV1) err
 
 	execPath, err := w.GetExecPath(name, exec)
 	if err != nil {
-		return breverrors.WrapAndTrace(err)
+		return nil, breverrors.WrapAndTrace(err)
 	}
 	logPath, err := w.GetLogPath(name, exec)
 	if err != nil {
-		return breverrors.WrapAndTrace(err)
+		return nil, breverrors.WrapAndTrace(err)
 	}
 	logArchPath, err := w.GetLogArchivePath(name, exec)
 	if err != nil {
-		return breverrors.WrapAndTrace(err)
+		return nil, breverrors.WrapAndTrace(err)
 	}
 
 	if exec.Type == entity.StringExecType {
 		err = w.CreateTempStrExecFile(execPath, exec.ExecStr)
 		if err != nil {
-			return breverrors.WrapAndTrace(err)
+			return nil, breverrors.WrapAndTrace(err)
 		}
 	}
-
-	err = RunSetupScript(logPath, workDirPath, execPath, w.User, logArchPath)
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-	return nil
+	return &preparedExec{execPath: execPath, workDir: workDirPath, logPath: logPath, logArchivePath: logArchPath}, nil
 }
 
 func (w WorkspaceIniter) CreateTempStrExecFile(execPath string, execStr string) error {
@@ -564,6 +728,20 @@ func (w WorkspaceIniter) GetLogPath(name entity.ExecName, exec entity.ExecV1) (s
 	return logPath, nil
 }
 
+// getExecLogFile is where RunSetupScript writes the output of an exec, or ""
+// if the exec is invalid
+func (w WorkspaceIniter) getExecLogFile(name entity.ExecName, exec entity.ExecV1) string {
+	execPath, err := w.GetExecPath(name, exec)
+	if err != nil {
+		return ""
+	}
+	logPath, err := w.GetLogPath(name, exec)
+	if err != nil {
+		return ""
+	}
+	return ExecLogFile(logPath, execPath)
+}
+
 func (w WorkspaceIniter) GetLogArchivePath(name entity.ExecName, exec entity.ExecV1) (string, error) {
 	logArchPath := ""
 	if exec.LogArchivePath == nil || *exec.LogArchivePath == "" {
@@ -896,7 +1074,10 @@ func (w WorkspaceIniter) SetupCodeServer(password string, bindAddr string, works
 }
 
 func (w WorkspaceIniter) SetupVsCodeExtensions() error {
-	extensionIDs := w.VscodeExtensionIDs
+	return w.installVsCodeExtensions(w.VscodeExtensionIDs)
+}
+
+func (w WorkspaceIniter) installVsCodeExtensions(extensionIDs []string) error {
 	fmt.Println("installing vscode extensions...")
 	codePathGlob := filepath.Join(w.BuildHomePath(), ".vscode-server/bin/*/bin/code-server")
 	matches, err := filepath.Glob(codePathGlob)
@@ -1162,7 +1343,18 @@ func (w WorkspaceIniter) setupDotBrev(dotBrevPath string) error {
 		}
 	}
 
+	// repos with a devcontainer.json are set up from it instead of the
+	// default .brev
+	repoPath := filepath.Dir(dotBrevPath)
+	_, hasDevContainer := devcontainer.Find(repoPath)
+
 	portsYamlPath := filepath.Join(dotBrevPath, "ports.yaml")
+	if !PathExists(portsYamlPath) && hasDevContainer {
+		err := w.writeDevContainerPorts(repoPath, portsYamlPath)
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
 	if !PathExists(portsYamlPath) {
 		cmd := CmdBuilder("curl", `https://raw.githubusercontent.com/brevdev/default-project-dotbrev/main/.brev/ports.yaml`, "-o", portsYamlPath)
 		err := w.CmdAsUser(cmd)
@@ -1176,7 +1368,7 @@ func (w WorkspaceIniter) setupDotBrev(dotBrevPath string) error {
 	}
 
 	setupScriptPath := filepath.Join(dotBrevPath, "setup.sh")
-	if !PathExists(setupScriptPath) {
+	if !PathExists(setupScriptPath) && !hasDevContainer {
 		cmd := CmdBuilder("curl", `https://raw.githubusercontent.com/brevdev/default-project-dotbrev/main/.brev/setup.sh`, "-o", setupScriptPath)
 		err := w.CmdAsUser(cmd)
 		if err != nil {
@@ -1243,9 +1435,15 @@ func (w WorkspaceIniter) GitCloneIfDNE(url string, dirPath string, branch string
 	return nil
 }
 
+// ExecLogFile is the log RunSetupScript writes for an exec, ex: logs/setup.log
+// for setup.sh
+func ExecLogFile(logsPath string, setupExecPath string) string {
+	return filepath.Join(logsPath, fmt.Sprintf("%s.log", util.RemoveFileExtenstion(filepath.Base(setupExecPath))))
+}
+
 func RunSetupScript(logsPath string, workingDir string, setupExecPath string, user *user.User, archivePath string) error {
 	namePrefix := util.RemoveFileExtenstion(filepath.Base(setupExecPath))
-	setupLogPath := filepath.Join(logsPath, fmt.Sprintf("%s.log", namePrefix))
+	setupLogPath := ExecLogFile(logsPath, setupExecPath)
 	if archivePath == "" {
 		archivePath = filepath.Join(logsPath, "archive")
 	}
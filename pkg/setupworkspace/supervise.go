@@ -0,0 +1,85 @@
+package setupworkspace
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/supervisor"
+)
+
+// superviseExecV1 hands an exec to the supervisor daemon, starting it if
+// needed, and waits for it to be ready so that what depends on it can run
+func (w WorkspaceIniter) superviseExecV1(name entity.ExecName, exec entity.ExecV1) error {
+	spec, err := w.serviceSpec(name, exec)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	client, err := supervisor.EnsureDaemon()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = client.Add(*spec)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	fmt.Printf("supervising %s, waiting for it to be ready\n", name)
+	status, err := client.WaitReady(spec.Name, supervisor.ReadyTimeout(spec.Probe))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	fmt.Printf("%s is %s\n", name, status.State)
+	return nil
+}
+
+func (w WorkspaceIniter) serviceSpec(name entity.ExecName, exec entity.ExecV1) (*supervisor.ServiceSpec, error) {
+	restart, err := supervisor.ParseRestartPolicy(exec.Supervise.RestartPolicy)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	probe := exec.Supervise.ReadinessProbe
+	if probe != nil {
+		err = supervisor.ValidateProbe(*probe)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+	}
+	prepared, err := w.prepareExecV1(name, exec)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if !PathExists(prepared.execPath) {
+		return nil, fmt.Errorf("no exec found at %s", prepared.execPath)
+	}
+	err = os.Chmod(prepared.execPath, 0o700) //nolint:gosec // occurs in safe area
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = os.MkdirAll(prepared.logPath, os.ModePerm)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	uid, err := strconv.ParseUint(w.User.Uid, 10, 32)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	gid, err := strconv.ParseUint(w.User.Gid, 10, 32)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	uid32, gid32 := uint32(uid), uint32(gid)
+	return &supervisor.ServiceSpec{
+		Name:    string(name),
+		Command: []string{"bash", "-c", prepared.execPath},
+		Dir:     prepared.workDir,
+		Env:     []string{"USER=" + w.User.Username, "HOME=" + w.User.HomeDir, "SHELL=/bin/bash"},
+		UID:     &uid32,
+		GID:     &gid32,
+		LogPath: ExecLogFile(prepared.logPath, prepared.execPath),
+		Restart: restart,
+		Probe:   probe,
+	}, nil
+}
@@ -1,7 +1,410 @@
 package setupworkspace
 
-import "github.com/brevdev/brev-cli/pkg/store"
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
-func ValidateSetup(_ store.SetupParamsV0) error {
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/supervisor"
+)
+
+type Severity string
+
+const (
+	SeverityError Severity = "error"
+	// SeverityWarning is for setups that run, but likely not as intended
+	SeverityWarning Severity = "warning"
+)
+
+// SetupProblem is one thing wrong with setup params. Location is where in
+// their json it is, ex: execsV1.server.dependsOn[0]
+type SetupProblem struct {
+	Location string   `json:"location"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (p SetupProblem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Location, p.Severity, p.Message)
+}
+
+// SetupProblems is the error of ValidateSetup
+type SetupProblems []SetupProblem
+
+func (ps SetupProblems) Error() string {
+	lines := []string{}
+	for _, p := range ps {
+		lines = append(lines, p.String())
+	}
+	return fmt.Sprintf("invalid setup:\n%s", strings.Join(lines, "\n"))
+}
+
+// ValidateSetup returns SetupProblems when the params have errors, warnings
+// alone don't fail it
+func ValidateSetup(params store.SetupParamsV0) error {
+	problems := LintSetup(params)
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return problems
+		}
+	}
 	return nil
 }
+
+// LintSetup finds what would otherwise only fail once envsetup runs on the
+// dev environment, sorted by location
+func LintSetup(params store.SetupParamsV0) SetupProblems {
+	l := &setupLinter{problems: SetupProblems{}, repoDirs: map[string]bool{".brev": true}}
+	l.lintTopLevel(params)
+	l.lintRepos(params)
+	l.lintExecs(params)
+	sort.SliceStable(l.problems, func(i, j int) bool { return l.problems[i].Location < l.problems[j].Location })
+	return l.problems
+}
+
+type setupLinter struct {
+	problems SetupProblems
+	// repoDirs are the directories repos are cloned to, relative to the
+	// workspace dir
+	repoDirs map[string]bool
+}
+
+func (l *setupLinter) errorf(location string, format string, a ...interface{}) {
+	l.problems = append(l.problems, SetupProblem{Location: location, Severity: SeverityError, Message: fmt.Sprintf(format, a...)})
+}
+
+func (l *setupLinter) warnf(location string, format string, a ...interface{}) {
+	l.problems = append(l.problems, SetupProblem{Location: location, Severity: SeverityWarning, Message: fmt.Sprintf(format, a...)})
+}
+
+func (l *setupLinter) lintTopLevel(params store.SetupParamsV0) {
+	l.lintRepoURL("workspaceBaseRepo", params.WorkspaceBaseRepo)
+	l.lintRepoURL("workspaceProjectRepo", params.WorkspaceProjectRepo)
+	if params.WorkspaceProjectRepoBranch != "" {
+		l.lintBranch("workspaceProjectRepoBranch", params.WorkspaceProjectRepoBranch)
+	}
+}
+
+// scpLikeRepo is git's user@host:path form, ex: git@github.com:brevdev/brev-cli.git
+var scpLikeRepo = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^/\s][^\s]*$`)
+
+func (l *setupLinter) lintRepoURL(location string, repo string) {
+	if repo == "" {
+		return
+	}
+	if strings.ContainsAny(repo, " \t\n") {
+		l.errorf(location, "%q is not a git url, it has whitespace", repo)
+		return
+	}
+	if scpLikeRepo.MatchString(repo) {
+		return
+	}
+	u, err := url.Parse(repo)
+	if err != nil || u.Scheme == "" {
+		// github.com/org/repo is cloned as https, see GetDefaultProjectFolderNameFromRepo
+		if err == nil && strings.Count(repo, "/") >= 2 && !strings.HasPrefix(repo, "/") {
+			return
+		}
+		l.errorf(location, "%q is not a git url, ex: https://github.com/org/repo or git@github.com:org/repo.git", repo)
+		return
+	}
+	switch u.Scheme {
+	case "https", "http", "ssh", "git":
+	default:
+		l.errorf(location, "%q has an unsupported scheme %s, use https or ssh", repo, u.Scheme)
+		return
+	}
+	if u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		l.errorf(location, "%q needs a host and a repository path", repo)
+	}
+}
+
+// lintBranch follows git check-ref-format, branches can also be tags or
+// commits so only the syntax is checked
+func (l *setupLinter) lintBranch(location string, branch string) {
+	bad := func(reason string) {
+		l.errorf(location, "%q is not a valid branch, tag or commit: %s", branch, reason)
+	}
+	switch {
+	case strings.TrimSpace(branch) == "":
+		bad("it is blank")
+	case strings.ContainsAny(branch, " \t\n~^:?*[\\"):
+		bad(`it can't contain whitespace or any of ~^:?*[\`)
+	case strings.Contains(branch, ".."), strings.Contains(branch, "@{"), strings.Contains(branch, "//"):
+		bad(`it can't contain "..", "@{" or "//"`)
+	case strings.HasPrefix(branch, "-"), strings.HasPrefix(branch, "/"), strings.HasSuffix(branch, "/"):
+		bad(`it can't start with "-" or start or end with "/"`)
+	case strings.HasSuffix(branch, "."), strings.HasSuffix(branch, ".lock"):
+		bad(`it can't end with "." or ".lock"`)
+	}
+}
+
+func (l *setupLinter) lintRepos(params store.SetupParamsV0) {
+	// where each directory was claimed first, to report duplicates
+	dirs := map[string]string{}
+	claimDir := func(location string, dir string) {
+		if dir == "" {
+			return
+		}
+		key := path.Clean(dir)
+		if first, ok := dirs[key]; ok {
+			l.errorf(location, "directory %s is also used by %s", dir, first)
+			return
+		}
+		dirs[key] = location
+		l.repoDirs[key] = true
+	}
+
+	if params.WorkspaceProjectRepo != "" {
+		if params.ProjectFolderName != "" {
+			claimDir("projectFolderName", params.ProjectFolderName)
+		} else {
+			claimDir("workspaceProjectRepo", entity.GetDefaultProjectFolderNameFromRepo(params.WorkspaceProjectRepo))
+		}
+	}
+	for _, n := range sortedKeys(params.ReposV0) {
+		r := params.ReposV0[entity.RepoName(n)]
+		loc := "repos." + n
+		l.lintRepoURL(loc+".repository", r.Repository)
+		if r.Branch != "" {
+			l.lintBranch(loc+".branch", r.Branch)
+		}
+		if r.Repository == "" && r.Directory == "" {
+			l.errorf(loc, "needs a repository or a directory")
+		}
+		claimDir(loc+".directory", initRepo(r).Directory)
+	}
+	l.lintDependencies(dependenciesOfReposV0(params.ReposV0), func(string) string { return "repos" }, nil)
+
+	for _, n := range sortedKeys(params.ReposV1) {
+		r := params.ReposV1[entity.RepoName(n)]
+		loc := "reposV1." + n
+		switch r.Type {
+		case entity.GitRepoType:
+			if r.Repository == "" {
+				l.errorf(loc+".repository", "git repos need a repository")
+				continue
+			}
+			l.lintRepoURL(loc+".repository", r.Repository)
+			if r.Branch != nil {
+				l.lintBranch(loc+".branch", *r.Branch)
+			}
+			dirLoc := loc + ".repository"
+			if r.GitDirectory != nil && *r.GitDirectory != "" {
+				dirLoc = loc + ".gitRepoDirectory"
+			}
+			claimDir(dirLoc, r.GitRepo.GetDir())
+		case entity.EmptyRepoType:
+			if r.EmptyDirectory == nil || *r.EmptyDirectory == "" {
+				l.errorf(loc+".emptyRepoDirectory", "empty repos need a directory")
+				continue
+			}
+			claimDir(loc+".emptyRepoDirectory", *r.EmptyDirectory)
+		default:
+			l.errorf(loc+".type", "type must be %s or %s, got %q", entity.GitRepoType, entity.EmptyRepoType, r.Type)
+		}
+	}
+}
+
+func dependenciesOfReposV0(repos entity.ReposV0) map[string][]string {
+	deps := map[string][]string{}
+	for n, r := range repos {
+		deps[string(n)] = r.DependsOn
+	}
+	return deps
+}
+
+func (l *setupLinter) lintExecs(params store.SetupParamsV0) {
+	deps := map[string][]string{}
+	stages := map[string]entity.ExecStage{}
+	locations := map[string]string{}
+	// execs can't share a log file, RunSetupScript would interleave them
+	logFiles := map[string]string{}
+	claimLog := func(location string, logFile string) {
+		if logFile == "" {
+			return
+		}
+		if first, ok := logFiles[logFile]; ok {
+			l.errorf(location, "logs to %s, like %s", logFile, first)
+			return
+		}
+		logFiles[logFile] = location
+	}
+	// paths are resolved relative to the workspace dir, which is the same for
+	// every exec, so leaving it empty is enough to compare them
+	w := WorkspaceIniter{}
+
+	for _, n := range sortedKeys(params.ExecsV0) {
+		e := params.ExecsV0[entity.ExecName(n)]
+		loc := "execs." + n
+		deps[n] = e.DependsOn
+		stages[n] = entity.StartStage
+		locations[n] = "execs"
+		if strings.TrimSpace(e.Exec) == "" {
+			l.errorf(loc+".exec", "exec is empty")
+		}
+		claimLog(loc, ExecLogFile(filepath.Join(".brev", "logs"), n))
+	}
+
+	for _, n := range sortedKeys(params.ExecsV1) {
+		e := params.ExecsV1[entity.ExecName(n)]
+		loc := "execsV1." + n
+		if _, ok := deps[n]; ok {
+			l.errorf(loc, "%s is also defined in execs", n)
+			continue
+		}
+		dependsOn := []string{}
+		for _, d := range e.DependsOn {
+			dependsOn = append(dependsOn, string(d))
+		}
+		deps[n] = dependsOn
+		locations[n] = "execsV1"
+		stages[n] = entity.StartStage
+		if e.Stage != nil && *e.Stage != "" {
+			stages[n] = *e.Stage
+			if *e.Stage != entity.StartStage && *e.Stage != entity.BuildStage {
+				l.errorf(loc+".stage", "stage must be %s or %s, got %q", entity.StartStage, entity.BuildStage, *e.Stage)
+			}
+		}
+
+		switch e.Type {
+		case entity.StringExecType:
+			if strings.TrimSpace(e.ExecStr) == "" {
+				l.errorf(loc+".execStr", "string execs need an execStr")
+			}
+		case entity.PathExecType:
+			if strings.TrimSpace(e.ExecPath) == "" {
+				l.errorf(loc+".execPath", "path execs need an execPath")
+			} else if !l.inRepo(e.ExecPath) {
+				l.warnf(loc+".execPath", "%s is not in any repo, it has to be on the image already", e.ExecPath)
+			}
+		default:
+			l.errorf(loc+".type", "type must be %s or %s, got %q", entity.StringExecType, entity.PathExecType, e.Type)
+		}
+		if e.LogArchivePath != nil && *e.LogArchivePath != "" && e.LogPath != nil && *e.LogPath != "" &&
+			path.Clean(*e.LogArchivePath) == path.Clean(*e.LogPath) {
+			l.errorf(loc+".logArchivePath", "is the same as logPath, archived logs would be mistaken for current ones")
+		}
+		if e.Supervise != nil {
+			l.lintSupervise(loc+".supervise", *e.Supervise, stages[n])
+		}
+		if !e.IsDisabled {
+			claimLog(loc+".logPath", w.getExecLogFile(entity.ExecName(n), e))
+		}
+	}
+
+	sectionOf := func(name string) string { return locations[name] }
+	l.lintDependencies(deps, sectionOf, func(name string, dep string, location string) {
+		if stages[name] == entity.BuildStage && stages[dep] != entity.BuildStage {
+			l.errorf(location, "%s is in the build stage, it can't depend on %s in the %s stage", name, dep, stages[dep])
+		}
+	})
+}
+
+func (l *setupLinter) inRepo(p string) bool {
+	if path.IsAbs(p) {
+		return true
+	}
+	p = path.Clean(p)
+	for dir := range l.repoDirs {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *setupLinter) lintSupervise(location string, s entity.ExecSupervision, stage entity.ExecStage) {
+	_, err := supervisor.ParseRestartPolicy(s.RestartPolicy)
+	if err != nil {
+		l.errorf(location+".restartPolicy", "restart policy must be one of never|on-failure|always, got %q", s.RestartPolicy)
+	}
+	if s.ReadinessProbe != nil {
+		err = supervisor.ValidateProbe(*s.ReadinessProbe)
+		if err != nil {
+			l.errorf(location+".readinessProbe", "needs exactly one of httpGet, tcpSocket or exec")
+		}
+	}
+	if stage == entity.BuildStage {
+		l.warnf(location, "build stage execs run once, they are not supervised")
+	}
+}
+
+// lintDependencies reports unknown dependsOn targets and cycles. sectionOf
+// is the json key a node is under, ex: execsV1. onEdge, if given, is called
+// for every dependency that exists
+func (l *setupLinter) lintDependencies(deps map[string][]string, sectionOf func(name string) string, onEdge func(name string, dep string, location string)) {
+	location := func(name string, i int) string {
+		return fmt.Sprintf("%s.%s.dependsOn[%d]", sectionOf(name), name, i)
+	}
+	names := make([]string, 0, len(deps))
+	for n := range deps {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		for i, d := range deps[n] {
+			if _, ok := deps[d]; !ok {
+				l.errorf(location(n, i), "%s does not exist", d)
+				continue
+			}
+			if onEdge != nil {
+				onEdge(n, d, location(n, i))
+			}
+		}
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := map[string]int{}
+	var visit func(name string, stack []string)
+	visit = func(name string, stack []string) {
+		state[name] = visiting
+		stack = append(stack, name)
+		for i, d := range deps[name] {
+			if _, ok := deps[d]; !ok {
+				continue
+			}
+			switch state[d] {
+			case visiting:
+				cycle := append(append([]string{}, stack[indexOf(stack, d):]...), d)
+				l.errorf(location(name, i), "dependency cycle: %s", strings.Join(cycle, " -> "))
+			case 0:
+				visit(d, stack)
+			}
+		}
+		state[name] = visited
+	}
+	for _, n := range names {
+		if state[n] == 0 {
+			visit(n, nil)
+		}
+	}
+}
+
+func indexOf(s []string, v string) int {
+	for i, e := range s {
+		if e == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortedKeys[K ~string, V any](m map[K]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	return keys
+}
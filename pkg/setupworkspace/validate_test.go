@@ -0,0 +1,116 @@
+package setupworkspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func locations(problems SetupProblems) []string {
+	locs := []string{}
+	for _, p := range problems {
+		locs = append(locs, string(p.Severity)+" "+p.Location)
+	}
+	return locs
+}
+
+func TestValidateSetupValid(t *testing.T) {
+	build := entity.BuildStage
+	params := store.SetupParamsV0{
+		WorkspaceProjectRepo:       "https://github.com/brevdev/brev-cli",
+		WorkspaceProjectRepoBranch: "release/v1.2",
+		ReposV0: entity.ReposV0{
+			"docs": {Repository: "git@github.com:brevdev/docs.git", Branch: "main"},
+		},
+		ReposV1: entity.ReposV1{
+			"data": {Type: entity.EmptyRepoType, EmptyRepo: entity.EmptyRepo{EmptyDirectory: strPtr("data")}},
+		},
+		ExecsV1: entity.ExecsV1{
+			"install": {Type: entity.PathExecType, Stage: &build, PathExec: entity.PathExec{ExecPath: "brev-cli/.brev/install.sh"}},
+			"server": {
+				Type:        entity.StringExecType,
+				StringExec:  entity.StringExec{ExecStr: "make run"},
+				ExecOptions: entity.ExecOptions{DependsOn: []entity.ExecName{"install"}},
+			},
+		},
+	}
+	assert.Empty(t, LintSetup(params))
+	assert.Nil(t, ValidateSetup(params))
+}
+
+func TestLintSetup(t *testing.T) {
+	build := entity.BuildStage
+	params := store.SetupParamsV0{
+		WorkspaceProjectRepo:       "not a url",
+		WorkspaceProjectRepoBranch: "feature..x",
+		ReposV0: entity.ReposV0{
+			"a": {Repository: "https://github.com/org/a", Directory: "shared", DependsOn: []string{"b"}},
+			"b": {Repository: "https://github.com/org/b", Directory: "shared", DependsOn: []string{"a", "missing"}},
+		},
+		ReposV1: entity.ReposV1{
+			"c": {Type: "svn"},
+		},
+		ExecsV0: entity.ExecsV0{
+			"run.sh": {Exec: "echo hi"},
+		},
+		ExecsV1: entity.ExecsV1{
+			"run.py": {Type: entity.StringExecType, StringExec: entity.StringExec{ExecStr: "python run.py"}},
+			"script": {Type: entity.PathExecType},
+			"compile": {
+				Type:        entity.StringExecType,
+				Stage:       &build,
+				StringExec:  entity.StringExec{ExecStr: "make"},
+				ExecOptions: entity.ExecOptions{DependsOn: []entity.ExecName{"run.py"}},
+			},
+			"elsewhere": {Type: entity.PathExecType, PathExec: entity.PathExec{ExecPath: "tools/run.sh"}},
+		},
+	}
+	problems := LintSetup(params)
+	assert.Equal(t, []string{
+		"error execsV1.compile.dependsOn[0]",
+		"warning execsV1.elsewhere.execPath",
+		"error execsV1.run.py.logPath",
+		"error execsV1.script.execPath",
+		"error repos.b.dependsOn[0]",
+		"error repos.b.dependsOn[1]",
+		"error repos.b.directory",
+		"error reposV1.c.type",
+		"error workspaceProjectRepo",
+		"error workspaceProjectRepoBranch",
+	}, locations(problems))
+	assert.Contains(t, problems[4].Message, "dependency cycle: a -> b -> a")
+	assert.Contains(t, problems[2].Message, "execs.run.sh")
+
+	err := ValidateSetup(params)
+	assert.ErrorContains(t, err, "repos.b.dependsOn[1]: error: missing does not exist")
+}
+
+func TestLintRepoURL(t *testing.T) {
+	for _, repo := range []string{
+		"https://github.com/brevdev/brev-cli.git",
+		"ssh://git@gitlab.com/org/repo",
+		"git@github.com:brevdev/brev-cli.git",
+		"github.com/brevdev/brev-cli",
+	} {
+		l := &setupLinter{}
+		l.lintRepoURL("repo", repo)
+		assert.Empty(t, l.problems, repo)
+	}
+	for _, repo := range []string{
+		"brev-cli",
+		"ftp://github.com/brevdev/brev-cli",
+		"https://github.com",
+		"https://github.com/brev dev/brev-cli",
+	} {
+		l := &setupLinter{}
+		l.lintRepoURL("repo", repo)
+		assert.Len(t, l.problems, 1, repo)
+	}
+}
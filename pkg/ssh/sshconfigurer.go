@@ -355,6 +355,51 @@ func (s SSHConfigurerV2) EnsureConfigHasInclude() error {
 	return nil
 }
 
+type UserSSHConfigStore interface {
+	GetUserSSHConfig() (string, error)
+	WriteUserSSHConfig(config string) error
+}
+
+// EnsureUserConfigIncludes adds an Include of configPath to ~/.ssh/config,
+// ex: for the config of brev local, which refresh does not manage
+func EnsureUserConfigIncludes(store UserSSHConfigStore, configPath string) error {
+	conf, err := store.GetUserSSHConfig()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if doesUserSSHConfigIncludeBrevConfig(conf, configPath) {
+		return nil
+	}
+	newConf, err := AddIncludeToUserConfig(conf, configPath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = store.WriteUserSSHConfig(newConf)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// MakeLocalSSHConfig has an entry per container of brev local, each reached
+// through the port published on localhost
+func MakeLocalSSHConfig(configPath string, entries []SSHConfigEntryV2) (string, error) {
+	tmpl, err := template.New("local").Parse(SSHConfigEntryTemplateV3)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("# included in %s\n", configPath))
+	for _, e := range entries {
+		e.IdentityFile = "\"" + e.IdentityFile + "\""
+		err = tmpl.Execute(buf, e)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+	}
+	return buf.String(), nil
+}
+
 func AddIncludeToUserConfig(conf string, brevConfigPath string) (string, error) {
 	newConf := makeIncludeBrevStr(brevConfigPath) + conf
 	return newConf, nil
@@ -226,6 +226,27 @@ blaksdf;asdf;
 	assert.Equal(t, correct, newConf)
 }
 
+func TestMakeLocalSSHConfig(t *testing.T) {
+	conf, err := MakeLocalSSHConfig("/my/user/config", []SSHConfigEntryV2{
+		{Alias: "my-env-local", IdentityFile: "/my/priv/key.pem", User: "brev", HostName: "127.0.0.1", Port: 2222},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `# included in /my/user/config
+Host my-env-local
+  Hostname 127.0.0.1
+  IdentityFile "/my/priv/key.pem"
+  User brev
+  ServerAliveInterval 30
+  UserKnownHostsFile /dev/null
+  IdentitiesOnly yes
+  StrictHostKeyChecking no
+  PasswordAuthentication no
+  RequestTTY yes
+  Port 2222
+
+`, conf)
+}
+
 func Test_makeSSHConfigEntryV2(t *testing.T) { //nolint:funlen // test
 	type args struct {
 		workspace      entity.Workspace
@@ -0,0 +1,427 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/autostartconf"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+	"github.com/hashicorp/go-multierror"
+)
+
+type SSHConfigSyncerStore interface {
+	SSHConfigurerTaskStore
+	GetBrevHomePath() (string, error)
+}
+
+// SSHConfigSyncer keeps the brev ssh config and the JetBrains Gateway config
+// in sync with the user's running dev environments. Unlike ConfigUpdater it
+// only writes a file when a host entry was added, removed or changed, so it
+// is cheap to run often
+type SSHConfigSyncer struct {
+	Store SSHConfigSyncerStore
+
+	mu      sync.Mutex
+	watcher *fileWatcher
+	logf    func(format string, v ...interface{})
+}
+
+var _ tasks.EventTask = &SSHConfigSyncer{}
+
+func NewSSHConfigSyncer(store SSHConfigSyncerStore) *SSHConfigSyncer {
+	return &SSHConfigSyncer{
+		Store: store,
+		logf:  func(string, ...interface{}) {},
+	}
+}
+
+// WithLogger reports which hosts changed on each write. It is off by default
+// since RunRefresh runs in the background of interactive commands
+func (s *SSHConfigSyncer) WithLogger(logf func(format string, v ...interface{})) *SSHConfigSyncer {
+	s.logf = logf
+	return s
+}
+
+// GetTaskSpec polls the api slowly to pick up changes made elsewhere, ex: the
+// console or autostop. Changes made by this cli show up through Events
+func (s *SSHConfigSyncer) GetTaskSpec() tasks.TaskSpec {
+	return tasks.TaskSpec{RunCronImmediately: true, Cron: "@every 30s"}
+}
+
+// Events fires when another brev command touches the dev environment cache,
+// or when one of the synced files is edited or deleted out from under us
+func (s *SSHConfigSyncer) Events() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watcher == nil {
+		s.watcher = newFileWatcher(s.watchedPaths(), time.Second)
+		s.watcher.Start()
+	}
+	return s.watcher.events
+}
+
+func (s *SSHConfigSyncer) watchedPaths() []string {
+	var paths []string
+	if brevHome, err := s.Store.GetBrevHomePath(); err == nil {
+		paths = append(paths, filepath.Join(brevHome, files.GetWorkspaceCacheFile()))
+	}
+	if path, err := s.Store.GetBrevSSHConfigPath(); err == nil {
+		paths = append(paths, path)
+	}
+	if path, err := s.Store.GetJetBrainsConfigPath(); err == nil {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Run does a single sync. Runs from cron and Events are serialized
+func (s *SSHConfigSyncer) Run() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.Store.GetCurrentUserKeys()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = s.syncPrivateKey(keys.PrivateKey)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	workspaces, err := s.Store.GetContextWorkspaces()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	var runningWorkspaces []entity.Workspace
+	for _, workspace := range workspaces {
+		if workspace.Status == entity.Running {
+			runningWorkspaces = append(runningWorkspaces, workspace)
+		}
+	}
+
+	var res error
+	err = s.syncBrevSSHConfig(runningWorkspaces)
+	if err != nil {
+		res = multierror.Append(res, err)
+	}
+	err = s.syncJetBrainsConfig(runningWorkspaces)
+	if err != nil {
+		res = multierror.Append(res, err)
+	}
+	if res != nil {
+		return breverrors.WrapAndTrace(res)
+	}
+	return nil
+}
+
+func (s *SSHConfigSyncer) Configure() error {
+	daemonConfigurer := autostartconf.NewSSHConfigSyncConfigurer(s.Store)
+	if daemonConfigurer == nil {
+		return breverrors.NewValidationError("installing the ssh config sync daemon is not supported on this os")
+	}
+	err := daemonConfigurer.Install()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (s *SSHConfigSyncer) syncPrivateKey(privateKey string) error {
+	path, err := s.Store.GetPrivateKeyPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	current, _ := s.readIfExists(path)
+	if current == privateKey {
+		return nil
+	}
+	err = s.Store.WritePrivateKey(privateKey)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (s *SSHConfigSyncer) syncBrevSSHConfig(workspaces []entity.Workspace) error {
+	configurer := NewSSHConfigurerV2(s.Store)
+	desired, err := configurer.CreateNewSSHConfig(workspaces)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	path, err := s.Store.GetBrevSSHConfigPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	current, exists := s.readIfExists(path)
+	diff := DiffHostEntries(ParseSSHConfigHosts(current), ParseSSHConfigHosts(desired))
+	if !exists || !diff.IsEmpty() {
+		s.logf("updating %s: %s", path, diff)
+		err = s.Store.WriteBrevSSHConfig(desired)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		s.ack(path)
+	}
+
+	// the user may have removed the Include since the last run, so this is
+	// checked even when the hosts are unchanged
+	err = configurer.EnsureConfigHasInclude()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	// same as SSHConfigurerV2.Update, wsl is best effort
+	wslConfig, err := configurer.CreateWSLConfig(workspaces)
+	if err != nil {
+		return nil
+	}
+	wslPath, err := s.Store.GetWSLHostBrevSSHConfigPath()
+	if err != nil {
+		return nil
+	}
+	currentWSL, exists := s.readIfExists(wslPath)
+	if !exists || !DiffHostEntries(ParseSSHConfigHosts(currentWSL), ParseSSHConfigHosts(wslConfig)).IsEmpty() {
+		err = s.Store.WriteBrevSSHConfigWSL(wslConfig)
+		if err != nil {
+			return nil
+		}
+	}
+	err = configurer.EnsureWSLConfigHasInclude()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (s *SSHConfigSyncer) syncJetBrainsConfig(workspaces []entity.Workspace) error {
+	doesJbPathExist, err := s.Store.DoesJetbrainsFilePathExist()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !doesJbPathExist {
+		return nil
+	}
+	configurer, err := NewSSHConfigurerJetBrains(s.Store)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	desired, err := configurer.CreateNewSSHConfig(workspaces)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	path, err := s.Store.GetJetBrainsConfigPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	current, exists := s.readIfExists(path)
+	diff := DiffHostEntries(ParseJetBrainsHosts(current), ParseJetBrainsHosts(desired))
+	if exists && diff.IsEmpty() {
+		return nil
+	}
+	s.logf("updating %s: %s", path, diff)
+	err = s.Store.WriteJetBrainsConfig(desired)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	s.ack(path)
+	return nil
+}
+
+// readIfExists treats an unreadable file the same as a missing one, so that
+// it gets rewritten
+func (s *SSHConfigSyncer) readIfExists(path string) (string, bool) {
+	exists, err := s.Store.FileExists(path)
+	if err != nil || !exists {
+		return "", false
+	}
+	contents, err := s.Store.GetFileAsString(path)
+	if err != nil {
+		return "", false
+	}
+	return contents, true
+}
+
+// ack stops our own writes from firing Events
+func (s *SSHConfigSyncer) ack(path string) {
+	if s.watcher != nil {
+		s.watcher.Ack(path)
+	}
+}
+
+// HostDiff lists host entries by name
+type HostDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (d HostDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func (d HostDiff) String() string {
+	if d.IsEmpty() {
+		return "no host changes"
+	}
+	var parts []string
+	if len(d.Added) > 0 {
+		parts = append(parts, "added "+strings.Join(d.Added, ", "))
+	}
+	if len(d.Removed) > 0 {
+		parts = append(parts, "removed "+strings.Join(d.Removed, ", "))
+	}
+	if len(d.Changed) > 0 {
+		parts = append(parts, "changed "+strings.Join(d.Changed, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// DiffHostEntries compares entries keyed by host. Ordering is ignored, since
+// the api does not return dev environments in a stable order
+func DiffHostEntries(current map[string]string, desired map[string]string) HostDiff {
+	var diff HostDiff
+	for host, entry := range desired {
+		currentEntry, ok := current[host]
+		if !ok {
+			diff.Added = append(diff.Added, host)
+		} else if currentEntry != entry {
+			diff.Changed = append(diff.Changed, host)
+		}
+	}
+	for host := range current {
+		if !MapContainsKey(desired, host) {
+			diff.Removed = append(diff.Removed, host)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// ParseSSHConfigHosts splits an ssh config into its Host blocks, keyed by
+// alias. Anything before the first Host line is dropped
+func ParseSSHConfigHosts(config string) map[string]string {
+	hosts := map[string]string{}
+	var alias string
+	var entry []string
+	flush := func() {
+		if alias != "" {
+			hosts[alias] = strings.TrimSpace(strings.Join(entry, "\n"))
+		}
+	}
+	for _, line := range strings.Split(config, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Host ") {
+			flush()
+			alias = strings.TrimSpace(strings.TrimPrefix(trimmed, "Host "))
+			entry = nil
+		}
+		if trimmed != "" {
+			entry = append(entry, trimmed)
+		}
+	}
+	flush()
+	return hosts
+}
+
+// ParseJetBrainsHosts keys JetBrains Gateway ssh configs by host and port.
+// A config that can't be parsed has no hosts, so it gets rewritten
+func ParseJetBrainsHosts(config string) map[string]string {
+	hosts := map[string]string{}
+	if strings.TrimSpace(config) == "" {
+		return hosts
+	}
+	parsed, err := ParseJetbrainsGatewayXML(config)
+	if err != nil {
+		return hosts
+	}
+	for _, c := range parsed.Component.Configs.SSHConfigs {
+		hosts[fmt.Sprintf("%s:%s", c.Host, c.Port)] = fmt.Sprintf("%+v", c)
+	}
+	return hosts
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// fileWatcher polls file metadata, which is cheap enough to do every second
+// and works the same on every os we ship to
+type fileWatcher struct {
+	paths    []string
+	interval time.Duration
+	events   chan struct{}
+
+	mu   sync.Mutex
+	seen map[string]fileState
+}
+
+func newFileWatcher(paths []string, interval time.Duration) *fileWatcher {
+	w := &fileWatcher{
+		paths:    paths,
+		interval: interval,
+		// buffered so that a burst of changes is coalesced into one run
+		events: make(chan struct{}, 1),
+		seen:   map[string]fileState{},
+	}
+	for _, p := range paths {
+		w.seen[p] = statFile(p)
+	}
+	return w
+}
+
+func (w *fileWatcher) Start() {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			w.Poll()
+		}
+	}()
+}
+
+func (w *fileWatcher) Poll() {
+	w.mu.Lock()
+	changed := false
+	for _, p := range w.paths {
+		st := statFile(p)
+		if st != w.seen[p] {
+			w.seen[p] = st
+			changed = true
+		}
+	}
+	w.mu.Unlock()
+	if changed {
+		select {
+		case w.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *fileWatcher) Ack(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if MapContainsKey(w.seen, path) {
+		w.seen[path] = statFile(path)
+	}
+}
@@ -0,0 +1,65 @@
+package ssh
+
+import (
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSSHConfigHosts(t *testing.T) {
+	c := NewSSHConfigurerV2(DummySSHConfigurerV2Store{})
+	cStr, err := c.CreateNewSSHConfig(somePlainWorkspaces)
+	assert.Nil(t, err)
+
+	hosts := ParseSSHConfigHosts(cStr)
+	assert.Len(t, hosts, 2)
+	entry := hosts[string(somePlainWorkspaces[0].GetLocalIdentifier())]
+	assert.Contains(t, entry, "Hostname test1-dns-org.brev.sh")
+	assert.NotContains(t, entry, "test2-dns-org.brev.sh")
+
+	assert.Empty(t, ParseSSHConfigHosts("# included in /my/user/config\n"))
+	assert.Empty(t, ParseSSHConfigHosts(""))
+}
+
+func TestDiffHostEntries(t *testing.T) {
+	c := NewSSHConfigurerV2(DummySSHConfigurerV2Store{})
+	both, err := c.CreateNewSSHConfig(somePlainWorkspaces)
+	assert.Nil(t, err)
+	reversed, err := c.CreateNewSSHConfig([]entity.Workspace{somePlainWorkspaces[1], somePlainWorkspaces[0]})
+	assert.Nil(t, err)
+	first, err := c.CreateNewSSHConfig(somePlainWorkspaces[:1])
+	assert.Nil(t, err)
+
+	// order from the api should not cause a rewrite
+	diff := DiffHostEntries(ParseSSHConfigHosts(both), ParseSSHConfigHosts(reversed))
+	assert.True(t, diff.IsEmpty())
+
+	secondID := string(somePlainWorkspaces[1].GetLocalIdentifier())
+	diff = DiffHostEntries(ParseSSHConfigHosts(first), ParseSSHConfigHosts(both))
+	assert.Equal(t, HostDiff{Added: []string{secondID}}, diff)
+
+	diff = DiffHostEntries(ParseSSHConfigHosts(both), ParseSSHConfigHosts(first))
+	assert.Equal(t, HostDiff{Removed: []string{secondID}}, diff)
+
+	moved := somePlainWorkspaces[1]
+	moved.DNS = "moved-dns-org.brev.sh"
+	changed, err := c.CreateNewSSHConfig([]entity.Workspace{somePlainWorkspaces[0], moved})
+	assert.Nil(t, err)
+	diff = DiffHostEntries(ParseSSHConfigHosts(both), ParseSSHConfigHosts(changed))
+	assert.Equal(t, HostDiff{Changed: []string{secondID}}, diff)
+}
+
+func TestParseJetBrainsHosts(t *testing.T) {
+	c, err := NewSSHConfigurerJetBrains(DummySSHConfigurerV2Store{})
+	assert.Nil(t, err)
+	cStr, err := c.CreateNewSSHConfig(somePlainWorkspaces)
+	assert.Nil(t, err)
+
+	hosts := ParseJetBrainsHosts(cStr)
+	assert.Len(t, hosts, 2)
+	assert.Contains(t, hosts, "test1-dns-org.brev.sh:22")
+
+	assert.Empty(t, ParseJetBrainsHosts(""))
+	assert.Empty(t, ParseJetBrainsHosts("not xml"))
+}
@@ -3,12 +3,11 @@ package store
 import (
 	"fmt"
 	"io/ioutil"
-	"path"
 
+	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
-	"github.com/brevdev/brev-cli/pkg/files"
-	"github.com/spf13/afero"
+	"github.com/golang-jwt/jwt"
 )
 
 // TODO 1 test cov
@@ -26,18 +25,61 @@ func (f FileStore) SaveAuthTokens(token entity.AuthTokens) error {
 	if token.AccessToken == "" {
 		return fmt.Errorf("access token is empty")
 	}
-	brevCredentialsFile, err := f.getBrevCredentialsFile()
+	backend, explicit, err := f.credentialBackend()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	err = files.OverwriteJSON(f.fs, *brevCredentialsFile, token)
+	profile := config.GlobalConfig.GetProfileName()
+	previous, err := f.getStoredAuthTokens(backend, explicit, profile)
+	if err != nil {
+		previous = &entity.AuthTokens{}
+	}
+
+	err = backend.save(profile, token)
+	if err != nil && !explicit {
+		// ex: a locked keyring that nobody is around to unlock
+		backend = f.newCredentialBackend(PlaintextCredentialStore)
+		err = backend.save(profile, token)
+		if err == nil {
+			// otherwise the keyring's old tokens win on the next read
+			_ = f.newCredentialBackend(KeyringCredentialStore).delete(profile)
+		}
+	}
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if backend.name() != PlaintextCredentialStore {
+		err = f.newCredentialBackend(PlaintextCredentialStore).delete(profile)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	// tokens are refreshed all the time, the cache only has to go when
+	// someone else logs in
+	if !isSameUser(previous.AccessToken, token.AccessToken) {
+		f.clearAllCaches()
+	}
 
 	return nil
 }
 
+// isSameUser compares the subject of two access tokens, tokens that can't be
+// parsed are treated as different users
+func isSameUser(previousToken string, token string) bool {
+	previous := tokenSubject(previousToken)
+	return previous != "" && previous == tokenSubject(token)
+}
+
+func tokenSubject(token string) string {
+	claims := jwt.MapClaims{}
+	_, _, err := (&jwt.Parser{}).ParseUnverified(token, claims)
+	if err != nil {
+		return ""
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
 func (f FileStore) GetAuthTokens() (*entity.AuthTokens, error) {
 	serviceToken, err := f.GetCurrentWorkspaceServiceToken()
 	if err == nil && serviceToken != "" {
@@ -46,25 +88,28 @@ func (f FileStore) GetAuthTokens() (*entity.AuthTokens, error) {
 		}, nil
 	}
 
-	brevCredentialsFile, err := f.getBrevCredentialsFile()
+	backend, explicit, err := f.credentialBackend()
 	if err != nil {
 		return nil, breverrors.WrapAndTrace(err)
 	}
+	return f.getStoredAuthTokens(backend, explicit, config.GlobalConfig.GetProfileName())
+}
 
-	exists, err := afero.Exists(f.fs, *brevCredentialsFile)
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+// getStoredAuthTokens falls back to the plaintext file, which is migrated to
+// backend on the way
+func (f FileStore) getStoredAuthTokens(backend credentialBackend, explicit bool, profile string) (*entity.AuthTokens, error) {
+	tokens, err := backend.get(profile)
+	if isCredentialsNotFound(err) && backend.name() != PlaintextCredentialStore {
+		tokens, err = f.migrateCredentials(backend, explicit, profile)
 	}
-	if !exists {
+	if isCredentialsNotFound(err) {
+		// unwrapped, auth checks for it by type
 		return nil, &breverrors.CredentialsFileNotFound{}
 	}
-
-	var token entity.AuthTokens
-	err = files.ReadJSON(f.fs, *brevCredentialsFile, &token)
 	if err != nil {
 		return nil, breverrors.WrapAndTrace(err)
 	}
-	return &token, nil
+	return tokens, nil
 }
 
 func (f FileStore) GetCurrentWorkspaceServiceToken() (string, error) {
@@ -94,22 +139,21 @@ func getServiceTokenFilePath() string {
 }
 
 func (f FileStore) DeleteAuthTokens() error {
-	brevCredentialsFile, err := f.getBrevCredentialsFile()
+	backend, _, err := f.credentialBackend()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	err = files.DeleteFile(f.fs, *brevCredentialsFile)
+	profile := config.GlobalConfig.GetProfileName()
+	err = backend.delete(profile)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	return nil
-}
-
-func (f FileStore) getBrevCredentialsFile() (*string, error) {
-	home, err := f.UserHomeDir()
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+	if backend.name() != PlaintextCredentialStore {
+		err = f.newCredentialBackend(PlaintextCredentialStore).delete(profile)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
 	}
-	brevCredentialsFile := path.Join(home, brevDirectory, brevCredentialsFile)
-	return &brevCredentialsFile, nil
+	f.clearAllCaches()
+	return nil
 }
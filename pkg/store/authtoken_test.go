@@ -1 +1,21 @@
 package store
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeToken(t *testing.T, sub string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": sub}).SignedString([]byte("secret"))
+	assert.Nil(t, err)
+	return token
+}
+
+func TestIsSameUser(t *testing.T) {
+	assert.True(t, isSameUser(makeToken(t, "u1"), makeToken(t, "u1")))
+	assert.False(t, isSameUser(makeToken(t, "u1"), makeToken(t, "u2")))
+	assert.False(t, isSameUser("", makeToken(t, "u1")))
+	assert.False(t, isSameUser("auto-login", "auto-login"))
+}
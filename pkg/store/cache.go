@@ -0,0 +1,328 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+)
+
+type CacheMode string
+
+const (
+	// CacheDefault uses fresh cache entries and falls back to stale ones when
+	// the api is unreachable
+	CacheDefault CacheMode = ""
+	// CacheBypass always asks the api, but still refreshes the cache
+	CacheBypass CacheMode = "no-cache"
+	// CacheOffline never asks the api
+	CacheOffline CacheMode = "offline"
+
+	// DefaultCacheTTL is long enough to make tab completion instant and short
+	// enough that new dev environments show up without thinking about it
+	DefaultCacheTTL = 5 * time.Minute
+
+	userCacheFile = "user_cache.json"
+)
+
+type cacheEntry[T any] struct {
+	FetchedAt time.Time `json:"fetchedAt"`
+	// Invalidated is set by mutating calls. The value is kept so it can still
+	// be shown, marked stale, when offline
+	Invalidated bool `json:"invalidated,omitempty"`
+	Value       T    `json:"value"`
+}
+
+// cacheFile is kept per profile and keyed by api url, so that profiles never
+// see each other's data
+type cacheFile[T any] struct {
+	APIURL  string                   `json:"apiURL"`
+	Entries map[string]cacheEntry[T] `json:"entries"`
+}
+
+// StaleHandler is called when a cached value is returned that may be out of
+// date, because the api was unreachable or --offline was passed
+type StaleHandler func(kind string, fetchedAt time.Time)
+
+// CachedStore caches the reads that nearly every command starts with: the
+// current user, their orgs and their dev environments. Everything else goes
+// straight to AuthHTTPStore
+type CachedStore struct {
+	*AuthHTTPStore
+	ttl     time.Duration
+	now     func() time.Time
+	onStale StaleHandler
+
+	mu   sync.Mutex
+	mode CacheMode
+}
+
+func NewCachedStore(s *AuthHTTPStore) *CachedStore {
+	return &CachedStore{AuthHTTPStore: s, ttl: DefaultCacheTTL, now: time.Now}
+}
+
+// WithTTL of 0 always asks the api, so the cache is only used as a fallback
+func (c *CachedStore) WithTTL(ttl time.Duration) *CachedStore {
+	c.ttl = ttl
+	return c
+}
+
+func (c *CachedStore) WithStaleHandler(onStale StaleHandler) *CachedStore {
+	c.onStale = onStale
+	return c
+}
+
+func (c *CachedStore) WithClock(now func() time.Time) *CachedStore {
+	c.now = now
+	return c
+}
+
+// SetMode is called once flags are parsed, after the store is built
+func (c *CachedStore) SetMode(mode CacheMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mode = mode
+}
+
+func (c *CachedStore) getMode() CacheMode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mode
+}
+
+func (c *CachedStore) GetCurrentUser() (*entity.User, error) {
+	user, err := cached(c, userCacheFile, "user", "me", c.AuthHTTPStore.GetCurrentUser)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return user, nil
+}
+
+func (c *CachedStore) GetOrganizations(options *GetOrganizationsOptions) ([]entity.Organization, error) {
+	orgs, err := cached(c, files.GetOrgCacheFile(), "orgs", "all", func() ([]entity.Organization, error) {
+		return c.AuthHTTPStore.GetOrganizations(nil)
+	})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if options == nil || options.Name == "" {
+		return orgs, nil
+	}
+	filtered := []entity.Organization{}
+	for _, o := range orgs {
+		if o.Name == options.Name {
+			filtered = append(filtered, o)
+		}
+	}
+	return filtered, nil
+}
+
+// GetActiveOrganizationOrDefault is cached as a whole since resolving it can
+// take several requests
+func (c *CachedStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
+	// keyed by the configured org so that changing it takes effect at once
+	key := "active:" + config.GlobalConfig.GetDefaultOrg()
+	org, err := cached(c, files.GetOrgCacheFile(), "active org", key, func() (*entity.Organization, error) {
+		return c.AuthHTTPStore.GetActiveOrganizationOrDefault()
+	})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return org, nil
+}
+
+// GetWorkspaces caches every dev environment in the org and filters after
+func (c *CachedStore) GetWorkspaces(organizationID string, options *GetWorkspacesOptions) ([]entity.Workspace, error) {
+	workspaces, err := cached(c, files.GetWorkspaceCacheFile(), "dev environments", organizationID, func() ([]entity.Workspace, error) {
+		return c.AuthHTTPStore.GetWorkspaces(organizationID, nil)
+	})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if options == nil {
+		return workspaces, nil
+	}
+	filtered := []entity.Workspace{}
+	for _, w := range workspaces {
+		if options.UserID != "" && w.CreatedByUserID != options.UserID {
+			continue
+		}
+		if options.Name != "" && w.Name != options.Name {
+			continue
+		}
+		filtered = append(filtered, w)
+	}
+	return filtered, nil
+}
+
+func (c *CachedStore) GetWorkspaceByNameOrID(orgID string, nameOrID string) ([]entity.Workspace, error) {
+	user, err := c.GetCurrentUser()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	workspaces, err := c.GetWorkspaces(orgID, &GetWorkspacesOptions{UserID: user.ID})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	matched := []entity.Workspace{}
+	for _, w := range workspaces {
+		if w.Name == nameOrID || w.ID == nameOrID {
+			matched = append(matched, w)
+		}
+	}
+	return matched, nil
+}
+
+func cached[T any](c *CachedStore, fileName string, kind string, key string, fetch func() (T, error)) (T, error) {
+	var zero T
+	path, err := c.cacheFilePath(fileName)
+	if err != nil {
+		return zero, breverrors.WrapAndTrace(err)
+	}
+	cache := readCacheFile[T](c.fs, path, c.apiURL())
+	entry, hit := cache.Entries[key]
+
+	mode := c.getMode()
+	if mode == CacheOffline {
+		if !hit {
+			return zero, breverrors.NewValidationError(fmt.Sprintf("no cached %s, run the command without --offline first", kind))
+		}
+		c.reportStale(kind, entry.FetchedAt)
+		return entry.Value, nil
+	}
+	if mode == CacheDefault && hit && !entry.Invalidated && c.now().Sub(entry.FetchedAt) < c.ttl {
+		return entry.Value, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		if mode == CacheDefault && hit && isUnreachable(err) {
+			c.reportStale(kind, entry.FetchedAt)
+			return entry.Value, nil
+		}
+		return zero, breverrors.WrapAndTrace(err)
+	}
+	cache.Entries[key] = cacheEntry[T]{FetchedAt: c.now(), Value: value}
+	// the cache is an optimisation, failing to write it should not fail the
+	// command
+	_ = writeCacheFile(c.fs, path, cache)
+	return value, nil
+}
+
+func (c *CachedStore) reportStale(kind string, fetchedAt time.Time) {
+	if c.onStale != nil {
+		c.onStale(kind, fetchedAt)
+	}
+}
+
+// isUnreachable is true when the api is down or can't be reached. Anything
+// else, ex: a bad response body, is a real error and is never papered over
+// with the cache
+func isUnreachable(err error) bool {
+	var httpErr *HTTPResponseError
+	if errors.As(err, &httpErr) {
+		return httpErr.Response.StatusCode() >= 500
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// cacheFilePath is per profile, since profiles on the same api are usually
+// different accounts
+func (f FileStore) cacheFilePath(fileName string) (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return files.GetProfileFilePath(home, config.GlobalConfig.GetProfileName(), fileName), nil
+}
+
+func (s AuthHTTPStore) apiURL() string {
+	return s.authHTTPClient.restyClient.BaseURL
+}
+
+// readCacheFile treats a missing, corrupt or foreign cache as empty
+func readCacheFile[T any](fs afero.Fs, path string, apiURL string) cacheFile[T] {
+	var cache cacheFile[T]
+	err := files.ReadJSON(fs, path, &cache)
+	if err != nil || cache.APIURL != apiURL || cache.Entries == nil {
+		return cacheFile[T]{APIURL: apiURL, Entries: map[string]cacheEntry[T]{}}
+	}
+	return cache
+}
+
+// writeCacheFile renames into place since bulk commands invalidate the cache
+// from several goroutines at once
+func writeCacheFile[T any](fs afero.Fs, path string, cache cacheFile[T]) error {
+	b, err := json.Marshal(cache)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = writeFileAtomic(fs, path, b, 0o644)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// invalidateWorkspaceCache is called after every call that changes a dev
+// environment. It marks entries invalid rather than deleting them so that
+// --offline still has something to show
+func (s AuthHTTPStore) invalidateWorkspaceCache() {
+	path, err := s.cacheFilePath(files.GetWorkspaceCacheFile())
+	if err != nil {
+		return
+	}
+	exists, err := afero.Exists(s.fs, path)
+	if err != nil || !exists {
+		return
+	}
+	cache := readCacheFile[[]entity.Workspace](s.fs, path, s.apiURL())
+	for k, e := range cache.Entries {
+		e.Invalidated = true
+		cache.Entries[k] = e
+	}
+	_ = writeCacheFile(s.fs, path, cache)
+}
+
+// clearCache removes cache files outright, ex: when the user changes, since
+// the data would be another user's
+func (f FileStore) clearCache(fileNames ...string) {
+	for _, name := range fileNames {
+		path, err := f.cacheFilePath(name)
+		if err != nil {
+			return
+		}
+		_ = f.fs.Remove(path)
+	}
+}
+
+func (f FileStore) clearAllCaches() {
+	f.clearCache(userCacheFile, files.GetOrgCacheFile(), files.GetWorkspaceCacheFile())
+}
+
+// FormatStaleWarning is the message commands print for a StaleHandler
+func FormatStaleWarning(kind string, fetchedAt time.Time, now time.Time) string {
+	return fmt.Sprintf("using cached %s from %s ago, may be out of date", kind, now.Sub(fetchedAt).Round(time.Second))
+}
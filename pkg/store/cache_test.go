@@ -0,0 +1,160 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+type cacheTest struct {
+	s        *AuthHTTPStore
+	c        *CachedStore
+	now      time.Time
+	stale    []string
+	listURL  string
+	stopURL  string
+	listings []entity.Workspace
+}
+
+func newCacheTest(t *testing.T) *cacheTest {
+	ct := &cacheTest{s: MakeMockAuthHTTPStore(), now: time.Now()}
+	ct.c = NewCachedStore(ct.s).
+		WithClock(func() time.Time { return ct.now }).
+		WithStaleHandler(func(kind string, _ time.Time) { ct.stale = append(ct.stale, kind) })
+	httpmock.ActivateNonDefault(ct.s.authHTTPClient.restyClient.GetClient())
+	t.Cleanup(httpmock.DeactivateAndReset)
+
+	base := ct.s.authHTTPClient.restyClient.BaseURL
+	ct.listURL = fmt.Sprintf("%s/%s", base, fmt.Sprintf(workspaceOrgPathPattern, "o"))
+	ct.stopURL = fmt.Sprintf("%s/%s", base, fmt.Sprintf(workspaceStopPathPattern, "w1"))
+	ct.listings = []entity.Workspace{{ID: "w1", Name: "one", CreatedByUserID: "u"}, {ID: "w2", Name: "two", CreatedByUserID: "other"}}
+	ct.respondWith(t)
+	stopRes, err := httpmock.NewJsonResponder(200, entity.Workspace{ID: "w1"})
+	assert.Nil(t, err)
+	httpmock.RegisterResponder("PUT", ct.stopURL, stopRes)
+	return ct
+}
+
+func (ct *cacheTest) respondWith(t *testing.T) {
+	res, err := httpmock.NewJsonResponder(200, ct.listings)
+	assert.Nil(t, err)
+	httpmock.RegisterResponder("GET", ct.listURL, res)
+}
+
+func (ct *cacheTest) listCalls() int {
+	return httpmock.GetCallCountInfo()["GET "+ct.listURL]
+}
+
+func TestCachedStoreUsesFreshEntries(t *testing.T) {
+	ct := newCacheTest(t)
+
+	ws, err := ct.c.GetWorkspaces("o", &GetWorkspacesOptions{UserID: "u"})
+	assert.Nil(t, err)
+	assert.Len(t, ws, 1)
+	ws, err = ct.c.GetWorkspaces("o", nil)
+	assert.Nil(t, err)
+	assert.Len(t, ws, 2)
+	assert.Equal(t, 1, ct.listCalls())
+
+	ct.now = ct.now.Add(DefaultCacheTTL)
+	_, err = ct.c.GetWorkspaces("o", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, ct.listCalls())
+	assert.Empty(t, ct.stale)
+}
+
+func TestCachedStoreInvalidatesAfterMutation(t *testing.T) {
+	ct := newCacheTest(t)
+
+	_, err := ct.c.GetWorkspaces("o", nil)
+	assert.Nil(t, err)
+	_, err = ct.s.StopWorkspace("w1")
+	assert.Nil(t, err)
+	_, err = ct.c.GetWorkspaces("o", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, ct.listCalls())
+}
+
+var connRefused = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+func TestCachedStoreFallsBackWhenUnreachable(t *testing.T) {
+	ct := newCacheTest(t)
+
+	_, err := ct.c.GetWorkspaces("o", nil)
+	assert.Nil(t, err)
+	ct.now = ct.now.Add(time.Hour)
+	httpmock.RegisterResponder("GET", ct.listURL, httpmock.NewErrorResponder(connRefused))
+
+	ws, err := ct.c.GetWorkspaces("o", nil)
+	assert.Nil(t, err)
+	assert.Len(t, ws, 2)
+	assert.Equal(t, []string{"dev environments"}, ct.stale)
+
+	// an answer from the api is never papered over with the cache
+	httpmock.RegisterResponder("GET", ct.listURL, httpmock.NewStringResponder(403, "forbidden"))
+	_, err = ct.c.GetWorkspaces("o", nil)
+	assert.Error(t, err)
+
+	// neither is an error that has nothing to do with the network
+	httpmock.RegisterResponder("GET", ct.listURL, httpmock.NewErrorResponder(errors.New("connection refused")))
+	_, err = ct.c.GetWorkspaces("o", nil)
+	assert.Error(t, err)
+
+	ct.c.SetMode(CacheBypass)
+	httpmock.RegisterResponder("GET", ct.listURL, httpmock.NewErrorResponder(connRefused))
+	_, err = ct.c.GetWorkspaces("o", nil)
+	assert.Error(t, err)
+}
+
+func TestCachedStoreOffline(t *testing.T) {
+	ct := newCacheTest(t)
+	ct.c.SetMode(CacheOffline)
+
+	_, err := ct.c.GetWorkspaces("o", nil)
+	assert.Error(t, err)
+	assert.Equal(t, 0, ct.listCalls())
+
+	ct.c.SetMode(CacheDefault)
+	_, err = ct.c.GetWorkspaces("o", nil)
+	assert.Nil(t, err)
+	_, err = ct.s.StopWorkspace("w1")
+	assert.Nil(t, err)
+
+	ct.c.SetMode(CacheOffline)
+	// the user was never cached
+	_, err = ct.c.GetWorkspaceByNameOrID("o", "w1")
+	assert.Error(t, err)
+	// invalidated entries are still shown offline
+	ws, err := ct.c.GetWorkspaces("o", &GetWorkspacesOptions{Name: "one"})
+	assert.Nil(t, err)
+	assert.Len(t, ws, 1)
+	assert.Equal(t, 1, ct.listCalls())
+}
+
+func TestCachedStoreIsPerProfile(t *testing.T) {
+	ct := newCacheTest(t)
+	_, err := ct.c.GetWorkspaces("o", nil)
+	assert.Nil(t, err)
+
+	previous := config.GlobalConfig
+	config.GlobalConfig = config.NewConstants().WithEnvVars().WithFileConfig(config.Profile{}).WithProfileName("work")
+	t.Cleanup(func() { config.GlobalConfig = previous })
+
+	ct.c.SetMode(CacheOffline)
+	_, err = ct.c.GetWorkspaces("o", nil)
+	assert.Error(t, err, "another profile's dev environments were shown")
+
+	ct.c.SetMode(CacheDefault)
+	_, err = ct.c.GetWorkspaces("o", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, ct.listCalls())
+}
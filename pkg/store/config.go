@@ -0,0 +1,64 @@
+package store
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+
+	"github.com/brevdev/brev-cli/pkg/config"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+func (f FileStore) getConfigFilePath() (string, error) {
+	brevHome, err := f.GetBrevHomePath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return filepath.Join(brevHome, config.ConfigFileName), nil
+}
+
+// GetConfigFile returns an empty config when there is no config file
+func (f FileStore) GetConfigFile() (*config.ConfigFile, error) {
+	path, err := f.getConfigFilePath()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	exists, err := afero.Exists(f.fs, path)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if !exists {
+		return &config.ConfigFile{}, nil
+	}
+	b, err := afero.ReadFile(f.fs, path)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	var c config.ConfigFile
+	err = yaml.UnmarshalStrict(b, &c)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, path)
+	}
+	return &c, nil
+}
+
+func (f FileStore) SaveConfigFile(c config.ConfigFile) error {
+	path, err := f.getConfigFilePath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.fs.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = afero.WriteFile(f.fs, path, b, 0o644)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
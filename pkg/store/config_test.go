@@ -0,0 +1,28 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/config"
+)
+
+func TestConfigFileRoundTrip(t *testing.T) {
+	fs := MakeMockFileStore()
+	c, err := fs.GetConfigFile()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, config.ConfigFile{}, *c)
+
+	assert.Nil(t, c.Set("staging", "org", "my-org"))
+	assert.Nil(t, c.UseProfile("staging"))
+	assert.Nil(t, fs.SaveConfigFile(*c))
+
+	c2, err := fs.GetConfigFile()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, *c, *c2)
+}
@@ -0,0 +1,471 @@
+package store
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/afero"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+)
+
+// credential stores, picked with brev login --credential-store or the
+// credential-store key of a profile
+const (
+	KeyringCredentialStore   = "keyring"
+	EncryptedCredentialStore = "encrypted"
+	PlaintextCredentialStore = "plaintext"
+)
+
+var CredentialStores = []string{KeyringCredentialStore, EncryptedCredentialStore, PlaintextCredentialStore}
+
+const (
+	brevEncryptedCredentialsFile = "credentials.enc"
+	// BREV_CREDENTIAL_PASSPHRASE unlocks the encrypted store without a prompt
+	credentialPassphraseEnvVar = "BREV_CREDENTIAL_PASSPHRASE"
+	keyringService             = "brev-cli"
+)
+
+// credentialBackend keeps the tokens of each profile. get returns
+// CredentialsFileNotFound when there are none
+type credentialBackend interface {
+	name() string
+	get(profile string) (*entity.AuthTokens, error)
+	save(profile string, tokens entity.AuthTokens) error
+	delete(profile string) error
+}
+
+// WithPassphraseGetter sets how the encrypted credential store asks for its
+// passphrase when $BREV_CREDENTIAL_PASSPHRASE is not set
+func (f *FileStore) WithPassphraseGetter(getter func() (string, error)) *FileStore {
+	f.passphraseGetter = getter
+	return f
+}
+
+// credentialBackend is the configured store, or when none is, the keyring
+// where there is one and the plaintext file everywhere else, ex: headless
+// dev environments
+func (f FileStore) credentialBackend() (credentialBackend, bool, error) {
+	name := config.GlobalConfig.GetCredentialStore()
+	if name == "" {
+		if f.keyringRunner != nil || keyringAvailable() {
+			return f.newCredentialBackend(KeyringCredentialStore), false, nil
+		}
+		return f.newCredentialBackend(PlaintextCredentialStore), false, nil
+	}
+	err := ValidateCredentialStore(name)
+	if err != nil {
+		return nil, true, breverrors.WrapAndTrace(err)
+	}
+	return f.newCredentialBackend(name), true, nil
+}
+
+func (f FileStore) newCredentialBackend(name string) credentialBackend {
+	switch name {
+	case KeyringCredentialStore:
+		if f.keyringRunner != nil {
+			return keyringCredentials{run: f.keyringRunner}
+		}
+		return keyringCredentials{run: runWithStdin}
+	case EncryptedCredentialStore:
+		return encryptedCredentials{fs: f.fs, home: f.UserHomeDir, passphrase: f.getPassphrase}
+	default:
+		return plaintextCredentials{fs: f.fs, home: f.UserHomeDir}
+	}
+}
+
+func ValidateCredentialStore(name string) error {
+	switch name {
+	case KeyringCredentialStore:
+		if !keyringAvailable() {
+			return breverrors.NewValidationError("the keyring credential store needs secret-tool and a desktop session, ex: apt install libsecret-tools, use encrypted or plaintext otherwise")
+		}
+		return nil
+	case EncryptedCredentialStore, PlaintextCredentialStore:
+		return nil
+	default:
+		return breverrors.NewValidationError(fmt.Sprintf("unknown credential store %s, must be one of %s", name, strings.Join(CredentialStores, ", ")))
+	}
+}
+
+func (f FileStore) getPassphrase() (string, error) {
+	if p := os.Getenv(credentialPassphraseEnvVar); p != "" {
+		return p, nil
+	}
+	if f.passphraseGetter == nil {
+		return "", breverrors.NewValidationError(fmt.Sprintf("the encrypted credential store needs a passphrase, set %s", credentialPassphraseEnvVar))
+	}
+	return f.passphraseGetter()
+}
+
+// migrateCredentials moves the tokens of the plaintext file, where brev kept
+// them before there were credential stores, into backend. When backend was
+// picked by default and doesn't work, ex: a locked keyring, they stay put
+func (f FileStore) migrateCredentials(backend credentialBackend, explicit bool, profile string) (*entity.AuthTokens, error) {
+	plaintext := f.newCredentialBackend(PlaintextCredentialStore)
+	tokens, err := plaintext.get(profile)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = backend.save(profile, *tokens)
+	if err != nil {
+		if explicit {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		return tokens, nil
+	}
+	err = plaintext.delete(profile)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return tokens, nil
+}
+
+// SetCredentialStore moves the tokens of the active profile to the named
+// store and saves it as the profile's credential-store
+func (f FileStore) SetCredentialStore(name string) error {
+	err := ValidateCredentialStore(name)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if env := os.Getenv(string(config.CredentialStoreEnvVar)); env != "" && env != name {
+		return breverrors.NewValidationError(fmt.Sprintf("%s is set to %s, unset it to use %s", config.CredentialStoreEnvVar, env, name))
+	}
+	profile := config.GlobalConfig.GetProfileName()
+	from, explicit, err := f.credentialBackend()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	to := f.newCredentialBackend(name)
+	if from.name() != to.name() {
+		tokens, err := f.getStoredAuthTokens(from, explicit, profile)
+		if err != nil && !isCredentialsNotFound(err) {
+			return breverrors.WrapAndTrace(err)
+		}
+		if err == nil {
+			err = to.save(profile, *tokens)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = from.delete(profile)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+		}
+	}
+
+	configFile, err := f.GetConfigFile()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = configFile.Set(profile, "credential-store", name)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.SaveConfigFile(*configFile)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	config.GlobalConfig = config.GlobalConfig.WithProfile(configFile.Profiles[profile])
+	return nil
+}
+
+func isCredentialsNotFound(err error) bool {
+	var notFound *breverrors.CredentialsFileNotFound
+	return errors.As(err, &notFound)
+}
+
+// plaintextCredentials is credentials.json, readable by anything running as
+// the user
+type plaintextCredentials struct {
+	fs   afero.Fs
+	home func() (string, error)
+}
+
+func (p plaintextCredentials) name() string {
+	return PlaintextCredentialStore
+}
+
+func (p plaintextCredentials) path(profile string) (string, error) {
+	home, err := p.home()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return files.GetProfileFilePath(home, profile, brevCredentialsFile), nil
+}
+
+func (p plaintextCredentials) get(profile string) (*entity.AuthTokens, error) {
+	path, err := p.path(profile)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	exists, err := afero.Exists(p.fs, path)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if !exists {
+		return nil, &breverrors.CredentialsFileNotFound{}
+	}
+	var token entity.AuthTokens
+	err = files.ReadJSON(p.fs, path, &token)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &token, nil
+}
+
+func (p plaintextCredentials) save(profile string, tokens entity.AuthTokens) error {
+	path, err := p.path(profile)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = files.OverwriteJSON(p.fs, path, tokens)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (p plaintextCredentials) delete(profile string) error {
+	path, err := p.path(profile)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	exists, err := afero.Exists(p.fs, path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !exists {
+		return nil
+	}
+	err = files.DeleteFile(p.fs, path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// keyringCredentials keeps the tokens in the Secret Service keyring, ex:
+// gnome-keyring or kwallet, through libsecret's secret-tool. Tokens are only
+// ever passed on stdin and stdout, never as arguments
+type keyringCredentials struct {
+	run func(stdin []byte, name string, args ...string) ([]byte, error)
+}
+
+func keyringAvailable() bool {
+	if runtime.GOOS != "linux" || os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return false
+	}
+	_, err := exec.LookPath("secret-tool")
+	return err == nil
+}
+
+func runWithStdin(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...) //nolint:gosec // secret-tool with fixed arguments
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return out, fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (k keyringCredentials) name() string {
+	return KeyringCredentialStore
+}
+
+func keyringAttributes(profile string) []string {
+	return []string{"service", keyringService, "profile", profile}
+}
+
+func (k keyringCredentials) get(profile string) (*entity.AuthTokens, error) {
+	out, err := k.run(nil, "secret-tool", append([]string{"lookup"}, keyringAttributes(profile)...)...)
+	// lookup exits 1 without output when there is no such secret
+	if len(bytes.TrimSpace(out)) == 0 {
+		var exitErr *exec.ExitError
+		if err == nil || errors.As(err, &exitErr) {
+			return nil, &breverrors.CredentialsFileNotFound{}
+		}
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	var tokens entity.AuthTokens
+	err = json.Unmarshal(out, &tokens)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, "unable to read the brev credentials in the keyring")
+	}
+	return &tokens, nil
+}
+
+func (k keyringCredentials) save(profile string, tokens entity.AuthTokens) error {
+	b, err := json.Marshal(tokens)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	args := append([]string{"store", "--label", fmt.Sprintf("brev credentials (%s)", profile)}, keyringAttributes(profile)...)
+	_, err = k.run(b, "secret-tool", args...)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (k keyringCredentials) delete(profile string) error {
+	_, err := k.run(nil, "secret-tool", append([]string{"clear"}, keyringAttributes(profile)...)...)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// encryptedCredentials is credentials.enc, the tokens sealed with AES-GCM
+// under a key derived from a passphrase with scrypt
+type encryptedCredentials struct {
+	fs         afero.Fs
+	home       func() (string, error)
+	passphrase func() (string, error)
+}
+
+type encryptedCredentialsFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (e encryptedCredentials) name() string {
+	return EncryptedCredentialStore
+}
+
+func (e encryptedCredentials) path(profile string) (string, error) {
+	home, err := e.home()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return files.GetProfileFilePath(home, profile, brevEncryptedCredentialsFile), nil
+}
+
+func credentialsCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return aead, nil
+}
+
+func (e encryptedCredentials) get(profile string) (*entity.AuthTokens, error) {
+	path, err := e.path(profile)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	b, err := afero.ReadFile(e.fs, path)
+	if os.IsNotExist(err) {
+		return nil, &breverrors.CredentialsFileNotFound{}
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	var file encryptedCredentialsFile
+	err = json.Unmarshal(b, &file)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, path)
+	}
+	passphrase, err := e.passphrase()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	aead, err := credentialsCipher(passphrase, file.Salt)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("unable to decrypt %s, wrong passphrase? brev logout removes it", path))
+	}
+	var tokens entity.AuthTokens
+	err = json.Unmarshal(plaintext, &tokens)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &tokens, nil
+}
+
+func (e encryptedCredentials) save(profile string, tokens entity.AuthTokens) error {
+	path, err := e.path(profile)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	passphrase, err := e.passphrase()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	file := encryptedCredentialsFile{Version: 1, Salt: make([]byte, 16)}
+	_, err = rand.Read(file.Salt)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	aead, err := credentialsCipher(passphrase, file.Salt)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	file.Nonce = make([]byte, aead.NonceSize())
+	_, err = rand.Read(file.Nonce)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, plaintext, nil)
+	b, err := json.Marshal(file)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = e.fs.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = afero.WriteFile(e.fs, path, b, 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (e encryptedCredentials) delete(profile string) error {
+	path, err := e.path(profile)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = e.fs.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+var testTokens = entity.AuthTokens{AccessToken: "access-token", RefreshToken: "refresh-token"}
+
+func TestEncryptedCredentials(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	passphrase := "correct horse"
+	e := encryptedCredentials{
+		fs:         fs,
+		home:       func() (string, error) { return "/home/me", nil },
+		passphrase: func() (string, error) { return passphrase, nil },
+	}
+
+	_, err := e.get("default")
+	assert.True(t, isCredentialsNotFound(err))
+
+	assert.Nil(t, e.save("default", testTokens))
+	b, err := afero.ReadFile(fs, "/home/me/.brev/credentials.enc")
+	assert.Nil(t, err)
+	assert.False(t, bytes.Contains(b, []byte(testTokens.AccessToken)))
+	info, err := fs.Stat("/home/me/.brev/credentials.enc")
+	assert.Nil(t, err)
+	assert.Equal(t, "-rw-------", info.Mode().String())
+
+	tokens, err := e.get("default")
+	assert.Nil(t, err)
+	assert.Equal(t, testTokens, *tokens)
+
+	passphrase = "wrong"
+	_, err = e.get("default")
+	assert.ErrorContains(t, err, "wrong passphrase")
+
+	assert.Nil(t, e.delete("default"))
+	assert.Nil(t, e.delete("default"))
+	_, err = e.get("default")
+	assert.True(t, isCredentialsNotFound(err))
+}
+
+// fakeSecretTool keeps secrets like secret-tool does, by attributes
+type fakeSecretTool struct {
+	secrets  map[string][]byte
+	args     [][]string
+	storeErr error
+}
+
+func (f *fakeSecretTool) run(stdin []byte, _ string, args ...string) ([]byte, error) {
+	f.args = append(f.args, args)
+	attrs := strings.Join(args[len(args)-4:], " ")
+	switch args[0] {
+	case "store":
+		if f.storeErr != nil {
+			return nil, f.storeErr
+		}
+		f.secrets[attrs] = stdin
+	case "lookup":
+		return f.secrets[attrs], nil
+	case "clear":
+		delete(f.secrets, attrs)
+	}
+	return nil, nil
+}
+
+func TestKeyringCredentials(t *testing.T) {
+	tool := &fakeSecretTool{secrets: map[string][]byte{}}
+	k := keyringCredentials{run: tool.run}
+
+	_, err := k.get("default")
+	assert.True(t, isCredentialsNotFound(err))
+
+	assert.Nil(t, k.save("default", testTokens))
+	tokens, err := k.get("default")
+	assert.Nil(t, err)
+	assert.Equal(t, testTokens, *tokens)
+	_, err = k.get("staging")
+	assert.True(t, isCredentialsNotFound(err))
+
+	assert.Nil(t, k.delete("default"))
+	_, err = k.get("default")
+	assert.True(t, isCredentialsNotFound(err))
+
+	for _, args := range tool.args {
+		assert.NotContains(t, strings.Join(args, " "), testTokens.AccessToken, "tokens are never arguments")
+	}
+}
+
+func withCredentialStore(t *testing.T, name string) {
+	previous := config.GlobalConfig
+	config.GlobalConfig = config.GlobalConfig.WithProfile(config.Profile{CredentialStore: name})
+	t.Cleanup(func() { config.GlobalConfig = previous })
+}
+
+func TestMigratePlaintextCredentials(t *testing.T) {
+	home := t.TempDir()
+	fs := MakeMockBasicStore().WithFileSystem(afero.NewOsFs()).
+		WithUserHomeDirGetter(func() (string, error) { return home, nil })
+	t.Setenv(credentialPassphraseEnvVar, "correct horse")
+	withCredentialStore(t, PlaintextCredentialStore)
+	assert.Nil(t, fs.SaveAuthTokens(testTokens))
+
+	withCredentialStore(t, EncryptedCredentialStore)
+	tokens, err := fs.GetAuthTokens()
+	assert.Nil(t, err)
+	assert.Equal(t, testTokens, *tokens)
+	exists, err := afero.Exists(fs.fs, home+"/.brev/credentials.json")
+	assert.Nil(t, err)
+	assert.False(t, exists, "the plaintext file is gone once migrated")
+
+	tokens, err = fs.GetAuthTokens()
+	assert.Nil(t, err)
+	assert.Equal(t, testTokens, *tokens)
+
+	assert.Nil(t, fs.DeleteAuthTokens())
+	_, err = fs.GetAuthTokens()
+	assert.True(t, isCredentialsNotFound(err))
+}
+
+func TestSaveFallsBackFromALockedKeyring(t *testing.T) {
+	home := t.TempDir()
+	fs := MakeMockBasicStore().WithFileSystem(afero.NewOsFs()).
+		WithUserHomeDirGetter(func() (string, error) { return home, nil })
+	tool := &fakeSecretTool{secrets: map[string][]byte{}}
+	fs.keyringRunner = tool.run
+	withCredentialStore(t, "")
+	assert.Nil(t, fs.SaveAuthTokens(testTokens))
+
+	// the keyring can still be read but no longer written, ex: locked
+	tool.storeErr = errors.New("secret-tool: exit status 1: cannot create an item in a locked collection")
+	refreshed := entity.AuthTokens{AccessToken: "refreshed-access-token", RefreshToken: "refreshed-refresh-token"}
+	assert.Nil(t, fs.SaveAuthTokens(refreshed))
+
+	tokens, err := fs.GetAuthTokens()
+	assert.Nil(t, err)
+	assert.Equal(t, refreshed, *tokens, "the stale keyring tokens were read back")
+}
+
+func TestValidateCredentialStore(t *testing.T) {
+	assert.Nil(t, ValidateCredentialStore(EncryptedCredentialStore))
+	assert.Nil(t, ValidateCredentialStore(PlaintextCredentialStore))
+	assert.Error(t, ValidateCredentialStore("vault"))
+}
@@ -28,6 +28,9 @@ type FileStore struct {
 	fs                afero.Fs
 	User              *user.User
 	userHomeDirGetter func() (string, error)
+	passphraseGetter  func() (string, error)
+	// keyringRunner runs secret-tool, when nil it is looked up on the path
+	keyringRunner func(stdin []byte, name string, args ...string) ([]byte, error)
 }
 
 func (f *FileStore) GetWindowsDir() (string, error) {
@@ -90,6 +93,36 @@ func (f FileStore) GetOrCreateFile(path string) (afero.File, error) {
 	return file, nil
 }
 
+// writeFileAtomic writes to a temp file next to path and renames it into
+// place, so that readers like ssh never see a half written file
+func writeFileAtomic(fs afero.Fs, path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	err := fs.MkdirAll(dir, 0o755)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	tmp, err := afero.TempFile(fs, dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = tmp.Write(data)
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fs.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		err = fs.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = fs.Remove(tmp.Name())
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func (f FileStore) FileExists(filepath string) (bool, error) {
 	fileExists, err := afero.Exists(f.fs, filepath)
 	if err != nil {
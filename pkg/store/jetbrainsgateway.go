@@ -11,7 +11,6 @@ import (
 	"strings"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
-	"github.com/spf13/afero"
 )
 
 const (
@@ -118,14 +117,7 @@ func (f FileStore) WriteJetBrainsConfig(config string) error {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	basePath := filepath.Dir(path)
-	dir := afero.NewBasePathFs(f.fs, basePath)
-	err = dir.MkdirAll("", 0o755)
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-
-	err = afero.WriteFile(f.fs, path, []byte(config), 0o644)
+	err = writeFileAtomic(f.fs, path, []byte(config), 0o644)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+	"github.com/spf13/afero"
+)
+
+// LocalWorkspace is a dev environment that brev local runs in a container on
+// this machine
+type LocalWorkspace struct {
+	Name        string `json:"name"`
+	WorkspaceID string `json:"workspaceId"`
+	Image       string `json:"image"`
+	// SSHPort on localhost is published to port 22 of the container
+	SSHPort int `json:"sshPort"`
+}
+
+// SSHAlias does not collide with the alias of the cloud dev environment
+func (l LocalWorkspace) SSHAlias() string {
+	return fmt.Sprintf("%s-local", l.Name)
+}
+
+const localWorkspacesFile = "workspaces.json"
+
+func (f FileStore) getLocalPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return files.GetLocalPath(home), nil
+}
+
+// GetLocalWorkspaces is empty until brev local start has run
+func (f FileStore) GetLocalWorkspaces() ([]LocalWorkspace, error) {
+	path, err := f.getLocalPath()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	path = filepath.Join(path, localWorkspacesFile)
+	exists, err := afero.Exists(f.fs, path)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	workspaces := []LocalWorkspace{}
+	if !exists {
+		return workspaces, nil
+	}
+	err = files.ReadJSON(f.fs, path, &workspaces)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return workspaces, nil
+}
+
+func (f FileStore) SaveLocalWorkspaces(workspaces []LocalWorkspace) error {
+	path, err := f.getLocalPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = files.OverwriteJSON(f.fs, filepath.Join(path, localWorkspacesFile), workspaces)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (f FileStore) GetLocalVolumesPath() (string, error) {
+	path, err := f.getLocalPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return filepath.Join(path, "volumes"), nil
+}
+
+// RemoveLocalWorkspaceVolumes deletes everything the container had mounted,
+// including the code in /home/brev/workspace
+func (f FileStore) RemoveLocalWorkspaceVolumes(workspaceID string) error {
+	path, err := f.GetLocalVolumesPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.fs.RemoveAll(filepath.Join(path, workspaceID))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (f FileStore) GetLocalSSHConfigPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return files.GetLocalSSHConfigPath(home), nil
+}
+
+func (f FileStore) WriteLocalSSHConfig(config string) error {
+	path, err := f.GetLocalSSHConfigPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = files.OverwriteString(f.fs, path, config)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"fmt"
 
+	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/files"
@@ -14,29 +15,40 @@ func (s AuthHTTPStore) SetDefaultOrganization(org *entity.Organization) error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	path := files.GetActiveOrgsPath(home)
+	// BREV_ORG and the profile's org both take precedence over
+	// active_org.json, so writing it would look like it did nothing
+	if source, pinned := config.GlobalConfig.GetDefaultOrgOverride(); source != "" {
+		return breverrors.NewValidationError(fmt.Sprintf("org is pinned to %s by %s, %s", pinned, source, orgOverrideHint(source)))
+	}
+	path := files.GetProfileActiveOrgsPath(home, config.GlobalConfig.GetProfileName())
 
 	err = files.OverwriteJSON(s.fs, path, org)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	s.clearCache(files.GetOrgCacheFile())
 
 	return nil
 }
 
+func orgOverrideHint(source string) string {
+	if source == "BREV_ORG" {
+		return "unset it to use brev set"
+	}
+	return "change it with brev config set org <name>"
+}
+
 func (f FileStore) ClearDefaultOrganization() error {
 	home, err := f.UserHomeDir()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	path := files.GetActiveOrgsPath(home)
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
+	path := files.GetProfileActiveOrgsPath(home, config.GlobalConfig.GetProfileName())
 	err = files.DeleteFile(f.fs, path)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	f.clearCache(files.GetOrgCacheFile())
 	return nil
 }
 
@@ -60,11 +72,15 @@ func (s AuthHTTPStore) GetActiveOrganizationOrNil() (*entity.Organization, error
 		return org, nil
 	}
 
+	if nameOrID := config.GlobalConfig.GetDefaultOrg(); nameOrID != "" {
+		return s.getOrganizationByNameOrID(nameOrID)
+	}
+
 	home, err := s.UserHomeDir()
 	if err != nil {
 		return nil, breverrors.WrapAndTrace(err)
 	}
-	brevActiveOrgsFile := files.GetActiveOrgsPath(home)
+	brevActiveOrgsFile := files.GetProfileActiveOrgsPath(home, config.GlobalConfig.GetProfileName())
 
 	exists, err := afero.Exists(s.fs, brevActiveOrgsFile)
 	if err != nil {
@@ -89,6 +105,20 @@ func (s AuthHTTPStore) GetActiveOrganizationOrNil() (*entity.Organization, error
 	return freshOrg, nil
 }
 
+func (s AuthHTTPStore) getOrganizationByNameOrID(nameOrID string) (*entity.Organization, error) {
+	orgs, err := s.GetOrganizations(nil)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	for _, o := range orgs {
+		if o.ID == nameOrID || o.Name == nameOrID {
+			o := o
+			return &o, nil
+		}
+	}
+	return nil, breverrors.NewValidationError(fmt.Sprintf("no org with name or id %s, check your brev config", nameOrID))
+}
+
 // returns the 'set'/active organization or the default one or nil if no orgs exist
 func (s AuthHTTPStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
 	org, err := s.GetActiveOrganizationOrNil()
@@ -1,6 +1,10 @@
 package store
 
-import breverrors "github.com/brevdev/brev-cli/pkg/errors"
+import (
+	"fmt"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
 
 type CreateSecretRequest struct {
 	Name          string        `json:"name"`
@@ -67,3 +71,70 @@ func (s AuthHTTPStore) CreateSecret(req CreateSecretRequest) (*CreateSecretReque
 
 	return &result, nil
 }
+
+// Secret is what the api returns when listing, values are never sent back
+type Secret struct {
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	HierarchyType HierarchyType `json:"hierarchyType"`
+	HierarchyID   string        `json:"hierarchyID"`
+	Dest          SecretReqDest `json:"dest"`
+	UpdatedAt     string        `json:"updatedAt,omitempty"`
+}
+
+var (
+	secretIDParamName = "secretID"
+	secretIDPath      = fmt.Sprintf("%s/{%s}", secretsPath, secretIDParamName)
+)
+
+func (s AuthHTTPStore) GetSecrets(hierarchyType HierarchyType, hierarchyID string) ([]Secret, error) {
+	var result []Secret
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetQueryParam("hierarchyType", string(hierarchyType)).
+		SetQueryParam("hierarchyID", hierarchyID).
+		SetResult(&result).
+		Get(secretsPath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return nil, NewHTTPResponseError(res)
+	}
+
+	return result, nil
+}
+
+// UpdateSecret replaces the value and destination of a secret, ex: to rotate it
+func (s AuthHTTPStore) UpdateSecret(secretID string, req CreateSecretRequest) (*Secret, error) {
+	var result Secret
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetPathParam(secretIDParamName, secretID).
+		SetResult(&result).
+		SetBody(req).
+		Put(secretIDPath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return nil, NewHTTPResponseError(res)
+	}
+
+	return &result, nil
+}
+
+func (s AuthHTTPStore) DeleteSecret(secretID string) error {
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetPathParam(secretIDParamName, secretID).
+		Delete(secretIDPath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return NewHTTPResponseError(res)
+	}
+
+	return nil
+}
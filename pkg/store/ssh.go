@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"path/filepath"
 	"strings"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -146,11 +145,7 @@ func (f FileStore) WriteBrevSSHConfig(config string) error {
 	}
 	bsp := files.GetBrevSSHConfigPath(home)
 
-	err = f.fs.MkdirAll(filepath.Dir(bsp), 0o755)
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-	err = afero.WriteFile(f.fs, bsp, []byte(config), 0o644)
+	err = writeFileAtomic(f.fs, bsp, []byte(config), 0o644)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -163,11 +158,7 @@ func (f FileStore) WriteBrevSSHConfigWSL(config string) error {
 		return breverrors.WrapAndTrace(err)
 	}
 	path := files.GetBrevSSHConfigPath(home)
-	err = f.fs.MkdirAll(filepath.Dir(path), 0o755)
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-	err = afero.WriteFile(f.fs, path, []byte(config), 0o644)
+	err = writeFileAtomic(f.fs, path, []byte(config), 0o644)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
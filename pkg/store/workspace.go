@@ -143,6 +143,7 @@ func (s AuthHTTPStore) CreateWorkspace(organizationID string, options *CreateWor
 		return nil, NewHTTPResponseError(res)
 	}
 
+	s.invalidateWorkspaceCache()
 	return &result, nil
 }
 
@@ -307,6 +308,7 @@ func (s AuthHTTPStore) ModifyWorkspace(workspaceID string, options *ModifyWorksp
 	// fmt.Printf("resource class %s\n", result.WorkspaceClassID)
 	// fmt.Printf("instance %s\n", result.InstanceType)
 	// fmt.Printf("workspace group %s\n", result.WorkspaceGroupID)
+	s.invalidateWorkspaceCache()
 	return &result, nil
 }
 
@@ -323,6 +325,7 @@ func (s AuthHTTPStore) DeleteWorkspace(workspaceID string) (*entity.Workspace, e
 	if res.IsError() {
 		return nil, NewHTTPResponseError(res)
 	}
+	s.invalidateWorkspaceCache()
 	return &result, nil
 }
 
@@ -365,6 +368,7 @@ func (s AuthHTTPStore) StopWorkspace(workspaceID string) (*entity.Workspace, err
 	if res.IsError() {
 		return nil, NewHTTPResponseError(res)
 	}
+	s.invalidateWorkspaceCache()
 	return &result, nil
 }
 
@@ -382,6 +386,7 @@ func (s AuthHTTPStore) AutoStopWorkspace(workspaceID string) (*entity.Workspace,
 	if res.IsError() {
 		return nil, NewHTTPResponseError(res)
 	}
+	s.invalidateWorkspaceCache()
 	return &result, nil
 }
 
@@ -403,6 +408,7 @@ func (s AuthHTTPStore) StartWorkspace(workspaceID string) (*entity.Workspace, er
 	if res.IsError() {
 		return nil, NewHTTPResponseError(res)
 	}
+	s.invalidateWorkspaceCache()
 	return &result, nil
 }
 
@@ -424,6 +430,7 @@ func (s AuthHTTPStore) ResetWorkspace(workspaceID string) (*entity.Workspace, er
 	if res.IsError() {
 		return nil, NewHTTPResponseError(res)
 	}
+	s.invalidateWorkspaceCache()
 	return &result, nil
 }
 
@@ -0,0 +1,57 @@
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// TaskName is the name of the daemon in brev tasks run
+const TaskName = "execsupervisord"
+
+const daemonStartTimeout = 10 * time.Second
+
+// EnsureDaemon starts brev tasks run execsupervisord in the background unless
+// it already answers on the control socket. Setup calls it as root
+func EnsureDaemon() (Client, error) {
+	client := NewClient(ControlSocketPath)
+	if _, err := client.Status(); err == nil {
+		return client, nil
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		return client, breverrors.WrapAndTrace(err)
+	}
+	out, err := os.OpenFile(LogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644) //nolint:gosec // read by the user
+	if err != nil {
+		return client, breverrors.WrapAndTrace(err)
+	}
+	defer out.Close() //nolint:errcheck // the daemon has its own copy
+
+	cmd := exec.Command(bin, "tasks", "run", TaskName) //nolint:gosec // this binary
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// its own session, so that it outlives setup
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	err = cmd.Start()
+	if err != nil {
+		return client, breverrors.WrapAndTrace(err)
+	}
+	_ = cmd.Process.Release()
+
+	deadline := time.Now().Add(daemonStartTimeout)
+	for {
+		_, err = client.Status()
+		if err == nil {
+			return client, nil
+		}
+		if time.Now().After(deadline) {
+			return client, breverrors.WrapAndTrace(fmt.Errorf("supervisor did not start, see %s: %w", LogPath, err))
+		}
+		time.Sleep(waitInterval)
+	}
+}
@@ -0,0 +1,110 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const (
+	defaultProbePeriod           = 5 * time.Second
+	defaultProbeTimeout          = 2 * time.Second
+	defaultProbeFailureThreshold = 3
+	// DefaultReadyTimeout is how long setup waits for a service to be ready
+	DefaultReadyTimeout = 5 * time.Minute
+)
+
+func probePeriod(p entity.ExecProbe) time.Duration {
+	if p.PeriodSeconds > 0 {
+		return time.Duration(p.PeriodSeconds) * time.Second
+	}
+	return defaultProbePeriod
+}
+
+func probeTimeout(p entity.ExecProbe) time.Duration {
+	if p.TimeoutSeconds > 0 {
+		return time.Duration(p.TimeoutSeconds) * time.Second
+	}
+	return defaultProbeTimeout
+}
+
+func probeFailureThreshold(p entity.ExecProbe) int {
+	if p.FailureThreshold > 0 {
+		return p.FailureThreshold
+	}
+	return defaultProbeFailureThreshold
+}
+
+// ReadyTimeout is how long a service may take to first be ready
+func ReadyTimeout(p *entity.ExecProbe) time.Duration {
+	if p != nil && p.ReadyTimeoutSeconds > 0 {
+		return time.Duration(p.ReadyTimeoutSeconds) * time.Second
+	}
+	return DefaultReadyTimeout
+}
+
+// ValidateProbe checks that exactly one kind of probe is set
+func ValidateProbe(p entity.ExecProbe) error {
+	set := 0
+	for _, v := range []string{p.HTTPGet, p.TCPSocket, p.Exec} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return breverrors.NewValidationError("a readiness probe needs exactly one of httpGet, tcpSocket or exec")
+	}
+	return nil
+}
+
+// runProbe is nil when the service is ready. exec probes run like the
+// service, as its user in its dir
+func runProbe(p entity.ExecProbe, spec ServiceSpec) error {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(p))
+	defer cancel()
+	switch {
+	case p.HTTPGet != "":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.HTTPGet, nil)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("GET %s: %w", p.HTTPGet, err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("GET %s: %s", p.HTTPGet, res.Status)
+		}
+		return nil
+	case p.TCPSocket != "":
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", p.TCPSocket)
+		if err != nil {
+			return fmt.Errorf("connect %s: %w", p.TCPSocket, err)
+		}
+		_ = conn.Close()
+		return nil
+	case p.Exec != "":
+		cmd := exec.CommandContext(ctx, "bash", "-c", p.Exec) //nolint:gosec // defined by the workspace's owner
+		cmd.Dir = spec.Dir
+		cmd.Env = append(os.Environ(), spec.Env...)
+		if spec.UID != nil && spec.GID != nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{Uid: *spec.UID, Gid: *spec.GID}}
+		}
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s: %w: %s", p.Exec, err, out)
+		}
+		return nil
+	}
+	return breverrors.WrapAndTrace(ValidateProbe(p))
+}
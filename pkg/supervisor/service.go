@@ -0,0 +1,260 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type service struct {
+	spec        ServiceSpec
+	backoffBase time.Duration
+	now         func() time.Time
+
+	mu     sync.Mutex
+	status ServiceStatus
+	cmd    *exec.Cmd
+	// exited is closed when the current process has been waited for
+	exited    chan struct{}
+	startedAt time.Time
+	// attempts are restarts since the service last ran for stableAfter
+	attempts      int
+	nextStart     time.Time
+	nextProbe     time.Time
+	probing       bool
+	probeFailures int
+	stopped       bool
+}
+
+func newService(spec ServiceSpec, backoffBase time.Duration, now func() time.Time) *service {
+	return &service{
+		spec:        spec,
+		backoffBase: backoffBase,
+		now:         now,
+		status:      ServiceStatus{Name: spec.Name},
+	}
+}
+
+func (s *service) getStatus() ServiceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *service) setState(state State, message string) {
+	s.status.State = state
+	s.status.Since = s.now()
+	s.status.Message = message
+}
+
+func (s *service) reconcile() {
+	s.mu.Lock()
+	switch s.status.State {
+	case "", StateBackoff:
+		if !s.now().Before(s.nextStart) {
+			s.start()
+		}
+		s.mu.Unlock()
+	case StateStarting, StateReady:
+		s.probeIfDue()
+	default:
+		s.mu.Unlock()
+	}
+}
+
+// start is called with the lock held
+func (s *service) start() {
+	if s.status.State != "" {
+		s.status.Restarts++
+		s.logf("--- restarting %s, restart %d ---\n", s.spec.Name, s.status.Restarts)
+	}
+	cmd, out, err := s.makeCmd()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		if out != nil {
+			_ = out.Close()
+		}
+		s.onExit(-1, fmt.Sprintf("could not start: %v", err))
+		return
+	}
+	s.cmd = cmd
+	s.exited = make(chan struct{})
+	s.startedAt = s.now()
+	s.status.PID = cmd.Process.Pid
+	s.status.ExitCode = nil
+	s.probeFailures = 0
+	s.nextProbe = s.startedAt
+	if s.spec.Probe == nil {
+		s.setState(StateReady, "")
+	} else {
+		s.setState(StateStarting, "")
+	}
+	go s.wait(cmd, out, s.exited)
+}
+
+func (s *service) makeCmd() (*exec.Cmd, io.WriteCloser, error) {
+	cmd := exec.Command(s.spec.Command[0], s.spec.Command[1:]...) //nolint:gosec // services are defined by the workspace's owner
+	cmd.Dir = s.spec.Dir
+	cmd.Env = append(os.Environ(), s.spec.Env...)
+	// a process group so that stop gets the children of shell scripts too
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if s.spec.UID != nil && s.spec.GID != nil {
+		cmd.SysProcAttr.Credential = &syscall.Credential{Uid: *s.spec.UID, Gid: *s.spec.GID}
+	}
+	if s.spec.LogPath == "" {
+		return cmd, nil, nil
+	}
+	out, err := os.OpenFile(s.spec.LogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644) //nolint:gosec // read by the user
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck // shown in the status
+	}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd, out, nil
+}
+
+func (s *service) logf(format string, a ...interface{}) {
+	if s.spec.LogPath == "" {
+		return
+	}
+	f, err := os.OpenFile(s.spec.LogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644) //nolint:gosec // read by the user
+	if err != nil {
+		return
+	}
+	defer f.Close() //nolint:errcheck // best effort
+	_, _ = fmt.Fprintf(f, format, a...)
+}
+
+func (s *service) wait(cmd *exec.Cmd, out io.Closer, exited chan struct{}) {
+	err := cmd.Wait()
+	if out != nil {
+		_ = out.Close()
+	}
+	code := 0
+	message := ""
+	if err != nil {
+		code = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+		message = err.Error()
+	}
+	s.mu.Lock()
+	if s.cmd == cmd {
+		s.cmd = nil
+		s.onExit(code, message)
+	}
+	s.mu.Unlock()
+	close(exited)
+}
+
+// onExit decides what happens next by the restart policy, it is called with
+// the lock held
+func (s *service) onExit(code int, message string) {
+	s.status.PID = 0
+	s.status.ExitCode = &code
+	if s.stopped {
+		s.setState(StateStopped, "")
+		return
+	}
+	if !s.startedAt.IsZero() && s.now().Sub(s.startedAt) >= stableAfter {
+		s.attempts = 0
+	}
+	restart := s.spec.Restart == RestartAlways || (s.spec.Restart == RestartOnFailure && code != 0)
+	switch {
+	case restart:
+		delay := s.backoff()
+		s.attempts++
+		s.nextStart = s.now().Add(delay)
+		s.setState(StateBackoff, fmt.Sprintf("exited %d, restarting in %s", code, delay))
+	case code == 0:
+		s.setState(StateExited, "")
+	default:
+		s.setState(StateFailed, message)
+	}
+	log.Printf("%s exited %d, now %s", s.spec.Name, code, s.status.State)
+}
+
+func (s *service) backoff() time.Duration {
+	delay := s.backoffBase
+	for i := 0; i < s.attempts && delay < backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > backoffMax {
+		delay = backoffMax
+	}
+	return delay
+}
+
+// probeIfDue is called with the lock held and releases it, so that statuses
+// can be read while a probe waits
+func (s *service) probeIfDue() {
+	probe := s.spec.Probe
+	if probe == nil || s.probing || s.now().Before(s.nextProbe) {
+		s.mu.Unlock()
+		return
+	}
+	s.probing = true
+	cmd := s.cmd
+	s.mu.Unlock()
+
+	err := runProbe(*probe, s.spec)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.probing = false
+	s.nextProbe = s.now().Add(probePeriod(*probe))
+	if s.cmd != cmd || cmd == nil { // exited while probing
+		return
+	}
+	if err == nil {
+		s.probeFailures = 0
+		if s.status.State != StateReady {
+			s.setState(StateReady, "")
+		}
+		return
+	}
+	s.probeFailures++
+	if s.status.State == StateStarting {
+		s.status.Message = err.Error()
+		return
+	}
+	if s.probeFailures >= probeFailureThreshold(*probe) {
+		s.setState(StateStarting, err.Error())
+	}
+}
+
+// stop kills the process group and waits for it, services are not restarted
+// after stop
+func (s *service) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	cmd, exited := s.cmd, s.exited
+	if cmd == nil {
+		if !s.status.State.IsDone() {
+			s.setState(StateStopped, "")
+		}
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	select {
+	case <-exited:
+	case <-time.After(stopTimeout):
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-exited
+	}
+}
+
+const stopTimeout = 10 * time.Second
@@ -0,0 +1,189 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// Serve answers GET /status on both sockets and PUT /services on the control
+// socket. The returned func closes them
+func Serve(s *Supervisor, statusSocketPath string, controlSocketPath string) (func(), error) {
+	status := http.NewServeMux()
+	status.HandleFunc("/status", s.handleStatus)
+	control := http.NewServeMux()
+	control.HandleFunc("/status", s.handleStatus)
+	control.HandleFunc("/services", s.handleAdd)
+
+	closeStatus, err := serveSocket(statusSocketPath, 0o666, status)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	closeControl, err := serveSocket(controlSocketPath, 0o600, control)
+	if err != nil {
+		closeStatus()
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return func() {
+		closeStatus()
+		closeControl()
+	}, nil
+}
+
+func serveSocket(path string, perm os.FileMode, handler http.Handler) (func(), error) {
+	_ = os.Remove(path) // left over from a daemon that was killed
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = os.Chmod(path, perm)
+	if err != nil {
+		_ = listener.Close()
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Print(err)
+		}
+	}()
+	return func() {
+		_ = server.Close()
+		_ = os.Remove(path)
+	}, nil
+}
+
+func (s *Supervisor) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.Statuses())
+}
+
+func (s *Supervisor) handleAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	spec := ServiceSpec{}
+	err := json.NewDecoder(r.Body).Decode(&spec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = s.Add(spec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("supervising %s", spec.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Client talks to the daemon over one of its sockets
+type Client struct {
+	http *http.Client
+}
+
+func NewClient(socketPath string) Client {
+	return Client{http: &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}}
+}
+
+// the host is ignored, requests go to the socket
+const socketURL = "http://supervisor"
+
+func (c Client) Status() ([]ServiceStatus, error) {
+	res, err := c.http.Get(socketURL + "/status")
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	defer res.Body.Close() //nolint:errcheck // read only
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("supervisor status: %s", res.Status)
+	}
+	statuses := []ServiceStatus{}
+	err = json.NewDecoder(res.Body).Decode(&statuses)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return statuses, nil
+}
+
+func (c Client) ServiceStatus(name string) (*ServiceStatus, error) {
+	statuses, err := c.Status()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	for _, s := range statuses {
+		if s.Name == name {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not supervised", name)
+}
+
+func (c Client) Add(spec ServiceSpec) error {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	req, err := http.NewRequest(http.MethodPut, socketURL+"/services", bytes.NewReader(b))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer res.Body.Close() //nolint:errcheck // read only
+	if res.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("could not supervise %s: %s", spec.Name, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// WaitReady polls until the service is ready, or has exited 0 for services
+// that aren't restarted
+func (c Client) WaitReady(name string, timeout time.Duration) (*ServiceStatus, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := c.ServiceStatus(name)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		switch status.State {
+		case StateReady, StateExited:
+			return status, nil
+		case StateFailed, StateStopped:
+			return status, fmt.Errorf("%s %s: %s", name, status.State, status.Message)
+		}
+		if time.Now().After(deadline) {
+			return status, fmt.Errorf("%s not ready after %s, %s: %s", name, timeout, status.State, status.Message)
+		}
+		time.Sleep(waitInterval)
+	}
+}
+
+const waitInterval = 500 * time.Millisecond
@@ -0,0 +1,237 @@
+// Package supervisor keeps start stage execs running after setup, ex: dev
+// servers. Services are restarted by their policy, probed for readiness and
+// their status is served on a local socket for brev status
+package supervisor
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+)
+
+const (
+	// StatusSocketPath can be read by anyone, it only serves statuses
+	StatusSocketPath = "/var/run/brev-supervisor.sock"
+	// ControlSocketPath is only for root, it adds services which run as any user
+	ControlSocketPath = "/var/run/brev-supervisor-control.sock"
+	// LogPath is where the daemon started by setup logs
+	LogPath = "/var/log/brev-supervisor.log"
+)
+
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
+)
+
+func ParseRestartPolicy(policy string) (RestartPolicy, error) {
+	switch RestartPolicy(policy) {
+	case "":
+		return RestartOnFailure, nil
+	case RestartNever, RestartOnFailure, RestartAlways:
+		return RestartPolicy(policy), nil
+	}
+	return "", breverrors.NewValidationError("restart policy must be one of never|on-failure|always, got " + policy)
+}
+
+type State string
+
+const (
+	// StateStarting is for running services that aren't ready yet
+	StateStarting State = "starting"
+	StateReady    State = "ready"
+	// StateBackoff is for services waiting to be restarted
+	StateBackoff State = "backoff"
+	// StateExited is for services that exited 0 and are not restarted
+	StateExited State = "exited"
+	// StateFailed is for services that exited non zero and are not restarted
+	StateFailed  State = "failed"
+	StateStopped State = "stopped"
+)
+
+// IsDone is true for states that won't change without a new service
+func (s State) IsDone() bool {
+	return s == StateExited || s == StateFailed || s == StateStopped
+}
+
+type ServiceSpec struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+	Dir     string   `json:"dir,omitempty"`
+	// Env is added to the environment of the daemon
+	Env []string `json:"env,omitempty"`
+	// UID and GID are who the service runs as, the daemon's user if nil
+	UID *uint32 `json:"uid,omitempty"`
+	GID *uint32 `json:"gid,omitempty"`
+	// LogPath gets the output of every run, appended
+	LogPath string            `json:"logPath,omitempty"`
+	Restart RestartPolicy     `json:"restart"`
+	Probe   *entity.ExecProbe `json:"probe,omitempty"`
+}
+
+type ServiceStatus struct {
+	Name     string    `json:"name"`
+	State    State     `json:"state"`
+	PID      int       `json:"pid,omitempty"`
+	Restarts int       `json:"restarts"`
+	ExitCode *int      `json:"exitCode,omitempty"`
+	Since    time.Time `json:"since"`
+	// Message is why the service isn't ready, ex: the last probe error
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	backoffBase = time.Second
+	backoffMax  = time.Minute
+	// stableAfter resets the backoff of services that ran at least this long
+	stableAfter = time.Minute
+)
+
+type Supervisor struct {
+	mu       sync.Mutex
+	services map[string]*service
+	// backoffBase is the first restart delay, doubled on every restart
+	backoffBase time.Duration
+	now         func() time.Time
+}
+
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		services:    map[string]*service{},
+		backoffBase: backoffBase,
+		now:         time.Now,
+	}
+}
+
+// Add starts supervising a service, replacing the one of the same name, ex:
+// when setup runs again
+func (s *Supervisor) Add(spec ServiceSpec) error {
+	if spec.Name == "" || len(spec.Command) == 0 {
+		return breverrors.NewValidationError("a service needs a name and a command")
+	}
+	restart, err := ParseRestartPolicy(string(spec.Restart))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	spec.Restart = restart
+
+	s.mu.Lock()
+	old := s.services[spec.Name]
+	s.services[spec.Name] = newService(spec, s.backoffBase, s.now)
+	s.mu.Unlock()
+	if old != nil {
+		old.stop()
+	}
+	return nil
+}
+
+func (s *Supervisor) list() []*service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	services := make([]*service, 0, len(s.services))
+	for _, svc := range s.services {
+		services = append(services, svc)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].spec.Name < services[j].spec.Name })
+	return services
+}
+
+func (s *Supervisor) Statuses() []ServiceStatus {
+	statuses := []ServiceStatus{}
+	for _, svc := range s.list() {
+		statuses = append(statuses, svc.getStatus())
+	}
+	return statuses
+}
+
+// Reconcile starts, restarts and probes services that are due, it is run
+// every second by the task runner
+func (s *Supervisor) Reconcile() error {
+	var wg sync.WaitGroup
+	for _, svc := range s.list() {
+		wg.Add(1)
+		go func(svc *service) {
+			defer wg.Done()
+			svc.reconcile()
+		}(svc)
+	}
+	wg.Wait()
+	return nil
+}
+
+// StopAll stops every service, their process groups get SIGTERM
+func (s *Supervisor) StopAll() {
+	for _, svc := range s.list() {
+		svc.stop()
+	}
+}
+
+// SupervisorTask is the daemon, brev tasks run execsupervisord. Setup starts
+// it when a dev environment has supervised execs
+type SupervisorTask struct {
+	Supervisor        *Supervisor
+	StatusSocketPath  string
+	ControlSocketPath string
+	sockets           *sockets
+}
+
+// sockets are served from the first run until the task runner stops
+type sockets struct {
+	mu    sync.Mutex
+	close func()
+}
+
+var _ tasks.StoppingTask = SupervisorTask{}
+
+func NewSupervisorTask() SupervisorTask {
+	return SupervisorTask{
+		Supervisor:        NewSupervisor(),
+		StatusSocketPath:  StatusSocketPath,
+		ControlSocketPath: ControlSocketPath,
+		sockets:           &sockets{},
+	}
+}
+
+func (t SupervisorTask) GetTaskSpec() tasks.TaskSpec {
+	return tasks.TaskSpec{RunCronImmediately: true, Cron: "@every 1s"}
+}
+
+// Configure is a no-op, the daemon is started by setup when it is needed
+func (t SupervisorTask) Configure() error {
+	return nil
+}
+
+// Run serves the sockets if they aren't yet, then starts, restarts and
+// probes the services that are due
+func (t SupervisorTask) Run() error {
+	t.sockets.mu.Lock()
+	if t.sockets.close == nil {
+		closeSockets, err := Serve(t.Supervisor, t.StatusSocketPath, t.ControlSocketPath)
+		if err != nil {
+			t.sockets.mu.Unlock()
+			return breverrors.WrapAndTrace(err)
+		}
+		t.sockets.close = closeSockets
+	}
+	t.sockets.mu.Unlock()
+	return t.Supervisor.Reconcile()
+}
+
+// Stop closes the sockets and stops every service
+func (t SupervisorTask) Stop() {
+	t.sockets.mu.Lock()
+	if t.sockets.close != nil {
+		t.sockets.close()
+		t.sockets.close = nil
+	}
+	t.sockets.mu.Unlock()
+	t.Supervisor.StopAll()
+	log.Print("stopped all services")
+}
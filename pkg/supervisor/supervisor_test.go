@@ -0,0 +1,213 @@
+package supervisor
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSupervisor() *Supervisor {
+	s := NewSupervisor()
+	s.backoffBase = 10 * time.Millisecond
+	return s
+}
+
+// reconcileUntil runs the supervisor until cond holds, like the task runner
+// would but faster
+func reconcileUntil(t *testing.T, s *Supervisor, name string, cond func(ServiceStatus) bool) ServiceStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_ = s.Reconcile()
+		for _, status := range s.Statuses() {
+			if status.Name == name && cond(status) {
+				return status
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out, statuses: %+v", s.Statuses())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRestartPolicies(t *testing.T) {
+	cases := []struct {
+		name     string
+		restart  RestartPolicy
+		exitCode string
+		want     State
+	}{
+		{"never exit 0", RestartNever, "0", StateExited},
+		{"never exit 1", RestartNever, "1", StateFailed},
+		{"on-failure exit 0", RestartOnFailure, "0", StateExited},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newTestSupervisor()
+			err := s.Add(ServiceSpec{Name: "svc", Command: []string{"sh", "-c", "exit " + c.exitCode}, Restart: c.restart})
+			if !assert.Nil(t, err) {
+				return
+			}
+			status := reconcileUntil(t, s, "svc", func(s ServiceStatus) bool { return s.State.IsDone() })
+			assert.Equal(t, c.want, status.State)
+			assert.Equal(t, 0, status.Restarts)
+		})
+	}
+}
+
+func TestRestartOnFailureWithBackoff(t *testing.T) {
+	s := newTestSupervisor()
+	logPath := filepath.Join(t.TempDir(), "svc.log")
+	err := s.Add(ServiceSpec{Name: "svc", Command: []string{"sh", "-c", "echo run; exit 3"}, LogPath: logPath})
+	if !assert.Nil(t, err) {
+		return
+	}
+	status := reconcileUntil(t, s, "svc", func(s ServiceStatus) bool { return s.Restarts >= 2 && s.State == StateBackoff })
+	assert.Equal(t, 3, *status.ExitCode)
+	assert.Contains(t, status.Message, "exited 3")
+}
+
+func TestRestartAlways(t *testing.T) {
+	s := newTestSupervisor()
+	err := s.Add(ServiceSpec{Name: "svc", Command: []string{"true"}, Restart: RestartAlways})
+	if !assert.Nil(t, err) {
+		return
+	}
+	reconcileUntil(t, s, "svc", func(s ServiceStatus) bool { return s.Restarts >= 1 })
+}
+
+func TestBackoff(t *testing.T) {
+	svc := newService(ServiceSpec{}, time.Second, time.Now)
+	delays := []time.Duration{}
+	for _, attempts := range []int{0, 1, 2, 10} {
+		svc.attempts = attempts
+		delays = append(delays, svc.backoff())
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, time.Minute}, delays)
+}
+
+func TestReadinessProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.Nil(t, err) {
+		return
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	s := newTestSupervisor()
+	err = s.Add(ServiceSpec{
+		Name:    "svc",
+		Command: []string{"sleep", "30"},
+		Probe:   &entity.ExecProbe{TCPSocket: addr, PeriodSeconds: 1},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer s.StopAll()
+	status := reconcileUntil(t, s, "svc", func(s ServiceStatus) bool { return s.Message != "" })
+	assert.Equal(t, StateStarting, status.State)
+
+	listener, err = net.Listen("tcp", addr)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer listener.Close() //nolint:errcheck // test
+	reconcileUntil(t, s, "svc", func(s ServiceStatus) bool { return s.State == StateReady })
+}
+
+func TestStop(t *testing.T) {
+	s := newTestSupervisor()
+	err := s.Add(ServiceSpec{Name: "svc", Command: []string{"sh", "-c", "sleep 30 & wait"}, Restart: RestartAlways})
+	if !assert.Nil(t, err) {
+		return
+	}
+	reconcileUntil(t, s, "svc", func(s ServiceStatus) bool { return s.State == StateReady })
+	s.StopAll()
+	status := reconcileUntil(t, s, "svc", func(s ServiceStatus) bool { return true })
+	assert.Equal(t, StateStopped, status.State)
+	assert.Equal(t, 0, status.PID)
+}
+
+func TestValidateProbe(t *testing.T) {
+	assert.Nil(t, ValidateProbe(entity.ExecProbe{HTTPGet: "http://localhost:3000"}))
+	assert.Error(t, ValidateProbe(entity.ExecProbe{}))
+	assert.Error(t, ValidateProbe(entity.ExecProbe{HTTPGet: "http://localhost:3000", TCPSocket: "localhost:3000"}))
+}
+
+func TestSockets(t *testing.T) {
+	dir := t.TempDir()
+	statusPath := filepath.Join(dir, "status.sock")
+	controlPath := filepath.Join(dir, "control.sock")
+	s := newTestSupervisor()
+	closeSockets, err := Serve(s, statusPath, controlPath)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer closeSockets()
+	go func() {
+		for i := 0; i < 100; i++ {
+			_ = s.Reconcile()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	spec := ServiceSpec{Name: "svc", Command: []string{"true"}}
+	err = NewClient(statusPath).Add(spec)
+	assert.Error(t, err, "services can only be added on the control socket")
+
+	control := NewClient(controlPath)
+	err = control.Add(spec)
+	if !assert.Nil(t, err) {
+		return
+	}
+	status, err := control.WaitReady("svc", 5*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, StateExited, status.State)
+
+	statuses, err := NewClient(statusPath).Status()
+	assert.Nil(t, err)
+	assert.Len(t, statuses, 1)
+
+	err = control.Add(ServiceSpec{Name: "bad", Command: []string{"true"}, Restart: "sometimes"})
+	assert.Error(t, err)
+}
+
+func TestSupervisorTaskStopsOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	task := NewSupervisorTask()
+	task.Supervisor.backoffBase = 10 * time.Millisecond
+	task.StatusSocketPath = filepath.Join(dir, "status.sock")
+	task.ControlSocketPath = filepath.Join(dir, "control.sock")
+	tr := tasks.NewTaskRunner([]tasks.Task{task})
+	done := make(chan error, 1)
+	go func() { done <- tr.Run() }()
+
+	control := NewClient(task.ControlSocketPath)
+	deadline := time.Now().Add(5 * time.Second)
+	for _, err := control.Status(); err != nil; _, err = control.Status() {
+		if time.Now().After(deadline) {
+			t.Fatalf("sockets not served: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, control.Add(ServiceSpec{Name: "svc", Command: []string{"sleep", "60"}, Restart: RestartAlways}))
+
+	tr.SendStop()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after one stop")
+	}
+	_, err := control.Status()
+	assert.Error(t, err, "the sockets are closed")
+	for _, status := range task.Supervisor.Statuses() {
+		assert.Equal(t, StateStopped, status.State)
+	}
+}
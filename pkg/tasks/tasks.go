@@ -87,6 +87,21 @@ type Task interface {
 	GetTaskSpec() TaskSpec
 }
 
+// EventTask is a Task that is also run every time Events fires, ex: when a
+// file it depends on changes, instead of waiting for its next cron run. Runs
+// may overlap with cron runs so Run must be safe to call concurrently
+type EventTask interface {
+	Task
+	Events() <-chan struct{}
+}
+
+// StoppingTask is a Task with something to clean up when the runner stops,
+// ex: sockets it serves. Stop is called once, after the running jobs are done
+type StoppingTask interface {
+	Task
+	Stop()
+}
+
 type TaskSpec struct {
 	Cron               string // can be "" if want to run once // https://pkg.go.dev/github.com/robfig/cron?utm_source=godoc#hdr-CRON_Expression_Format
 	RunCronImmediately bool   // only applied if cron not ""
@@ -116,6 +131,9 @@ func LogErr(f func() error) func() {
 func (tr TaskRunner) Run() error {
 	c := cron.New()
 	for _, t := range tr.Tasks {
+		if et, ok := t.(EventTask); ok {
+			runOnEvents(et)
+		}
 		spec := t.GetTaskSpec()
 		if spec.Cron != "" {
 			e, err := c.AddFunc(spec.Cron, LogErr(t.Run))
@@ -139,11 +157,28 @@ func (tr TaskRunner) Run() error {
 	c.Start()
 
 	tr.WaitTillSignal(c.Stop)
+	for _, t := range tr.Tasks {
+		if st, ok := t.(StoppingTask); ok {
+			st.Stop()
+		}
+	}
 	log.Print("stopped")
 
 	return nil
 }
 
+func runOnEvents(t EventTask) {
+	events := t.Events()
+	if events == nil {
+		return
+	}
+	go func() {
+		for range events {
+			LogErr(t.Run)()
+		}
+	}()
+}
+
 func (tr TaskRunner) WaitTillSignal(ctxfn func() context.Context) {
 	signal.Notify(tr.StopSignals, syscall.SIGQUIT)
 	signal.Notify(tr.StopSignals, syscall.SIGTERM)
@@ -76,3 +76,59 @@ func TestNotRunImmediateAndCron(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 1, dt.Ran)
 }
+
+type DummyEventTask struct {
+	DummyTask
+	events chan struct{}
+}
+
+func (d *DummyEventTask) Events() <-chan struct{} {
+	return d.events
+}
+
+func TestRunOnEvents(t *testing.T) {
+	dt := DummyEventTask{
+		DummyTask: DummyTask{TaskSpec: TaskSpec{
+			RunCronImmediately: false,
+			Cron:               "@every 1h",
+		}},
+		events: make(chan struct{}),
+	}
+	tr := NewTaskRunner([]Task{&dt})
+	go func() {
+		dt.events <- struct{}{}
+		dt.events <- struct{}{}
+		time.Sleep(time.Millisecond * 50)
+		tr.SendStop()
+	}()
+	err := tr.Run()
+	assert.Nil(t, err)
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	assert.Equal(t, 2, dt.Ran)
+}
+
+type DummyStoppingTask struct {
+	DummyTask
+	stopped int
+}
+
+func (d *DummyStoppingTask) Stop() {
+	d.stopped++
+}
+
+func TestStopAfterSignal(t *testing.T) {
+	dt := DummyStoppingTask{DummyTask: DummyTask{TaskSpec: TaskSpec{
+		RunCronImmediately: true,
+		Cron:               "@every 1h",
+	}}}
+	tr := NewTaskRunner([]Task{&dt})
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		tr.SendStop()
+	}()
+	err := tr.Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, dt.Ran)
+	assert.Equal(t, 1, dt.stopped)
+}
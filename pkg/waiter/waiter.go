@@ -0,0 +1,212 @@
+// Package waiter polls a workspace until it reaches a desired state
+package waiter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+type WaiterStore interface {
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+}
+
+type State string
+
+const (
+	Running State = entity.Running
+	Stopped State = entity.Stopped
+	Healthy State = entity.Healthy
+)
+
+var ValidStates = []State{Running, Stopped, Healthy}
+
+func ParseState(state string) (State, error) {
+	for _, s := range ValidStates {
+		if strings.EqualFold(state, string(s)) {
+			return s, nil
+		}
+	}
+	return "", breverrors.NewValidationError(fmt.Sprintf("invalid state %q, must be one of RUNNING|STOPPED|HEALTHY", state))
+}
+
+func (s State) IsReachedBy(w *entity.Workspace) bool {
+	switch s {
+	case Healthy:
+		return w.Status == entity.Running && w.HealthStatus == entity.Healthy
+	default:
+		return w.Status == string(s)
+	}
+}
+
+// isTerminal is true when the workspace can no longer reach any state without
+// someone acting on it. STOPPED is not terminal since a workspace that was
+// just started may still report it
+func isTerminal(w *entity.Workspace) bool {
+	return w.Status == entity.Failure || w.Status == entity.Deleting
+}
+
+const (
+	ExitCodeTimeout          = 2
+	ExitCodeWorkspaceFailure = 3
+)
+
+type TimeoutError struct {
+	WorkspaceName string
+	State         State
+	LastStatus    string
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("timed out waiting for %s to be %s, last status was %s", e.WorkspaceName, e.State, e.LastStatus)
+}
+
+func (e TimeoutError) ExitCode() int {
+	return ExitCodeTimeout
+}
+
+type WorkspaceFailureError struct {
+	WorkspaceName string
+	State         State
+	Status        string
+}
+
+func (e WorkspaceFailureError) Error() string {
+	return fmt.Sprintf("%s is %s and will not become %s", e.WorkspaceName, e.Status, e.State)
+}
+
+func (e WorkspaceFailureError) ExitCode() int {
+	return ExitCodeWorkspaceFailure
+}
+
+var (
+	_ breverrors.ExitCodeError = TimeoutError{}
+	_ breverrors.ExitCodeError = WorkspaceFailureError{}
+)
+
+type Waiter struct {
+	store    WaiterStore
+	terminal *terminal.Terminal
+
+	spinner       *spinner.Spinner
+	ownsSpinner   bool
+	waitMsg       string
+	canSafelyExit bool
+
+	pollImmediately bool
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	multiplier      float64
+}
+
+func NewWaiter(store WaiterStore, t *terminal.Terminal) *Waiter {
+	return &Waiter{
+		store:           store,
+		terminal:        t,
+		ownsSpinner:     true,
+		waitMsg:         " hang tight 🤙",
+		initialInterval: 5 * time.Second,
+		maxInterval:     20 * time.Second,
+		multiplier:      1.5,
+	}
+}
+
+// WithSpinner reports progress on a spinner owned by the caller, it is
+// started if needed and left running when waiting is done
+func (w *Waiter) WithSpinner(s *spinner.Spinner) *Waiter {
+	w.spinner = s
+	w.ownsSpinner = false
+	return w
+}
+
+func (w *Waiter) WithWaitMessage(msg string) *Waiter {
+	w.waitMsg = msg
+	return w
+}
+
+func (w *Waiter) WithSafeExitMessage() *Waiter {
+	w.canSafelyExit = true
+	return w
+}
+
+// WithPollImmediately checks the workspace before the first backoff interval,
+// callers that just requested a transition should not use this since the old
+// status may still be reported
+func (w *Waiter) WithPollImmediately() *Waiter {
+	w.pollImmediately = true
+	return w
+}
+
+func (w *Waiter) WithBackoff(initial time.Duration, max time.Duration) *Waiter {
+	w.initialInterval = initial
+	w.maxInterval = max
+	return w
+}
+
+// WaitFor blocks until the workspace reaches state, ends up somewhere it can
+// not reach state from, or ctx is done
+func (w *Waiter) WaitFor(ctx context.Context, workspaceID string, state State) (*entity.Workspace, error) {
+	s := w.spinner
+	if s == nil {
+		s = w.terminal.NewSpinner()
+	}
+	if w.canSafelyExit {
+		w.terminal.Vprintf("You can safely ctrl+c to exit\n")
+	}
+	s.Suffix = w.waitMsg
+	s.Start()
+	if w.ownsSpinner {
+		defer s.Stop()
+	}
+
+	interval := w.initialInterval
+	wait := interval
+	if w.pollImmediately {
+		wait = 0
+	}
+	lastStatus := "UNKNOWN"
+	name := workspaceID
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, TimeoutError{WorkspaceName: name, State: state, LastStatus: lastStatus}
+		case <-time.After(wait):
+		}
+
+		ws, err := w.store.GetWorkspace(workspaceID)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		name = ws.Name
+		lastStatus = ws.Status
+		if state == Healthy && ws.Status == entity.Running {
+			lastStatus = ws.HealthStatus
+		}
+		if w.ownsSpinner {
+			s.Suffix = "  dev environment is " + strings.ToLower(lastStatus)
+		}
+
+		if state.IsReachedBy(ws) {
+			if w.ownsSpinner {
+				s.Suffix = "Dev environment is ready!"
+			}
+			return ws, nil
+		}
+		if isTerminal(ws) {
+			return nil, WorkspaceFailureError{WorkspaceName: ws.Name, State: state, Status: ws.Status}
+		}
+
+		wait = interval
+		interval = time.Duration(float64(interval) * w.multiplier)
+		if interval > w.maxInterval {
+			interval = w.maxInterval
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package waiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	statuses []entity.Workspace
+	calls    int
+}
+
+func (f *fakeStore) GetWorkspace(_ string) (*entity.Workspace, error) {
+	i := f.calls
+	if i >= len(f.statuses) {
+		i = len(f.statuses) - 1
+	}
+	f.calls++
+	ws := f.statuses[i]
+	return &ws, nil
+}
+
+func newTestWaiter(store WaiterStore) *Waiter {
+	return NewWaiter(store, terminal.New()).WithBackoff(time.Millisecond, 2*time.Millisecond).WithPollImmediately()
+}
+
+func TestWaitForRunning(t *testing.T) {
+	store := &fakeStore{statuses: []entity.Workspace{
+		{Name: "ws", Status: entity.Stopped},
+		{Name: "ws", Status: entity.Starting},
+		{Name: "ws", Status: entity.Running},
+	}}
+	ws, err := newTestWaiter(store).WaitFor(context.Background(), "id", Running)
+	assert.Nil(t, err)
+	assert.Equal(t, entity.Running, ws.Status)
+	assert.Equal(t, 3, store.calls)
+}
+
+func TestWaitForHealthy(t *testing.T) {
+	store := &fakeStore{statuses: []entity.Workspace{
+		{Name: "ws", Status: entity.Running, HealthStatus: entity.Unavailable},
+		{Name: "ws", Status: entity.Running, HealthStatus: entity.Healthy},
+	}}
+	_, err := newTestWaiter(store).WaitFor(context.Background(), "id", Healthy)
+	assert.Nil(t, err)
+}
+
+func TestWaitForFailure(t *testing.T) {
+	store := &fakeStore{statuses: []entity.Workspace{
+		{Name: "ws", Status: entity.Deploying},
+		{Name: "ws", Status: entity.Failure},
+	}}
+	_, err := newTestWaiter(store).WaitFor(context.Background(), "id", Running)
+	assert.ErrorAs(t, err, &WorkspaceFailureError{})
+	assert.Equal(t, ExitCodeWorkspaceFailure, err.(WorkspaceFailureError).ExitCode())
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	store := &fakeStore{statuses: []entity.Workspace{
+		{Name: "ws", Status: entity.Deploying},
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := newTestWaiter(store).WaitFor(ctx, "id", Running)
+	assert.ErrorAs(t, err, &TimeoutError{})
+	assert.Equal(t, ExitCodeTimeout, err.(TimeoutError).ExitCode())
+}
+
+func TestParseState(t *testing.T) {
+	s, err := ParseState("healthy")
+	assert.Nil(t, err)
+	assert.Equal(t, Healthy, s)
+
+	_, err = ParseState("DEPLOYING")
+	assert.Error(t, err)
+}
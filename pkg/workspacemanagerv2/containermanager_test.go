@@ -14,14 +14,22 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func GetAllContainerManagers() []ContainerManager {
-	return []ContainerManager{DockerContainerManager{}}
+func GetRuntimeContainerManagers(t *testing.T) []ContainerManager {
+	cm, err := NewContainerManager(context.Background(), "")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return []ContainerManager{cm}
+}
+
+func GetAllContainerManagers(t *testing.T) []ContainerManager {
+	return append(GetRuntimeContainerManagers(t), NewFakeContainerManager())
 }
 
 // TIP: use docker inspect to get information about container like volume mounted, command, ports etc.
 
 func Test_GetContainerDNE(t *testing.T) {
-	dcms := GetAllContainerManagers()
+	dcms := GetAllContainerManagers(t)
 	for _, cm := range dcms {
 		res, err := cm.GetContainer(context.TODO(), "dne")
 		assert.Error(t, err)
@@ -30,7 +38,7 @@ func Test_GetContainerDNE(t *testing.T) {
 }
 
 func Test_CreateThenGetContainer(t *testing.T) {
-	dcms := GetAllContainerManagers()
+	dcms := GetAllContainerManagers(t)
 	for _, cm := range dcms {
 		ctx := context.Background()
 		containerID, err := cm.CreateContainer(ctx, CreateContainerOptions{}, "hello-world")
@@ -44,7 +52,7 @@ func Test_CreateThenGetContainer(t *testing.T) {
 }
 
 func Test_CreateThenStartThenStopContainer(t *testing.T) {
-	dcms := GetAllContainerManagers()
+	dcms := GetAllContainerManagers(t)
 	for _, cm := range dcms {
 		ctx := context.Background()
 		containerID, err := cm.CreateContainer(ctx, CreateContainerOptions{}, "nginx")
@@ -79,7 +87,7 @@ func Test_CreateThenStartThenStopContainer(t *testing.T) {
 }
 
 func Test_PortMapping(t *testing.T) {
-	dcms := GetAllContainerManagers()
+	dcms := GetRuntimeContainerManagers(t)
 	for _, cm := range dcms {
 		ctx := context.Background()
 		containerID, err := cm.CreateContainer(ctx, CreateContainerOptions{
@@ -104,7 +112,7 @@ func Test_PortMapping(t *testing.T) {
 }
 
 func Test_Volumes(t *testing.T) {
-	dcms := GetAllContainerManagers()
+	dcms := GetRuntimeContainerManagers(t)
 	for _, cm := range dcms {
 		ctx := context.Background()
 		localPath := fmt.Sprintf("/tmp/brevcli-test-volume/%s", uuid.New().String())
@@ -138,3 +146,54 @@ func Test_Volumes(t *testing.T) {
 		assert.Len(t, info, 2)
 	}
 }
+
+func Test_GetContainerDNEIsNotFound(t *testing.T) {
+	cm := NewFakeContainerManager()
+	_, err := cm.GetContainer(context.Background(), "dne")
+	assert.True(t, IsContainerNotFound(err))
+}
+
+func Test_FakeDeleteRunningContainer(t *testing.T) {
+	ctx := context.Background()
+	cm := NewFakeContainerManager()
+	containerID, err := cm.CreateContainer(ctx, CreateContainerOptions{Name: "ws"}, "nginx")
+	if !assert.Nil(t, err) {
+		return
+	}
+	_, err = cm.CreateContainer(ctx, CreateContainerOptions{Name: "ws"}, "nginx")
+	assert.Error(t, err)
+
+	err = cm.StartContainer(ctx, "ws")
+	assert.Nil(t, err)
+	err = cm.DeleteContainer(ctx, containerID)
+	assert.Error(t, err)
+
+	err = cm.StopContainer(ctx, "ws")
+	assert.Nil(t, err)
+	err = cm.DeleteContainer(ctx, "ws")
+	assert.Nil(t, err)
+	assert.Empty(t, cm.Containers)
+}
+
+func Test_NoRuntime(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "unix:///dne/docker.sock")
+	_, err := connect(context.Background(), Runtime{Name: RuntimeDocker, Host: "unix:///dne/docker.sock"})
+	assert.EqualError(t, err, "socket does not exist")
+
+	_, err = NewContainerManager(context.Background(), "rkt")
+	assert.Error(t, err)
+
+	e := &NoRuntimeError{Name: RuntimePodman, Tried: []string{"  podman at unix:///run/podman/podman.sock: socket does not exist"}}
+	assert.Contains(t, e.Error(), "looked for podman at:\n  podman at unix:///run/podman/podman.sock: socket does not exist")
+}
+
+func Test_RuntimeCandidates(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+	t.Setenv("CONTAINER_HOST", "")
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	assert.Equal(t, []Runtime{
+		{Name: RuntimePodman, Host: "unix:///run/user/1000/podman/podman.sock"},
+		{Name: RuntimePodman, Host: "unix:///run/podman/podman.sock"},
+	}, RuntimeCandidates(RuntimePodman))
+	assert.Equal(t, Runtime{Name: RuntimeDocker, Host: "tcp://127.0.0.1:2375"}, RuntimeCandidates("")[0])
+}
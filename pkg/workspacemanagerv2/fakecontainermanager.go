@@ -0,0 +1,116 @@
+package workspacemanagerv2
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeContainerManager keeps containers in memory, for tests that don't have
+// a runtime. Containers are found by id or name, like with docker
+type FakeContainerManager struct {
+	mu         sync.Mutex
+	nextID     int
+	Containers map[string]*FakeContainer
+	Volumes    map[string]bool
+}
+
+type FakeContainer struct {
+	ID      string
+	Image   string
+	Options CreateContainerOptions
+	Status  ContainerStatus
+}
+
+var _ ContainerManager = &FakeContainerManager{}
+
+func NewFakeContainerManager() *FakeContainerManager {
+	return &FakeContainerManager{
+		Containers: map[string]*FakeContainer{},
+		Volumes:    map[string]bool{},
+	}
+}
+
+func (f *FakeContainerManager) find(containerIdentifier string) (*FakeContainer, error) {
+	if c, ok := f.Containers[containerIdentifier]; ok {
+		return c, nil
+	}
+	for _, c := range f.Containers {
+		if c.Options.Name != "" && c.Options.Name == containerIdentifier {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrContainerNotFound, containerIdentifier)
+}
+
+func (f *FakeContainerManager) GetContainer(_ context.Context, containerIdentifier string) (*Container, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.find(containerIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	return &Container{ID: c.ID, Status: c.Status}, nil
+}
+
+func (f *FakeContainerManager) CreateContainer(_ context.Context, options CreateContainerOptions, image string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.find(options.Name); options.Name != "" && err == nil {
+		return "", fmt.Errorf("container name %s is already in use", options.Name)
+	}
+	f.nextID++
+	id := fmt.Sprintf("%064x", f.nextID)
+	f.Containers[id] = &FakeContainer{ID: id, Image: image, Options: options, Status: ContainerStopped}
+	for _, v := range options.Volumes {
+		f.Volumes[v.GetIdentifier()] = true
+	}
+	return id, nil
+}
+
+func (f *FakeContainerManager) StartContainer(_ context.Context, containerIdentifier string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.find(containerIdentifier)
+	if err != nil {
+		return err
+	}
+	c.Status = ContainerRunning
+	return nil
+}
+
+func (f *FakeContainerManager) StopContainer(_ context.Context, containerIdentifier string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.find(containerIdentifier)
+	if err != nil {
+		return err
+	}
+	c.Status = ContainerStopped
+	return nil
+}
+
+// DeleteContainer fails for running containers, like docker rm without -f
+func (f *FakeContainerManager) DeleteContainer(_ context.Context, containerIdentifier string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.find(containerIdentifier)
+	if err != nil {
+		return err
+	}
+	if c.Status == ContainerRunning {
+		return fmt.Errorf("cannot remove running container %s, stop it first", containerIdentifier)
+	}
+	delete(f.Containers, c.ID)
+	return nil
+}
+
+func (f *FakeContainerManager) DeleteVolume(_ context.Context, volumeName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.Volumes[volumeName] {
+		return fmt.Errorf("no such volume: %s", volumeName)
+	}
+	delete(f.Volumes, volumeName)
+	return nil
+}
@@ -0,0 +1,122 @@
+package workspacemanagerv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+var ErrContainerNotFound = errors.New("No such container")
+
+// IsContainerNotFound is true for errors about a container that does not exist
+func IsContainerNotFound(err error) bool {
+	return errors.Is(err, ErrContainerNotFound)
+}
+
+const (
+	RuntimeDocker = "docker"
+	RuntimePodman = "podman"
+)
+
+// Runtimes are the values accepted by NewContainerManager, "" detects one
+var Runtimes = []string{RuntimeDocker, RuntimePodman}
+
+// Runtime is a container engine serving the docker engine api
+type Runtime struct {
+	Name string
+	// Host is the address of the api, ex: unix:///var/run/docker.sock
+	Host string
+}
+
+const pingTimeout = 3 * time.Second
+
+// RuntimeCandidates are the places a runtime is looked for, in order:
+// DOCKER_HOST and CONTAINER_HOST if set, then the default docker socket and
+// the rootless and rootful podman sockets
+func RuntimeCandidates(name string) []Runtime {
+	candidates := []Runtime{}
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		candidates = append(candidates, Runtime{Name: RuntimeDocker, Host: host})
+	}
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		candidates = append(candidates, Runtime{Name: RuntimePodman, Host: host})
+	}
+	candidates = append(candidates, Runtime{Name: RuntimeDocker, Host: "unix:///var/run/docker.sock"})
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		candidates = append(candidates, Runtime{Name: RuntimePodman, Host: "unix://" + filepath.Join(dir, "podman", "podman.sock")})
+	}
+	candidates = append(candidates, Runtime{Name: RuntimePodman, Host: "unix:///run/podman/podman.sock"})
+
+	if name == "" {
+		return candidates
+	}
+	res := []Runtime{}
+	for _, c := range candidates {
+		if c.Name == name {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// NewContainerManager connects to the first runtime that answers, the name
+// limits the search to docker or podman
+func NewContainerManager(ctx context.Context, name string) (*DockerContainerManager, error) {
+	if name != "" && name != RuntimeDocker && name != RuntimePodman {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("unsupported container runtime %q, must be one of %s", name, strings.Join(Runtimes, "|")))
+	}
+	tried := []string{}
+	for _, runtime := range RuntimeCandidates(name) {
+		cm, err := connect(ctx, runtime)
+		if err != nil {
+			tried = append(tried, fmt.Sprintf("  %s at %s: %s", runtime.Name, runtime.Host, err))
+			continue
+		}
+		return cm, nil
+	}
+	return nil, &NoRuntimeError{Name: name, Tried: tried}
+}
+
+// connect errors are the reason shown to the user, so they aren't traced
+func connect(ctx context.Context, runtime Runtime) (*DockerContainerManager, error) {
+	if path := strings.TrimPrefix(runtime.Host, "unix://"); path != runtime.Host {
+		_, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.New("socket does not exist")
+		}
+	}
+	cm, err := NewDockerContainerManager(runtime)
+	if err != nil {
+		return nil, errors.New("invalid address")
+	}
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	err = cm.Ping(ctx)
+	if err != nil {
+		return nil, errors.New("not answering")
+	}
+	return cm, nil
+}
+
+// NoRuntimeError lists where a runtime was looked for
+type NoRuntimeError struct {
+	Name  string
+	Tried []string
+}
+
+func (e *NoRuntimeError) Error() string {
+	name := "docker or podman"
+	if e.Name != "" {
+		name = e.Name
+	}
+	return fmt.Sprintf(`no container runtime found, looked for %s at:
+%s
+start docker, or podman's socket with: systemctl --user enable --now podman.socket
+or set DOCKER_HOST to the address of the runtime`, name, strings.Join(e.Tried, "\n"))
+}
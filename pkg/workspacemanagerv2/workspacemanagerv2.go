@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"io"
 	"path/filepath"
-	"strings"
 
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -20,8 +19,15 @@ import (
 type WorkspaceManager struct {
 	ContainerManager ContainerManager
 	Store            WorkspaceManagerStore
+	// VolumesPath is where the files mounted into containers are kept
+	VolumesPath string
+	// Local is for containers on the user's machine, which have no cluster
+	// token or fuse device to mount
+	Local bool
 }
 
+const defaultVolumesPath = "/tmp/brev/volumes" // TODO proper path that will be saved
+
 type ContainerStatus string
 
 const (
@@ -61,7 +67,15 @@ type WorkspaceManagerStore interface {
 }
 
 func NewWorkspaceManager(cm ContainerManager, store WorkspaceManagerStore) *WorkspaceManager {
-	return &WorkspaceManager{ContainerManager: cm, Store: store}
+	return &WorkspaceManager{ContainerManager: cm, Store: store, VolumesPath: defaultVolumesPath}
+}
+
+// WithLocalVolumes keeps volumes under path so they survive reboots, ex: for
+// brev local
+func (w *WorkspaceManager) WithLocalVolumes(path string) *WorkspaceManager {
+	w.VolumesPath = path
+	w.Local = true
+	return w
 }
 
 func (w WorkspaceManager) MakeContainerWorkspace(workspaceID string) (*ContainerWorkspace, error) {
@@ -95,8 +109,7 @@ func (w WorkspaceManager) MakeContainerWorkspace(workspaceID string) (*Container
 		return nil, breverrors.WrapAndTrace(err)
 	}
 
-	basePath := "/tmp/brev/volumes" // TODO proper path that will be saved
-	workspaceVolumesPath := filepath.Join(basePath, workspace.ID)
+	workspaceVolumesPath := filepath.Join(w.VolumesPath, workspace.ID)
 
 	localMeta := filepath.Join(workspaceVolumesPath, "etc/meta")
 	metaVolumes := NewStaticFiles("/etc/meta", map[string]io.Reader{
@@ -127,15 +140,15 @@ func (w WorkspaceManager) MakeContainerWorkspace(workspaceID string) (*Container
 		MountToPath: "/dev/fuse",
 	}
 
-	containerWorkspace := NewContainerWorkspace(w.ContainerManager, workspaceID, workspace.WorkspaceTemplate.Image,
-		[]Volume{
-			metaVolumes,
-			secretsConfigVolumes,
-			workspaceVol,
-			k8sTokenVol,
-			fuseVol,
-		},
-	)
+	volumes := []Volume{
+		metaVolumes,
+		secretsConfigVolumes,
+		workspaceVol,
+	}
+	if !w.Local {
+		volumes = append(volumes, k8sTokenVol, fuseVol)
+	}
+	containerWorkspace := NewContainerWorkspace(w.ContainerManager, workspaceID, workspace.WorkspaceTemplate.Image, volumes)
 
 	return containerWorkspace, nil
 }
@@ -182,15 +195,22 @@ type ContainerWorkspace struct {
 	Identifier       string
 	Image            string
 	Volumes          []Volume
+	// Ports are published when the container is created, ex: 127.0.0.1:2222:22
+	Ports []string
 }
 
 func NewContainerWorkspace(cm ContainerManager, identifier string, image string, volumes []Volume) *ContainerWorkspace {
 	return &ContainerWorkspace{ContainerManager: cm, Identifier: identifier, Image: image, Volumes: volumes}
 }
 
+func (c *ContainerWorkspace) WithPorts(ports ...string) *ContainerWorkspace {
+	c.Ports = ports
+	return c
+}
+
 func (c ContainerWorkspace) Start(ctx context.Context) error {
 	container, err := c.ContainerManager.GetContainer(ctx, c.Identifier)
-	if err != nil && !strings.Contains(err.Error(), "No such container") {
+	if err != nil && !IsContainerNotFound(err) {
 		return breverrors.WrapAndTrace(err)
 	}
 	if container == nil { //nolint:gocritic // I like the else statement here
@@ -218,6 +238,7 @@ func (c ContainerWorkspace) CreateNew(ctx context.Context) error {
 	containerID, err := c.ContainerManager.CreateContainer(ctx, CreateContainerOptions{
 		Name:    c.Identifier,
 		Volumes: c.Volumes,
+		Ports:   c.Ports,
 	}, c.Image)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -272,6 +293,37 @@ func (c ContainerWorkspace) Rebuild(ctx context.Context) error {
 	return nil
 }
 
+// Recreate replaces the container with a new one, so that setup runs again
+// from scratch. Volumes are kept, ex: the code in /home/brev/workspace
+func (c ContainerWorkspace) Recreate(ctx context.Context) error {
+	err := c.Delete(ctx)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = c.Start(ctx)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// Delete stops and removes the container, it is a no-op if there is none.
+// Volumes are left for the caller to delete
+func (c ContainerWorkspace) Delete(ctx context.Context) error {
+	err := c.Stop(ctx)
+	if IsContainerNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = c.ContainerManager.DeleteContainer(ctx, c.Identifier)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func (c ContainerWorkspace) CreateVolumes(ctx context.Context) error {
 	// two kinds of updates depending on env
 	// // start volume processes (to support dynamic volumes like k8s token, hcl config, etc)
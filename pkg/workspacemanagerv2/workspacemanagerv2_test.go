@@ -60,7 +60,7 @@ func (t TestStore) GetWorkspaceMeta(id string) (*store.WorkspaceMeta, error) {
 }
 
 func Test_NewWorkspaceManager(t *testing.T) {
-	cm := DockerContainerManager{}
+	cm := NewFakeContainerManager()
 	store := TestStore{}
 	wm := NewWorkspaceManager(cm, store)
 	assert.NotNil(t, wm)
@@ -69,7 +69,7 @@ func Test_NewWorkspaceManager(t *testing.T) {
 func Test_StartWorkspaceManager(t *testing.T) {
 	t.Skip()
 	ctx := context.Background()
-	cm := DockerContainerManager{}
+	cm := NewFakeContainerManager()
 	store := TestStore{}
 	wm := NewWorkspaceManager(cm, store)
 	err := wm.Start(ctx, "test")
@@ -79,7 +79,7 @@ func Test_StartWorkspaceManager(t *testing.T) {
 func Test_StopWorkspaceManager(t *testing.T) {
 	t.Skip()
 	ctx := context.Background()
-	cm := DockerContainerManager{}
+	cm := NewFakeContainerManager()
 	store := TestStore{}
 	wm := NewWorkspaceManager(cm, store)
 	err := wm.Stop(ctx, "test")
@@ -89,7 +89,7 @@ func Test_StopWorkspaceManager(t *testing.T) {
 func Test_ResetWorkspaceManager(t *testing.T) {
 	t.Skip()
 	ctx := context.Background()
-	cm := DockerContainerManager{}
+	cm := NewFakeContainerManager()
 	store := TestStore{}
 	wm := NewWorkspaceManager(cm, store)
 	err := wm.Reset(ctx, "test")
@@ -101,3 +101,45 @@ func TestCreateWorkspace(t *testing.T) {
 	v = v.WithPathPrefix("prefix")
 	assert.Equal(t, "prefix", v.FromMountPathPrefix)
 }
+
+func Test_MakeContainerWorkspaceLocal(t *testing.T) {
+	wm := NewWorkspaceManager(NewFakeContainerManager(), TestStore{}).WithLocalVolumes("/home/me/.brev/local/volumes")
+	cw, err := wm.MakeContainerWorkspace("test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	mounts := map[string]string{}
+	for _, v := range cw.Volumes {
+		mounts[v.GetMountToPath()] = v.GetIdentifier()
+	}
+	assert.Equal(t, "/home/me/.brev/local/volumes/test/home/brev/workspace", mounts["/home/brev/workspace"])
+	assert.NotContains(t, mounts, "/var/run/secrets")
+	assert.NotContains(t, mounts, "/dev/fuse")
+}
+
+func Test_RecreateContainerWorkspace(t *testing.T) {
+	ctx := context.Background()
+	cm := NewFakeContainerManager()
+	cw := NewContainerWorkspace(cm, "ws", TestImage, nil).WithPorts("127.0.0.1:2222:22")
+
+	err := cw.Start(ctx)
+	if !assert.Nil(t, err) {
+		return
+	}
+	first, err := cm.GetContainer(ctx, "ws")
+	assert.Nil(t, err)
+	assert.Equal(t, ContainerRunning, first.Status)
+
+	err = cw.Recreate(ctx)
+	assert.Nil(t, err)
+	second, err := cm.GetContainer(ctx, "ws")
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Equal(t, []string{"127.0.0.1:2222:22"}, cm.Containers[second.ID].Options.Ports)
+
+	err = cw.Delete(ctx)
+	assert.Nil(t, err)
+	err = cw.Delete(ctx)
+	assert.Nil(t, err)
+	assert.Empty(t, cm.Containers)
+}